@@ -102,6 +102,12 @@ type CompletionRequest struct {
 	// Adapters should clamp values to their provider's supported range.
 	Temperature float64
 
+	// TemperatureSet reports that Temperature was chosen by the caller, so that
+	// adapters can tell an explicit 0 from an unset value. Builder sets it when
+	// WithTemperature was called. The Anthropic adapter only sends the
+	// temperature when it is set.
+	TemperatureSet bool
+
 	// MaxTokens is the maximum number of tokens to generate.
 	// This limits the length of the response.
 	//
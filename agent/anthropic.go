@@ -0,0 +1,662 @@
+package agent
+
+// Native Anthropic Messages API adapter.
+// The adapter talks to the HTTP API directly (no SDK dependency) and supports
+// synchronous completions, SSE streaming and tool use.
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAnthropicURL is the default Anthropic API base URL
+	DefaultAnthropicURL = "https://api.anthropic.com"
+
+	// AnthropicAPIVersion is the Messages API version sent in the anthropic-version header
+	AnthropicAPIVersion = "2023-06-01"
+
+	// DefaultAnthropicMaxTokens is used when the request does not set MaxTokens.
+	// The Messages API requires max_tokens on every request.
+	DefaultAnthropicMaxTokens = 4096
+)
+
+// AnthropicAdapter implements LLMAdapter for the Anthropic Messages API
+type AnthropicAdapter struct {
+	apiKey           string
+	baseURL          string
+	model            string
+	client           *http.Client
+	defaultMaxTokens int
+}
+
+// NewAnthropicAdapter creates a new Anthropic Messages API adapter.
+//
+// Example:
+//
+//	adapter, err := agent.NewAnthropicAdapter(apiKey, "claude-3-5-sonnet-latest")
+//	builder := agent.NewWithAdapter("claude-3-5-sonnet-latest", adapter)
+func NewAnthropicAdapter(apiKey, model string) (*AnthropicAdapter, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key is required for Anthropic")
+	}
+
+	return &AnthropicAdapter{
+		apiKey:  apiKey,
+		baseURL: DefaultAnthropicURL,
+		model:   model,
+		client: &http.Client{
+			Timeout: 5 * time.Minute,
+		},
+		defaultMaxTokens: DefaultAnthropicMaxTokens,
+	}, nil
+}
+
+// WithBaseURL sets a custom base URL (e.g. a proxy or a test server)
+func (a *AnthropicAdapter) WithBaseURL(baseURL string) *AnthropicAdapter {
+	if baseURL != "" {
+		a.baseURL = strings.TrimRight(baseURL, "/")
+	}
+	return a
+}
+
+// WithHTTPClient sets a custom HTTP client
+func (a *AnthropicAdapter) WithHTTPClient(client *http.Client) *AnthropicAdapter {
+	a.client = client
+	return a
+}
+
+// WithDefaultMaxTokens sets the max_tokens value used when a request leaves MaxTokens at 0
+func (a *AnthropicAdapter) WithDefaultMaxTokens(maxTokens int) *AnthropicAdapter {
+	if maxTokens > 0 {
+		a.defaultMaxTokens = maxTokens
+	}
+	return a
+}
+
+// GetModel returns the default model
+func (a *AnthropicAdapter) GetModel() string {
+	return a.model
+}
+
+// Anthropic API structures
+
+// anthropicRequest represents a Messages API request
+type anthropicRequest struct {
+	Model         string                 `json:"model"`
+	Messages      []anthropicMessage     `json:"messages"`
+	System        string                 `json:"system,omitempty"`
+	MaxTokens     int                    `json:"max_tokens"`
+	Temperature   *float64               `json:"temperature,omitempty"`
+	TopP          *float64               `json:"top_p,omitempty"`
+	StopSequences []string               `json:"stop_sequences,omitempty"`
+	Tools         []anthropicTool        `json:"tools,omitempty"`
+	ToolChoice    map[string]interface{} `json:"tool_choice,omitempty"`
+	Stream        bool                   `json:"stream,omitempty"`
+}
+
+// anthropicMessage represents a single conversation turn
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicContentBlock represents text, image, tool_use or tool_result content
+type anthropicContentBlock struct {
+	Type      string                `json:"type"`
+	Text      string                `json:"text,omitempty"`
+	Source    *anthropicImageSource `json:"source,omitempty"`
+	ID        string                `json:"id,omitempty"`
+	Name      string                `json:"name,omitempty"`
+	Input     json.RawMessage       `json:"input,omitempty"`
+	ToolUseID string                `json:"tool_use_id,omitempty"`
+	Content   string                `json:"content,omitempty"`
+}
+
+// anthropicImageSource is the base64 data or URL of an image block
+type anthropicImageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// anthropicTool represents a tool definition
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// anthropicUsage represents token usage
+type anthropicUsage struct {
+	InputTokens          int `json:"input_tokens"`
+	OutputTokens         int `json:"output_tokens"`
+	CacheReadInputTokens int `json:"cache_read_input_tokens"`
+}
+
+// anthropicResponse represents a Messages API response
+type anthropicResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+// anthropicErrorResponse represents an error payload
+type anthropicErrorResponse struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicStreamEvent represents a single SSE event payload
+type anthropicStreamEvent struct {
+	Type         string                 `json:"type"`
+	Index        int                    `json:"index"`
+	Message      *anthropicResponse     `json:"message,omitempty"`
+	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Complete implements LLMAdapter interface
+func (a *AnthropicAdapter) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	body, err := a.buildRequest(req, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	resp, err := a.doRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, WrapInvalidResponse(fmt.Errorf("failed to decode anthropic response: %w", err))
+	}
+
+	return a.convertResponse(&result), nil
+}
+
+// Stream implements LLMAdapter interface using server-sent events.
+// onChunk may be nil, in which case the response is only accumulated.
+func (a *AnthropicAdapter) Stream(ctx context.Context, req *CompletionRequest, onChunk func(string)) (*CompletionResponse, error) {
+	body, err := a.buildRequest(req, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	resp, err := a.doRequest(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return a.readStream(ctx, resp.Body, onChunk)
+}
+
+// buildRequest converts a CompletionRequest to the Anthropic wire format
+func (a *AnthropicAdapter) buildRequest(req *CompletionRequest, stream bool) (*anthropicRequest, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages cannot be empty")
+	}
+
+	model := req.Model
+	if model == "" {
+		model = a.model
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = a.defaultMaxTokens
+	}
+
+	system, messages, err := a.convertMessages(req.System, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("at least one non-system message is required")
+	}
+
+	body := &anthropicRequest{
+		Model:         model,
+		Messages:      messages,
+		System:        system,
+		MaxTokens:     maxTokens,
+		StopSequences: req.Stop,
+		Stream:        stream,
+	}
+
+	// Only sent when chosen by the caller, so that 0 is not replaced by the
+	// API default of 1. Anthropic accepts temperature in [0, 1].
+	if req.TemperatureSet {
+		if req.Temperature < 0 || req.Temperature > 1 {
+			return nil, fmt.Errorf("temperature must be between 0 and 1 for Anthropic, got %v", req.Temperature)
+		}
+		temp := req.Temperature
+		body.Temperature = &temp
+	}
+
+	// Only send top_p when it actually narrows sampling
+	if req.TopP > 0 && req.TopP < 1 {
+		topP := req.TopP
+		body.TopP = &topP
+	}
+
+	if len(req.Tools) > 0 {
+		body.Tools = a.convertTools(req.Tools)
+		body.ToolChoice = a.convertToolChoice(req.ToolChoice)
+	}
+
+	return body, nil
+}
+
+// convertMessages converts messages to Anthropic format.
+// System messages are merged into the top-level system prompt, tool results are
+// sent as tool_result blocks in a user turn, images as image blocks before the
+// text of their turn, and consecutive turns with the same role are merged
+// because the API requires alternating roles.
+func (a *AnthropicAdapter) convertMessages(system string, messages []Message) (string, []anthropicMessage, error) {
+	var systemParts []string
+	if system != "" {
+		systemParts = append(systemParts, system)
+	}
+
+	var result []anthropicMessage
+	appendBlocks := func(role string, blocks ...anthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Content = append(result[n-1].Content, blocks...)
+			return
+		}
+		result = append(result, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" && msg.Content != system {
+				systemParts = append(systemParts, msg.Content)
+			}
+
+		case "assistant", "model":
+			var blocks []anthropicContentBlock
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Name,
+					Input: input,
+				})
+			}
+			appendBlocks("assistant", blocks...)
+
+		case "tool":
+			appendBlocks("user", anthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   msg.Content,
+			})
+
+		default:
+			var blocks []anthropicContentBlock
+			for _, image := range msg.Images {
+				block, err := convertAnthropicImage(image)
+				if err != nil {
+					return "", nil, err
+				}
+				blocks = append(blocks, block)
+			}
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			appendBlocks("user", blocks...)
+		}
+	}
+
+	return strings.Join(systemParts, "\n\n"), result, nil
+}
+
+// convertAnthropicImage converts an image URL or base64 data URI to an image block
+func convertAnthropicImage(image ImageContent) (anthropicContentBlock, error) {
+	block := anthropicContentBlock{Type: "image"}
+	switch {
+	case strings.HasPrefix(image.URL, "data:"):
+		header, data, found := strings.Cut(strings.TrimPrefix(image.URL, "data:"), ",")
+		mediaType, encoding, _ := strings.Cut(header, ";")
+		if !found || encoding != "base64" || mediaType == "" {
+			return block, fmt.Errorf("unsupported image data URI: Anthropic requires base64 data with a media type")
+		}
+		block.Source = &anthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}
+	case strings.HasPrefix(image.URL, "http://") || strings.HasPrefix(image.URL, "https://"):
+		block.Source = &anthropicImageSource{Type: "url", URL: image.URL}
+	default:
+		return block, fmt.Errorf("unsupported image URL %q: use an http(s) URL or a base64 data URI", image.URL)
+	}
+	return block, nil
+}
+
+// convertTools converts tools to Anthropic format
+func (a *AnthropicAdapter) convertTools(tools []*Tool) []anthropicTool {
+	result := make([]anthropicTool, 0, len(tools))
+	for _, tool := range tools {
+		if tool == nil {
+			continue
+		}
+		schema := tool.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			}
+		}
+		result = append(result, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	return result
+}
+
+// convertToolChoice maps OpenAI-style tool choice values to Anthropic format.
+// Supported inputs: "auto", "required"/"any", "none", a tool name map
+// ({"type": "function", "function": {"name": "..."}}) or a native Anthropic map.
+func (a *AnthropicAdapter) convertToolChoice(choice interface{}) map[string]interface{} {
+	switch v := choice.(type) {
+	case nil:
+		return nil
+	case string:
+		switch v {
+		case "auto", "":
+			return map[string]interface{}{"type": "auto"}
+		case "required", "any":
+			return map[string]interface{}{"type": "any"}
+		case "none":
+			return map[string]interface{}{"type": "none"}
+		default:
+			return map[string]interface{}{"type": "tool", "name": v}
+		}
+	case map[string]interface{}:
+		if fn, ok := v["function"].(map[string]interface{}); ok {
+			if name, ok := fn["name"].(string); ok {
+				return map[string]interface{}{"type": "tool", "name": name}
+			}
+		}
+		if _, ok := v["type"].(string); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// convertResponse converts an Anthropic response to unified format
+func (a *AnthropicAdapter) convertResponse(resp *anthropicResponse) *CompletionResponse {
+	result := &CompletionResponse{
+		ID:           resp.ID,
+		Model:        resp.Model,
+		Created:      time.Now().Unix(),
+		FinishReason: a.convertStopReason(resp.StopReason),
+		Usage:        a.convertUsage(resp.Usage),
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			args := string(block.Input)
+			if args == "" {
+				args = "{}"
+			}
+			result.ToolCalls = append(result.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Type:      "function",
+				Name:      block.Name,
+				Arguments: args,
+			})
+		}
+	}
+	result.Content = text.String()
+
+	return result
+}
+
+// convertUsage converts Anthropic usage to TokenUsage
+func (a *AnthropicAdapter) convertUsage(usage anthropicUsage) TokenUsage {
+	return TokenUsage{
+		PromptTokens:       usage.InputTokens,
+		CompletionTokens:   usage.OutputTokens,
+		TotalTokens:        usage.InputTokens + usage.OutputTokens,
+		PromptCachedTokens: usage.CacheReadInputTokens,
+	}
+}
+
+// convertStopReason maps Anthropic stop reasons to unified finish reasons
+func (a *AnthropicAdapter) convertStopReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return reason
+	}
+}
+
+// readStream consumes an SSE stream and accumulates the response
+func (a *AnthropicAdapter) readStream(ctx context.Context, body io.Reader, onChunk func(string)) (*CompletionResponse, error) {
+	result := &CompletionResponse{Created: time.Now().Unix()}
+	var usage anthropicUsage
+	var text strings.Builder
+
+	// Tool use blocks are assembled from partial JSON deltas keyed by block index
+	type pendingToolUse struct {
+		id   string
+		name string
+		args strings.Builder
+	}
+	toolBlocks := make(map[int]*pendingToolUse)
+	var toolOrder []int
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// event: lines and blank separators carry no extra information
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, WrapInvalidResponse(fmt.Errorf("failed to decode anthropic stream event: %w", err))
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				result.ID = event.Message.ID
+				result.Model = event.Message.Model
+				usage.InputTokens = event.Message.Usage.InputTokens
+				usage.CacheReadInputTokens = event.Message.Usage.CacheReadInputTokens
+			}
+
+		case "content_block_start":
+			if event.ContentBlock != nil && event.ContentBlock.Type == "tool_use" {
+				toolBlocks[event.Index] = &pendingToolUse{
+					id:   event.ContentBlock.ID,
+					name: event.ContentBlock.Name,
+				}
+				toolOrder = append(toolOrder, event.Index)
+			}
+
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				text.WriteString(event.Delta.Text)
+				if onChunk != nil && event.Delta.Text != "" {
+					onChunk(event.Delta.Text)
+				}
+			case "input_json_delta":
+				if tb, ok := toolBlocks[event.Index]; ok {
+					tb.args.WriteString(event.Delta.PartialJSON)
+				}
+			}
+
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				result.FinishReason = a.convertStopReason(event.Delta.StopReason)
+			}
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+
+		case "error":
+			if event.Error != nil {
+				return nil, a.newAPIError(0, event.Error.Type, event.Error.Message)
+			}
+			return nil, WrapInvalidResponse(fmt.Errorf("anthropic stream error: %s", data))
+
+		case "message_stop":
+			// End of message; the connection will be closed by the server
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("anthropic stream read failed: %w", err)
+	}
+
+	for _, index := range toolOrder {
+		tb := toolBlocks[index]
+		args := tb.args.String()
+		if args == "" {
+			args = "{}"
+		}
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:        tb.id,
+			Type:      "function",
+			Name:      tb.name,
+			Arguments: args,
+		})
+	}
+
+	result.Content = text.String()
+	result.Usage = a.convertUsage(usage)
+	return result, nil
+}
+
+// doRequest sends a request to the Messages endpoint and checks the status code
+func (a *AnthropicAdapter) doRequest(ctx context.Context, body *anthropicRequest) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/messages", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", AnthropicAPIVersion)
+	if body.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, WrapTimeout(err)
+		}
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+
+		var errResp anthropicErrorResponse
+		if err := json.Unmarshal(respBody, &errResp); err == nil && errResp.Error.Type != "" {
+			return nil, a.newAPIError(resp.StatusCode, errResp.Error.Type, errResp.Error.Message)
+		}
+		return nil, a.newAPIError(resp.StatusCode, "", string(respBody))
+	}
+
+	return resp, nil
+}
+
+// newAPIError maps Anthropic error types to APIError types understood by
+// IsAPIKeyError, IsRateLimitError and the retry logic.
+func (a *AnthropicAdapter) newAPIError(statusCode int, errorType, message string) error {
+	httpErr := &HTTPError{StatusCode: statusCode, Message: message}
+
+	switch {
+	case errorType == "authentication_error" || statusCode == http.StatusUnauthorized:
+		return NewAPIError("invalid_api_key", "anthropic authentication error: "+message, statusCode, httpErr)
+	case errorType == "rate_limit_error" || statusCode == http.StatusTooManyRequests:
+		return NewAPIError("rate_limit_exceeded", "anthropic rate limit exceeded: "+message, statusCode, httpErr)
+	case errorType == "overloaded_error":
+		return NewAPIError("overloaded", "anthropic API overloaded: "+message, statusCode, httpErr)
+	case errorType != "":
+		return NewAPIError(errorType, "anthropic API error: "+message, statusCode, httpErr)
+	default:
+		return NewAPIError("api_error", "anthropic API error: "+message, statusCode, httpErr)
+	}
+}
@@ -0,0 +1,552 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Recorded SSE stream with text deltas only
+const anthropicTextStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_01","type":"message","role":"assistant","model":"claude-3-5-sonnet-latest","content":[],"stop_reason":null,"usage":{"input_tokens":25,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":12}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+// Recorded SSE stream with a text block followed by a tool_use block
+const anthropicToolStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_02","type":"message","role":"assistant","model":"claude-3-5-sonnet-latest","content":[],"stop_reason":null,"usage":{"input_tokens":40,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking the weather."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_01","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"location\": \"Par"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"is\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":30}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func newAnthropicTestAdapter(t *testing.T, url string) *AnthropicAdapter {
+	t.Helper()
+	adapter, err := NewAnthropicAdapter("sk-ant-test", "claude-3-5-sonnet-latest")
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+	return adapter.WithBaseURL(url)
+}
+
+func TestNewAnthropicAdapter(t *testing.T) {
+	if _, err := NewAnthropicAdapter("", "claude-3-5-sonnet-latest"); err == nil {
+		t.Error("Expected error for empty API key")
+	}
+
+	adapter, err := NewAnthropicAdapter("sk-ant-test", "claude-3-5-sonnet-latest")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if adapter.baseURL != DefaultAnthropicURL {
+		t.Errorf("Expected baseURL %s, got %s", DefaultAnthropicURL, adapter.baseURL)
+	}
+	if adapter.GetModel() != "claude-3-5-sonnet-latest" {
+		t.Errorf("Unexpected model: %s", adapter.GetModel())
+	}
+
+	var _ LLMAdapter = adapter
+}
+
+func TestAnthropicAdapterComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "sk-ant-test" {
+			t.Errorf("Missing x-api-key header")
+		}
+		if r.Header.Get("anthropic-version") != AnthropicAPIVersion {
+			t.Errorf("Unexpected anthropic-version: %s", r.Header.Get("anthropic-version"))
+		}
+
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.MaxTokens != DefaultAnthropicMaxTokens {
+			t.Errorf("Expected default max_tokens %d, got %d", DefaultAnthropicMaxTokens, req.MaxTokens)
+		}
+		if req.System != "Be brief" {
+			t.Errorf("Expected system prompt, got %q", req.System)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Name != "get_weather" {
+			t.Errorf("Expected get_weather tool, got %+v", req.Tools)
+		}
+		if req.Stream {
+			t.Error("Complete should not request streaming")
+		}
+
+		fmt.Fprint(w, `{
+			"id": "msg_01",
+			"model": "claude-3-5-sonnet-latest",
+			"content": [
+				{"type": "text", "text": "Let me check."},
+				{"type": "tool_use", "id": "toolu_01", "name": "get_weather", "input": {"location": "Paris"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 20, "output_tokens": 10, "cache_read_input_tokens": 5}
+		}`)
+	}))
+	defer server.Close()
+
+	adapter := newAnthropicTestAdapter(t, server.URL)
+	tool := NewTool("get_weather", "Get weather").AddParameter("location", "string", "City", true)
+
+	resp, err := adapter.Complete(context.Background(), &CompletionRequest{
+		Model:    "claude-3-5-sonnet-latest",
+		System:   "Be brief",
+		Messages: []Message{User("Weather in Paris?")},
+		Tools:    []*Tool{tool},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if resp.Content != "Let me check." {
+		t.Errorf("Unexpected content: %q", resp.Content)
+	}
+	if resp.FinishReason != "tool_calls" {
+		t.Errorf("Expected finish reason tool_calls, got %s", resp.FinishReason)
+	}
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("Expected 1 tool call, got %d", len(resp.ToolCalls))
+	}
+	if resp.ToolCalls[0].ID != "toolu_01" || resp.ToolCalls[0].Name != "get_weather" {
+		t.Errorf("Unexpected tool call: %+v", resp.ToolCalls[0])
+	}
+	if !strings.Contains(resp.ToolCalls[0].Arguments, "Paris") {
+		t.Errorf("Unexpected arguments: %s", resp.ToolCalls[0].Arguments)
+	}
+	if resp.Usage.PromptTokens != 20 || resp.Usage.CompletionTokens != 10 || resp.Usage.TotalTokens != 30 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+	if resp.Usage.PromptCachedTokens != 5 {
+		t.Errorf("Expected 5 cached tokens, got %d", resp.Usage.PromptCachedTokens)
+	}
+}
+
+func TestAnthropicAdapterStream(t *testing.T) {
+	tests := []struct {
+		name          string
+		stream        string
+		wantContent   string
+		wantChunks    []string
+		wantFinish    string
+		wantToolCalls int
+		wantUsage     TokenUsage
+	}{
+		{
+			name:        "text only",
+			stream:      anthropicTextStream,
+			wantContent: "Hello world",
+			wantChunks:  []string{"Hello", " world"},
+			wantFinish:  "stop",
+			wantUsage:   TokenUsage{PromptTokens: 25, CompletionTokens: 12, TotalTokens: 37},
+		},
+		{
+			name:          "text and tool use",
+			stream:        anthropicToolStream,
+			wantContent:   "Checking the weather.",
+			wantChunks:    []string{"Checking the weather."},
+			wantFinish:    "tool_calls",
+			wantToolCalls: 1,
+			wantUsage:     TokenUsage{PromptTokens: 40, CompletionTokens: 30, TotalTokens: 70},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req anthropicRequest
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Fatalf("Failed to decode request: %v", err)
+				}
+				if !req.Stream {
+					t.Error("Expected stream=true")
+				}
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, tt.stream)
+			}))
+			defer server.Close()
+
+			adapter := newAnthropicTestAdapter(t, server.URL)
+
+			var chunks []string
+			resp, err := adapter.Stream(context.Background(), &CompletionRequest{
+				Messages: []Message{User("Hi")},
+			}, func(chunk string) {
+				chunks = append(chunks, chunk)
+			})
+			if err != nil {
+				t.Fatalf("Stream failed: %v", err)
+			}
+
+			if resp.Content != tt.wantContent {
+				t.Errorf("Expected content %q, got %q", tt.wantContent, resp.Content)
+			}
+			if strings.Join(chunks, "|") != strings.Join(tt.wantChunks, "|") {
+				t.Errorf("Expected chunks %v, got %v", tt.wantChunks, chunks)
+			}
+			if resp.FinishReason != tt.wantFinish {
+				t.Errorf("Expected finish reason %s, got %s", tt.wantFinish, resp.FinishReason)
+			}
+			if len(resp.ToolCalls) != tt.wantToolCalls {
+				t.Fatalf("Expected %d tool calls, got %d", tt.wantToolCalls, len(resp.ToolCalls))
+			}
+			if tt.wantToolCalls > 0 {
+				var args map[string]string
+				if err := json.Unmarshal([]byte(resp.ToolCalls[0].Arguments), &args); err != nil {
+					t.Fatalf("Tool arguments are not valid JSON: %v", err)
+				}
+				if args["location"] != "Paris" {
+					t.Errorf("Expected location Paris, got %v", args)
+				}
+			}
+			if resp.Usage != tt.wantUsage {
+				t.Errorf("Expected usage %+v, got %+v", tt.wantUsage, resp.Usage)
+			}
+		})
+	}
+}
+
+func TestAnthropicAdapterStreamNilCallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, anthropicTextStream)
+	}))
+	defer server.Close()
+
+	adapter := newAnthropicTestAdapter(t, server.URL)
+	resp, err := adapter.Stream(context.Background(), &CompletionRequest{
+		Messages: []Message{User("Hi")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if resp.Content != "Hello world" {
+		t.Errorf("Unexpected content: %q", resp.Content)
+	}
+}
+
+func TestAnthropicAdapterConvertMessages(t *testing.T) {
+	adapter, _ := NewAnthropicAdapter("sk-ant-test", "claude-3-5-sonnet-latest")
+
+	system, messages, err := adapter.convertMessages("", []Message{
+		System("You are helpful"),
+		User("Weather in Paris and Rome?"),
+		{
+			Role: "assistant",
+			ToolCalls: []ToolCall{
+				{ID: "toolu_1", Name: "get_weather", Arguments: `{"location":"Paris"}`},
+				{ID: "toolu_2", Name: "get_weather", Arguments: ""},
+			},
+		},
+		{Role: "tool", ToolCallID: "toolu_1", Content: "Sunny"},
+		{Role: "tool", ToolCallID: "toolu_2", Content: "Rainy"},
+		User("Thanks"),
+	})
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	if system != "You are helpful" {
+		t.Errorf("Expected system message to be extracted, got %q", system)
+	}
+
+	// user, assistant, user (tool results + follow-up merged)
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d: %+v", len(messages), messages)
+	}
+	if messages[1].Role != "assistant" || len(messages[1].Content) != 2 {
+		t.Fatalf("Expected assistant turn with 2 tool_use blocks, got %+v", messages[1])
+	}
+	if string(messages[1].Content[1].Input) != "{}" {
+		t.Errorf("Expected empty arguments to become {}, got %s", messages[1].Content[1].Input)
+	}
+
+	last := messages[2]
+	if last.Role != "user" || len(last.Content) != 3 {
+		t.Fatalf("Expected merged user turn with 3 blocks, got %+v", last)
+	}
+	if last.Content[0].Type != "tool_result" || last.Content[0].ToolUseID != "toolu_1" {
+		t.Errorf("Unexpected first tool result: %+v", last.Content[0])
+	}
+	if last.Content[2].Type != "text" || last.Content[2].Text != "Thanks" {
+		t.Errorf("Unexpected trailing text block: %+v", last.Content[2])
+	}
+}
+
+func TestAnthropicAdapterImages(t *testing.T) {
+	adapter, _ := NewAnthropicAdapter("sk-ant-test", "claude-3-5-sonnet-latest")
+
+	_, messages, err := adapter.convertMessages("", []Message{{
+		Role:    "user",
+		Content: "What is in these pictures?",
+		Images: []ImageContent{
+			{URL: "https://example.com/cat.jpg"},
+			{URL: "data:image/png;base64,iVBORw0KGgo="},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+	blocks := messages[0].Content
+	if len(blocks) != 3 || blocks[2].Type != "text" {
+		t.Fatalf("Expected 2 image blocks before the text, got %+v", blocks)
+	}
+	if blocks[0].Type != "image" || *blocks[0].Source != (anthropicImageSource{Type: "url", URL: "https://example.com/cat.jpg"}) {
+		t.Errorf("Unexpected URL image block: %+v", blocks[0])
+	}
+	if *blocks[1].Source != (anthropicImageSource{Type: "base64", MediaType: "image/png", Data: "iVBORw0KGgo="}) {
+		t.Errorf("Unexpected base64 image block: %+v", blocks[1].Source)
+	}
+
+	for _, url := range []string{"data:image/png,not-base64", "/tmp/cat.jpg"} {
+		msg := Message{Role: "user", Content: "Look", Images: []ImageContent{{URL: url}}}
+		if _, err := adapter.buildRequest(&CompletionRequest{Messages: []Message{msg}}, false); err == nil {
+			t.Errorf("Expected error for image %q", url)
+		}
+	}
+}
+
+func TestAnthropicAdapterTemperature(t *testing.T) {
+	adapter, _ := NewAnthropicAdapter("sk-ant-test", "claude-3-5-sonnet-latest")
+
+	// Unset temperatures are left to the API default
+	body, err := adapter.buildRequest(&CompletionRequest{Messages: []Message{User("Hi")}}, false)
+	if err != nil {
+		t.Fatalf("buildRequest failed: %v", err)
+	}
+	if body.Temperature != nil {
+		t.Errorf("unset temperature sent as %v", *body.Temperature)
+	}
+
+	for _, temperature := range []float64{0, 0.5, 1} {
+		body, err := adapter.buildRequest(&CompletionRequest{Messages: []Message{User("Hi")}, Temperature: temperature, TemperatureSet: true}, false)
+		if err != nil {
+			t.Fatalf("buildRequest failed: %v", err)
+		}
+		if body.Temperature == nil || *body.Temperature != temperature {
+			t.Errorf("temperature %v: sent %v", temperature, body.Temperature)
+		}
+	}
+
+	for _, temperature := range []float64{-0.1, 1.5} {
+		if _, err := adapter.buildRequest(&CompletionRequest{Messages: []Message{User("Hi")}, Temperature: temperature, TemperatureSet: true}, false); err == nil {
+			t.Errorf("temperature %v: expected an out of range error", temperature)
+		}
+	}
+}
+
+func TestAnthropicAdapterToolChoice(t *testing.T) {
+	adapter, _ := NewAnthropicAdapter("sk-ant-test", "claude-3-5-sonnet-latest")
+
+	tests := []struct {
+		input interface{}
+		want  map[string]interface{}
+	}{
+		{nil, nil},
+		{"auto", map[string]interface{}{"type": "auto"}},
+		{"required", map[string]interface{}{"type": "any"}},
+		{"none", map[string]interface{}{"type": "none"}},
+		{
+			map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "calc"}},
+			map[string]interface{}{"type": "tool", "name": "calc"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := adapter.convertToolChoice(tt.input)
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("convertToolChoice(%v) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestAnthropicAdapterErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		checkFunc func(error) bool
+	}{
+		{
+			name:      "authentication error",
+			status:    http.StatusUnauthorized,
+			body:      `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+			checkFunc: IsAPIKeyError,
+		},
+		{
+			name:      "rate limit error",
+			status:    http.StatusTooManyRequests,
+			body:      `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			checkFunc: IsRateLimitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			adapter := newAnthropicTestAdapter(t, server.URL)
+			_, err := adapter.Complete(context.Background(), &CompletionRequest{
+				Messages: []Message{User("Hi")},
+			})
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			if !tt.checkFunc(err) {
+				t.Errorf("Unexpected error classification: %v", err)
+			}
+		})
+	}
+
+	t.Run("stream error event", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+		}))
+		defer server.Close()
+
+		adapter := newAnthropicTestAdapter(t, server.URL)
+		_, err := adapter.Stream(context.Background(), &CompletionRequest{
+			Messages: []Message{User("Hi")},
+		}, nil)
+		if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+			t.Errorf("Expected overloaded error, got %v", err)
+		}
+	})
+}
+
+func TestAnthropicAdapterWithBuilderToolExecution(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		last := req.Messages[len(req.Messages)-1]
+		if last.Content[0].Type == "tool_result" {
+			if last.Content[0].ToolUseID != "toolu_01" || last.Content[0].Content != "Sunny, 25C" {
+				t.Errorf("Unexpected tool result: %+v", last.Content[0])
+			}
+			fmt.Fprint(w, `{"id":"msg_2","content":[{"type":"text","text":"It is sunny in Paris."}],"stop_reason":"end_turn","usage":{"input_tokens":30,"output_tokens":8}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"id":"msg_1","content":[{"type":"tool_use","id":"toolu_01","name":"get_weather","input":{"location":"Paris"}}],"stop_reason":"tool_use","usage":{"input_tokens":20,"output_tokens":10}}`)
+	}))
+	defer server.Close()
+
+	adapter := newAnthropicTestAdapter(t, server.URL)
+	tool := NewTool("get_weather", "Get weather").
+		AddParameter("location", "string", "City", true).
+		WithHandler(func(args string) (string, error) {
+			return "Sunny, 25C", nil
+		})
+
+	builder := NewWithAdapter("claude-3-5-sonnet-latest", adapter).
+		WithSystem("You are a weather assistant").
+		WithTool(tool).
+		WithAutoExecute(true)
+
+	result, err := builder.Ask(context.Background(), "Weather in Paris?")
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if result != "It is sunny in Paris." {
+		t.Errorf("Unexpected result: %q", result)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 API calls, got %d", calls)
+	}
+}
+
+func TestAnthropicAdapterWithMultiProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"msg_1","content":[{"type":"text","text":"Hello from Claude"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":4}}`)
+	}))
+	defer server.Close()
+
+	mp, err := NewMultiProvider(&MultiProviderConfig{
+		Providers: []ProviderConfig{
+			{
+				Name:    "claude",
+				Type:    "anthropic",
+				Model:   "claude-3-5-sonnet-latest",
+				APIKey:  "sk-ant-test",
+				BaseURL: server.URL,
+			},
+		},
+		SelectionStrategy: StrategyRoundRobin,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create MultiProvider: %v", err)
+	}
+
+	result, err := mp.Ask(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if result != "Hello from Claude" {
+		t.Errorf("Unexpected result: %q", result)
+	}
+}
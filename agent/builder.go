@@ -11,6 +11,7 @@ import (
 	"github.com/openai/openai-go/v3/shared"
 	"github.com/openai/openai-go/v3/shared/constant"
 	"github.com/taipm/go-deep-agent/agent/memory"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Link to tools.SetLogFunc using go:linkname to avoid import cycle
@@ -90,6 +91,7 @@ type Builder struct {
 	ragDocuments      []Document   // Documents for RAG
 	ragRetriever      RAGRetriever // Custom retriever function
 	ragConfig         *RAGConfig   // RAG configuration
+	ragIndex          *BM25Index   // BM25 index of the chunked RAG documents (built on first use)
 	lastRetrievedDocs []Document   // Last retrieved documents
 
 	// Vector RAG
@@ -101,10 +103,20 @@ type Builder struct {
 	cache        Cache         // Cache implementation
 	cacheEnabled bool          // Whether caching is enabled
 	cacheTTL     time.Duration // Cache TTL for next request
+	cachePolicy  CachePolicy   // Which requests are cached and how they are keyed
+
+	replayChunkSize int             // Runes per chunk when replaying cache hits to OnStream
+	replayInterval  time.Duration   // Pause between replayed chunks
+	cacheTags       []string        // Tags of cached responses (TaggedCache)
+	stampede        *StampedeConfig // Cache stampede protection (nil = disabled)
 
 	// Logging
 	logger Logger // Logger for observability (default: NoopLogger)
 
+	// Metrics and tracing
+	metricsRegistry MetricsRegistry // Registry receiving request, cache and tool metrics (nil = disabled)
+	tracer          trace.Tracer    // OpenTelemetry tracer (nil = disabled)
+
 	// Enhanced debug mode
 	debugConfig DebugConfig  // Debug configuration
 	debugLogger *debugLogger // Debug logger instance
@@ -280,6 +292,70 @@ func isOllamaRunning() bool {
 	return err == nil && resp.StatusCode == 200
 }
 
+// Clone returns a Builder with the same configuration, tools and cache, and
+// a copy of the conversation history, for independent conversations such as
+// one per client. The clone has its own hierarchical memory configured like
+// this one's, and is not bound to its long-term memory ID. Custom summarizers,
+// fact extractors and semantic memory tiers are not carried over.
+func (b *Builder) Clone() *Builder {
+	clone := *b
+	clone.messages = append([]Message{}, b.messages...)
+	clone.tools = append([]*Tool(nil), b.tools...)
+	clone.ragDocuments = append([]Document(nil), b.ragDocuments...)
+	clone.cacheTags = append([]string(nil), b.cacheTags...)
+
+	// Configs changed in place by With* methods must not be shared
+	clone.temperature = clonePtr(b.temperature)
+	clone.topP = clonePtr(b.topP)
+	clone.maxTokens = clonePtr(b.maxTokens)
+	clone.presencePenalty = clonePtr(b.presencePenalty)
+	clone.frequencyPenalty = clonePtr(b.frequencyPenalty)
+	clone.seed = clonePtr(b.seed)
+	clone.logprobs = clonePtr(b.logprobs)
+	clone.topLogprobs = clonePtr(b.topLogprobs)
+	clone.n = clonePtr(b.n)
+	clone.toolChoice = clonePtr(b.toolChoice)
+	clone.responseFormat = clonePtr(b.responseFormat)
+	clone.ragConfig = clonePtr(b.ragConfig)
+	clone.stampede = clonePtr(b.stampede)
+	if b.persona != nil {
+		clone.persona = b.persona.clone()
+	}
+	if b.fewshotConfig != nil {
+		clone.fewshotConfig = b.fewshotConfig.clone()
+	}
+	if b.reactConfig != nil {
+		clone.reactConfig = b.reactConfig.Clone()
+	}
+
+	clone.pendingImages = nil
+	clone.lastError = nil
+	clone.lastRetrievedDocs = nil
+	clone.lastUsage = TokenUsage{}
+	clone.longMemoryID = ""
+
+	if b.memory != nil {
+		config := b.memory.GetConfig()
+		clone.memory = memory.NewWithConfig(config)
+		if config.SummarizationMode == "llm" {
+			clone.memory.SetSummarizer(memory.NewLLMSummarizer(&builderCompletionAdapter{builder: &clone}))
+		}
+		if config.SemanticAutoLearn {
+			clone.memory.SetFactExtractor(memory.NewLLMFactExtractor(&builderCompletionAdapter{builder: &clone}))
+		}
+	}
+	return &clone
+}
+
+// clonePtr returns a pointer to a copy of *p, or nil
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
 // WithAPIKey sets the API key for the provider.
 // Required for OpenAI, not needed for Ollama.
 //
@@ -91,8 +91,8 @@ func TestBuilderAdapterIntegration(t *testing.T) {
 		}
 
 		req := adapter.lastRequest
-		if req.Temperature != 0.8 {
-			t.Errorf("Expected temperature 0.8, got: %f", req.Temperature)
+		if req.Temperature != 0.8 || !req.TemperatureSet {
+			t.Errorf("Expected temperature 0.8 to be set, got: %f (set: %v)", req.Temperature, req.TemperatureSet)
 		}
 		if req.MaxTokens != 1000 {
 			t.Errorf("Expected maxTokens 1000, got: %d", req.MaxTokens)
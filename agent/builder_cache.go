@@ -2,13 +2,20 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Cache configuration methods for Builder
 // This file contains all methods related to cache management,
 // including memory cache, Redis cache, and cache operations.
 
+// defaultReplayChunkSize is the number of runes per chunk when replaying cache hits
+const defaultReplayChunkSize = 16
+
 func (b *Builder) WithCache(cache Cache) *Builder {
 	b.cache = cache
 	b.cacheEnabled = true
@@ -43,6 +50,92 @@ func (b *Builder) WithRedisCacheOptions(opts *RedisCacheOptions) *Builder {
 	return b
 }
 
+// WithSemanticCache enables a semantic cache, answering paraphrased prompts
+// from the response cached for a similar prompt.
+//
+// Example:
+//
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithSemanticCache(agent.SemanticCacheConfig{Embedder: embedder, Threshold: 0.95})
+func (b *Builder) WithSemanticCache(config SemanticCacheConfig) *Builder {
+	cache, err := NewSemanticCache(config)
+	if err != nil {
+		// Log error but don't fail - fall back to no caching
+		return b
+	}
+	return b.WithCache(cache)
+}
+
+// WithCachePolicy sets which requests are cached and how their cache key is
+// computed. By default every request is cached, keyed by a fingerprint of
+// the full request (GenerateRequestCacheKey).
+//
+// Example:
+//
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithMemoryCache(1000, 5*time.Minute).
+//	    WithCachePolicy(agent.CachePolicy{SkipWithTools: true, DeterministicOnly: true})
+func (b *Builder) WithCachePolicy(policy CachePolicy) *Builder {
+	b.cachePolicy = policy
+	return b
+}
+
+// WithStreamReplay sets how cache hits are replayed to the OnStream
+// callback: chunkSize runes per chunk (default 16), with interval between
+// chunks to mimic the pacing of a live stream (default 0, no pause).
+//
+// Example:
+//
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithMemoryCache(1000, 5*time.Minute).
+//	    WithStreamReplay(8, 20*time.Millisecond).
+//	    OnStream(func(chunk string) { fmt.Print(chunk) })
+func (b *Builder) WithStreamReplay(chunkSize int, interval time.Duration) *Builder {
+	b.replayChunkSize = chunkSize
+	b.replayInterval = interval
+	return b
+}
+
+// WithCacheTags tags the responses cached by this builder, so they can be
+// invalidated together with InvalidateCacheTag. Requires a TaggedCache such
+// as MemoryCache or RedisCache.
+//
+// Example:
+//
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithRedisCache("localhost:6379", "", 0).
+//	    WithCacheTags("persona:support", "docs:v42")
+func (b *Builder) WithCacheTags(tags ...string) *Builder {
+	b.cacheTags = tags
+	return b
+}
+
+// InvalidateCacheTag removes the cached responses stored with tag
+func (b *Builder) InvalidateCacheTag(ctx context.Context, tag string) error {
+	tagged, ok := b.cache.(TaggedCache)
+	if !ok {
+		return fmt.Errorf("cache does not support tags: use MemoryCache or RedisCache")
+	}
+	return tagged.InvalidateTag(ctx, tag)
+}
+
+// WithStampedeProtection coalesces concurrent cache misses on the same
+// request: a single request calls the LLM and fills the cache while the
+// others wait for its response. Within a process requests are coalesced in
+// memory; with a CacheLocker such as RedisCache, a lock also coalesces
+// requests across processes.
+//
+// Example:
+//
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithRedisCache("localhost:6379", "", 0).
+//	    WithStampedeProtection(agent.StampedeConfig{LockTTL: time.Minute})
+func (b *Builder) WithStampedeProtection(config StampedeConfig) *Builder {
+	config = config.withDefaults()
+	b.stampede = &config
+	return b
+}
+
 func (b *Builder) WithCacheTTL(ttl time.Duration) *Builder {
 	b.cacheTTL = ttl
 	return b
@@ -94,3 +187,225 @@ func (b *Builder) ClearCache(ctx context.Context) error {
 	logger.Debug(ctx, "No cache to clear")
 	return nil
 }
+
+// cacheRequest returns the request sent for message, as fingerprinted by the cache
+func (b *Builder) cacheRequest(ctx context.Context, message string) *CompletionRequest {
+	var messages []Message
+	if memoryContext := b.memoryContext(ctx, message); memoryContext != "" {
+		messages = append(messages, System(memoryContext))
+	}
+	if b.fewshotConfig != nil && len(b.fewshotConfig.Examples) > 0 {
+		if fewshotPrompt := b.fewshotConfig.ToPrompt(); fewshotPrompt != "" {
+			messages = append(messages, System(fewshotPrompt))
+		}
+	}
+	messages = append(messages, b.messages...)
+	messages = append(messages, Message{Role: "user", Content: message, Images: b.pendingImages})
+
+	req := &CompletionRequest{
+		Model:            b.model,
+		Messages:         messages,
+		System:           b.systemPrompt,
+		Temperature:      b.getTemperature(),
+		TemperatureSet:   b.temperature != nil,
+		MaxTokens:        b.getMaxTokens(),
+		TopP:             b.getTopP(),
+		PresencePenalty:  b.getPresencePenalty(),
+		FrequencyPenalty: b.getFrequencyPenalty(),
+		Seed:             b.getSeed(),
+		Tools:            b.tools,
+	}
+	if b.toolChoice != nil {
+		req.ToolChoice = b.toolChoice
+	}
+	if b.responseFormat != nil {
+		req.ResponseFormat = b.responseFormat
+	}
+	if b.logprobs != nil {
+		req.LogProbs = *b.logprobs
+	}
+	if b.topLogprobs != nil {
+		req.TopLogProbs = int(*b.topLogprobs)
+	}
+	if b.n != nil {
+		req.N = int(*b.n)
+	}
+	return req
+}
+
+// cacheEntry locates the cached response to a request
+type cacheEntry struct {
+	key    string     // Fingerprint of the request
+	prompt string     // Question matched by semantic caches
+	scope  CacheScope // Rest of the request, for semantic caches
+}
+
+// cacheEntryFor returns the cache entry of message, asked as question before
+// RAG context was added, and whether the cache policy allows caching its response
+func (b *Builder) cacheEntryFor(ctx context.Context, message, question string) (cacheEntry, bool) {
+	req := b.cacheRequest(ctx, message)
+	if !b.cachePolicy.cacheable(req) {
+		return cacheEntry{}, false
+	}
+	entry := cacheEntry{key: b.cachePolicy.key(req), prompt: question}
+	if _, ok := b.cache.(SemanticLookup); ok {
+		entry.scope = b.cacheScope(req, question)
+	}
+	return entry, true
+}
+
+// lookupCache looks up the cached response to a request, recording the
+// lookup in metrics, traces and logs
+func (b *Builder) lookupCache(ctx context.Context, entry cacheEntry) (string, bool) {
+	logger := b.getLogger()
+	cacheStart := time.Now()
+	cacheCtx, cacheSpan := b.startSpan(ctx, "cache.lookup", trace.SpanKindInternal)
+	cached, found, err := b.cacheGet(cacheCtx, entry)
+	hit := err == nil && found
+	b.recordCacheLookup(hit)
+	cacheSpan.SetAttributes(attrCacheHit.Bool(hit))
+	endSpan(cacheSpan, err)
+
+	cacheDuration := time.Since(cacheStart)
+	if hit {
+		logger.Info(ctx, "Cache hit",
+			F("cache_key", entry.key),
+			F("duration_ms", cacheDuration.Milliseconds()))
+		return cached, true
+	}
+	logger.Debug(ctx, "Cache miss",
+		F("cache_key", entry.key),
+		F("duration_ms", cacheDuration.Milliseconds()))
+	return "", false
+}
+
+// replayStream sends a cached response to the OnStream callback in chunks
+func (b *Builder) replayStream(ctx context.Context, content string) error {
+	if b.onStream == nil {
+		return nil
+	}
+
+	chunkSize := b.replayChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultReplayChunkSize
+	}
+	runes := []rune(content)
+	for i := 0; i < len(runes); i += chunkSize {
+		if i > 0 && b.replayInterval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(b.replayInterval):
+			}
+		}
+		b.onStream(string(runes[i:min(i+chunkSize, len(runes))]))
+	}
+	return nil
+}
+
+// acquireCacheFill waits until no other request is filling the cache entry. It returns the response cached meanwhile, if any, or a release
+// function to call once the caller has filled the entry.
+func (b *Builder) acquireCacheFill(ctx context.Context, entry cacheEntry) (string, bool, func(), error) {
+	if b.stampede == nil {
+		return "", false, func() {}, nil
+	}
+	logger := b.getLogger()
+
+	// In-process: wait for the request filling the same entry of the same cache
+	flightKey := fmt.Sprintf("%p:%s", b.cache, entry.key)
+	for {
+		done, leader := cacheFlights.join(flightKey)
+		if leader {
+			break
+		}
+		logger.Debug(ctx, "Waiting for concurrent request", F("cache_key", entry.key))
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return "", false, nil, ctx.Err()
+		}
+		if cached, found := b.lookupCache(ctx, entry); found {
+			return cached, true, nil, nil
+		}
+	}
+	release := func() { cacheFlights.leave(flightKey) }
+
+	// Across processes: wait for the process holding the fill lock
+	locker, ok := b.cache.(CacheLocker)
+	if !ok {
+		return "", false, release, nil
+	}
+	deadline := time.Now().Add(b.stampede.WaitTimeout)
+	for waited := false; ; waited = true {
+		unlock, acquired, err := locker.TryLock(ctx, entry.key, b.stampede.LockTTL)
+		if err != nil {
+			logger.Warn(ctx, "Cache lock failed", F("cache_key", entry.key), F("error", err.Error()))
+			return "", false, release, nil
+		}
+		if acquired {
+			if waited {
+				if cached, found := b.lookupCache(ctx, entry); found {
+					unlock()
+					release()
+					return cached, true, nil, nil
+				}
+			}
+			return "", false, func() { unlock(); release() }, nil
+		}
+		if time.Now().After(deadline) {
+			logger.Warn(ctx, "Cache lock wait timed out", F("cache_key", entry.key))
+			return "", false, release, nil
+		}
+		select {
+		case <-time.After(b.stampede.PollInterval):
+		case <-ctx.Done():
+			release()
+			return "", false, nil, ctx.Err()
+		}
+	}
+}
+
+// cacheResponse stores the response to a request, using a 5 minute TTL
+// unless WithCacheTTL is set
+func (b *Builder) cacheResponse(ctx context.Context, entry cacheEntry, value string) {
+	ttl := b.cacheTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute // Default TTL
+	}
+	_ = b.cacheSet(ctx, entry, value, ttl)
+	b.getLogger().Debug(ctx, "Response cached", F("cache_key", entry.key), F("ttl_seconds", ttl.Seconds()))
+}
+
+// cacheScope returns the scope of the semantic cache entry of req: the
+// request without the question, whose similarity the cache matches
+func (b *Builder) cacheScope(req *CompletionRequest, question string) CacheScope {
+	scope := CacheScope{Model: b.model, SystemPrompt: b.systemPrompt}
+	if b.persona != nil {
+		scope.Persona = b.persona.Name
+	}
+
+	// The last message keeps its images and any RAG context before the question
+	last := &req.Messages[len(req.Messages)-1]
+	last.Content = strings.TrimSuffix(last.Content, question)
+	scope.Context = b.cachePolicy.key(req)
+	return scope
+}
+
+// cacheGet looks up a cached response by key, or by prompt for semantic caches
+func (b *Builder) cacheGet(ctx context.Context, entry cacheEntry) (string, bool, error) {
+	if semantic, ok := b.cache.(SemanticLookup); ok {
+		return semantic.GetSemantic(ctx, entry.scope, entry.prompt)
+	}
+	return b.cache.Get(ctx, entry.key)
+}
+
+// cacheSet stores a response by key, or by prompt for semantic caches
+func (b *Builder) cacheSet(ctx context.Context, entry cacheEntry, value string, ttl time.Duration) error {
+	if semantic, ok := b.cache.(SemanticLookup); ok {
+		return semantic.SetSemantic(ctx, entry.scope, entry.prompt, value, ttl)
+	}
+	if tagged, ok := b.cache.(TaggedCache); ok && len(b.cacheTags) > 0 {
+		return tagged.SetWithTags(ctx, entry.key, value, ttl, b.cacheTags...)
+	}
+	return b.cache.Set(ctx, entry.key, value, ttl)
+}
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunkStreamAdapter streams its chunks, failing after them when err is set
+type chunkStreamAdapter struct {
+	chunks       []string
+	finishReason string
+	err          error
+	calls        int
+}
+
+func (a *chunkStreamAdapter) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	a.calls++
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &CompletionResponse{Content: strings.Join(a.chunks, "")}, nil
+}
+
+func (a *chunkStreamAdapter) Stream(ctx context.Context, req *CompletionRequest, onChunk func(string)) (*CompletionResponse, error) {
+	a.calls++
+	for _, chunk := range a.chunks {
+		onChunk(chunk)
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &CompletionResponse{Content: strings.Join(a.chunks, ""), FinishReason: a.finishReason}, nil
+}
+
+func TestBuilder_StreamCache(t *testing.T) {
+	adapter := &chunkStreamAdapter{chunks: []string{"The capital ", "of France ", "is Paris."}}
+	var chunks []string
+	ai := NewWithAdapter("test-model", adapter).
+		WithMemoryCache(10, time.Minute).
+		WithStreamReplay(10, 0).
+		OnStream(func(chunk string) { chunks = append(chunks, chunk) })
+
+	for i := 0; i < 2; i++ {
+		chunks = nil
+		result, err := ai.Stream(context.Background(), "Capital of France?")
+		if err != nil || result != "The capital of France is Paris." {
+			t.Fatalf("Stream #%d = %q, %v", i+1, result, err)
+		}
+		if strings.Join(chunks, "") != result {
+			t.Errorf("Stream #%d chunks = %q, want the response", i+1, chunks)
+		}
+	}
+	if adapter.calls != 1 {
+		t.Fatalf("provider calls = %d, want the second stream served from cache", adapter.calls)
+	}
+	if len(chunks) != 4 || chunks[0] != "The capita" {
+		t.Errorf("replayed chunks = %q, want chunks of 10 runes", chunks)
+	}
+	if stats := ai.GetCacheStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("GetCacheStats() = %+v", stats)
+	}
+
+	// Ask shares the cache key of Stream
+	if answer, err := ai.Ask(context.Background(), "Capital of France?"); err != nil || answer != "The capital of France is Paris." || adapter.calls != 1 {
+		t.Errorf("Ask = %q, %v after %d provider calls, want the cached answer", answer, err, adapter.calls)
+	}
+}
+
+func TestBuilder_StreamCache_FailedStreamNotCached(t *testing.T) {
+	adapter := &chunkStreamAdapter{chunks: []string{"The capital "}, err: errors.New("connection reset")}
+	ai := NewWithAdapter("test-model", adapter).WithMemoryCache(10, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := ai.Stream(context.Background(), "Capital of France?"); err == nil {
+			t.Fatalf("Stream #%d should fail", i+1)
+		}
+	}
+	if adapter.calls != 2 {
+		t.Errorf("provider calls = %d, want the partial response not cached", adapter.calls)
+	}
+	if stats := ai.GetCacheStats(); stats.Size != 0 {
+		t.Errorf("cache size = %d, want 0", stats.Size)
+	}
+}
+
+func TestBuilder_StreamCache_TruncatedStreamNotCached(t *testing.T) {
+	for _, reason := range []string{"length", "content_filter"} {
+		adapter := &chunkStreamAdapter{chunks: []string{"The capital "}, finishReason: reason}
+		ai := NewWithAdapter("test-model", adapter).WithMemoryCache(10, time.Minute)
+		for i := 0; i < 2; i++ {
+			if _, err := ai.Stream(context.Background(), "Capital of France?"); err != nil {
+				t.Fatalf("Stream failed: %v", err)
+			}
+		}
+		if adapter.calls != 2 {
+			t.Errorf("finish reason %s: provider calls = %d, want the partial response not cached", reason, adapter.calls)
+		}
+	}
+
+	// Streams from OpenAI-compatible providers report the finish reason in the last chunk
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"llama3","choices":[{"index":0,"delta":{"role":"assistant","content":"The capital "},"finish_reason":null}]}`,
+			`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"llama3","choices":[{"index":0,"delta":{},"finish_reason":"length"}]}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+	defer server.Close()
+	ai := New(ProviderOllama, "llama3").WithBaseURL(server.URL+"/v1/").WithMemoryCache(10, time.Minute)
+	for i := 0; i < 2; i++ {
+		if result, err := ai.Stream(context.Background(), "Capital of France?"); err != nil || result != "The capital " {
+			t.Fatalf("Stream = %q, %v", result, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("provider calls = %d, want the truncated stream not cached", calls)
+	}
+}
+
+func TestBuilder_StreamCache_HitRecordsTurn(t *testing.T) {
+	adapter := &chunkStreamAdapter{chunks: []string{"Paris"}}
+	cache := NewMemoryCache(10, time.Minute)
+	ai := NewWithAdapter("test-model", adapter).WithCache(cache)
+	cached := NewWithAdapter("test-model", adapter).WithCache(cache).WithShortMemory()
+
+	// Fill the cache from another conversation
+	if _, err := ai.Stream(context.Background(), "Capital of France?"); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if _, err := cached.Stream(context.Background(), "Capital of France?"); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if adapter.calls != 1 {
+		t.Fatalf("provider calls = %d, want a cache hit", adapter.calls)
+	}
+	history := cached.GetHistory()
+	if len(history) != 2 || history[0].Content != "Capital of France?" || history[1].Content != "Paris" {
+		t.Errorf("history after a cache hit = %+v, want the turn", history)
+	}
+	if stats := cached.GetMemory().Stats(context.Background()); stats.TotalMessages != 2 {
+		t.Errorf("hierarchical memory holds %d messages, want the turn", stats.TotalMessages)
+	}
+}
+
+func TestBuilder_StreamCache_ReplayPacing(t *testing.T) {
+	adapter := &chunkStreamAdapter{chunks: []string{"Paris"}}
+	var chunks []string
+	ai := NewWithAdapter("test-model", adapter).
+		WithMemoryCache(10, time.Minute).
+		WithStreamReplay(1, 20*time.Millisecond).
+		OnStream(func(chunk string) { chunks = append(chunks, chunk) })
+
+	if _, err := ai.Stream(context.Background(), "Capital of France?"); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	chunks = nil
+	start := time.Now()
+	if _, err := ai.Stream(context.Background(), "Capital of France?"); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if elapsed := time.Since(start); len(chunks) != 5 || elapsed < 80*time.Millisecond {
+		t.Errorf("replayed %d chunks in %v, want 5 chunks paced by 20ms", len(chunks), elapsed)
+	}
+
+	// Cancelling the context stops the replay
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	chunks = nil
+	if _, err := ai.Stream(ctx, "Capital of France?"); !errors.Is(err, context.DeadlineExceeded) || len(chunks) >= 5 {
+		t.Errorf("Stream = %v after %d chunks, want the replay cancelled", err, len(chunks))
+	}
+}
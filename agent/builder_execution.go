@@ -14,9 +14,20 @@ import (
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/taipm/go-deep-agent/agent/memory"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
+	start := time.Now()
+	ctx, span := b.startChatSpan(ctx)
+	result, err := b.ask(ctx, message)
+	b.recordRequest(start, err)
+	endSpan(span, err)
+	return result, err
+}
+
+// ask implements Ask
+func (b *Builder) ask(ctx context.Context, message string) (string, error) {
 	start := time.Now()
 	logger := b.getLogger()
 
@@ -49,6 +60,7 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 		}
 
 		rateLimitDuration := time.Since(rateLimitStart)
+		b.recordRateLimitWait(rateLimitDuration)
 		if rateLimitDuration > 0 {
 			logger.Debug(ctx, "Rate limit wait completed",
 				F("duration_ms", rateLimitDuration.Milliseconds()),
@@ -79,38 +91,74 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 		}
 	}
 
+	// Semantic caches match the question, before RAG context is added
+	question := message
+	useToolLoop := b.autoExecute && len(b.tools) > 0
+
+	// RAG: Retrieve and inject relevant context if enabled. Retrieval runs
+	// before the cache lookup, so the cache key covers the retrieved context.
+	if b.ragEnabled && !useToolLoop && b.adapter == nil {
+		ragStart := time.Now()
+		ragCtx, ragSpan := b.startSpan(ctx, "rag.retrieve", trace.SpanKindInternal)
+		docs, err := b.retrieveRelevantDocs(ragCtx, question)
+		ragSpan.SetAttributes(attrRAGDocuments.Int(len(docs)))
+		endSpan(ragSpan, err)
+		if err != nil {
+			logger.Error(ctx, "RAG retrieval failed", F("error", err.Error()))
+			return "", fmt.Errorf("RAG retrieval failed: %w", err)
+		}
+
+		b.lastRetrievedDocs = docs
+		ragDuration := time.Since(ragStart)
+
+		if len(docs) > 0 {
+			logger.Debug(ctx, "RAG documents retrieved",
+				F("doc_count", len(docs)),
+				F("duration_ms", ragDuration.Milliseconds()))
+			// Inject context into the message
+			ragContext := b.buildRAGContext(docs)
+			message = fmt.Sprintf("Context:\n%s\n\nQuestion: %s", ragContext, message)
+		} else {
+			logger.Debug(ctx, "No RAG documents found", F("duration_ms", ragDuration.Milliseconds()))
+		}
+	}
+
 	// Check cache first if enabled
+	var entry cacheEntry
+	cacheable := false
 	if b.cacheEnabled && b.cache != nil {
-		cacheStart := time.Now()
-		temp := 0.0
-		if b.temperature != nil {
-			temp = *b.temperature
-		}
-		cacheKey := GenerateCacheKey(b.model, message, temp, b.systemPrompt)
-		if cached, found, err := b.cache.Get(ctx, cacheKey); err == nil && found {
-			cacheDuration := time.Since(cacheStart)
-			logger.Info(ctx, "Cache hit",
-				F("cache_key", cacheKey),
-				F("duration_ms", cacheDuration.Milliseconds()))
+		entry, cacheable = b.cacheEntryFor(ctx, message, question)
+	}
+	if cacheable {
+		if cached, found := b.lookupCache(ctx, entry); found {
 			return cached, nil
-		} else {
-			cacheDuration := time.Since(cacheStart)
-			logger.Debug(ctx, "Cache miss",
-				F("cache_key", cacheKey),
-				F("duration_ms", cacheDuration.Milliseconds()))
 		}
+		cached, found, release, err := b.acquireCacheFill(ctx, entry)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return cached, nil
+		}
+		defer release()
 	}
 
 	// If auto-execute is enabled and we have tools, use tool execution loop
-	if b.autoExecute && len(b.tools) > 0 {
+	if useToolLoop {
 		logger.Debug(ctx, "Using tool execution loop", F("tool_count", len(b.tools)), F("has_adapter", b.adapter != nil))
 
+		var result string
+		var err error
 		// CRITICAL FIX: Use adapter-based tool execution if adapter is available
 		if b.adapter != nil {
-			return b.askWithToolExecutionAdapter(ctx, message)
+			result, err = b.askWithToolExecutionAdapter(ctx, message)
+		} else {
+			result, err = b.askWithToolExecution(ctx, message)
 		}
-
-		return b.askWithToolExecution(ctx, message)
+		if err == nil && cacheable {
+			b.cacheResponse(ctx, entry, result)
+		}
+		return result, err
 	}
 
 	// ADAPTER FALLBACK: If adapter is available but no auto-execute/tools, use simple adapter completion
@@ -120,11 +168,17 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 		// Build unified messages for adapter (excluding system prompt)
 		unifiedMessages := []Message{}
 
+		// Add context from hierarchical memory (rolling summary, known facts)
+		if memoryContext := b.memoryContext(ctx, message); memoryContext != "" {
+			unifiedMessages = append(unifiedMessages, System(memoryContext))
+		}
+
 		// Add existing conversation history
 		unifiedMessages = append(unifiedMessages, b.messages...)
 
-		// Add current message
-		unifiedMessages = append(unifiedMessages, User(message))
+		// Add current message with its images
+		unifiedMessages = append(unifiedMessages, Message{Role: "user", Content: message, Images: b.pendingImages})
+		b.pendingImages = nil
 
 		// Create completion request for adapter with all parameters
 		req := &CompletionRequest{
@@ -132,6 +186,7 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 			Messages:         unifiedMessages,
 			System:           b.systemPrompt, // Use dedicated System field
 			Temperature:      b.getTemperature(),
+			TemperatureSet:   b.temperature != nil,
 			MaxTokens:        b.getMaxTokens(),
 			TopP:             b.getTopP(),
 			PresencePenalty:  b.getPresencePenalty(),
@@ -155,6 +210,34 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 			return "", err
 		}
 
+		b.recordTokenUsage(resp.Usage)
+		b.traceCompletion(ctx, resp.Usage, resp.FinishReason, resp.Model)
+
+		// Hierarchical memory: store messages in memory system
+		if b.memoryEnabled && b.memory != nil {
+			userMsg := memory.Message{
+				Role:      "user",
+				Content:   message,
+				Timestamp: time.Now(),
+				Metadata: map[string]interface{}{
+					"adapter": true,
+				},
+			}
+			_ = b.memory.Add(ctx, userMsg)
+
+			assistantMsg := memory.Message{
+				Role:      "assistant",
+				Content:   resp.Content,
+				Timestamp: time.Now(),
+				Metadata: map[string]interface{}{
+					"adapter":       true,
+					"prompt_tokens": resp.Usage.PromptTokens,
+					"total_tokens":  resp.Usage.TotalTokens,
+				},
+			}
+			_ = b.memory.Add(ctx, assistantMsg)
+		}
+
 		// Update conversation history if auto-memory is enabled
 		if b.autoMemory {
 			b.messages = append(b.messages, Message{Role: "user", Content: message})
@@ -163,35 +246,15 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 			}
 		}
 
-		return resp.Content, nil
-	}
-
-	// RAG: Retrieve and inject relevant context if enabled
-	if b.ragEnabled {
-		ragStart := time.Now()
-		docs, err := b.retrieveRelevantDocs(ctx, message)
-		if err != nil {
-			logger.Error(ctx, "RAG retrieval failed", F("error", err.Error()))
-			return "", fmt.Errorf("RAG retrieval failed: %w", err)
+		if cacheable {
+			b.cacheResponse(ctx, entry, resp.Content)
 		}
 
-		b.lastRetrievedDocs = docs
-		ragDuration := time.Since(ragStart)
-
-		if len(docs) > 0 {
-			logger.Debug(ctx, "RAG documents retrieved",
-				F("doc_count", len(docs)),
-				F("duration_ms", ragDuration.Milliseconds()))
-			// Inject context into the message
-			ragContext := b.buildRAGContext(docs)
-			message = fmt.Sprintf("Context:\n%s\n\nQuestion: %s", ragContext, message)
-		} else {
-			logger.Debug(ctx, "No RAG documents found", F("duration_ms", ragDuration.Milliseconds()))
-		}
+		return resp.Content, nil
 	}
 
 	// Build messages array (includes multimodal content if images added)
-	messages := b.buildMessages(message)
+	messages := b.buildMessages(ctx, message)
 
 	// Clear pending images after building messages
 	b.pendingImages = nil
@@ -211,18 +274,8 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 	result := completion.Choices[0].Message.Content
 
 	// Store in cache if enabled
-	if b.cacheEnabled && b.cache != nil {
-		temp := 0.0
-		if b.temperature != nil {
-			temp = *b.temperature
-		}
-		cacheKey := GenerateCacheKey(b.model, message, temp, b.systemPrompt)
-		ttl := b.cacheTTL
-		if ttl <= 0 {
-			ttl = 5 * time.Minute // Default TTL
-		}
-		_ = b.cache.Set(ctx, cacheKey, result, ttl)
-		logger.Debug(ctx, "Response cached", F("cache_key", cacheKey), F("ttl_seconds", ttl.Seconds()))
+	if cacheable {
+		b.cacheResponse(ctx, entry, result)
 	}
 
 	// Track token usage
@@ -231,6 +284,8 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 		CompletionTokens: int(completion.Usage.CompletionTokens),
 		TotalTokens:      int(completion.Usage.TotalTokens),
 	}
+	b.recordTokenUsage(b.lastUsage)
+	b.traceCompletion(ctx, b.lastUsage, string(completion.Choices[0].FinishReason), completion.Model)
 
 	totalDuration := time.Since(start)
 	logger.Info(ctx, "Ask request completed",
@@ -293,7 +348,11 @@ func (b *Builder) Ask(ctx context.Context, message string) (string, error) {
 			defer cancel()
 
 			saveStart := time.Now()
-			if err := b.longMemoryBackend.Save(saveCtx, b.longMemoryID, b.messages); err != nil {
+			err := b.longMemoryBackend.Save(saveCtx, b.longMemoryID, b.messages)
+			if err == nil {
+				err = b.saveMemorySnapshot(saveCtx)
+			}
+			if err != nil {
 				logger.Error(saveCtx, "Failed to auto-save long-term memory",
 					F("memory_id", b.longMemoryID),
 					F("error", err.Error()),
@@ -315,7 +374,7 @@ func (b *Builder) askWithToolExecution(ctx context.Context, message string) (str
 	logger.Debug(ctx, "Tool execution loop started", F("max_rounds", b.maxToolRounds))
 
 	// Build messages array (includes multimodal content if images added)
-	messages := b.buildMessages(message)
+	messages := b.buildMessages(ctx, message)
 
 	// Clear pending images after building messages
 	b.pendingImages = nil
@@ -347,6 +406,10 @@ func (b *Builder) askWithToolExecution(ctx context.Context, message string) (str
 		if len(choice.Message.ToolCalls) == 0 {
 			// No tool calls, return the final response
 			result := choice.Message.Content
+			b.traceCompletion(ctx, TokenUsage{
+				PromptTokens:     int(completion.Usage.PromptTokens),
+				CompletionTokens: int(completion.Usage.CompletionTokens),
+			}, string(choice.FinishReason), completion.Model)
 			logger.Info(ctx, "Tool execution completed",
 				F("rounds", round+1),
 				F("response_length", len(result)))
@@ -437,7 +500,7 @@ func (b *Builder) AskMultiple(ctx context.Context, message string) ([]string, er
 	}
 
 	// Build messages array
-	messages := b.buildMessages(message)
+	messages := b.buildMessages(ctx, message)
 
 	// Execute request
 	completion, err := b.executeSyncRaw(ctx, messages)
@@ -461,6 +524,16 @@ func (b *Builder) AskMultiple(ctx context.Context, message string) ([]string, er
 }
 
 func (b *Builder) Stream(ctx context.Context, message string) (string, error) {
+	start := time.Now()
+	ctx, span := b.startChatSpan(ctx)
+	result, err := b.stream(ctx, message)
+	b.recordRequest(start, err)
+	endSpan(span, err)
+	return result, err
+}
+
+// stream implements Stream
+func (b *Builder) stream(ctx context.Context, message string) (string, error) {
 	start := time.Now()
 	logger := b.getLogger()
 
@@ -496,6 +569,7 @@ func (b *Builder) Stream(ctx context.Context, message string) (string, error) {
 		}
 
 		rateLimitDuration := time.Since(rateLimitStart)
+		b.recordRateLimitWait(rateLimitDuration)
 		if rateLimitDuration > 0 {
 			logger.Debug(ctx, "Rate limit wait completed in stream",
 				F("duration_ms", rateLimitDuration.Milliseconds()),
@@ -518,20 +592,54 @@ func (b *Builder) Stream(ctx context.Context, message string) (string, error) {
 		return "", err
 	}
 
+	// Check cache first if enabled, replaying hits through the stream callback
+	var entry cacheEntry
+	cacheable := false
+	if b.cacheEnabled && b.cache != nil {
+		entry, cacheable = b.cacheEntryFor(ctx, message, message)
+	}
+	if cacheable {
+		cached, found := b.lookupCache(ctx, entry)
+		if !found {
+			var release func()
+			var err error
+			cached, found, release, err = b.acquireCacheFill(ctx, entry)
+			if err != nil {
+				return "", err
+			}
+			if !found {
+				defer release()
+			}
+		}
+		if found {
+			b.pendingImages = nil
+			if err := b.replayStream(ctx, cached); err != nil {
+				return "", err
+			}
+			b.recordStreamTurn(ctx, message, cached, map[string]interface{}{"cached": true})
+			return cached, nil
+		}
+	}
+
 	// Build messages array (includes multimodal content if images added)
-	messages := b.buildMessages(message)
+	messages := b.buildMessages(ctx, message)
 
 	// Clear pending images after building messages
 	b.pendingImages = nil
 
 	// CRITICAL FIX: Use adapter if available for streaming
 	if b.adapter != nil {
-		return b.streamWithAdapter(ctx, messages, func(chunk string) {
+		result, finishReason, err := b.streamWithAdapter(ctx, messages, func(chunk string) {
 			// Call onStream callback if available
 			if b.onStream != nil {
 				b.onStream(chunk)
 			}
 		})
+		// Only complete streams are cached
+		if err == nil && cacheable && result != "" && streamCompleted(finishReason) {
+			b.cacheResponse(ctx, entry, result)
+		}
+		return result, err
 	}
 
 	// Ensure client is initialized
@@ -603,8 +711,38 @@ func (b *Builder) Stream(ctx context.Context, message string) (string, error) {
 		F("chunks", chunkCount),
 		F("response_length", len(fullContent)))
 
+	// Store in cache if enabled. Streams cut off by the token limit or the
+	// content filter are partial and not cached.
+	finishReason := ""
+	if len(acc.Choices) > 0 {
+		finishReason = string(acc.Choices[0].FinishReason)
+	}
+	if cacheable && fullContent != "" && streamCompleted(finishReason) {
+		b.cacheResponse(ctx, entry, fullContent)
+	}
+
+	b.recordStreamTurn(ctx, message, fullContent, map[string]interface{}{"chunks": chunkCount})
+
+	return fullContent, nil
+}
+
+// streamCompleted reports whether a stream ending with finishReason holds the
+// complete response
+func streamCompleted(finishReason string) bool {
+	return finishReason != "length" && finishReason != "content_filter"
+}
+
+// recordStreamTurn stores a streamed exchange in hierarchical memory and
+// conversation history, and auto-saves long-term memory. metadata is added to
+// the assistant message in hierarchical memory.
+func (b *Builder) recordStreamTurn(ctx context.Context, message, content string, metadata map[string]interface{}) {
+	if content == "" {
+		return
+	}
+	logger := b.getLogger()
+
 	// Hierarchical memory: store messages in memory system
-	if b.memoryEnabled && b.memory != nil && fullContent != "" {
+	if b.memoryEnabled && b.memory != nil {
 		// Store user message
 		userMsg := memory.Message{
 			Role:      "user",
@@ -617,32 +755,37 @@ func (b *Builder) Stream(ctx context.Context, message string) (string, error) {
 		_ = b.memory.Add(ctx, userMsg)
 
 		// Store assistant response
+		assistantMetadata := map[string]interface{}{"streaming": true}
+		for key, value := range metadata {
+			assistantMetadata[key] = value
+		}
 		assistantMsg := memory.Message{
 			Role:      "assistant",
-			Content:   fullContent,
+			Content:   content,
 			Timestamp: time.Now(),
-			Metadata: map[string]interface{}{
-				"streaming": true,
-				"chunks":    chunkCount,
-			},
+			Metadata:  assistantMetadata,
 		}
 		_ = b.memory.Add(ctx, assistantMsg)
 	}
 
 	// Auto-memory: store conversation (legacy FIFO)
-	if b.autoMemory && fullContent != "" {
+	if b.autoMemory {
 		b.addMessage(User(message))
-		b.addMessage(Assistant(fullContent))
+		b.addMessage(Assistant(content))
 	}
 
 	// Long-term memory: auto-save after successful stream (v0.9.0+)
-	if b.autoSaveLongMemory && b.longMemoryID != "" && b.longMemoryBackend != nil && fullContent != "" {
+	if b.autoSaveLongMemory && b.longMemoryID != "" && b.longMemoryBackend != nil {
 		go func() {
 			saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
 
 			saveStart := time.Now()
-			if err := b.longMemoryBackend.Save(saveCtx, b.longMemoryID, b.messages); err != nil {
+			err := b.longMemoryBackend.Save(saveCtx, b.longMemoryID, b.messages)
+			if err == nil {
+				err = b.saveMemorySnapshot(saveCtx)
+			}
+			if err != nil {
 				logger.Error(saveCtx, "Failed to auto-save long-term memory after stream",
 					F("memory_id", b.longMemoryID),
 					F("error", err.Error()),
@@ -655,8 +798,6 @@ func (b *Builder) Stream(ctx context.Context, message string) (string, error) {
 			}
 		}()
 	}
-
-	return fullContent, nil
 }
 
 func (b *Builder) StreamPrint(ctx context.Context, message string) (string, error) {
@@ -702,7 +843,7 @@ func (b *Builder) ensureClient() error {
 	return nil
 }
 
-func (b *Builder) buildMessages(userMessage string) []openai.ChatCompletionMessageParamUnion {
+func (b *Builder) buildMessages(ctx context.Context, userMessage string) []openai.ChatCompletionMessageParamUnion {
 	result := []openai.ChatCompletionMessageParamUnion{}
 
 	// Add system prompt if set
@@ -710,6 +851,11 @@ func (b *Builder) buildMessages(userMessage string) []openai.ChatCompletionMessa
 		result = append(result, openai.SystemMessage(b.systemPrompt))
 	}
 
+	// Add context from hierarchical memory (rolling summary, known facts)
+	if memoryContext := b.memoryContext(ctx, userMessage); memoryContext != "" {
+		result = append(result, openai.SystemMessage(memoryContext))
+	}
+
 	// Add few-shot examples after system prompt, before conversation history
 	// This ensures examples guide behavior without being part of conversation context
 	if b.fewshotConfig != nil && len(b.fewshotConfig.Examples) > 0 {
@@ -862,10 +1008,11 @@ func (b *Builder) executeWithAdapter(ctx context.Context, messages []openai.Chat
 
 	// Create completion request
 	req := &CompletionRequest{
-		Model:       b.model,
-		Messages:    unifiedMessages,
-		Temperature: b.getTemperature(),
-		MaxTokens:   b.getMaxTokens(),
+		Model:          b.model,
+		Messages:       unifiedMessages,
+		Temperature:    b.getTemperature(),
+		TemperatureSet: b.temperature != nil,
+		MaxTokens:      b.getMaxTokens(),
 	}
 
 	// Execute through adapter
@@ -890,6 +1037,139 @@ func (b *Builder) executeWithAdapter(ctx context.Context, messages []openai.Chat
 	}, nil
 }
 
+// completeText performs a single stateless completion with the Builder's model.
+// It does not read or write conversation history, memory, cache or tools, which
+// makes it safe to call from internal components (e.g. memory summarization).
+func (b *Builder) completeText(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	if b.adapter != nil {
+		resp, err := b.adapter.Complete(ctx, &CompletionRequest{
+			Model:          b.model,
+			Messages:       []Message{User(prompt)},
+			System:         systemPrompt,
+			Temperature:    b.getTemperature(),
+			TemperatureSet: b.temperature != nil,
+			MaxTokens:      b.getMaxTokens(),
+		})
+		if err != nil {
+			return "", fmt.Errorf("adapter completion failed: %w", err)
+		}
+		return resp.Content, nil
+	}
+
+	if err := b.ensureClient(); err != nil {
+		return "", fmt.Errorf("failed to initialize client: %w", err)
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{}
+	if systemPrompt != "" {
+		messages = append(messages, openai.SystemMessage(systemPrompt))
+	}
+	messages = append(messages, openai.UserMessage(prompt))
+
+	params := openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(b.model),
+		Messages: messages,
+	}
+	if b.temperature != nil {
+		params.Temperature = openai.Float(*b.temperature)
+	}
+	if b.maxTokens != nil {
+		params.MaxTokens = openai.Int(*b.maxTokens)
+	}
+
+	completion, err := b.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return "", fmt.Errorf("chat completion failed: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("no response choices returned")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+// completeMessages performs a single stateless completion of a conversation,
+// offering tools to the model without executing them. Tool calls are returned
+// in the response so that the caller drives the tool loop (e.g. MultiProvider).
+func (b *Builder) completeMessages(ctx context.Context, messages []Message, tools []*Tool) (*CompletionResponse, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	if b.adapter != nil {
+		resp, err := b.adapter.Complete(ctx, &CompletionRequest{
+			Model:          b.model,
+			Messages:       messages,
+			Temperature:    b.getTemperature(),
+			TemperatureSet: b.temperature != nil,
+			MaxTokens:      b.getMaxTokens(),
+			Tools:          tools,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("adapter completion failed: %w", err)
+		}
+		return resp, nil
+	}
+
+	if err := b.ensureClient(); err != nil {
+		return nil, fmt.Errorf("failed to initialize client: %w", err)
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(b.model),
+		Messages: convertMessages(messages),
+	}
+	if b.temperature != nil {
+		params.Temperature = openai.Float(*b.temperature)
+	}
+	if b.maxTokens != nil {
+		params.MaxTokens = openai.Int(*b.maxTokens)
+	}
+	for _, tool := range tools {
+		params.Tools = append(params.Tools, tool.toOpenAI())
+	}
+
+	completion, err := b.client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("chat completion failed: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("no response choices returned")
+	}
+
+	choice := completion.Choices[0]
+	resp := &CompletionResponse{
+		Content:      choice.Message.Content,
+		FinishReason: choice.FinishReason,
+		Refusal:      choice.Message.Refusal,
+		ID:           completion.ID,
+		Model:        completion.Model,
+		Created:      completion.Created,
+		Usage: TokenUsage{
+			PromptTokens:     int(completion.Usage.PromptTokens),
+			CompletionTokens: int(completion.Usage.CompletionTokens),
+			TotalTokens:      int(completion.Usage.TotalTokens),
+		},
+	}
+	for _, toolCall := range choice.Message.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:        toolCall.ID,
+			Type:      "function",
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		})
+	}
+	return resp, nil
+}
+
 // getTemperature returns the temperature value with proper defaults
 func (b *Builder) getTemperature() float64 {
 	if b.temperature != nil {
@@ -939,9 +1219,9 @@ func (b *Builder) getSeed() int64 {
 }
 
 // streamWithAdapter executes a streaming request using the adapter (basic implementation)
-func (b *Builder) streamWithAdapter(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, onChunk func(string)) (string, error) {
+func (b *Builder) streamWithAdapter(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, onChunk func(string)) (string, string, error) {
 	if b.adapter == nil {
-		return "", fmt.Errorf("no adapter available")
+		return "", "", fmt.Errorf("no adapter available")
 	}
 
 	// Extract user message content (same logic as executeWithAdapter)
@@ -980,6 +1260,7 @@ func (b *Builder) streamWithAdapter(ctx context.Context, messages []openai.ChatC
 		Messages:         unifiedMessages,
 		System:           b.systemPrompt,
 		Temperature:      b.getTemperature(),
+		TemperatureSet:   b.temperature != nil,
 		MaxTokens:        b.getMaxTokens(),
 		TopP:             b.getTopP(),
 		PresencePenalty:  b.getPresencePenalty(),
@@ -991,11 +1272,11 @@ func (b *Builder) streamWithAdapter(ctx context.Context, messages []openai.ChatC
 	// Execute streaming through adapter
 	resp, err := b.adapter.Stream(adapterCtx, req, onChunk)
 	if err != nil {
-		return "", fmt.Errorf("adapter streaming failed: %w", err)
+		return "", "", fmt.Errorf("adapter streaming failed: %w", err)
 	}
 
-	// Return the accumulated content
-	return resp.Content, nil
+	// Return the accumulated content and why the stream ended
+	return resp.Content, resp.FinishReason, nil
 }
 
 func (b *Builder) executeWithRetry(ctx context.Context, operation func(context.Context) error) error {
@@ -1162,6 +1443,11 @@ func (b *Builder) askWithToolExecutionAdapter(ctx context.Context, message strin
 		unifiedMessages = append(unifiedMessages, System(b.systemPrompt))
 	}
 
+	// Add context from hierarchical memory (rolling summary, known facts)
+	if memoryContext := b.memoryContext(ctx, message); memoryContext != "" {
+		unifiedMessages = append(unifiedMessages, System(memoryContext))
+	}
+
 	// Add existing conversation history
 	unifiedMessages = append(unifiedMessages, b.messages...)
 
@@ -1179,6 +1465,7 @@ func (b *Builder) askWithToolExecutionAdapter(ctx context.Context, message strin
 			Model:            b.model,
 			Messages:         unifiedMessages,
 			Temperature:      b.getTemperature(),
+			TemperatureSet:   b.temperature != nil,
 			MaxTokens:        b.getMaxTokens(),
 			TopP:             b.getTopP(),
 			PresencePenalty:  b.getPresencePenalty(),
@@ -1208,6 +1495,7 @@ func (b *Builder) askWithToolExecutionAdapter(ctx context.Context, message strin
 		if len(resp.ToolCalls) == 0 {
 			// No tool calls, return the final response
 			result := resp.Content
+			b.traceCompletion(ctx, resp.Usage, resp.FinishReason, resp.Model)
 			logger.Info(ctx, "Adapter tool execution completed",
 				F("rounds", round+1),
 				F("response_length", len(result)))
@@ -1362,9 +1650,5 @@ func (b *Builder) executeSingleAdapterTool(ctx context.Context, toolCall ToolCal
 	}
 
 	// Execute the tool handler
-	if targetTool.Handler != nil {
-		return targetTool.Handler(toolCall.Arguments)
-	}
-
-	return "", fmt.Errorf("tool %s has no handler", toolCall.Name)
+	return b.callTool(ctx, targetTool, toolCall.Arguments)
 }
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"testing"
 
 	"github.com/openai/openai-go/v3"
@@ -381,7 +382,7 @@ func TestBuildMessagesWithSystem(t *testing.T) {
 	builder := NewOpenAI("gpt-4o-mini", "test-key").
 		WithSystem("You are helpful")
 
-	messages := builder.buildMessages("Hello")
+	messages := builder.buildMessages(context.Background(), "Hello")
 
 	if len(messages) != 2 {
 		t.Fatalf("Expected 2 messages, got %d", len(messages))
@@ -400,7 +401,7 @@ func TestBuildMessagesWithHistory(t *testing.T) {
 			Assistant("Previous response"),
 		})
 
-	messages := builder.buildMessages("New message")
+	messages := builder.buildMessages(context.Background(), "New message")
 
 	// Should have: previous user, previous assistant, new user
 	if len(messages) != 3 {
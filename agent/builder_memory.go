@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"strings"
 
 	"github.com/taipm/go-deep-agent/agent/memory"
 )
@@ -48,12 +49,96 @@ func (b *Builder) WithMemory() *Builder {
 	return b.WithShortMemory()
 }
 
+// WithHierarchicalMemory replaces the hierarchical memory system with one built from config.
+// With SummarizationMode "llm", compressed working memory is summarized by this
+// Builder's model into a single rolling summary.
+//
+// Example:
+//
+//	config := memory.DefaultMemoryConfig()
+//	config.SummarizationMode = "llm"
+//	agent := NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithHierarchicalMemory(config)
 func (b *Builder) WithHierarchicalMemory(config memory.MemoryConfig) *Builder {
 	b.memory = memory.NewWithConfig(config)
 	b.memoryEnabled = true
+
+	// Restore state already persisted for WithLongMemory() called earlier in the chain
+	if b.longMemoryID != "" {
+		_ = b.loadMemorySnapshot(context.Background())
+	}
+
+	if config.SummarizationMode == "llm" {
+		b.memory.SetSummarizer(memory.NewLLMSummarizer(&builderCompletionAdapter{builder: b}))
+	}
+	if config.SemanticAutoLearn {
+		b.memory.SetFactExtractor(memory.NewLLMFactExtractor(&builderCompletionAdapter{builder: b}))
+	}
+	return b
+}
+
+// WithMemorySummarizer sets a custom summarizer for working memory compression.
+// Use this to summarize with a different (e.g. cheaper) model than the main one.
+//
+// Example:
+//
+//	summaryModel := agent.NewOpenAI("gpt-4o-mini", apiKey)
+//	agent := NewOpenAI("gpt-4o", apiKey).
+//	    WithMemorySummarizer(memory.NewLLMSummarizer(summaryModel.CompletionAdapter()))
+func (b *Builder) WithMemorySummarizer(summarizer memory.Summarizer) *Builder {
+	if b.memory == nil {
+		b.memory = memory.New()
+	}
+	b.memory.SetSummarizer(summarizer)
+	b.memoryEnabled = true
 	return b
 }
 
+// CompletionAdapter exposes this Builder's model as a memory.CompletionAdapter.
+// Requests made through it are stateless: they bypass conversation history,
+// hierarchical memory, caching and tools.
+func (b *Builder) CompletionAdapter() memory.CompletionAdapter {
+	return &builderCompletionAdapter{builder: b}
+}
+
+// defaultFactsInPrompt is how many known facts are included in prompts
+const defaultFactsInPrompt = 5
+
+// memoryContext returns context from hierarchical memory to include in the
+// prompt: the rolling summary of compressed conversation and known facts
+// relevant to the message. Returns an empty string if there is none.
+func (b *Builder) memoryContext(ctx context.Context, message string) string {
+	if !b.memoryEnabled || b.memory == nil {
+		return ""
+	}
+
+	var sections []string
+
+	if summary := b.memory.Summary(ctx); summary != "" {
+		sections = append(sections, "Summary of earlier conversation:\n"+summary)
+	}
+
+	if facts, err := b.memory.Facts(ctx, message, defaultFactsInPrompt); err == nil && len(facts) > 0 {
+		lines := make([]string, 0, len(facts))
+		for _, fact := range facts {
+			lines = append(lines, "- "+fact.Content)
+		}
+		sections = append(sections, "Known facts about the user:\n"+strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+// builderCompletionAdapter adapts a Builder to memory.CompletionAdapter
+type builderCompletionAdapter struct {
+	builder *Builder
+}
+
+// Complete implements memory.CompletionAdapter
+func (a *builderCompletionAdapter) Complete(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	return a.builder.completeText(ctx, systemPrompt, prompt)
+}
+
 // DisableShortMemory disables short-term memory.
 // Messages will not be kept in RAM.
 func (b *Builder) DisableShortMemory() *Builder {
@@ -124,6 +209,62 @@ func (b *Builder) WithSemanticMemory() *Builder {
 	return b
 }
 
+// WithSemanticMemoryConfig enables semantic memory with embedding-based fact search.
+// Facts are embedded when stored or updated and ranked by similarity to the
+// message, confidence and recency. Set VectorStore (see NewMemoryVectorStoreAdapter)
+// to keep facts in Qdrant or Chroma.
+//
+// Example:
+//
+//	embedding, _ := NewOpenAIEmbedding("text-embedding-3-small", apiKey)
+//	agent := NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithSemanticMemoryConfig(memory.SemanticMemoryConfig{Embedding: embedding}).
+//	    WithSemanticAutoLearn()
+func (b *Builder) WithSemanticMemoryConfig(config memory.SemanticMemoryConfig) *Builder {
+	if b.memory == nil {
+		b.memory = memory.New()
+	}
+	memConfig := b.memory.GetConfig()
+	memConfig.SemanticEnabled = true
+	_ = b.memory.SetConfig(memConfig)
+	b.memory.SetSemanticMemory(memory.NewSemanticMemoryWithConfig(config))
+	b.memoryEnabled = true
+	return b
+}
+
+// WithSemanticAutoLearn enables semantic memory and automatic fact extraction.
+// After each assistant turn, this Builder's model extracts durable facts about
+// the user (preferences, personal details, goals) and reconciles them with the
+// facts already known. Relevant facts are included in later prompts.
+//
+// Extraction runs synchronously when the turn is recorded, so every Ask pays
+// for an additional LLM round trip and its tokens. Use WithFactExtractor with
+// a cheaper model to reduce the cost.
+//
+// Example:
+//
+//	agent := NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithSemanticAutoLearn()
+func (b *Builder) WithSemanticAutoLearn() *Builder {
+	return b.WithFactExtractor(memory.NewLLMFactExtractor(&builderCompletionAdapter{builder: b}))
+}
+
+// WithFactExtractor enables semantic memory and automatic fact extraction
+// using a custom extractor (e.g. one backed by a cheaper model). The extractor
+// is called synchronously after each assistant turn.
+func (b *Builder) WithFactExtractor(extractor memory.FactExtractor) *Builder {
+	if b.memory == nil {
+		b.memory = memory.New()
+	}
+	config := b.memory.GetConfig()
+	config.SemanticEnabled = true
+	config.SemanticAutoLearn = true
+	_ = b.memory.SetConfig(config)
+	b.memory.SetFactExtractor(extractor)
+	b.memoryEnabled = true
+	return b
+}
+
 // ============================================================================
 // LONG-TERM MEMORY (Persistent Storage) - v0.9.0+
 // ============================================================================
@@ -152,6 +293,9 @@ func (b *Builder) WithSemanticMemory() *Builder {
 //  2. If yes, load previous conversation history
 //  3. After each Ask()/Stream(), auto-save to storage
 //
+// Backends implementing MemorySnapshotBackend (FileBackend, RedisBackend) also
+// persist hierarchical memory: episodic messages, learned facts and compression stats.
+//
 // See also: SaveLongMemory(), LoadLongMemory(), DeleteLongMemory()
 func (b *Builder) WithLongMemory(id string) *Builder {
 	b.longMemoryID = id
@@ -198,6 +342,14 @@ func (b *Builder) WithLongMemory(id string) *Builder {
 					F("message_count", len(messages)))
 			}
 		}
+
+		// Restore hierarchical memory (episodic messages, facts) if the backend supports it
+		if err := b.loadMemorySnapshot(ctx); err != nil && b.logger != nil {
+			b.logger.Error(ctx, "Failed to load hierarchical memory snapshot",
+				F("memory_id", id),
+				F("error", err.Error()),
+				F("fallback", "empty hierarchical memory"))
+		}
 	}
 
 	return b
@@ -317,7 +469,11 @@ func (b *Builder) SaveLongMemory(ctx context.Context) error {
 		return ErrLongMemoryBackendRequired
 	}
 
-	return b.longMemoryBackend.Save(ctx, b.longMemoryID, b.messages)
+	if err := b.longMemoryBackend.Save(ctx, b.longMemoryID, b.messages); err != nil {
+		return err
+	}
+
+	return b.saveMemorySnapshot(ctx)
 }
 
 // SaveSession is deprecated. Use SaveLongMemory() instead.
@@ -355,7 +511,37 @@ func (b *Builder) LoadLongMemory(ctx context.Context) error {
 		b.messages = messages
 	}
 
-	return nil
+	return b.loadMemorySnapshot(ctx)
+}
+
+// saveMemorySnapshot saves the hierarchical memory state if the backend
+// implements MemorySnapshotBackend. No-op otherwise.
+func (b *Builder) saveMemorySnapshot(ctx context.Context) error {
+	snapshotBackend, ok := b.longMemoryBackend.(MemorySnapshotBackend)
+	if !ok || !b.memoryEnabled || b.memory == nil || b.longMemoryID == "" {
+		return nil
+	}
+
+	snapshot, err := b.memory.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	return snapshotBackend.SaveSnapshot(ctx, b.longMemoryID, snapshot)
+}
+
+// loadMemorySnapshot restores the hierarchical memory state if the backend
+// implements MemorySnapshotBackend and a snapshot exists. No-op otherwise.
+func (b *Builder) loadMemorySnapshot(ctx context.Context) error {
+	snapshotBackend, ok := b.longMemoryBackend.(MemorySnapshotBackend)
+	if !ok || !b.memoryEnabled || b.memory == nil || b.longMemoryID == "" {
+		return nil
+	}
+
+	snapshot, err := snapshotBackend.LoadSnapshot(ctx, b.longMemoryID)
+	if err != nil || snapshot == nil {
+		return err
+	}
+	return b.memory.Restore(ctx, snapshot)
 }
 
 // LoadSession is deprecated. Use LoadLongMemory() instead.
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -556,3 +557,165 @@ func TestBuilder_SessionPersistence_WithTimeout(t *testing.T) {
 	// May or may not error depending on timing, but shouldn't crash
 	_ = err
 }
+
+// summaryRecordingAdapter answers summarization requests with a fixed summary
+// and records the chat requests it receives
+type summaryRecordingAdapter struct {
+	chatRequests []*CompletionRequest
+	summaryCalls int
+}
+
+func (a *summaryRecordingAdapter) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if strings.Contains(req.System, "rolling summary") {
+		a.summaryCalls++
+		return &CompletionResponse{Content: "User Alice wants a refund for order 42."}, nil
+	}
+	a.chatRequests = append(a.chatRequests, req)
+	return &CompletionResponse{Content: "ok"}, nil
+}
+
+func (a *summaryRecordingAdapter) Stream(ctx context.Context, req *CompletionRequest, onChunk func(string)) (*CompletionResponse, error) {
+	return a.Complete(ctx, req)
+}
+
+func TestBuilder_WithHierarchicalMemory_LLMSummarization(t *testing.T) {
+	adapter := &summaryRecordingAdapter{}
+	config := memory.DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	config.SummarizationMode = "llm"
+
+	builder := NewWithAdapter("test-model", adapter).
+		WithHierarchicalMemory(config)
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if _, err := builder.Ask(ctx, "message"); err != nil {
+			t.Fatalf("Ask failed: %v", err)
+		}
+	}
+
+	if adapter.summaryCalls == 0 {
+		t.Fatal("Expected the builder's model to be used for summarization")
+	}
+	if got := builder.GetMemory().Summary(ctx); got != "User Alice wants a refund for order 42." {
+		t.Errorf("Unexpected summary: %q", got)
+	}
+
+	// The summary is included in subsequent requests
+	last := adapter.chatRequests[len(adapter.chatRequests)-1]
+	found := false
+	for _, msg := range last.Messages {
+		if msg.Role == "system" && strings.Contains(msg.Content, "order 42") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected rolling summary in request messages")
+	}
+}
+
+func TestBuilder_WithMemorySummarizer(t *testing.T) {
+	summarizer := memory.NewLLMSummarizer(NewWithAdapter("cheap-model", &mockTestAdapter{}).CompletionAdapter())
+	builder := NewOpenAI("gpt-4o-mini", "test-key").
+		WithMemorySummarizer(summarizer)
+
+	if builder.GetMemory() == nil {
+		t.Fatal("Expected memory to be initialized")
+	}
+	if !builder.memoryEnabled {
+		t.Error("Expected memory to be enabled")
+	}
+}
+
+// factAdapter answers fact extraction requests with a fixed fact
+// and records the chat requests it receives
+type factAdapter struct {
+	chatRequests    []*CompletionRequest
+	extractionCalls int
+}
+
+func (a *factAdapter) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	if strings.Contains(req.System, "extract durable facts") {
+		a.extractionCalls++
+		return &CompletionResponse{Content: `{"operations":[{"action":"add","content":"User is allergic to peanuts","category":"personal","confidence":0.9}]}`}, nil
+	}
+	a.chatRequests = append(a.chatRequests, req)
+	return &CompletionResponse{Content: "ok"}, nil
+}
+
+func (a *factAdapter) Stream(ctx context.Context, req *CompletionRequest, onChunk func(string)) (*CompletionResponse, error) {
+	return a.Complete(ctx, req)
+}
+
+func TestBuilder_WithSemanticAutoLearn(t *testing.T) {
+	adapter := &factAdapter{}
+	builder := NewWithAdapter("test-model", adapter).
+		WithSemanticAutoLearn()
+
+	config := builder.GetMemory().GetConfig()
+	if !config.SemanticEnabled || !config.SemanticAutoLearn {
+		t.Fatal("Expected semantic memory and auto-learn to be enabled")
+	}
+
+	ctx := context.Background()
+	if _, err := builder.Ask(ctx, "I'm allergic to peanuts"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if adapter.extractionCalls != 1 {
+		t.Fatalf("Expected 1 extraction call, got %d", adapter.extractionCalls)
+	}
+
+	facts, err := builder.GetMemory().Facts(ctx, "", 10)
+	if err != nil || len(facts) != 1 {
+		t.Fatalf("Expected 1 learned fact, got %d (err: %v)", len(facts), err)
+	}
+
+	// Learned facts are included in subsequent requests
+	if _, err := builder.Ask(ctx, "Suggest a snack"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	last := adapter.chatRequests[len(adapter.chatRequests)-1]
+	found := false
+	for _, msg := range last.Messages {
+		if msg.Role == "system" && strings.Contains(msg.Content, "allergic to peanuts") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected learned fact in request messages")
+	}
+}
+
+// ctxKey identifies the request in contexts passed to memory tiers
+type ctxKey struct{}
+
+// contextEmbedding records the request contexts it embeds text with
+type contextEmbedding struct {
+	requests []interface{}
+}
+
+func (e *contextEmbedding) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.requests = append(e.requests, ctx.Value(ctxKey{}))
+	return []float32{1, 0}, nil
+}
+
+func (e *contextEmbedding) Dimensions() int { return 2 }
+
+func TestBuilder_MemoryContextUsesRequestContext(t *testing.T) {
+	embedding := &contextEmbedding{}
+	builder := NewWithAdapter("test-model", &factAdapter{}).
+		WithSemanticMemoryConfig(memory.SemanticMemoryConfig{Embedding: embedding})
+	builder.WithFactExtractor(staticFactExtractor{content: "User is vegan"})
+	if err := builder.GetMemory().LearnFacts(context.Background(), []memory.Message{{Role: "user", Content: "I'm vegan"}}); err != nil {
+		t.Fatalf("LearnFacts failed: %v", err)
+	}
+
+	embedding.requests = nil
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request-1")
+	if _, err := builder.Ask(ctx, "Suggest a dinner"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if len(embedding.requests) == 0 || embedding.requests[0] != "request-1" {
+		t.Errorf("fact search embedded the query with contexts %v, want the request's", embedding.requests)
+	}
+}
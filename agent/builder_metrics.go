@@ -0,0 +1,119 @@
+package agent
+
+// Metrics configuration methods for Builder
+// This file contains the methods recording Builder metrics into a MetricsRegistry.
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithMetricsRegistry records request counts and latencies, token usage,
+// cache hits, rate limit waits and tool execution durations into registry.
+// Metrics are labelled with the provider and model of the Builder.
+//
+// Example:
+//
+//	registry := agent.NewPrometheusRegistry()
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).WithMetricsRegistry(registry)
+//	http.Handle("/metrics", registry.Handler())
+func (b *Builder) WithMetricsRegistry(registry MetricsRegistry) *Builder {
+	b.metricsRegistry = registry
+	if registry != nil {
+		registry.RegisterCollector(b.collectMetrics)
+	}
+	return b
+}
+
+// metricLabels returns the labels identifying the Builder
+func (b *Builder) metricLabels() map[string]string {
+	return map[string]string{"provider": string(b.provider), "model": b.model}
+}
+
+// collectMetrics records the cache hit ratio at scrape time
+func (b *Builder) collectMetrics(registry MetricsRegistry) {
+	if b.cache == nil {
+		return
+	}
+	stats := b.cache.Stats()
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		registry.SetGauge(MetricCacheHitRatio, b.metricLabels(), float64(stats.Hits)/float64(lookups))
+	}
+}
+
+// recordRequest records a completed request
+func (b *Builder) recordRequest(start time.Time, err error) {
+	if b.metricsRegistry == nil {
+		return
+	}
+	labels := b.metricLabels()
+	b.metricsRegistry.ObserveHistogram(MetricRequestDuration, labels, time.Since(start).Seconds())
+
+	labels["status"] = "success"
+	if err != nil {
+		labels["status"] = "error"
+	}
+	b.metricsRegistry.AddCounter(MetricRequestsTotal, labels, 1)
+}
+
+// recordTokenUsage records the tokens used by a request
+func (b *Builder) recordTokenUsage(usage TokenUsage) {
+	if b.metricsRegistry == nil {
+		return
+	}
+	recordTokens(b.metricsRegistry, b.metricLabels(), usage)
+}
+
+// recordTokens records prompt and completion tokens under the labels
+func recordTokens(registry MetricsRegistry, labels map[string]string, usage TokenUsage) {
+	for tokenType, count := range map[string]int{"prompt": usage.PromptTokens, "completion": usage.CompletionTokens} {
+		if count <= 0 {
+			continue
+		}
+		typed := map[string]string{"type": tokenType}
+		for name, value := range labels {
+			typed[name] = value
+		}
+		registry.AddCounter(MetricTokensTotal, typed, float64(count))
+	}
+}
+
+// recordCacheLookup records a cache hit or miss
+func (b *Builder) recordCacheLookup(hit bool) {
+	if b.metricsRegistry == nil {
+		return
+	}
+	name := MetricCacheMissesTotal
+	if hit {
+		name = MetricCacheHitsTotal
+	}
+	b.metricsRegistry.AddCounter(name, b.metricLabels(), 1)
+}
+
+// recordRateLimitWait records the time spent waiting for the rate limiter
+func (b *Builder) recordRateLimitWait(wait time.Duration) {
+	if b.metricsRegistry == nil {
+		return
+	}
+	b.metricsRegistry.ObserveHistogram(MetricRateLimitWait, b.metricLabels(), wait.Seconds())
+}
+
+// callTool executes a tool handler in its own span, recording its duration
+func (b *Builder) callTool(ctx context.Context, tool *Tool, args string) (string, error) {
+	start := time.Now()
+	ctx, span := b.startSpan(ctx, "execute_tool "+tool.Name, trace.SpanKindInternal,
+		attrGenAIOperationName.String("execute_tool"),
+		attrGenAIToolName.String(tool.Name))
+	result, err := tool.call(ctx, args)
+	endSpan(span, err)
+	if b.metricsRegistry != nil {
+		labels := map[string]string{"tool": tool.Name, "status": "success"}
+		if err != nil {
+			labels["status"] = "error"
+		}
+		b.metricsRegistry.ObserveHistogram(MetricToolDuration, labels, time.Since(start).Seconds())
+	}
+	return result, err
+}
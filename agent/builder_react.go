@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // WithReActMode enables or disables ReAct pattern execution.
@@ -435,6 +437,11 @@ func (b *Builder) WithReActForceFinalAnswer(enabled bool) *Builder {
 //	    fmt.Printf("[%s] %s\n", step.Type, step.Content)
 //	}
 func (b *Builder) Execute(ctx context.Context, task string) (*ReActResult, error) {
+	ctx, span := b.startSpan(ctx, "invoke_agent", trace.SpanKindInternal,
+		attrGenAIOperationName.String("invoke_agent"),
+		attrGenAIRequestModel.String(b.model))
+	defer span.End()
+
 	// Check if ReAct is enabled
 	if b.reactConfig == nil || !b.reactConfig.Enabled {
 		// Fallback to normal Ask()
@@ -533,8 +540,11 @@ func (b *Builder) executeReAct(ctx context.Context, task string) (*ReActResult,
 	// Add user task
 	messages = append(messages, User(task))
 
-	// Execution loop
+	// Execution loop, with a span per iteration
+	iterations := b.reactIterationSpans()
+	defer iterations.end()
 	for iteration := 0; iteration < b.reactConfig.MaxIterations; iteration++ {
+		ctx := iterations.next(ctx, iteration+1)
 		if b.reactConfig.EnableTimeline {
 			result.Timeline.AddEvent("iteration_start", fmt.Sprintf("Iteration %d started", iteration+1), 0, nil)
 		}
@@ -888,10 +898,17 @@ func (b *Builder) executeTool(ctx context.Context, toolName string, args map[str
 	}
 
 	// Check if handler is set
-	if targetTool.Handler == nil {
+	if !targetTool.hasHandler() {
 		return "", fmt.Errorf("tool %s has no handler", toolName)
 	}
 
+	// Apply tool timeout if configured, context handlers stop when it expires
+	if b.toolTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.toolTimeout)
+		defer cancel()
+	}
+
 	// Convert args to JSON string for handler
 	argsJSON, err := json.Marshal(args)
 	if err != nil {
@@ -899,7 +916,7 @@ func (b *Builder) executeTool(ctx context.Context, toolName string, args map[str
 	}
 
 	// Execute the tool handler
-	result, err := targetTool.Handler(string(argsJSON))
+	result, err := b.callTool(ctx, targetTool, string(argsJSON))
 	if err != nil {
 		return "", fmt.Errorf("tool execution failed: %w", err)
 	}
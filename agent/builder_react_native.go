@@ -127,8 +127,12 @@ func (b *Builder) executeReActNative(ctx context.Context, task string) (*ReActRe
 	// Add user task
 	messages = append(messages, User(task))
 
-	// Execution loop
+	// Execution loop, with a span per iteration
+	iterations := b.reactIterationSpans()
+	defer iterations.end()
 	for iteration := 0; iteration < b.reactConfig.MaxIterations; iteration++ {
+		ctx := iterations.next(ctx, iteration+1)
+
 		// Track iteration start time for debug logging (v0.7.7)
 		iterationStart := time.Now()
 		var iterationThought, iterationAction, iterationObservation string
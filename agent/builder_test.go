@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"testing"
 )
 
@@ -99,6 +100,57 @@ func TestBuilder_NewOllama(t *testing.T) {
 	}
 }
 
+func TestBuilder_Clone(t *testing.T) {
+	builder := NewOllama("qwen3:1.7b").
+		WithSystem("Be brief").
+		WithTool(NewTool("noop", "Does nothing")).
+		WithMessages([]Message{User("Hi"), Assistant("Hello!")})
+
+	clone := builder.Clone()
+	clone.WithTool(NewTool("extra", "Only on the clone")).
+		WithMessages(append(clone.GetHistory(), User("Bye")))
+
+	if clone.systemPrompt != "Be brief" || clone.model != "qwen3:1.7b" {
+		t.Errorf("clone config = %q, %q, want the original's", clone.systemPrompt, clone.model)
+	}
+	if len(builder.tools) != 1 || len(builder.messages) != 2 {
+		t.Errorf("original has %d tools and %d messages after changing the clone, want 1 and 2",
+			len(builder.tools), len(builder.messages))
+	}
+	if clone.memory == builder.memory {
+		t.Error("clone shares the original's hierarchical memory")
+	}
+}
+
+func TestBuilder_Clone_ConfigsNotShared(t *testing.T) {
+	builder := NewOllama("qwen3:1.7b").
+		WithTemperature(0.2).
+		WithReActMode(true).
+		WithReActMaxIterations(3).
+		WithReActAutoFallback(true).
+		AddFewShotExample("2+2", "4")
+
+	clone := builder.Clone()
+	if clone.reactConfig.MaxIterations != 3 || !clone.reactConfig.EnableAutoFallback || clone.fewshotConfig.Count() != 1 {
+		t.Fatalf("clone configs = %+v, %+v, want the original's", clone.reactConfig, clone.fewshotConfig)
+	}
+
+	clone.WithTemperature(0.9).
+		WithReActMaxIterations(10).
+		WithReActStrict(true).
+		AddFewShotExample("3+3", "6")
+
+	if *builder.temperature != 0.2 {
+		t.Errorf("original temperature = %v, want 0.2", *builder.temperature)
+	}
+	if builder.reactConfig.MaxIterations != 3 || builder.reactConfig.Strict {
+		t.Errorf("original ReAct config = %+v, want it unchanged", builder.reactConfig)
+	}
+	if len(builder.fewshotConfig.Examples) != 1 {
+		t.Errorf("original has %d few-shot examples, want 1", len(builder.fewshotConfig.Examples))
+	}
+}
+
 func TestBuilder_WithAPIKey(t *testing.T) {
 	builder := New(ProviderOpenAI, "gpt-4o-mini").
 		WithAPIKey("new-key")
@@ -185,7 +237,7 @@ func TestBuilder_BuildMessages(t *testing.T) {
 			Assistant("Previous response"),
 		})
 
-	messages := builder.buildMessages("New message")
+	messages := builder.buildMessages(context.Background(), "New message")
 
 	// Should have: system + 2 history + current user = 4 messages
 	if len(messages) != 4 {
@@ -212,7 +212,7 @@ func TestToolChoice_BuildParamsIntegration(t *testing.T) {
 		WithTools(tool).
 		WithToolChoice("required")
 
-	params := builder.buildParams(builder.buildMessages("test"))
+	params := builder.buildParams(builder.buildMessages(context.Background(), "test"))
 
 	assert.NotNil(t, params.ToolChoice, "ToolChoice should be in params")
 	assert.NotEmpty(t, params.Tools, "Tools should be in params")
@@ -222,7 +222,7 @@ func TestToolChoiceNilDoesNotSetParams(t *testing.T) {
 	// When toolChoice is nil (not set), it should not be in params
 	builder := NewOpenAI("gpt-4o-mini", "test-key")
 
-	_ = builder.buildParams(builder.buildMessages("test"))
+	_ = builder.buildParams(builder.buildMessages(context.Background(), "test"))
 
 	// toolChoice field should use zero value when nil
 	// OpenAI SDK omits zero values with omitzero tag
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -26,6 +27,31 @@ func (b *Builder) WithTools(tools ...*Tool) *Builder {
 	return b
 }
 
+// GetTools returns the tools registered on the builder.
+// The slice is a copy; the tools themselves are shared.
+func (b *Builder) GetTools() []*Tool {
+	tools := make([]*Tool, len(b.tools))
+	copy(tools, b.tools)
+	return tools
+}
+
+// CallTool runs a registered tool outside of the tool loop, e.g. on behalf of
+// an MCP client, applying the tool timeout and recording metrics and traces
+func (b *Builder) CallTool(ctx context.Context, name, args string) (string, error) {
+	for _, tool := range b.tools {
+		if tool.Name != name {
+			continue
+		}
+		if b.toolTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, b.toolTimeout)
+			defer cancel()
+		}
+		return b.callTool(ctx, tool, args)
+	}
+	return "", fmt.Errorf("tool not found: %s", name)
+}
+
 func (b *Builder) WithAutoExecute(enable bool) *Builder {
 	b.autoExecute = enable
 	if b.maxToolRounds == 0 {
@@ -0,0 +1,86 @@
+package agent
+
+// Tracing configuration methods for Builder
+// This file contains the methods creating OpenTelemetry spans for Builder requests.
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// WithTracing enables OpenTelemetry spans for requests (one per Ask or
+// Stream, following the GenAI semantic conventions), cache lookups, RAG
+// retrieval and vector store queries, tool executions and ReAct iterations.
+// Tool spans are passed to tool handlers through their context, so
+// tools.NewHTTPRequestTool propagates the trace to the services it calls.
+// A nil provider uses the global tracer provider.
+//
+// Example:
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//	ai := agent.NewOpenAI("gpt-4o-mini", apiKey).WithTracing(tp)
+func (b *Builder) WithTracing(provider trace.TracerProvider) *Builder {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	b.tracer = provider.Tracer(tracerName)
+	return b
+}
+
+// startSpan starts a span if tracing is enabled
+func (b *Builder) startSpan(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if b.tracer == nil {
+		return ctx, noop.Span{}
+	}
+	return b.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// startChatSpan starts the span of a chat request
+func (b *Builder) startChatSpan(ctx context.Context) (context.Context, trace.Span) {
+	if b.tracer == nil {
+		return ctx, noop.Span{}
+	}
+
+	attrs := []attribute.KeyValue{
+		attrGenAIOperationName.String("chat"),
+		attrGenAIRequestModel.String(b.model),
+	}
+	if b.provider != "" {
+		attrs = append(attrs, attrGenAISystem.String(string(b.provider)))
+	}
+	if b.temperature != nil {
+		attrs = append(attrs, attrGenAIRequestTemperature.Float64(*b.temperature))
+	}
+	if b.maxTokens != nil {
+		attrs = append(attrs, attrGenAIRequestMaxTokens.Int64(*b.maxTokens))
+	}
+	return b.startSpan(ctx, "chat "+b.model, trace.SpanKindClient, attrs...)
+}
+
+// traceCompletion adds the usage and finish reason of a completion to the chat span
+func (b *Builder) traceCompletion(ctx context.Context, usage TokenUsage, finishReason, responseModel string) {
+	if b.tracer == nil {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attrGenAIInputTokens.Int(usage.PromptTokens),
+		attrGenAIOutputTokens.Int(usage.CompletionTokens),
+	)
+	if finishReason != "" {
+		span.SetAttributes(attrGenAIFinishReasons.StringSlice([]string{finishReason}))
+	}
+	if responseModel != "" {
+		span.SetAttributes(attrGenAIResponseModel.String(responseModel))
+	}
+}
+
+// reactIterationSpans returns the spans of the iterations of a ReAct loop
+func (b *Builder) reactIterationSpans() *iterationSpans {
+	return &iterationSpans{builder: b, name: "react.iteration"}
+}
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestTracerProvider returns a tracer provider recording spans in memory
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+// spansByName indexes recorded spans by name
+func spansByName(exporter *tracetest.InMemoryExporter) map[string][]tracetest.SpanStub {
+	spans := make(map[string][]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+	return spans
+}
+
+// spanAttr returns the value of a span attribute
+func spanAttr(span tracetest.SpanStub, key string) interface{} {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value.AsInterface()
+		}
+	}
+	return nil
+}
+
+// staticVectorStore answers every search with its documents.
+// Unused VectorStore methods panic via the embedded nil interface.
+type staticVectorStore struct {
+	VectorStore
+	docs []*VectorDocument
+}
+
+func (s *staticVectorStore) SearchByText(ctx context.Context, req *TextSearchRequest) ([]*SearchResult, error) {
+	results := make([]*SearchResult, len(s.docs))
+	for i, doc := range s.docs {
+		results[i] = &SearchResult{Document: doc, Score: 0.9, Rank: i + 1}
+	}
+	return results, nil
+}
+
+func TestBuilder_WithTracing_AskWithTools(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	// The tool calls a service, propagating the trace like tools.NewHTTPRequestTool
+	fetch := NewTool("fetch", "Fetch a page").WithContextHandler(func(ctx context.Context, args string) (string, error) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		InjectTraceContext(ctx, req.Header)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		return "page", nil
+	})
+
+	adapter := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		if call == 1 {
+			return &CompletionResponse{ToolCalls: []ToolCall{{ID: "a", Name: "fetch", Arguments: `{}`}}}, nil
+		}
+		return &CompletionResponse{
+			Content:      "done",
+			FinishReason: "stop",
+			Model:        "test-model-0613",
+			Usage:        TokenUsage{PromptTokens: 12, CompletionTokens: 3},
+		}, nil
+	}}
+
+	tp, exporter := newTestTracerProvider()
+	ai := NewWithAdapter("test-model", adapter).
+		WithCache(NewMemoryCache(10, 0)).
+		WithTools(fetch).
+		WithAutoExecute(true).
+		WithTracing(tp)
+
+	if _, err := ai.Ask(context.Background(), "Fetch the page"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+
+	spans := spansByName(exporter)
+	chats, tools, lookups := spans["chat test-model"], spans["execute_tool fetch"], spans["cache.lookup"]
+	if len(chats) != 1 || len(tools) != 1 || len(lookups) != 1 {
+		t.Fatalf("spans = %v, want a chat, a tool and a cache lookup span", spans)
+	}
+
+	chat := chats[0]
+	if chat.SpanKind != trace.SpanKindClient || spanAttr(chat, "gen_ai.operation.name") != "chat" || spanAttr(chat, "gen_ai.request.model") != "test-model" {
+		t.Errorf("chat span = %s with %v", chat.SpanKind, chat.Attributes)
+	}
+	if spanAttr(chat, "gen_ai.usage.input_tokens") != int64(12) || spanAttr(chat, "gen_ai.usage.output_tokens") != int64(3) {
+		t.Errorf("chat span usage = %v", chat.Attributes)
+	}
+	if reasons, _ := spanAttr(chat, "gen_ai.response.finish_reasons").([]string); len(reasons) != 1 || reasons[0] != "stop" {
+		t.Errorf("finish reasons = %v", reasons)
+	}
+	if spanAttr(chat, "gen_ai.response.model") != "test-model-0613" {
+		t.Errorf("response model = %v", spanAttr(chat, "gen_ai.response.model"))
+	}
+
+	// Tool and cache spans are children of the chat span
+	tool := tools[0]
+	if tool.Parent.SpanID() != chat.SpanContext.SpanID() || lookups[0].Parent.SpanID() != chat.SpanContext.SpanID() {
+		t.Error("tool and cache lookup spans should be children of the chat span")
+	}
+	if spanAttr(tool, "gen_ai.tool.name") != "fetch" || spanAttr(lookups[0], "cache.hit") != false {
+		t.Errorf("tool span = %v, cache span = %v", tool.Attributes, lookups[0].Attributes)
+	}
+
+	// The outgoing request of the tool carries the tool span
+	want := "00-" + tool.SpanContext.TraceID().String() + "-" + tool.SpanContext.SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+}
+
+func TestBuilder_WithTracing_VectorSearch(t *testing.T) {
+	tp, exporter := newTestTracerProvider()
+	ai := New(ProviderOpenAI, "gpt-4o-mini").WithTracing(tp)
+	ai.vectorStore = &staticVectorStore{docs: []*VectorDocument{{Content: "Paris is in France"}}}
+	ai.embeddingProvider = NewMockEmbeddingProvider("mock", 3)
+	ai.vectorCollection = "facts"
+
+	docs, err := ai.retrieveFromVector(context.Background(), "Where is Paris?")
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("retrieveFromVector = %v, %v", docs, err)
+	}
+
+	searches := spansByName(exporter)["search facts"]
+	if len(searches) != 1 {
+		t.Fatalf("spans = %v, want a search span", exporter.GetSpans())
+	}
+	search := searches[0]
+	if search.SpanKind != trace.SpanKindClient || spanAttr(search, "db.collection.name") != "facts" || spanAttr(search, "db.response.returned_rows") != int64(1) {
+		t.Errorf("search span = %s with %v", search.SpanKind, search.Attributes)
+	}
+}
+
+func TestBuilder_WithTracing_ReActIterations(t *testing.T) {
+	answers := []string{"THOUGHT: I know this", "FINAL: Paris"}
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := answers[calls%len(answers)]
+		calls++
+		body, _ := json.Marshal(map[string]interface{}{
+			"id": "chatcmpl-1", "object": "chat.completion", "created": 1, "model": "test",
+			"choices": []map[string]interface{}{{
+				"index": 0, "finish_reason": "stop",
+				"message": map[string]interface{}{"role": "assistant", "content": content},
+			}},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	tp, exporter := newTestTracerProvider()
+	ai := New(ProviderOllama, "llama3").
+		WithBaseURL(server.URL + "/v1/").
+		WithReActMode(true).
+		WithReActTextMode().
+		WithTracing(tp)
+
+	result, err := ai.Execute(context.Background(), "Capital of France?")
+	if err != nil || result.Answer != "Paris" {
+		t.Fatalf("Execute = %+v, %v", result, err)
+	}
+
+	spans := spansByName(exporter)
+	agents, iterations := spans["invoke_agent"], spans["react.iteration"]
+	if len(agents) != 1 || len(iterations) != 2 {
+		t.Fatalf("spans = %v, want an agent span and two iterations", spans)
+	}
+	for i, iteration := range iterations {
+		if iteration.Parent.SpanID() != agents[0].SpanContext.SpanID() || spanAttr(iteration, "react.iteration") != int64(i+1) {
+			t.Errorf("iteration span %d = parent %s, %v", i, iteration.Parent.SpanID(), iteration.Attributes)
+		}
+	}
+}
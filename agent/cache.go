@@ -27,6 +27,19 @@ type Cache interface {
 	Stats() CacheStats
 }
 
+// TaggedCache is implemented by caches supporting tag-based invalidation,
+// e.g. to drop every response of a persona or of an outdated document
+// collection at once
+type TaggedCache interface {
+	Cache
+
+	// SetWithTags stores a response in cache with TTL and tags
+	SetWithTags(ctx context.Context, key string, value string, ttl time.Duration, tags ...string) error
+
+	// InvalidateTag removes all keys stored with tag
+	InvalidateTag(ctx context.Context, tag string) error
+}
+
 // CacheStats represents cache statistics
 type CacheStats struct {
 	Hits        int64 // Number of cache hits
@@ -34,6 +47,9 @@ type CacheStats struct {
 	Size        int   // Current number of cached items
 	Evictions   int64 // Number of evictions (LRU)
 	TotalWrites int64 // Total number of writes
+
+	SemanticHits   int64 // Number of hits on a similar prompt (SemanticCache)
+	SemanticMisses int64 // Number of semantic lookups without similar prompt (SemanticCache)
 }
 
 // CacheEntry represents a cached item
@@ -43,14 +59,16 @@ type CacheEntry struct {
 	CreatedAt   time.Time // Creation time
 	AccessedAt  time.Time // Last access time
 	AccessCount int64     // Number of times accessed
+	Tags        []string  // Tags for invalidation
 }
 
 // MemoryCache is an in-memory LRU cache implementation
 type MemoryCache struct {
 	mu         sync.RWMutex
 	entries    map[string]*CacheEntry
-	maxSize    int           // Maximum number of entries
-	defaultTTL time.Duration // Default TTL
+	tags       map[string]map[string]struct{} // Keys by tag
+	maxSize    int                            // Maximum number of entries
+	defaultTTL time.Duration                  // Default TTL
 	stats      CacheStats
 }
 
@@ -65,6 +83,7 @@ func NewMemoryCache(maxSize int, defaultTTL time.Duration) *MemoryCache {
 
 	cache := &MemoryCache{
 		entries:    make(map[string]*CacheEntry),
+		tags:       make(map[string]map[string]struct{}),
 		maxSize:    maxSize,
 		defaultTTL: defaultTTL,
 	}
@@ -77,8 +96,9 @@ func NewMemoryCache(maxSize int, defaultTTL time.Duration) *MemoryCache {
 
 // Get retrieves a value from cache
 func (c *MemoryCache) Get(ctx context.Context, key string) (string, bool, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	// Write lock: a lookup updates the stats and the entry's access time
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	entry, exists := c.entries[key]
 	if !exists {
@@ -89,11 +109,11 @@ func (c *MemoryCache) Get(ctx context.Context, key string) (string, bool, error)
 	// Check if expired
 	if time.Now().After(entry.ExpiresAt) {
 		c.stats.Misses++
-		// Don't delete here to avoid deadlock - cleanup will handle it
+		// Cleanup will delete it
 		return "", false, nil
 	}
 
-	// Update access stats (safe because we have read lock and only updating this entry)
+	// Update access stats
 	entry.AccessedAt = time.Now()
 	entry.AccessCount++
 
@@ -103,6 +123,11 @@ func (c *MemoryCache) Get(ctx context.Context, key string) (string, bool, error)
 
 // Set stores a value in cache
 func (c *MemoryCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return c.SetWithTags(ctx, key, value, ttl)
+}
+
+// SetWithTags stores a value in cache with tags
+func (c *MemoryCache) SetWithTags(ctx context.Context, key string, value string, ttl time.Duration, tags ...string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -113,7 +138,9 @@ func (c *MemoryCache) Set(ctx context.Context, key string, value string, ttl tim
 	now := time.Now()
 
 	// Check if we need to evict (LRU)
-	if len(c.entries) >= c.maxSize {
+	if _, exists := c.entries[key]; exists {
+		c.removeEntry(key)
+	} else if len(c.entries) >= c.maxSize {
 		c.evictLRU()
 	}
 
@@ -123,6 +150,13 @@ func (c *MemoryCache) Set(ctx context.Context, key string, value string, ttl tim
 		CreatedAt:   now,
 		AccessedAt:  now,
 		AccessCount: 0,
+		Tags:        tags,
+	}
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
 	}
 
 	c.stats.TotalWrites++
@@ -134,16 +168,44 @@ func (c *MemoryCache) Delete(ctx context.Context, key string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.entries, key)
+	c.removeEntry(key)
 	return nil
 }
 
+// InvalidateTag removes all keys stored with tag
+func (c *MemoryCache) InvalidateTag(ctx context.Context, tag string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.tags[tag] {
+		c.removeEntry(key)
+	}
+	delete(c.tags, tag)
+	return nil
+}
+
+// removeEntry removes a key and its tags. Must be called with c.mu held.
+func (c *MemoryCache) removeEntry(key string) {
+	entry, exists := c.entries[key]
+	if !exists {
+		return
+	}
+	for _, tag := range entry.Tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+	delete(c.entries, key)
+}
+
 // Clear removes all entries
 func (c *MemoryCache) Clear(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.entries = make(map[string]*CacheEntry)
+	c.tags = make(map[string]map[string]struct{})
 	c.stats = CacheStats{} // Reset stats
 	return nil
 }
@@ -171,7 +233,7 @@ func (c *MemoryCache) evictLRU() {
 	}
 
 	if oldestKey != "" {
-		delete(c.entries, oldestKey)
+		c.removeEntry(oldestKey)
 		c.stats.Evictions++
 	}
 }
@@ -187,14 +249,16 @@ func (c *MemoryCache) cleanup() {
 
 		for key, entry := range c.entries {
 			if now.After(entry.ExpiresAt) {
-				delete(c.entries, key)
+				c.removeEntry(key)
 			}
 		}
 		c.mu.Unlock()
 	}
 }
 
-// GenerateCacheKey generates a cache key from prompt and configuration
+// GenerateCacheKey generates a cache key from prompt and configuration.
+// Builder keys responses with GenerateRequestCacheKey, which also covers
+// the conversation history, tools and response format.
 func GenerateCacheKey(model string, prompt string, temperature float64, systemPrompt string) string {
 	// Create a deterministic key based on request parameters
 	data := struct {
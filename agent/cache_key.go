@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// CachePolicy controls which requests are cached and how they are keyed
+type CachePolicy struct {
+	// SkipWithTools bypasses the cache for requests with tools, whose
+	// answers may depend on tool results that change over time
+	SkipWithTools bool
+
+	// DeterministicOnly caches only deterministic requests: temperature 0
+	// or a fixed seed
+	DeterministicOnly bool
+
+	// KeyFunc computes the cache key of a request.
+	// Default: GenerateRequestCacheKey
+	KeyFunc func(req *CompletionRequest) string
+}
+
+// cacheable reports whether the response to req may be cached
+func (p CachePolicy) cacheable(req *CompletionRequest) bool {
+	if p.SkipWithTools && len(req.Tools) > 0 {
+		return false
+	}
+	if p.DeterministicOnly && req.Temperature != 0 && req.Seed == 0 {
+		return false
+	}
+	return true
+}
+
+// key returns the cache key of req
+func (p CachePolicy) key(req *CompletionRequest) string {
+	if p.KeyFunc != nil {
+		return p.KeyFunc(req)
+	}
+	return GenerateRequestCacheKey(req)
+}
+
+// GenerateRequestCacheKey computes a canonical fingerprint of a completion
+// request, covering the conversation history, system prompt, images, tools,
+// response format and sampling parameters. Tool call IDs, which differ
+// between otherwise identical conversations, are ignored.
+func GenerateRequestCacheKey(req *CompletionRequest) string {
+	type fingerprintToolCall struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	}
+	type fingerprintMessage struct {
+		Role      string                `json:"role"`
+		Content   string                `json:"content"`
+		ToolCalls []fingerprintToolCall `json:"tool_calls,omitempty"`
+		Images    []ImageContent        `json:"images,omitempty"`
+	}
+	type fingerprintTool struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	}
+
+	messages := make([]fingerprintMessage, len(req.Messages))
+	for i, msg := range req.Messages {
+		messages[i] = fingerprintMessage{Role: msg.Role, Content: msg.Content, Images: msg.Images}
+		for _, call := range msg.ToolCalls {
+			messages[i].ToolCalls = append(messages[i].ToolCalls, fingerprintToolCall{Name: call.Name, Arguments: call.Arguments})
+		}
+	}
+	tools := make([]fingerprintTool, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		if tool != nil {
+			tools = append(tools, fingerprintTool{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters})
+		}
+	}
+
+	// Maps are marshalled with sorted keys, so the encoding is canonical
+	data, _ := json.Marshal(struct {
+		Model            string               `json:"model"`
+		System           string               `json:"system"`
+		Messages         []fingerprintMessage `json:"messages"`
+		Temperature      float64              `json:"temperature"`
+		MaxTokens        int                  `json:"max_tokens"`
+		TopP             float64              `json:"top_p"`
+		Stop             []string             `json:"stop,omitempty"`
+		Seed             int64                `json:"seed"`
+		Tools            []fingerprintTool    `json:"tools,omitempty"`
+		ToolChoice       interface{}          `json:"tool_choice,omitempty"`
+		ResponseFormat   interface{}          `json:"response_format,omitempty"`
+		PresencePenalty  float64              `json:"presence_penalty"`
+		FrequencyPenalty float64              `json:"frequency_penalty"`
+		LogProbs         bool                 `json:"logprobs"`
+		TopLogProbs      int                  `json:"top_logprobs"`
+		N                int                  `json:"n"`
+	}{
+		Model:            req.Model,
+		System:           req.System,
+		Messages:         messages,
+		Temperature:      req.Temperature,
+		MaxTokens:        req.MaxTokens,
+		TopP:             req.TopP,
+		Stop:             req.Stop,
+		Seed:             req.Seed,
+		Tools:            tools,
+		ToolChoice:       req.ToolChoice,
+		ResponseFormat:   req.ResponseFormat,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		LogProbs:         req.LogProbs,
+		TopLogProbs:      req.TopLogProbs,
+		N:                req.N,
+	})
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGenerateRequestCacheKey(t *testing.T) {
+	base := func() *CompletionRequest {
+		return &CompletionRequest{
+			Model:       "gpt-4o-mini",
+			System:      "Be brief",
+			Messages:    []Message{User("Hi"), Assistant("Hello!"), User("Weather?")},
+			Temperature: 0.7,
+		}
+	}
+	key := GenerateRequestCacheKey(base())
+	if key != GenerateRequestCacheKey(base()) {
+		t.Fatal("identical requests should share a key")
+	}
+
+	variants := map[string]func(req *CompletionRequest){
+		"history": func(req *CompletionRequest) { req.Messages[1].Content = "Hey!" },
+		"images": func(req *CompletionRequest) {
+			req.Messages[2].Images = []ImageContent{{URL: "https://example.com/sky.png"}}
+		},
+		"tools":           func(req *CompletionRequest) { req.Tools = []*Tool{NewTool("weather", "Get the weather")} },
+		"response format": func(req *CompletionRequest) { req.ResponseFormat = map[string]string{"type": "json_object"} },
+		"top p":           func(req *CompletionRequest) { req.TopP = 0.5 },
+		"seed":            func(req *CompletionRequest) { req.Seed = 42 },
+	}
+	for name, vary := range variants {
+		req := base()
+		vary(req)
+		if GenerateRequestCacheKey(req) == key {
+			t.Errorf("requests differing by %s should not share a key", name)
+		}
+	}
+
+	// Tool call IDs are generated per call and do not change the conversation
+	withCall := func(id string) *CompletionRequest {
+		req := base()
+		req.Messages = append(req.Messages,
+			Message{Role: "assistant", ToolCalls: []ToolCall{{ID: id, Name: "weather", Arguments: `{}`}}},
+			Message{Role: "tool", ToolCallID: id, Content: "sunny"})
+		return req
+	}
+	if GenerateRequestCacheKey(withCall("call_1")) != GenerateRequestCacheKey(withCall("call_2")) {
+		t.Error("tool call IDs should not change the key")
+	}
+}
+
+func TestBuilder_CacheKeyCoversConversation(t *testing.T) {
+	adapter := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: "answer"}, nil
+	}}
+	cache := NewMemoryCache(10, time.Minute)
+	ai := NewWithAdapter("test-model", adapter).WithCache(cache)
+
+	ask := func(b *Builder) {
+		t.Helper()
+		if _, err := b.Ask(context.Background(), "Why?"); err != nil {
+			t.Fatalf("Ask failed: %v", err)
+		}
+	}
+	ask(ai)
+	ask(ai)
+	if len(adapter.requests) != 1 {
+		t.Fatalf("provider calls = %d, want the repeated request served from cache", len(adapter.requests))
+	}
+
+	ask(ai.WithMessages([]Message{User("The sky is blue"), Assistant("Indeed")}))
+	ask(ai.WithJSONMode())
+	ask(ai.WithTopP(0.5))
+	if len(adapter.requests) != 4 {
+		t.Errorf("provider calls = %d, want a miss per change of history, response format and top p", len(adapter.requests))
+	}
+}
+
+func TestBuilder_WithCachePolicy(t *testing.T) {
+	adapter := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: "answer"}, nil
+	}}
+	askTwice := func(b *Builder) int {
+		t.Helper()
+		before := len(adapter.requests)
+		for i := 0; i < 2; i++ {
+			if _, err := b.Ask(context.Background(), "Why?"); err != nil {
+				t.Fatalf("Ask failed: %v", err)
+			}
+		}
+		return len(adapter.requests) - before
+	}
+	newBuilder := func(policy CachePolicy) *Builder {
+		return NewWithAdapter("test-model", adapter).
+			WithCache(NewMemoryCache(10, time.Minute)).
+			WithCachePolicy(policy)
+	}
+
+	deterministic := CachePolicy{DeterministicOnly: true}
+	if calls := askTwice(newBuilder(deterministic)); calls != 2 {
+		t.Errorf("sampled request: provider calls = %d, want no caching", calls)
+	}
+	if calls := askTwice(newBuilder(deterministic).WithTemperature(0)); calls != 1 {
+		t.Errorf("temperature 0: provider calls = %d, want caching", calls)
+	}
+	if calls := askTwice(newBuilder(deterministic).WithSeed(42)); calls != 1 {
+		t.Errorf("fixed seed: provider calls = %d, want caching", calls)
+	}
+
+	withTools := newBuilder(CachePolicy{SkipWithTools: true}).WithTools(NewTool("noop", "Does nothing"))
+	if calls := askTwice(withTools); calls != 2 {
+		t.Errorf("request with tools: provider calls = %d, want no caching", calls)
+	}
+
+	var keyed []string
+	custom := newBuilder(CachePolicy{KeyFunc: func(req *CompletionRequest) string {
+		keyed = append(keyed, req.Model)
+		return "fixed"
+	}})
+	if calls := askTwice(custom); calls != 1 || len(keyed) != 2 || keyed[0] != "test-model" {
+		t.Errorf("custom key: provider calls = %d, keyed = %v", calls, keyed)
+	}
+}
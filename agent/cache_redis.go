@@ -6,9 +6,17 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+// unlockScript releases a lock only if it is still held by the caller
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
 // RedisCache is a Redis-based cache implementation
 type RedisCache struct {
 	client     redis.UniversalClient
@@ -142,6 +150,16 @@ func (c *RedisCache) statsKey(statType string) string {
 	return fmt.Sprintf("%s:stats:%s", c.prefix, statType)
 }
 
+// tagKey returns the key of the set of cache keys stored with a tag
+func (c *RedisCache) tagKey(tag string) string {
+	return fmt.Sprintf("%s:tag:%s", c.prefix, tag)
+}
+
+// lockKey returns the key of the fill lock of a cache key
+func (c *RedisCache) lockKey(key string) string {
+	return fmt.Sprintf("%s:lock:%s", c.prefix, key)
+}
+
 // Get retrieves a value from cache
 func (c *RedisCache) Get(ctx context.Context, key string) (string, bool, error) {
 	redisKey := c.makeKey(key)
@@ -200,6 +218,81 @@ func (c *RedisCache) Set(ctx context.Context, key string, value string, ttl time
 	return nil
 }
 
+// SetWithTags stores a value in cache with TTL and tags.
+// Tag sets expire with the longest-lived of their keys.
+func (c *RedisCache) SetWithTags(ctx context.Context, key string, value string, ttl time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return c.Set(ctx, key, value, ttl)
+	}
+
+	redisKey := c.makeKey(key)
+	if ttl == 0 {
+		ttl = c.defaultTTL
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.Set(ctx, redisKey, value, ttl)
+	for _, tag := range tags {
+		tagKey := c.tagKey(tag)
+		pipe.SAdd(ctx, tagKey, redisKey)
+		pipe.ExpireNX(ctx, tagKey, ttl)
+		pipe.ExpireGT(ctx, tagKey, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+
+	// Update stats
+	c.statsLock.Lock()
+	c.stats.TotalWrites++
+	c.statsLock.Unlock()
+
+	// Increment write counter in Redis
+	c.client.Incr(ctx, c.statsKey("writes"))
+
+	return nil
+}
+
+// InvalidateTag removes all keys stored with tag
+func (c *RedisCache) InvalidateTag(ctx context.Context, tag string) error {
+	tagKey := c.tagKey(tag)
+
+	keys, err := c.client.SMembers(ctx, tagKey).Result()
+	if err != nil {
+		return fmt.Errorf("redis smembers failed: %w", err)
+	}
+
+	if err := c.client.Del(ctx, append(keys, tagKey)...).Err(); err != nil {
+		return fmt.Errorf("redis delete batch failed: %w", err)
+	}
+
+	return nil
+}
+
+// TryLock acquires the fill lock of a key, so a single process computes a
+// missing response while the others wait for it. The lock expires after ttl
+// if it is not released.
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	lockKey := c.lockKey(key)
+	token := uuid.NewString()
+
+	acquired, err := c.client.SetNX(ctx, lockKey, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("redis setnx failed: %w", err)
+	}
+	if !acquired {
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// The request context may be cancelled, the lock is released anyway
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		unlockScript.Run(unlockCtx, c.client, []string{lockKey}, token)
+	}
+	return unlock, true, nil
+}
+
 // Delete removes a key from cache
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
 	redisKey := c.makeKey(key)
@@ -234,6 +327,11 @@ func (c *RedisCache) Clear(ctx context.Context) error {
 		}
 	}
 
+	// Delete tag sets
+	if err := c.deleteMatching(ctx, c.tagKey("*")); err != nil {
+		return err
+	}
+
 	// Clear local stats
 	c.statsLock.Lock()
 	c.stats = CacheStats{}
@@ -392,8 +490,11 @@ func (c *RedisCache) MSet(ctx context.Context, pairs map[string]string, ttl time
 
 // DeletePattern deletes all keys matching a pattern
 func (c *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
-	fullPattern := c.makeKey(pattern)
+	return c.deleteMatching(ctx, c.makeKey(pattern))
+}
 
+// deleteMatching deletes all Redis keys matching a full pattern
+func (c *RedisCache) deleteMatching(ctx context.Context, fullPattern string) error {
 	// Use SCAN to find all matching keys
 	iter := c.client.Scan(ctx, 0, fullPattern, 0).Iterator()
 	var keys []string
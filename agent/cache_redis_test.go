@@ -593,3 +593,63 @@ func TestRedisCacheConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestRedisCacheInvalidateTag(t *testing.T) {
+	mr, cache := setupMiniRedis(t)
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	cache.SetWithTags(ctx, "support-1", "a", time.Minute, "persona:support")
+	cache.SetWithTags(ctx, "support-2", "b", 2*time.Minute, "persona:support")
+	cache.SetWithTags(ctx, "sales-1", "c", time.Minute, "persona:sales")
+
+	// The tag set lives as long as its longest-lived key
+	if ttl := mr.TTL("go-deep-agent:tag:persona:support"); ttl != 2*time.Minute {
+		t.Errorf("Expected tag TTL 2m, got %v", ttl)
+	}
+
+	if err := cache.InvalidateTag(ctx, "persona:support"); err != nil {
+		t.Fatalf("InvalidateTag failed: %v", err)
+	}
+	for key, want := range map[string]bool{"support-1": false, "support-2": false, "sales-1": true} {
+		if _, found, _ := cache.Get(ctx, key); found != want {
+			t.Errorf("Get(%s) found = %v, want %v", key, found, want)
+		}
+	}
+
+	// Clear removes the remaining tag sets
+	cache.Clear(ctx)
+	if mr.Exists("go-deep-agent:tag:persona:sales") {
+		t.Error("Expected tag set to be cleared")
+	}
+}
+
+func TestRedisCacheTryLock(t *testing.T) {
+	mr, cache := setupMiniRedis(t)
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	unlock, acquired, err := cache.TryLock(ctx, "key1", time.Minute)
+	if err != nil || !acquired {
+		t.Fatalf("TryLock = %v, %v, want the lock", acquired, err)
+	}
+	if _, acquired, _ := cache.TryLock(ctx, "key1", time.Minute); acquired {
+		t.Error("Expected lock to be held")
+	}
+
+	unlock()
+	unlockAgain, acquired, _ := cache.TryLock(ctx, "key1", time.Minute)
+	if !acquired {
+		t.Fatal("Expected lock to be released")
+	}
+
+	// An expired lock taken over by another holder is not released by the first one
+	mr.FastForward(2 * time.Minute)
+	_, acquired, _ = cache.TryLock(ctx, "key1", time.Minute)
+	unlockAgain()
+	if !acquired || !mr.Exists("go-deep-agent:lock:key1") {
+		t.Error("Expected the stale unlock to keep the new holder's lock")
+	}
+}
@@ -0,0 +1,462 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSemanticThreshold  = 0.92
+	defaultSemanticCollection = "semantic_cache"
+	defaultSemanticTTL        = time.Hour
+	defaultSemanticMaxEntries = 1000
+)
+
+// CacheScope identifies the context a cached response is valid in.
+// Semantic lookups only match prompts cached in the same scope.
+type CacheScope struct {
+	Model        string // Model name
+	SystemPrompt string // System prompt
+	Persona      string // Persona name (empty without persona)
+
+	// Context fingerprints the rest of the request: history, tools, response
+	// format, sampling parameters, images and RAG context. Thresholds ignore it.
+	Context string
+}
+
+// key returns a stable identifier of the scope
+func (s CacheScope) key() string {
+	data, _ := json.Marshal(s)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:16])
+}
+
+// matches reports whether a threshold scope applies to s; empty fields match any value
+func (s CacheScope) matches(other CacheScope) bool {
+	return (s.Model == "" || s.Model == other.Model) &&
+		(s.SystemPrompt == "" || s.SystemPrompt == other.SystemPrompt) &&
+		(s.Persona == "" || s.Persona == other.Persona)
+}
+
+// specificity returns the number of fields set in the scope
+func (s CacheScope) specificity() int {
+	count := 0
+	for _, field := range []string{s.Model, s.SystemPrompt, s.Persona} {
+		if field != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// SemanticLookup is implemented by caches matching prompts by meaning rather
+// than by exact key. Builder uses it instead of Get and Set when available.
+type SemanticLookup interface {
+	Cache
+
+	// GetSemantic returns the response cached for the most similar prompt in the scope
+	GetSemantic(ctx context.Context, scope CacheScope, prompt string) (string, bool, error)
+
+	// SetSemantic caches the response to a prompt in the scope
+	SetSemantic(ctx context.Context, scope CacheScope, prompt, value string, ttl time.Duration) error
+}
+
+// SemanticCacheConfig configures a SemanticCache
+type SemanticCacheConfig struct {
+	// Embedder embeds prompts (required)
+	Embedder EmbeddingProvider
+
+	// Store persists the embedded prompts. When nil, an in-memory index is used.
+	Store VectorStore
+
+	// Collection is the vector store collection owned by the cache.
+	// Default: "semantic_cache"
+	Collection string
+
+	// Threshold is the minimum cosine similarity of a hit (0-1).
+	// Default: 0.92
+	Threshold float64
+
+	// DefaultTTL is used when Set is called without TTL.
+	// Default: 1 hour
+	DefaultTTL time.Duration
+
+	// MaxEntries limits the size of the in-memory index (LRU eviction).
+	// Default: 1000
+	MaxEntries int
+}
+
+// SemanticCache is a response cache matching prompts by embedding similarity,
+// so "What's the capital of France?" hits the response cached for
+// "capital of France?". Exact-key Get and Set are served by a MemoryCache.
+type SemanticCache struct {
+	config SemanticCacheConfig
+	exact  *MemoryCache
+
+	mu          sync.RWMutex
+	entries     map[string]*semanticEntry // In-memory index by ID
+	thresholds  []scopedThreshold
+	collection  bool // Whether the store collection is known to exist
+	hits        int64
+	misses      int64
+	evictions   int64
+	totalWrites int64
+}
+
+// semanticEntry is a prompt of the in-memory index
+type semanticEntry struct {
+	scope      string
+	prompt     string
+	embedding  []float32
+	value      string
+	expiresAt  time.Time
+	accessedAt time.Time
+}
+
+// scopedThreshold overrides the similarity threshold of a scope
+type scopedThreshold struct {
+	scope     CacheScope
+	threshold float64
+}
+
+// NewSemanticCache creates a semantic cache
+func NewSemanticCache(config SemanticCacheConfig) (*SemanticCache, error) {
+	if config.Embedder == nil {
+		return nil, fmt.Errorf("semantic cache requires an embedding provider")
+	}
+	if config.Threshold < 0 || config.Threshold > 1 {
+		return nil, fmt.Errorf("semantic cache threshold must be between 0 and 1, got %.2f", config.Threshold)
+	}
+	if config.Threshold == 0 {
+		config.Threshold = defaultSemanticThreshold
+	}
+	if config.Collection == "" {
+		config.Collection = defaultSemanticCollection
+	}
+	if config.DefaultTTL <= 0 {
+		config.DefaultTTL = defaultSemanticTTL
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultSemanticMaxEntries
+	}
+
+	return &SemanticCache{
+		config:  config,
+		exact:   NewMemoryCache(config.MaxEntries, config.DefaultTTL),
+		entries: make(map[string]*semanticEntry),
+	}, nil
+}
+
+// WithThreshold sets the similarity threshold of a scope. Empty scope fields
+// match any value; the most specific matching scope wins.
+//
+// Example:
+//
+//	cache.WithThreshold(agent.CacheScope{Model: "gpt-4o-mini"}, 0.95)
+func (c *SemanticCache) WithThreshold(scope CacheScope, threshold float64) *SemanticCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.thresholds {
+		if c.thresholds[i].scope == scope {
+			c.thresholds[i].threshold = threshold
+			return c
+		}
+	}
+	c.thresholds = append(c.thresholds, scopedThreshold{scope: scope, threshold: threshold})
+	return c
+}
+
+// Threshold returns the similarity threshold of a scope
+func (c *SemanticCache) Threshold(scope CacheScope) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	threshold, best := c.config.Threshold, -1
+	for _, scoped := range c.thresholds {
+		if scoped.scope.matches(scope) && scoped.scope.specificity() > best {
+			threshold, best = scoped.threshold, scoped.scope.specificity()
+		}
+	}
+	return threshold
+}
+
+// GetSemantic returns the response cached for the most similar prompt in the
+// scope, if its similarity reaches the scope's threshold
+func (c *SemanticCache) GetSemantic(ctx context.Context, scope CacheScope, prompt string) (string, bool, error) {
+	embedding, err := c.config.Embedder.Embed(ctx, prompt)
+	if err != nil {
+		c.recordLookup(false)
+		return "", false, fmt.Errorf("failed to embed prompt: %w", err)
+	}
+
+	threshold := c.Threshold(scope)
+	var value string
+	var found bool
+	if c.config.Store != nil {
+		value, found, err = c.searchStore(ctx, scope, embedding, threshold)
+	} else {
+		value, found = c.searchIndex(scope, embedding, threshold)
+	}
+
+	c.recordLookup(found)
+	return value, found, err
+}
+
+// SetSemantic caches the response to a prompt in the scope
+func (c *SemanticCache) SetSemantic(ctx context.Context, scope CacheScope, prompt, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.config.DefaultTTL
+	}
+	embedding, err := c.config.Embedder.Embed(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("failed to embed prompt: %w", err)
+	}
+
+	scopeKey := scope.key()
+	id := semanticEntryID(scopeKey, prompt)
+	expiresAt := time.Now().Add(ttl)
+
+	if c.config.Store != nil {
+		if err := c.ensureCollection(ctx); err != nil {
+			return err
+		}
+		_, err := c.config.Store.Add(ctx, c.config.Collection, []*VectorDocument{{
+			ID:        id,
+			Content:   prompt,
+			Embedding: embedding,
+			Metadata: map[string]interface{}{
+				"scope":      scopeKey,
+				"response":   value,
+				"expires_at": expiresAt.Unix(),
+			},
+		}})
+		if err != nil {
+			return fmt.Errorf("failed to store prompt: %w", err)
+		}
+	} else {
+		c.mu.Lock()
+		if _, exists := c.entries[id]; !exists && len(c.entries) >= c.config.MaxEntries {
+			c.evictLRU()
+		}
+		c.entries[id] = &semanticEntry{
+			scope:      scopeKey,
+			prompt:     prompt,
+			embedding:  embedding,
+			value:      value,
+			expiresAt:  expiresAt,
+			accessedAt: time.Now(),
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.totalWrites++
+	c.mu.Unlock()
+	return nil
+}
+
+// searchIndex finds the most similar prompt of the in-memory index
+func (c *SemanticCache) searchIndex(scope CacheScope, embedding []float32, threshold float64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	scopeKey := scope.key()
+	now := time.Now()
+	var best *semanticEntry
+	var bestScore float32
+	for _, entry := range c.entries {
+		if entry.scope != scopeKey || now.After(entry.expiresAt) {
+			continue
+		}
+		score, err := CosineSimilarity(embedding, entry.embedding)
+		if err != nil || float64(score) < threshold {
+			continue
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+
+	if best == nil {
+		return "", false
+	}
+	best.accessedAt = now
+	return best.value, true
+}
+
+// searchStore finds the most similar prompt of the vector store
+func (c *SemanticCache) searchStore(ctx context.Context, scope CacheScope, embedding []float32, threshold float64) (string, bool, error) {
+	if err := c.ensureCollection(ctx); err != nil {
+		return "", false, err
+	}
+
+	results, err := c.config.Store.Search(ctx, &SearchRequest{
+		Collection:      c.config.Collection,
+		QueryVector:     embedding,
+		TopK:            5,
+		Filter:          map[string]interface{}{"scope": scope.key()},
+		IncludeMetadata: true,
+		MinScore:        float32(threshold),
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("semantic cache search failed: %w", err)
+	}
+
+	// Stores may not support every filter, so results are checked again
+	now := time.Now().Unix()
+	for _, result := range results {
+		if result == nil || result.Document == nil || float64(result.Score) < threshold {
+			continue
+		}
+		metadata := result.Document.Metadata
+		if fmt.Sprint(metadata["scope"]) != scope.key() {
+			continue
+		}
+		if expiresAt, ok := toInt64(metadata["expires_at"]); ok && expiresAt < now {
+			continue
+		}
+		if response, ok := metadata["response"].(string); ok {
+			return response, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// ensureCollection creates the store collection if it does not exist
+func (c *SemanticCache) ensureCollection(ctx context.Context) error {
+	c.mu.RLock()
+	known := c.collection
+	c.mu.RUnlock()
+	if known {
+		return nil
+	}
+
+	exists, err := c.config.Store.CollectionExists(ctx, c.config.Collection)
+	if err != nil {
+		return fmt.Errorf("failed to check semantic cache collection: %w", err)
+	}
+	if !exists {
+		err := c.config.Store.CreateCollection(ctx, c.config.Collection, &CollectionConfig{
+			Name:           c.config.Collection,
+			Description:    "Semantic response cache",
+			Dimension:      c.config.Embedder.Dimensions(),
+			DistanceMetric: DistanceMetricCosine,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create semantic cache collection: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.collection = true
+	c.mu.Unlock()
+	return nil
+}
+
+// evictLRU evicts the least recently used entry of the in-memory index.
+// Must be called with c.mu held.
+func (c *SemanticCache) evictLRU() {
+	var oldestID string
+	var oldest time.Time
+	for id, entry := range c.entries {
+		if oldestID == "" || entry.accessedAt.Before(oldest) {
+			oldestID, oldest = id, entry.accessedAt
+		}
+	}
+	if oldestID != "" {
+		delete(c.entries, oldestID)
+		c.evictions++
+	}
+}
+
+// recordLookup counts a semantic hit or miss
+func (c *SemanticCache) recordLookup(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// Get retrieves a response by exact key
+func (c *SemanticCache) Get(ctx context.Context, key string) (string, bool, error) {
+	return c.exact.Get(ctx, key)
+}
+
+// Set stores a response by exact key
+func (c *SemanticCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return c.exact.Set(ctx, key, value, ttl)
+}
+
+// Delete removes a response by exact key
+func (c *SemanticCache) Delete(ctx context.Context, key string) error {
+	return c.exact.Delete(ctx, key)
+}
+
+// Clear removes all responses. The store collection, owned by the cache, is deleted.
+func (c *SemanticCache) Clear(ctx context.Context) error {
+	if err := c.exact.Clear(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.entries = make(map[string]*semanticEntry)
+	c.hits, c.misses, c.evictions, c.totalWrites = 0, 0, 0, 0
+	known := c.collection
+	c.collection = false
+	c.mu.Unlock()
+
+	if c.config.Store != nil && known {
+		if err := c.config.Store.DeleteCollection(ctx, c.config.Collection); err != nil {
+			return fmt.Errorf("failed to clear semantic cache collection: %w", err)
+		}
+	}
+	return nil
+}
+
+// Stats returns cache statistics. Hits and Misses include the semantic lookups.
+func (c *SemanticCache) Stats() CacheStats {
+	stats := c.exact.Stats()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats.SemanticHits = c.hits
+	stats.SemanticMisses = c.misses
+	stats.Hits += c.hits
+	stats.Misses += c.misses
+	stats.Evictions += c.evictions
+	stats.TotalWrites += c.totalWrites
+	stats.Size += len(c.entries)
+	return stats
+}
+
+// semanticEntryID identifies a prompt within a scope
+func semanticEntryID(scopeKey, prompt string) string {
+	hash := sha256.Sum256([]byte(scopeKey + "\x00" + prompt))
+	return hex.EncodeToString(hash[:])
+}
+
+// toInt64 converts a numeric metadata value, as decoded by vector stores
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
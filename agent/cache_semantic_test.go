@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cosineVectorStore is an in-memory vector store ranking documents by cosine
+// similarity and honouring equality filters on metadata.
+// Unused VectorStore methods panic via the embedded nil interface.
+type cosineVectorStore struct {
+	VectorStore
+	mu          sync.Mutex
+	collections map[string][]*VectorDocument
+}
+
+func newCosineVectorStore() *cosineVectorStore {
+	return &cosineVectorStore{collections: make(map[string][]*VectorDocument)}
+}
+
+func (s *cosineVectorStore) CollectionExists(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.collections[name]
+	return ok, nil
+}
+
+func (s *cosineVectorStore) CreateCollection(ctx context.Context, name string, config *CollectionConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections[name] = nil
+	return nil
+}
+
+func (s *cosineVectorStore) DeleteCollection(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.collections, name)
+	return nil
+}
+
+func (s *cosineVectorStore) Add(ctx context.Context, collection string, docs []*VectorDocument) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		s.collections[collection] = append(s.collections[collection], doc)
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+func (s *cosineVectorStore) Search(ctx context.Context, req *SearchRequest) ([]*SearchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var results []*SearchResult
+	for _, doc := range s.collections[req.Collection] {
+		matches := true
+		for key, value := range req.Filter {
+			if doc.Metadata[key] != value {
+				matches = false
+			}
+		}
+		score, err := CosineSimilarity(req.QueryVector, doc.Embedding)
+		if !matches || err != nil || score < req.MinScore {
+			continue
+		}
+		results = append(results, &SearchResult{Document: doc, Score: score, Rank: len(results) + 1})
+	}
+	return results, nil
+}
+
+// newChatServer serves OpenAI-compatible chat completions answering content,
+// counting the requests in calls
+func newChatServer(t *testing.T, content string, calls *int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := json.Marshal(map[string]interface{}{
+			"id": "chatcmpl-1", "object": "chat.completion", "created": 1, "model": "llama3",
+			"choices": []map[string]interface{}{{
+				"index": 0, "finish_reason": "stop",
+				"message": map[string]interface{}{"role": "assistant", "content": content},
+			}},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newSemanticTestEmbedder embeds a question, a paraphrase (cosine ~0.99)
+// and an unrelated prompt (cosine ~0.5)
+func newSemanticTestEmbedder() *MockEmbeddingProvider {
+	embedder := NewMockEmbeddingProvider("mock", 3)
+	embedder.AddEmbedding("What is the capital of France?", []float32{1, 0, 0})
+	embedder.AddEmbedding("capital of France?", []float32{0.99, 0.1, 0})
+	embedder.AddEmbedding("How tall is the Eiffel Tower?", []float32{0.5, 0.866, 0})
+	return embedder
+}
+
+func TestSemanticCache_GetSemantic(t *testing.T) {
+	ctx := context.Background()
+	scope := CacheScope{Model: "gpt-4o-mini", SystemPrompt: "Be brief"}
+
+	stores := map[string]VectorStore{"memory": nil, "vector store": newCosineVectorStore()}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			cache, err := NewSemanticCache(SemanticCacheConfig{Embedder: newSemanticTestEmbedder(), Store: store})
+			if err != nil {
+				t.Fatalf("NewSemanticCache failed: %v", err)
+			}
+			if err := cache.SetSemantic(ctx, scope, "What is the capital of France?", "Paris", 0); err != nil {
+				t.Fatalf("SetSemantic failed: %v", err)
+			}
+
+			// A paraphrase hits
+			if value, found, err := cache.GetSemantic(ctx, scope, "capital of France?"); err != nil || !found || value != "Paris" {
+				t.Errorf("GetSemantic(paraphrase) = %q, %v, %v, want Paris", value, found, err)
+			}
+
+			// An unrelated prompt misses
+			if _, found, _ := cache.GetSemantic(ctx, scope, "How tall is the Eiffel Tower?"); found {
+				t.Error("GetSemantic(unrelated) should miss")
+			}
+
+			// Another system prompt misses
+			other := CacheScope{Model: "gpt-4o-mini", SystemPrompt: "Answer in French"}
+			if _, found, _ := cache.GetSemantic(ctx, other, "capital of France?"); found {
+				t.Error("GetSemantic(other scope) should miss")
+			}
+
+			stats := cache.Stats()
+			if stats.SemanticHits != 1 || stats.SemanticMisses != 2 || stats.Hits != 1 || stats.Misses != 2 {
+				t.Errorf("Stats() = %+v, want 1 semantic hit and 2 semantic misses", stats)
+			}
+		})
+	}
+}
+
+func TestSemanticCache_WithThreshold(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSemanticCache(SemanticCacheConfig{Embedder: newSemanticTestEmbedder()})
+	if err != nil {
+		t.Fatalf("NewSemanticCache failed: %v", err)
+	}
+	cache.WithThreshold(CacheScope{Model: "strict"}, 0.999).
+		WithThreshold(CacheScope{Model: "strict", Persona: "Poet"}, 0.4)
+
+	strict := CacheScope{Model: "strict"}
+	poet := CacheScope{Model: "strict", Persona: "Poet"}
+	if got := cache.Threshold(CacheScope{Model: "other"}); got != defaultSemanticThreshold {
+		t.Errorf("Threshold(other) = %v, want default", got)
+	}
+	if got := cache.Threshold(poet); got != 0.4 {
+		t.Errorf("Threshold(poet) = %v, want the most specific threshold", got)
+	}
+
+	for _, scope := range []CacheScope{strict, poet} {
+		_ = cache.SetSemantic(ctx, scope, "What is the capital of France?", "Paris", time.Minute)
+	}
+	if _, found, _ := cache.GetSemantic(ctx, strict, "capital of France?"); found {
+		t.Error("paraphrase should miss above the strict threshold")
+	}
+	if _, found, _ := cache.GetSemantic(ctx, poet, "How tall is the Eiffel Tower?"); !found {
+		t.Error("unrelated prompt should hit below the lenient threshold")
+	}
+}
+
+func TestSemanticCache_ExpiryAndEviction(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSemanticCache(SemanticCacheConfig{Embedder: newSemanticTestEmbedder(), MaxEntries: 1})
+	if err != nil {
+		t.Fatalf("NewSemanticCache failed: %v", err)
+	}
+
+	_ = cache.SetSemantic(ctx, CacheScope{}, "What is the capital of France?", "Paris", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, found, _ := cache.GetSemantic(ctx, CacheScope{}, "capital of France?"); found {
+		t.Error("expired entry should miss")
+	}
+
+	_ = cache.SetSemantic(ctx, CacheScope{}, "How tall is the Eiffel Tower?", "330 m", time.Minute)
+	if stats := cache.Stats(); stats.Size != 1 || stats.Evictions != 1 {
+		t.Errorf("Stats() = %+v, want 1 entry after 1 eviction", stats)
+	}
+
+	if _, err := NewSemanticCache(SemanticCacheConfig{}); err == nil {
+		t.Error("NewSemanticCache without embedder should fail")
+	}
+}
+
+func TestBuilder_WithSemanticCache(t *testing.T) {
+	var calls int
+	server := newChatServer(t, "Paris", &calls)
+
+	ai := New(ProviderOllama, "llama3").
+		WithBaseURL(server.URL + "/v1/").
+		WithSemanticCache(SemanticCacheConfig{Embedder: newSemanticTestEmbedder()})
+
+	for _, prompt := range []string{"What is the capital of France?", "capital of France?"} {
+		answer, err := ai.Ask(context.Background(), prompt)
+		if err != nil || answer != "Paris" {
+			t.Fatalf("Ask(%q) = %q, %v", prompt, answer, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("provider calls = %d, want the paraphrase served from cache", calls)
+	}
+	if stats := ai.GetCacheStats(); stats.SemanticHits != 1 || stats.SemanticMisses != 1 {
+		t.Errorf("GetCacheStats() = %+v", stats)
+	}
+
+	// Another system prompt does not reuse the response
+	if _, err := ai.WithSystem("Answer in French").Ask(context.Background(), "capital of France?"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("provider calls = %d, want a miss for another system prompt", calls)
+	}
+
+	// Nor does a question asked in another conversation or with other parameters
+	calls = 0
+	cache, err := NewSemanticCache(SemanticCacheConfig{Embedder: newSemanticTestEmbedder()})
+	if err != nil {
+		t.Fatalf("NewSemanticCache failed: %v", err)
+	}
+	ask := func(b *Builder) {
+		t.Helper()
+		if _, err := b.Ask(context.Background(), "capital of France?"); err != nil {
+			t.Fatalf("Ask failed: %v", err)
+		}
+	}
+	shared := New(ProviderOllama, "llama3").WithBaseURL(server.URL + "/v1/").WithCache(cache)
+	ask(shared)
+	ask(shared.WithMessages([]Message{User("Let's talk about Texas"), Assistant("Sure")}))
+	ask(shared.WithTemperature(1.5))
+	ask(shared.WithJSONMode())
+	if calls != 4 {
+		t.Errorf("provider calls = %d, want a miss per change of history, temperature and response format", calls)
+	}
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheLocker is implemented by caches shared between processes, such as
+// RedisCache, so that a single process fills a missing entry while the
+// others wait for it
+type CacheLocker interface {
+	// TryLock acquires the fill lock of key for at most ttl. It returns
+	// false when another process holds the lock.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error)
+}
+
+// StampedeConfig configures cache stampede protection
+type StampedeConfig struct {
+	// LockTTL bounds how long a process holds the fill lock of a key in a
+	// CacheLocker, should it crash before releasing it.
+	// Default: 30 seconds
+	LockTTL time.Duration
+
+	// WaitTimeout bounds how long a request waits for another process to
+	// fill the cache before calling the LLM itself.
+	// Default: LockTTL
+	WaitTimeout time.Duration
+
+	// PollInterval is the interval between attempts to acquire the fill lock
+	// held by another process.
+	// Default: 50ms
+	PollInterval time.Duration
+}
+
+// withDefaults returns the config with defaults applied
+func (c StampedeConfig) withDefaults() StampedeConfig {
+	if c.LockTTL <= 0 {
+		c.LockTTL = 30 * time.Second
+	}
+	if c.WaitTimeout <= 0 {
+		c.WaitTimeout = c.LockTTL
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = 50 * time.Millisecond
+	}
+	return c
+}
+
+// cacheFlights coalesces concurrent misses on the same key within the
+// process: the first request fills the cache, the others wait for it
+var cacheFlights = &flightGroup{flights: make(map[string]chan struct{})}
+
+// flightGroup tracks the keys being filled
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]chan struct{}
+}
+
+// join makes the caller the leader of key, or returns the channel closed
+// when the current leader leaves
+func (g *flightGroup) join(key string) (done chan struct{}, leader bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if done, ok := g.flights[key]; ok {
+		return done, false
+	}
+	g.flights[key] = make(chan struct{})
+	return nil, true
+}
+
+// leave ends the flight of key, waking up its waiters
+func (g *flightGroup) leave(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if done, ok := g.flights[key]; ok {
+		close(done)
+		delete(g.flights, key)
+	}
+}
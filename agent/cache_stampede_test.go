@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// askConcurrently asks the same question from one builder per goroutine
+func askConcurrently(t *testing.T, builders []*Builder) []string {
+	t.Helper()
+	answers := make([]string, len(builders))
+	var wg sync.WaitGroup
+	for i, builder := range builders {
+		wg.Add(1)
+		go func(i int, builder *Builder) {
+			defer wg.Done()
+			answer, err := builder.Ask(context.Background(), "Capital of France?")
+			if err != nil {
+				t.Errorf("Ask failed: %v", err)
+			}
+			answers[i] = answer
+		}(i, builder)
+	}
+	wg.Wait()
+	return answers
+}
+
+func TestBuilder_WithStampedeProtection_InProcess(t *testing.T) {
+	adapter := &latencyAdapter{delay: 50 * time.Millisecond, content: "Paris"}
+	cache := NewMemoryCache(10, time.Minute)
+
+	builders := make([]*Builder, 10)
+	for i := range builders {
+		builders[i] = NewWithAdapter("test-model", adapter).
+			WithCache(cache).
+			WithStampedeProtection(StampedeConfig{})
+	}
+
+	for _, answer := range askConcurrently(t, builders) {
+		if answer != "Paris" {
+			t.Errorf("answer = %q, want Paris", answer)
+		}
+	}
+	if calls := adapter.calls.Load(); calls != 1 {
+		t.Errorf("provider calls = %d, want concurrent misses coalesced", calls)
+	}
+}
+
+func TestBuilder_WithStampedeProtection_AcrossProcesses(t *testing.T) {
+	mr := miniredis.RunT(t)
+	adapter := &latencyAdapter{delay: 50 * time.Millisecond, content: "Paris"}
+
+	// Each builder has its own Redis client, like replicas of a service
+	builders := make([]*Builder, 5)
+	for i := range builders {
+		cache, err := NewRedisCache(mr.Addr(), "", 0, time.Minute)
+		if err != nil {
+			t.Fatalf("NewRedisCache failed: %v", err)
+		}
+		defer cache.Close()
+		builders[i] = NewWithAdapter("test-model", adapter).
+			WithCache(cache).
+			WithStampedeProtection(StampedeConfig{LockTTL: time.Second, PollInterval: 5 * time.Millisecond})
+	}
+
+	for _, answer := range askConcurrently(t, builders) {
+		if answer != "Paris" {
+			t.Errorf("answer = %q, want Paris", answer)
+		}
+	}
+	if calls := adapter.calls.Load(); calls != 1 {
+		t.Errorf("provider calls = %d, want concurrent misses coalesced by the lock", calls)
+	}
+	for _, key := range mr.Keys() {
+		if strings.HasPrefix(key, "go-deep-agent:lock:") {
+			t.Errorf("lock %s should be released", key)
+		}
+	}
+}
+
+func TestBuilder_WithCacheTags(t *testing.T) {
+	adapter := &latencyAdapter{content: "Paris"}
+	ai := NewWithAdapter("test-model", adapter).
+		WithMemoryCache(10, time.Minute).
+		WithCacheTags("docs:v1")
+
+	ask := func() {
+		t.Helper()
+		if _, err := ai.Ask(context.Background(), "Capital of France?"); err != nil {
+			t.Fatalf("Ask failed: %v", err)
+		}
+	}
+	ask()
+	ask()
+	if err := ai.InvalidateCacheTag(context.Background(), "docs:v1"); err != nil {
+		t.Fatalf("InvalidateCacheTag failed: %v", err)
+	}
+	ask()
+	if calls := adapter.calls.Load(); calls != 2 {
+		t.Errorf("provider calls = %d, want a miss after invalidation", calls)
+	}
+
+	semantic, _ := NewSemanticCache(SemanticCacheConfig{Embedder: NewMockEmbeddingProvider("mock", 3)})
+	if err := ai.WithCache(semantic).InvalidateCacheTag(context.Background(), "docs:v1"); err == nil {
+		t.Error("expected an error for a cache without tags")
+	}
+}
@@ -410,6 +410,35 @@ func TestCacheConcurrency(t *testing.T) {
 	}
 }
 
+func TestMemoryCacheInvalidateTag(t *testing.T) {
+	cache := NewMemoryCache(10, 1*time.Minute)
+	ctx := context.Background()
+
+	cache.SetWithTags(ctx, "support-1", "a", 0, "persona:support", "docs:v1")
+	cache.SetWithTags(ctx, "support-2", "b", 0, "persona:support")
+	cache.SetWithTags(ctx, "sales-1", "c", 0, "persona:sales", "docs:v1")
+	cache.Set(ctx, "untagged", "d", 0)
+
+	if err := cache.InvalidateTag(ctx, "persona:support"); err != nil {
+		t.Fatalf("InvalidateTag failed: %v", err)
+	}
+	for key, want := range map[string]bool{"support-1": false, "support-2": false, "sales-1": true, "untagged": true} {
+		if _, found, _ := cache.Get(ctx, key); found != want {
+			t.Errorf("Get(%s) found = %v, want %v", key, found, want)
+		}
+	}
+
+	// Overwriting a key drops its previous tags
+	cache.Set(ctx, "sales-1", "e", 0)
+	cache.InvalidateTag(ctx, "docs:v1")
+	if _, found, _ := cache.Get(ctx, "sales-1"); !found {
+		t.Error("Expected sales-1 to survive invalidation of its previous tag")
+	}
+	if len(cache.tags) != 0 {
+		t.Errorf("Expected empty tag index, got %v", cache.tags)
+	}
+}
+
 func BenchmarkCacheSet(b *testing.B) {
 	cache := NewMemoryCache(1000, 1*time.Minute)
 	ctx := context.Background()
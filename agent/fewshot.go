@@ -313,6 +313,24 @@ func (c *FewShotConfig) selectBest(examples []FewShotExample) []FewShotExample {
 	return examples
 }
 
+// clone returns a copy of the configuration with its own examples
+func (c *FewShotConfig) clone() *FewShotConfig {
+	clone := *c
+	clone.Examples = make([]FewShotExample, len(c.Examples))
+	for i, example := range c.Examples {
+		example.Tags = append([]string(nil), example.Tags...)
+		if example.Context != nil {
+			values := make(map[string]interface{}, len(example.Context))
+			for k, v := range example.Context {
+				values[k] = v
+			}
+			example.Context = values
+		}
+		clone.Examples[i] = example
+	}
+	return &clone
+}
+
 // Count returns the number of examples that would be selected
 func (c *FewShotConfig) Count() int {
 	if c == nil {
@@ -0,0 +1,736 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/taipm/go-deep-agent/agent"
+)
+
+// Client defaults
+const (
+	DefaultRequestTimeout = 60 * time.Second
+	DefaultMaxReconnects  = 3
+	DefaultReconnectDelay = 500 * time.Millisecond
+)
+
+// ClientConfig configures a connection to one MCP server.
+// Exactly one of Command, URL or Transport must be set.
+type ClientConfig struct {
+	// Name identifies the server and is used as the tool namespace
+	Name string
+
+	// Command and Args start a server speaking MCP over stdio.
+	// Env entries ("KEY=value") are added to the current environment.
+	Command string
+	Args    []string
+	Env     []string
+
+	// URL is the endpoint of a server speaking streamable HTTP.
+	// Headers are sent with every request (e.g. Authorization).
+	URL        string
+	Headers    map[string]string
+	HTTPClient *http.Client
+
+	// Transport creates a custom transport, called on every (re)connection
+	Transport func() (Transport, error)
+
+	// Namespace prefixes imported tool names ("<namespace>_<tool>").
+	// Defaults to Name. Set DisableNamespace to keep the original names.
+	Namespace        string
+	DisableNamespace bool
+
+	// RequestTimeout bounds every request (default: 60s)
+	RequestTimeout time.Duration
+
+	// MaxReconnects is the number of reconnection attempts after the
+	// connection is lost (default: 3, negative disables reconnection)
+	MaxReconnects int
+
+	// ReconnectDelay is the initial delay between attempts, doubled after each (default: 500ms)
+	ReconnectDelay time.Duration
+
+	// Logger receives connection events (default: no logging)
+	Logger agent.Logger
+}
+
+// Client is a connection to an MCP server that imports its tools.
+// It reconnects automatically when the connection is lost and keeps its tool
+// list current when the server sends list change notifications.
+// A Client is safe for concurrent use.
+type Client struct {
+	config ClientConfig
+	logger agent.Logger
+
+	mu              sync.Mutex
+	transport       Transport
+	connected       bool
+	closed          bool
+	serverInfo      Implementation
+	capabilities    ServerCapabilities
+	protocolVersion string
+	tools           []ToolInfo
+	listeners       []func([]*agent.Tool)
+
+	connectMu sync.Mutex // serializes (re)connection attempts
+	pendingMu sync.Mutex
+	pending   map[string]chan *Message
+	progress  map[string]func(Progress)
+	nextID    atomic.Int64
+}
+
+// NewClient creates a client. No connection is made until Connect or the first request.
+func NewClient(config ClientConfig) *Client {
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = DefaultRequestTimeout
+	}
+	if config.MaxReconnects == 0 {
+		config.MaxReconnects = DefaultMaxReconnects
+	}
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = DefaultReconnectDelay
+	}
+	if config.Namespace == "" {
+		config.Namespace = config.Name
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = &agent.NoopLogger{}
+	}
+
+	return &Client{
+		config:   config,
+		logger:   logger,
+		pending:  make(map[string]chan *Message),
+		progress: make(map[string]func(Progress)),
+	}
+}
+
+// Connect opens the connection, performs the initialize handshake and loads
+// the tool list. Calling Connect on a connected client is a no-op.
+func (c *Client) Connect(ctx context.Context) error {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	c.mu.Lock()
+	closed, connected := c.closed, c.connected
+	c.mu.Unlock()
+	if closed {
+		return ErrClientClosed
+	}
+	if connected {
+		return nil
+	}
+
+	return c.connect(ctx)
+}
+
+// connect creates a transport and runs the handshake. Caller holds connectMu.
+func (c *Client) connect(ctx context.Context) error {
+	transport, err := c.newTransport()
+	if err != nil {
+		return err
+	}
+	if err := transport.Start(context.Background(), c.handleMessage); err != nil {
+		transport.Close()
+		return fmt.Errorf("failed to start transport: %w", err)
+	}
+
+	c.mu.Lock()
+	c.transport = transport
+	c.mu.Unlock()
+
+	fail := func(err error) error {
+		c.mu.Lock()
+		c.transport = nil
+		c.mu.Unlock()
+		transport.Close()
+		return err
+	}
+
+	var result initializeResult
+	err = c.roundTrip(ctx, transport, methodInitialize, initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]interface{}{},
+		ClientInfo:      Implementation{Name: "go-deep-agent", Version: PackageVersion},
+	}, &result)
+	if err != nil {
+		return fail(fmt.Errorf("mcp initialize failed: %w", err))
+	}
+	if !isSupportedVersion(result.ProtocolVersion) {
+		return fail(fmt.Errorf("mcp: unsupported protocol version %q", result.ProtocolVersion))
+	}
+	if setter, ok := transport.(interface{ setProtocolVersion(string) }); ok {
+		setter.setProtocolVersion(result.ProtocolVersion)
+	}
+
+	notification, _ := newNotification(notificationInitialized, nil)
+	if err := transport.Send(ctx, notification); err != nil {
+		return fail(fmt.Errorf("mcp initialized notification failed: %w", err))
+	}
+
+	tools, err := c.fetchTools(ctx, transport)
+	if err != nil {
+		return fail(err)
+	}
+
+	c.mu.Lock()
+	c.serverInfo = result.ServerInfo
+	c.capabilities = result.Capabilities
+	c.protocolVersion = result.ProtocolVersion
+	changed := c.tools != nil && !sameTools(c.tools, tools)
+	c.tools = tools
+	c.connected = true
+	c.mu.Unlock()
+
+	go c.watch(transport)
+
+	c.logger.Info(ctx, "Connected to MCP server",
+		agent.F("server", c.config.Name),
+		agent.F("server_name", result.ServerInfo.Name),
+		agent.F("protocol_version", result.ProtocolVersion),
+		agent.F("tool_count", len(tools)))
+
+	if changed {
+		c.notifyToolsChanged()
+	}
+	return nil
+}
+
+// newTransport creates the transport selected by the configuration
+func (c *Client) newTransport() (Transport, error) {
+	switch {
+	case c.config.Transport != nil:
+		return c.config.Transport()
+	case c.config.Command != "":
+		return NewStdioTransport(c.config.Command, c.config.Args, c.config.Env)
+	case c.config.URL != "":
+		return NewHTTPTransport(c.config.URL, c.config.Headers, c.config.HTTPClient), nil
+	default:
+		return nil, fmt.Errorf("mcp: one of Command, URL or Transport is required")
+	}
+}
+
+// watch marks the client disconnected when the transport goes away
+func (c *Client) watch(transport Transport) {
+	<-transport.Done()
+	c.disconnect(transport)
+}
+
+// disconnect forgets a transport and fails its pending requests
+func (c *Client) disconnect(transport Transport) {
+	c.mu.Lock()
+	if c.transport != transport {
+		c.mu.Unlock()
+		return
+	}
+	c.transport = nil
+	c.connected = false
+	closed := c.closed
+	c.mu.Unlock()
+
+	transport.Close()
+
+	c.pendingMu.Lock()
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.pendingMu.Unlock()
+
+	if !closed {
+		c.logger.Warn(context.Background(), "MCP server connection lost",
+			agent.F("server", c.config.Name))
+	}
+}
+
+// ensureConnected returns a live transport, reconnecting with backoff if needed
+func (c *Client) ensureConnected(ctx context.Context) (Transport, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClientClosed
+	}
+	if c.connected {
+		transport := c.transport
+		c.mu.Unlock()
+		return transport, nil
+	}
+	everConnected := c.tools != nil
+	c.mu.Unlock()
+
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	// Another goroutine may have reconnected while we waited
+	c.mu.Lock()
+	if c.connected {
+		transport := c.transport
+		c.mu.Unlock()
+		return transport, nil
+	}
+	c.mu.Unlock()
+
+	attempts := 1
+	if everConnected {
+		if c.config.MaxReconnects < 0 {
+			return nil, ErrNotConnected
+		}
+		attempts = c.config.MaxReconnects
+	}
+
+	delay := c.config.ReconnectDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = c.connect(ctx); err == nil {
+			c.mu.Lock()
+			transport := c.transport
+			c.mu.Unlock()
+			return transport, nil
+		}
+
+		c.logger.Warn(ctx, "MCP connection attempt failed",
+			agent.F("server", c.config.Name),
+			agent.F("attempt", attempt),
+			agent.F("error", err.Error()))
+
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
+}
+
+// call sends a request, reconnecting first if needed. A request that could not
+// be sent because the connection was lost is retried once on a new connection;
+// requests that reached the server are never retried.
+func (c *Client) call(ctx context.Context, method string, params, result interface{}) error {
+	for retry := 0; ; retry++ {
+		transport, err := c.ensureConnected(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = c.roundTrip(ctx, transport, method, params, result)
+		var sendErr *sendError
+		if errors.As(err, &sendErr) && retry == 0 && c.config.MaxReconnects > 0 {
+			c.disconnect(transport)
+			continue
+		}
+		return err
+	}
+}
+
+// sendError marks a request that never reached the server
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string { return e.err.Error() }
+func (e *sendError) Unwrap() error { return e.err }
+
+// roundTrip sends a request on a transport and waits for its response
+func (c *Client) roundTrip(ctx context.Context, transport Transport, method string, params, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	req, err := newRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *Message, 1)
+	c.pendingMu.Lock()
+	c.pending[string(id)] = ch
+	c.pendingMu.Unlock()
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, string(id))
+		c.pendingMu.Unlock()
+	}()
+
+	if err := transport.Send(ctx, req); err != nil {
+		if errors.Is(err, ErrConnectionClosed) || errors.Is(err, ErrSessionExpired) {
+			return &sendError{err: err}
+		}
+		return err
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("%s: %w", method, ErrConnectionClosed)
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			if err := json.Unmarshal(resp.Result, result); err != nil {
+				return fmt.Errorf("failed to parse %s result: %w", method, err)
+			}
+		}
+		return nil
+	case <-transport.Done():
+		return fmt.Errorf("%s: %w", method, ErrConnectionClosed)
+	case <-ctx.Done():
+		// Tell the server to stop working on the request
+		if notification, err := newNotification(notificationCancelled, cancelledParams{
+			RequestID: id,
+			Reason:    ctx.Err().Error(),
+		}); err == nil {
+			transport.Send(context.Background(), notification)
+		}
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+// handleMessage dispatches a message received from the server
+func (c *Client) handleMessage(msg *Message) {
+	switch {
+	case msg.IsResponse():
+		c.pendingMu.Lock()
+		ch, ok := c.pending[string(msg.ID)]
+		if ok {
+			delete(c.pending, string(msg.ID))
+		}
+		c.pendingMu.Unlock()
+		if ok {
+			ch <- msg
+		}
+
+	case msg.IsNotification():
+		switch msg.Method {
+		case notificationToolsListChanged:
+			// Refresh outside of the read loop, which must keep delivering responses
+			go c.refreshTools()
+		case notificationProgress:
+			var params progressParams
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				return
+			}
+			c.pendingMu.Lock()
+			onProgress := c.progress[string(params.ProgressToken)]
+			c.pendingMu.Unlock()
+			if onProgress != nil {
+				onProgress(Progress{Progress: params.Progress, Total: params.Total, Message: params.Message})
+			}
+		}
+
+	case msg.IsRequest():
+		c.mu.Lock()
+		transport := c.transport
+		c.mu.Unlock()
+		if transport == nil {
+			return
+		}
+
+		var resp *Message
+		if msg.Method == methodPing {
+			resp, _ = newResponse(msg.ID, struct{}{})
+		} else {
+			resp = newErrorResponse(msg.ID, CodeMethodNotFound, "method not found: "+msg.Method)
+		}
+		go transport.Send(context.Background(), resp)
+	}
+}
+
+// fetchTools lists all tools of the server, following pagination
+func (c *Client) fetchTools(ctx context.Context, transport Transport) ([]ToolInfo, error) {
+	tools := []ToolInfo{}
+	cursor := ""
+	for {
+		var page listToolsResult
+		if err := c.roundTrip(ctx, transport, methodToolsList, listToolsParams{Cursor: cursor}, &page); err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// refreshTools reloads the tool list after a change notification
+func (c *Client) refreshTools() {
+	ctx := context.Background()
+	transport, err := c.ensureConnected(ctx)
+	if err != nil {
+		return
+	}
+
+	tools, err := c.fetchTools(ctx, transport)
+	if err != nil {
+		c.logger.Warn(ctx, "Failed to refresh MCP tools",
+			agent.F("server", c.config.Name),
+			agent.F("error", err.Error()))
+		return
+	}
+
+	c.mu.Lock()
+	c.tools = tools
+	c.mu.Unlock()
+
+	c.logger.Info(ctx, "MCP tool list changed",
+		agent.F("server", c.config.Name),
+		agent.F("tool_count", len(tools)))
+
+	c.notifyToolsChanged()
+}
+
+// notifyToolsChanged calls the registered listeners with the current tools
+func (c *Client) notifyToolsChanged() {
+	c.mu.Lock()
+	listeners := append([]func([]*agent.Tool){}, c.listeners...)
+	c.mu.Unlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+	tools := c.agentTools()
+	for _, listener := range listeners {
+		listener(tools)
+	}
+}
+
+// OnToolsChanged registers a callback called with the new proxies whenever the
+// server's tool list changes (on notification or after a reconnection).
+// Tools registered on a Builder keep working if they still exist on the server;
+// use the callback to register new tools.
+func (c *Client) OnToolsChanged(callback func(tools []*agent.Tool)) {
+	c.mu.Lock()
+	c.listeners = append(c.listeners, callback)
+	c.mu.Unlock()
+}
+
+// ListTools returns the tools offered by the server, as last reported
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	if _, err := c.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ToolInfo{}, c.tools...), nil
+}
+
+// CallTool invokes a tool by its original (not namespaced) name.
+// arguments may be nil, a json.RawMessage or any value marshaling to a JSON object.
+func (c *Client) CallTool(ctx context.Context, name string, arguments interface{}) (*CallToolResult, error) {
+	return c.CallToolWithProgress(ctx, name, arguments, nil)
+}
+
+// CallToolWithProgress invokes a tool and asks the server for progress
+// notifications, which are passed to onProgress while the call runs.
+// onProgress is called from the connection's read loop and must not block.
+func (c *Client) CallToolWithProgress(ctx context.Context, name string, arguments interface{}, onProgress func(Progress)) (*CallToolResult, error) {
+	params := callToolParams{Name: name}
+	if onProgress != nil {
+		token := json.RawMessage(strconv.Quote("progress-" + strconv.FormatInt(c.nextID.Add(1), 10)))
+		params.Meta = &requestMeta{ProgressToken: token}
+
+		c.pendingMu.Lock()
+		c.progress[string(token)] = onProgress
+		c.pendingMu.Unlock()
+		defer func() {
+			c.pendingMu.Lock()
+			delete(c.progress, string(token))
+			c.pendingMu.Unlock()
+		}()
+	}
+	switch args := arguments.(type) {
+	case nil:
+	case json.RawMessage:
+		params.Arguments = args
+	default:
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal arguments: %w", err)
+		}
+		params.Arguments = data
+	}
+
+	var result CallToolResult
+	if err := c.call(ctx, methodToolsCall, params, &result); err != nil {
+		return nil, fmt.Errorf("mcp tool %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// Ping checks that the server is responsive
+func (c *Client) Ping(ctx context.Context) error {
+	return c.call(ctx, methodPing, nil, nil)
+}
+
+// Tools connects if needed and returns agent.Tool proxies for every server tool
+func (c *Client) Tools(ctx context.Context) ([]*agent.Tool, error) {
+	if _, err := c.ensureConnected(ctx); err != nil {
+		return nil, err
+	}
+	return c.agentTools(), nil
+}
+
+// agentTools converts the cached tool list into agent.Tool proxies
+func (c *Client) agentTools() []*agent.Tool {
+	c.mu.Lock()
+	infos := append([]ToolInfo{}, c.tools...)
+	c.mu.Unlock()
+
+	tools := make([]*agent.Tool, 0, len(infos))
+	for _, info := range infos {
+		tools = append(tools, c.proxyTool(info))
+	}
+	return tools
+}
+
+// proxyTool creates an agent.Tool forwarding calls to the server.
+// Tool-level errors (isError) are returned as text so the model can react to them.
+func (c *Client) proxyTool(info ToolInfo) *agent.Tool {
+	description := info.Description
+	if description == "" {
+		description = info.Title
+	}
+
+	tool := agent.NewTool(c.ToolName(info.Name), description)
+	tool.Parameters = convertSchema(info.InputSchema)
+
+	name := info.Name
+	return tool.WithContextHandler(func(ctx context.Context, args string) (string, error) {
+		var arguments json.RawMessage
+		if len(args) > 0 {
+			arguments = json.RawMessage(args)
+		}
+		result, err := c.CallTool(ctx, name, arguments)
+		if err != nil {
+			return "", err
+		}
+		if result.IsError {
+			return "Error: " + result.Text(), nil
+		}
+		return result.Text(), nil
+	})
+}
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ToolName returns the namespaced name of a server tool as registered on a Builder
+func (c *Client) ToolName(name string) string {
+	if c.config.DisableNamespace || c.config.Namespace == "" {
+		return name
+	}
+	return invalidToolNameChars.ReplaceAllString(c.config.Namespace, "_") + "_" + name
+}
+
+// convertSchema turns an MCP input schema into Tool.Parameters.
+// The result always has the object shape produced by agent.NewTool.
+func convertSchema(schema map[string]interface{}) map[string]interface{} {
+	params := make(map[string]interface{}, len(schema)+3)
+	for key, value := range schema {
+		if key == "$schema" {
+			continue
+		}
+		params[key] = value
+	}
+
+	params["type"] = "object"
+	if _, ok := params["properties"].(map[string]interface{}); !ok {
+		params["properties"] = map[string]interface{}{}
+	}
+
+	required := []string{}
+	switch reqs := params["required"].(type) {
+	case []string:
+		required = reqs
+	case []interface{}:
+		for _, r := range reqs {
+			if s, ok := r.(string); ok {
+				required = append(required, s)
+			}
+		}
+	}
+	params["required"] = required
+
+	return params
+}
+
+// ServerInfo returns the name and version reported by the server
+func (c *Client) ServerInfo() Implementation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.serverInfo
+}
+
+// Connected reports whether the client currently has a live connection
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+// Close closes the connection. The client cannot be used afterwards.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	transport := c.transport
+	c.mu.Unlock()
+
+	if transport != nil {
+		c.disconnect(transport)
+	}
+	return nil
+}
+
+// isSupportedVersion reports whether a negotiated protocol version is supported
+func isSupportedVersion(version string) bool {
+	for _, v := range supportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// sameTools reports whether two tool lists have the same names and schemas
+func sameTools(a, b []ToolInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
+
+// WithTools connects the clients and registers all their tools on the builder.
+//
+// Example:
+//
+//	builder, err := mcp.WithTools(ctx, agent.NewOpenAI("gpt-4o", apiKey), githubClient, jiraClient)
+func WithTools(ctx context.Context, b *agent.Builder, clients ...*Client) (*agent.Builder, error) {
+	for _, client := range clients {
+		tools, err := client.Tools(ctx)
+		if err != nil {
+			return b, fmt.Errorf("mcp server %s: %w", client.config.Name, err)
+		}
+		b.WithTools(tools...)
+	}
+	return b, nil
+}
@@ -0,0 +1,546 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/taipm/go-deep-agent/agent"
+)
+
+// fakeServer is a minimal in-process MCP server used to test the client
+type fakeServer struct {
+	mu        sync.Mutex
+	tools     []ToolInfo
+	pageSize  int
+	calls     []string
+	sessions  int
+	transport *StreamTransport
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		pageSize: 1,
+		tools: []ToolInfo{
+			{
+				Name:        "echo",
+				Description: "Echo the input",
+				InputSchema: map[string]interface{}{
+					"$schema":    "http://json-schema.org/draft-07/schema#",
+					"type":       "object",
+					"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+					"required":   []interface{}{"text"},
+				},
+			},
+			{Name: "fail", Description: "Always fails", InputSchema: map[string]interface{}{"type": "object"}},
+		},
+	}
+}
+
+// handle answers a request, or returns nil for notifications
+func (s *fakeServer) handle(msg *Message) *Message {
+	if !msg.IsRequest() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch msg.Method {
+	case methodInitialize:
+		s.sessions++
+		resp, _ := newResponse(msg.ID, initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			ServerInfo:      Implementation{Name: "fake", Version: "1.0"},
+		})
+		return resp
+	case methodPing:
+		resp, _ := newResponse(msg.ID, struct{}{})
+		return resp
+	case methodToolsList:
+		var params listToolsParams
+		json.Unmarshal(msg.Params, &params)
+		start := 0
+		if params.Cursor != "" {
+			fmt.Sscanf(params.Cursor, "%d", &start)
+		}
+		end := start + s.pageSize
+		result := listToolsResult{}
+		if end < len(s.tools) {
+			result.NextCursor = fmt.Sprint(end)
+		} else {
+			end = len(s.tools)
+		}
+		result.Tools = append([]ToolInfo{}, s.tools[start:end]...)
+		resp, _ := newResponse(msg.ID, result)
+		return resp
+	case methodToolsCall:
+		var params callToolParams
+		json.Unmarshal(msg.Params, &params)
+		s.calls = append(s.calls, params.Name)
+		switch params.Name {
+		case "echo":
+			var args struct {
+				Text string `json:"text"`
+			}
+			json.Unmarshal(params.Arguments, &args)
+			resp, _ := newResponse(msg.ID, CallToolResult{Content: []Content{TextContent("echo: " + args.Text)}})
+			return resp
+		case "fail":
+			resp, _ := newResponse(msg.ID, CallToolResult{Content: []Content{TextContent("boom")}, IsError: true})
+			return resp
+		default:
+			for _, tool := range s.tools {
+				if tool.Name == params.Name {
+					resp, _ := newResponse(msg.ID, CallToolResult{Content: []Content{TextContent(tool.Name + " called")}})
+					return resp
+				}
+			}
+			return newErrorResponse(msg.ID, CodeInvalidParams, "unknown tool: "+params.Name)
+		}
+	default:
+		return newErrorResponse(msg.ID, CodeMethodNotFound, "method not found")
+	}
+}
+
+// connect returns a client-side transport connected to a new server-side stream
+func (s *fakeServer) connect() (Transport, error) {
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+
+	server := NewStreamTransport(serverRead, serverWrite)
+	server.Start(context.Background(), func(msg *Message) {
+		if resp := s.handle(msg); resp != nil {
+			go server.Send(context.Background(), resp)
+		}
+	})
+
+	s.mu.Lock()
+	s.transport = server
+	s.mu.Unlock()
+
+	return NewStreamTransport(clientRead, clientWrite), nil
+}
+
+// setTools replaces the tool list and notifies the connected client
+func (s *fakeServer) setTools(tools []ToolInfo) {
+	s.mu.Lock()
+	s.tools = tools
+	transport := s.transport
+	s.mu.Unlock()
+
+	notification, _ := newNotification(notificationToolsListChanged, nil)
+	transport.Send(context.Background(), notification)
+}
+
+// drop closes the current connection, as if the server process died
+func (s *fakeServer) drop() {
+	s.mu.Lock()
+	transport := s.transport
+	s.mu.Unlock()
+	transport.Close()
+}
+
+func newTestClient(server *fakeServer) *Client {
+	return NewClient(ClientConfig{
+		Name:           "fake",
+		Transport:      server.connect,
+		RequestTimeout: 2 * time.Second,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+}
+
+func TestClient_ImportTools(t *testing.T) {
+	server := newFakeServer()
+	client := newTestClient(server)
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if client.ServerInfo().Name != "fake" {
+		t.Errorf("Expected server info, got %+v", client.ServerInfo())
+	}
+
+	// Both pages are loaded
+	tools, err := client.Tools(ctx)
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("Expected 2 tools, got %d", len(tools))
+	}
+
+	echo := tools[0]
+	if echo.Name != "fake_echo" {
+		t.Errorf("Expected namespaced name fake_echo, got %s", echo.Name)
+	}
+	if _, ok := echo.Parameters["$schema"]; ok {
+		t.Error("Expected $schema to be removed")
+	}
+	if required, ok := echo.Parameters["required"].([]string); !ok || len(required) != 1 || required[0] != "text" {
+		t.Errorf("Expected required=[text], got %#v", echo.Parameters["required"])
+	}
+	if _, ok := tools[1].Parameters["properties"].(map[string]interface{}); !ok {
+		t.Error("Expected empty properties map for schema without properties")
+	}
+
+	result, err := echo.ContextHandler(ctx, `{"text": "hi"}`)
+	if err != nil || result != "echo: hi" {
+		t.Errorf("Expected echo result, got %q, %v", result, err)
+	}
+
+	// Tool errors are returned to the model as text
+	result, err = tools[1].ContextHandler(ctx, `{}`)
+	if err != nil || result != "Error: boom" {
+		t.Errorf("Expected tool error text, got %q, %v", result, err)
+	}
+
+	// Protocol errors are Go errors
+	_, err = client.CallTool(ctx, "missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("Expected RPC error, got %v", err)
+	}
+
+	if err := client.Ping(ctx); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+}
+
+func TestClient_Namespacing(t *testing.T) {
+	tests := []struct {
+		config ClientConfig
+		want   string
+	}{
+		{ClientConfig{Name: "github"}, "github_echo"},
+		{ClientConfig{Name: "my server.v2"}, "my_server_v2_echo"},
+		{ClientConfig{Name: "github", Namespace: "gh"}, "gh_echo"},
+		{ClientConfig{Name: "github", DisableNamespace: true}, "echo"},
+	}
+
+	for _, tt := range tests {
+		if got := NewClient(tt.config).ToolName("echo"); got != tt.want {
+			t.Errorf("ToolName with %+v = %s, want %s", tt.config, got, tt.want)
+		}
+	}
+}
+
+func TestClient_ToolListChanged(t *testing.T) {
+	server := newFakeServer()
+	client := newTestClient(server)
+	defer client.Close()
+	ctx := context.Background()
+
+	changed := make(chan []*agent.Tool, 1)
+	client.OnToolsChanged(func(tools []*agent.Tool) {
+		changed <- tools
+	})
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	server.setTools([]ToolInfo{{Name: "search", InputSchema: map[string]interface{}{"type": "object"}}})
+
+	select {
+	case tools := <-changed:
+		if len(tools) != 1 || tools[0].Name != "fake_search" {
+			t.Fatalf("Expected new tool list, got %d tools", len(tools))
+		}
+		result, err := tools[0].ContextHandler(ctx, "")
+		if err != nil || result != "search called" {
+			t.Errorf("Expected new tool to be callable, got %q, %v", result, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnToolsChanged was not called")
+	}
+
+	infos, _ := client.ListTools(ctx)
+	if len(infos) != 1 {
+		t.Errorf("Expected cached tool list to be updated, got %d", len(infos))
+	}
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	server := newFakeServer()
+	client := newTestClient(server)
+	defer client.Close()
+	ctx := context.Background()
+
+	tools, err := client.Tools(ctx)
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+
+	server.drop()
+	deadline := time.Now().Add(time.Second)
+	for client.Connected() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.Connected() {
+		t.Fatal("Expected client to notice the lost connection")
+	}
+
+	// Existing proxies keep working on the new connection
+	result, err := tools[0].ContextHandler(ctx, `{"text": "again"}`)
+	if err != nil || result != "echo: again" {
+		t.Fatalf("Expected call after reconnect to succeed, got %q, %v", result, err)
+	}
+	if server.sessions != 2 {
+		t.Errorf("Expected 2 sessions, got %d", server.sessions)
+	}
+
+	// Closed clients don't reconnect
+	client.Close()
+	if _, err := client.CallTool(ctx, "echo", nil); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Expected ErrClientClosed, got %v", err)
+	}
+}
+
+func TestClient_ReconnectDisabled(t *testing.T) {
+	server := newFakeServer()
+	client := NewClient(ClientConfig{Name: "fake", Transport: server.connect, MaxReconnects: -1})
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	server.drop()
+	for client.Connected() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := client.CallTool(ctx, "echo", nil); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestClient_ConnectErrors(t *testing.T) {
+	ctx := context.Background()
+
+	if err := NewClient(ClientConfig{Name: "none"}).Connect(ctx); err == nil {
+		t.Error("Expected error without transport configuration")
+	}
+
+	server := newFakeServer()
+	old := NewClient(ClientConfig{
+		Name: "old",
+		Transport: func() (Transport, error) {
+			clientRead, serverWrite := io.Pipe()
+			serverRead, clientWrite := io.Pipe()
+			peer := NewStreamTransport(serverRead, serverWrite)
+			peer.Start(ctx, func(msg *Message) {
+				if msg.Method == methodInitialize {
+					resp, _ := newResponse(msg.ID, initializeResult{ProtocolVersion: "1999-01-01"})
+					go peer.Send(ctx, resp)
+				} else if resp := server.handle(msg); resp != nil {
+					go peer.Send(ctx, resp)
+				}
+			})
+			return NewStreamTransport(clientRead, clientWrite), nil
+		},
+	})
+	if err := old.Connect(ctx); err == nil || !strings.Contains(err.Error(), "unsupported protocol version") {
+		t.Errorf("Expected protocol version error, got %v", err)
+	}
+}
+
+// httpFakeServer serves a fakeServer over streamable HTTP
+type httpFakeServer struct {
+	*fakeServer
+	notify   chan *Message
+	sessions sync.Map
+	sseCalls atomic.Int32
+}
+
+func (h *httpFakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if _, ok := h.sessions.Load(r.Header.Get(headerSessionID)); !ok {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case msg := <-h.notify:
+				data, _ := json.Marshal(msg)
+				fmt.Fprintf(w, "data: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+	case http.MethodPost:
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if msg.Method == methodInitialize {
+			sessionID := fmt.Sprintf("session-%d", time.Now().UnixNano())
+			h.sessions.Store(sessionID, true)
+			w.Header().Set(headerSessionID, sessionID)
+		} else {
+			if _, ok := h.sessions.Load(r.Header.Get(headerSessionID)); !ok {
+				http.Error(w, "unknown session", http.StatusNotFound)
+				return
+			}
+			if r.Header.Get(headerProtocolVersion) != ProtocolVersion {
+				http.Error(w, "missing protocol version", http.StatusBadRequest)
+				return
+			}
+		}
+
+		resp := h.handle(&msg)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		// Tool calls answer with an event stream, other requests with JSON
+		data, _ := json.Marshal(resp)
+		if msg.Method == methodToolsCall {
+			h.sseCalls.Add(1)
+			w.Header().Set("Content-Type", "text/event-stream")
+			progress, _ := newNotification(notificationProgress, progressParams{Progress: 1})
+			progressData, _ := json.Marshal(progress)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\ndata: %s\n\n", progressData, data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+
+	case http.MethodDelete:
+		h.sessions.Delete(r.Header.Get(headerSessionID))
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestClient_HTTP(t *testing.T) {
+	fake := &httpFakeServer{fakeServer: newFakeServer(), notify: make(chan *Message, 1)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		Name:           "remote",
+		URL:            server.URL,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	defer client.Close()
+	ctx := context.Background()
+
+	changed := make(chan []*agent.Tool, 1)
+	client.OnToolsChanged(func(tools []*agent.Tool) { changed <- tools })
+
+	tools, err := client.Tools(ctx)
+	if err != nil {
+		t.Fatalf("Tools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "remote_echo" {
+		t.Fatalf("Unexpected tools: %d", len(tools))
+	}
+
+	result, err := tools[0].ContextHandler(ctx, `{"text": "over http"}`)
+	if err != nil || result != "echo: over http" {
+		t.Fatalf("Expected SSE tool result, got %q, %v", result, err)
+	}
+	if fake.sseCalls.Load() != 1 {
+		t.Errorf("Expected tool call over SSE")
+	}
+
+	// Notifications arrive on the GET stream
+	fake.mu.Lock()
+	fake.tools = fake.tools[:1]
+	fake.mu.Unlock()
+	notification, _ := newNotification(notificationToolsListChanged, nil)
+	fake.notify <- notification
+
+	select {
+	case tools := <-changed:
+		if len(tools) != 1 {
+			t.Errorf("Expected 1 tool after change, got %d", len(tools))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Tool list change notification not received")
+	}
+
+	// An expired session is re-established transparently
+	fake.sessions.Range(func(key, value interface{}) bool {
+		fake.sessions.Delete(key)
+		return true
+	})
+	result, err = tools[0].ContextHandler(ctx, `{"text": "new session"}`)
+	if err != nil || result != "echo: new session" {
+		t.Fatalf("Expected call to succeed on a new session, got %q, %v", result, err)
+	}
+}
+
+// toolCallingAdapter asks for one tool call, then answers with the tool result
+type toolCallingAdapter struct {
+	toolName string
+	tools    []string
+}
+
+func (a *toolCallingAdapter) Complete(ctx context.Context, req *agent.CompletionRequest) (*agent.CompletionResponse, error) {
+	a.tools = a.tools[:0]
+	for _, tool := range req.Tools {
+		a.tools = append(a.tools, tool.Name)
+	}
+
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role == "tool" {
+		return &agent.CompletionResponse{Content: "Tool said: " + last.Content}, nil
+	}
+	return &agent.CompletionResponse{ToolCalls: []agent.ToolCall{
+		{ID: "call_1", Type: "function", Name: a.toolName, Arguments: `{"text": "from agent"}`},
+	}}, nil
+}
+
+func (a *toolCallingAdapter) Stream(ctx context.Context, req *agent.CompletionRequest, onChunk func(string)) (*agent.CompletionResponse, error) {
+	return a.Complete(ctx, req)
+}
+
+func TestWithTools(t *testing.T) {
+	server := newFakeServer()
+	client := newTestClient(server)
+	defer client.Close()
+	ctx := context.Background()
+
+	adapter := &toolCallingAdapter{toolName: "fake_echo"}
+	builder, err := WithTools(ctx, agent.NewWithAdapter("test-model", adapter), client)
+	if err != nil {
+		t.Fatalf("WithTools failed: %v", err)
+	}
+
+	answer, err := builder.WithAutoExecute(true).Ask(ctx, "Echo something")
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if answer != "Tool said: echo: from agent" {
+		t.Errorf("Unexpected answer: %s", answer)
+	}
+	if len(adapter.tools) != 2 {
+		t.Errorf("Expected 2 tools sent to the model, got %v", adapter.tools)
+	}
+
+	unreachable := NewClient(ClientConfig{Name: "down", URL: "http://127.0.0.1:1"})
+	if _, err := WithTools(ctx, agent.NewWithAdapter("test-model", adapter), unreachable); err == nil {
+		t.Error("Expected error for unreachable server")
+	}
+}
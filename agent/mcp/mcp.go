@@ -0,0 +1,296 @@
+// Package mcp connects go-deep-agent to the Model Context Protocol (MCP).
+//
+// The Client imports tools from external MCP servers over stdio or streamable
+// HTTP and turns them into agent.Tool proxies that can be registered on a Builder:
+//
+//	client := mcp.NewClient(mcp.ClientConfig{
+//	    Name:    "github",
+//	    Command: "github-mcp-server",
+//	    Args:    []string{"stdio"},
+//	})
+//	defer client.Close()
+//
+//	builder, err := mcp.WithTools(ctx, agent.NewOpenAI("gpt-4o", apiKey), client)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	builder.WithAutoExecute(true).Ask(ctx, "List my open issues")
+//
+// Tools are namespaced with the server name ("github_list_issues") so that
+// several servers can be used by the same agent without name collisions.
+//
+// The Server goes the other way and lets other agent hosts call a Builder.
+// Its tools are exposed directly, next to an "ask" tool running the agent:
+//
+//	server := mcp.NewServer(builder, mcp.ServerConfig{Name: "researcher"})
+//	server.ServeStdio(ctx)                // or: http.Handle("/mcp", server)
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Version information
+const (
+	// ProtocolVersion is the latest MCP protocol version implemented by this package
+	ProtocolVersion = "2025-06-18"
+
+	// PackageVersion is reported as the implementation version in handshakes
+	PackageVersion = "1.0.0"
+)
+
+// supportedProtocolVersions lists the protocol versions this package can speak, newest first
+var supportedProtocolVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC method names
+const (
+	methodInitialize             = "initialize"
+	methodPing                   = "ping"
+	methodToolsList              = "tools/list"
+	methodToolsCall              = "tools/call"
+	notificationInitialized      = "notifications/initialized"
+	notificationToolsListChanged = "notifications/tools/list_changed"
+	notificationProgress         = "notifications/progress"
+	notificationCancelled        = "notifications/cancelled"
+)
+
+// JSON-RPC error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Common errors
+var (
+	ErrNotConnected     = errors.New("mcp: not connected")
+	ErrConnectionClosed = errors.New("mcp: connection closed")
+	ErrSessionExpired   = errors.New("mcp: session expired")
+	ErrClientClosed     = errors.New("mcp: client closed")
+)
+
+// Message is a JSON-RPC 2.0 message: a request, a response or a notification.
+// IDs are kept raw because MCP allows both string and number IDs.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// IsRequest reports whether the message is a request (has a method and an ID)
+func (m *Message) IsRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+// IsNotification reports whether the message is a notification (method without ID)
+func (m *Message) IsNotification() bool {
+	return m.Method != "" && len(m.ID) == 0
+}
+
+// IsResponse reports whether the message is a response to a request
+func (m *Message) IsResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// newRequest creates a request message
+func newRequest(id json.RawMessage, method string, params interface{}) (*Message, error) {
+	msg := &Message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s params: %w", method, err)
+		}
+		msg.Params = data
+	}
+	return msg, nil
+}
+
+// newNotification creates a notification message
+func newNotification(method string, params interface{}) (*Message, error) {
+	return newRequest(nil, method, params)
+}
+
+// newResponse creates a successful response message
+func newResponse(id json.RawMessage, result interface{}) (*Message, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return &Message{JSONRPC: "2.0", ID: id, Result: data}, nil
+}
+
+// newErrorResponse creates an error response message
+func newErrorResponse(id json.RawMessage, code int, message string) *Message {
+	return &Message{JSONRPC: "2.0", ID: id, Error: &RPCError{Code: code, Message: message}}
+}
+
+// RPCError is a JSON-RPC error returned by the other side
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp: rpc error %d: %s", e.Code, e.Message)
+}
+
+// Implementation describes an MCP client or server
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ServerCapabilities are the capabilities announced by a server
+type ServerCapabilities struct {
+	Tools     *ToolsCapability       `json:"tools,omitempty"`
+	Logging   map[string]interface{} `json:"logging,omitempty"`
+	Prompts   map[string]interface{} `json:"prompts,omitempty"`
+	Resources map[string]interface{} `json:"resources,omitempty"`
+}
+
+// ToolsCapability announces that a server offers tools
+type ToolsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// initializeParams is sent by the client to start a session
+type initializeParams struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ClientInfo      Implementation         `json:"clientInfo"`
+}
+
+// initializeResult is returned by the server when a session starts
+type initializeResult struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ServerCapabilities `json:"capabilities"`
+	ServerInfo      Implementation     `json:"serverInfo"`
+	Instructions    string             `json:"instructions,omitempty"`
+}
+
+// ToolInfo describes a tool offered by a server
+type ToolInfo struct {
+	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// listToolsParams requests a page of tools
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// listToolsResult is a page of tools
+type listToolsResult struct {
+	Tools      []ToolInfo `json:"tools"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// callToolParams invokes a tool
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Meta      *requestMeta    `json:"_meta,omitempty"`
+}
+
+// requestMeta carries the progress token of a request
+type requestMeta struct {
+	ProgressToken json.RawMessage `json:"progressToken,omitempty"`
+}
+
+// CallToolResult is the result of a tool invocation.
+// IsError marks tool-level failures that should be shown to the model.
+type CallToolResult struct {
+	Content           []Content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
+}
+
+// Content is an item of tool output
+type Content struct {
+	Type     string    `json:"type"` // text, image, audio, resource, resource_link
+	Text     string    `json:"text,omitempty"`
+	Data     string    `json:"data,omitempty"`     // base64 for image and audio
+	MimeType string    `json:"mimeType,omitempty"` // for image, audio and resource_link
+	URI      string    `json:"uri,omitempty"`      // for resource_link
+	Name     string    `json:"name,omitempty"`     // for resource_link
+	Resource *Resource `json:"resource,omitempty"` // for resource
+}
+
+// Resource is an embedded resource in tool output
+type Resource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// TextContent creates a text content item
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
+
+// Text flattens the result content into a single string for the model.
+// Binary content is replaced by a short placeholder.
+func (r *CallToolResult) Text() string {
+	parts := make([]string, 0, len(r.Content))
+	for _, c := range r.Content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "resource":
+			if c.Resource == nil {
+				continue
+			}
+			if c.Resource.Text != "" {
+				parts = append(parts, c.Resource.Text)
+			} else {
+				parts = append(parts, fmt.Sprintf("[resource: %s]", c.Resource.URI))
+			}
+		case "resource_link":
+			parts = append(parts, fmt.Sprintf("[resource: %s]", c.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s: %s]", c.Type, c.MimeType))
+		}
+	}
+
+	if len(parts) == 0 && r.StructuredContent != nil {
+		if data, err := json.Marshal(r.StructuredContent); err == nil {
+			return string(data)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// progressParams is the payload of a progress notification
+type progressParams struct {
+	ProgressToken json.RawMessage `json:"progressToken"`
+	Progress      float64         `json:"progress"`
+	Total         float64         `json:"total,omitempty"`
+	Message       string          `json:"message,omitempty"`
+}
+
+// Progress is a progress update for a long-running request.
+// Total is zero when the amount of work is unknown.
+type Progress struct {
+	Progress float64
+	Total    float64
+	Message  string
+}
+
+// cancelledParams is the payload of a cancellation notification
+type cancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
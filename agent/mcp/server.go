@@ -0,0 +1,485 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taipm/go-deep-agent/agent"
+)
+
+// Default server settings
+const (
+	DefaultServerName         = "go-deep-agent"
+	DefaultAskToolName        = "ask"
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	DefaultMaxSessions        = 1000
+)
+
+// defaultAskToolDescription describes the ask tool to the calling model
+const defaultAskToolDescription = "Ask the agent to complete a task. The agent reasons step by step, " +
+	"uses its own tools as needed and returns its final answer."
+
+// ServerConfig configures an MCP server wrapping a Builder
+type ServerConfig struct {
+	// Name and Version are reported to clients (default: "go-deep-agent", PackageVersion)
+	Name    string
+	Version string
+
+	// Instructions are sent to clients on initialize, as a hint for their model
+	Instructions string
+
+	// AskToolName and AskToolDescription describe the tool running the agent itself
+	// (default: "ask")
+	AskToolName        string
+	AskToolDescription string
+
+	// DisableAskTool exposes only the builder's tools
+	DisableAskTool bool
+
+	// DisableTools exposes only the ask tool, keeping the builder's tools private
+	DisableTools bool
+
+	// NewBuilder creates the builder running the asks of each session, so
+	// that clients don't share conversation history (default: a Clone of
+	// the server's builder)
+	NewBuilder func() *agent.Builder
+
+	// SessionIdleTimeout ends HTTP sessions without requests for this long
+	// (default: 30 minutes)
+	SessionIdleTimeout time.Duration
+
+	// MaxSessions caps the HTTP sessions; the least recently used session
+	// is ended to make room for a new one (default: 1000)
+	MaxSessions int
+
+	// Logger receives request events (default: no logging).
+	// With ServeStdio, the logger must not write to stdout.
+	Logger agent.Logger
+}
+
+// Server exposes a Builder to other agent hosts over MCP.
+//
+// Every tool registered on the builder is offered directly, and the agent
+// itself is offered as an "ask" tool taking a task. When the client requests
+// progress and ReAct is enabled on the builder, the task runs through
+// StreamReAct and every ReActStreamEvent is forwarded as a progress
+// notification. Otherwise the task runs through Execute.
+//
+// Each session asks its own builder, so conversations don't leak between
+// clients; asks of one session run one at a time. Tool calls run
+// concurrently, with the builder's tool timeout.
+type Server struct {
+	builder *agent.Builder
+	config  ServerConfig
+	logger  agent.Logger
+
+	sessionsMu sync.Mutex
+	sessions   map[string]*serverSession // HTTP sessions by ID
+}
+
+// serverSession holds the builder of one connection and tracks its requests in flight
+type serverSession struct {
+	builder *agent.Builder
+	askMu   sync.Mutex // Builder is not safe for concurrent asks
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+	lastUsed time.Time
+}
+
+func newServerSession(builder *agent.Builder) *serverSession {
+	return &serverSession{
+		builder:  builder,
+		inflight: make(map[string]context.CancelFunc),
+		lastUsed: time.Now(),
+	}
+}
+
+// begin registers a cancellable request
+func (s *serverSession) begin(ctx context.Context, id json.RawMessage) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	s.inflight[string(id)] = cancel
+	s.lastUsed = time.Now()
+	s.mu.Unlock()
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inflight, string(id))
+		s.lastUsed = time.Now()
+		s.mu.Unlock()
+		cancel()
+	}
+}
+
+// touch records activity on the session
+func (s *serverSession) touch() {
+	s.mu.Lock()
+	s.lastUsed = time.Now()
+	s.mu.Unlock()
+}
+
+// idleSince returns when the session was last used, or the zero time while
+// requests are in flight
+func (s *serverSession) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.inflight) > 0 {
+		return time.Time{}
+	}
+	return s.lastUsed
+}
+
+// cancel stops a request in flight, if any
+func (s *serverSession) cancel(id json.RawMessage) {
+	s.mu.Lock()
+	cancel := s.inflight[string(id)]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// cancelAll stops every request in flight
+func (s *serverSession) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.inflight {
+		cancel()
+	}
+}
+
+// NewServer creates a server for the given builder
+func NewServer(builder *agent.Builder, config ServerConfig) *Server {
+	if config.Name == "" {
+		config.Name = DefaultServerName
+	}
+	if config.Version == "" {
+		config.Version = PackageVersion
+	}
+	if config.AskToolName == "" {
+		config.AskToolName = DefaultAskToolName
+	}
+	if config.AskToolDescription == "" {
+		config.AskToolDescription = defaultAskToolDescription
+	}
+	if config.NewBuilder == nil {
+		config.NewBuilder = builder.Clone
+	}
+	if config.SessionIdleTimeout <= 0 {
+		config.SessionIdleTimeout = DefaultSessionIdleTimeout
+	}
+	if config.MaxSessions <= 0 {
+		config.MaxSessions = DefaultMaxSessions
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = &agent.NoopLogger{}
+	}
+
+	return &Server{
+		builder:  builder,
+		config:   config,
+		logger:   logger,
+		sessions: make(map[string]*serverSession),
+	}
+}
+
+// ServeStdio serves a single client over the process's stdin and stdout
+// until stdin is closed or ctx is cancelled.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	return s.ServeTransport(ctx, NewStreamTransport(os.Stdin, os.Stdout))
+}
+
+// ServeTransport serves a single client over a transport until the
+// transport is closed or ctx is cancelled. Requests are handled concurrently.
+func (s *Server) ServeTransport(ctx context.Context, transport Transport) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session := newServerSession(s.config.NewBuilder())
+	var wg sync.WaitGroup
+	notify := func(msg *Message) {
+		if err := transport.Send(ctx, msg); err != nil {
+			s.logger.Debug(ctx, "MCP server failed to send message", agent.F("error", err.Error()))
+		}
+	}
+
+	err := transport.Start(ctx, func(msg *Message) {
+		switch {
+		case msg.IsRequest():
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				notify(s.handleRequest(ctx, session, msg, notify))
+			}()
+		case msg.IsNotification():
+			s.handleNotification(session, msg)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start transport: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-transport.Done():
+	}
+
+	cancel()
+	transport.Close()
+	wg.Wait()
+	return err
+}
+
+// handleNotification processes a notification from the client
+func (s *Server) handleNotification(session *serverSession, msg *Message) {
+	if msg.Method != notificationCancelled {
+		return
+	}
+	var params cancelledParams
+	if err := json.Unmarshal(msg.Params, &params); err == nil {
+		session.cancel(params.RequestID)
+	}
+}
+
+// handleRequest answers a request. notify sends progress notifications
+// to the client while the request runs.
+func (s *Server) handleRequest(ctx context.Context, session *serverSession, msg *Message, notify func(*Message)) *Message {
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch msg.Method {
+	case methodInitialize:
+		result, err = s.initialize(msg.Params)
+	case methodPing:
+		result = struct{}{}
+	case methodToolsList:
+		result = listToolsResult{Tools: s.listTools()}
+	case methodToolsCall:
+		ctx, done := session.begin(ctx, msg.ID)
+		result, err = s.callTool(ctx, session, msg.Params, notify)
+		done()
+	default:
+		return newErrorResponse(msg.ID, CodeMethodNotFound, "method not found: "+msg.Method)
+	}
+
+	if err != nil {
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			return &Message{JSONRPC: "2.0", ID: msg.ID, Error: rpcErr}
+		}
+		return newErrorResponse(msg.ID, CodeInternalError, err.Error())
+	}
+
+	resp, err := newResponse(msg.ID, result)
+	if err != nil {
+		return newErrorResponse(msg.ID, CodeInternalError, err.Error())
+	}
+	return resp
+}
+
+// initialize negotiates the protocol version and announces the server
+func (s *Server) initialize(raw json.RawMessage) (*initializeResult, error) {
+	var params initializeParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid initialize params: " + err.Error()}
+	}
+
+	// Answer with the client's version when we speak it, else with our latest
+	version := ProtocolVersion
+	if isSupportedVersion(params.ProtocolVersion) {
+		version = params.ProtocolVersion
+	}
+
+	return &initializeResult{
+		ProtocolVersion: version,
+		Capabilities:    ServerCapabilities{Tools: &ToolsCapability{}},
+		ServerInfo:      Implementation{Name: s.config.Name, Version: s.config.Version},
+		Instructions:    s.config.Instructions,
+	}, nil
+}
+
+// listTools describes the exposed tools
+func (s *Server) listTools() []ToolInfo {
+	tools := []ToolInfo{}
+	if !s.config.DisableAskTool {
+		tools = append(tools, ToolInfo{
+			Name:        s.config.AskToolName,
+			Description: s.config.AskToolDescription,
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"task": map[string]interface{}{
+						"type":        "string",
+						"description": "The task or question for the agent",
+					},
+				},
+				"required": []string{"task"},
+			},
+		})
+	}
+	for _, tool := range s.agentTools() {
+		schema := tool.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		tools = append(tools, ToolInfo{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	return tools
+}
+
+// agentTools returns the builder's tools that can be called
+func (s *Server) agentTools() []*agent.Tool {
+	if s.config.DisableTools {
+		return nil
+	}
+	var tools []*agent.Tool
+	for _, tool := range s.builder.GetTools() {
+		if tool.ContextHandler != nil || tool.Handler != nil {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// callTool runs a tool. Tool failures are reported in the result with
+// IsError so that the calling model can see them; unknown tools are
+// protocol errors.
+func (s *Server) callTool(ctx context.Context, session *serverSession, raw json.RawMessage, notify func(*Message)) (*CallToolResult, error) {
+	var params callToolParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "invalid tools/call params: " + err.Error()}
+	}
+
+	arguments := string(params.Arguments)
+	if strings.TrimSpace(arguments) == "" || arguments == "null" {
+		arguments = "{}"
+	}
+
+	var (
+		output string
+		err    error
+	)
+	if !s.config.DisableAskTool && params.Name == s.config.AskToolName {
+		var onProgress func(agent.ReActStreamEvent)
+		if params.Meta != nil && len(params.Meta.ProgressToken) > 0 {
+			onProgress = s.progressNotifier(ctx, params.Meta.ProgressToken, notify)
+		}
+		output, err = s.ask(ctx, session, arguments, onProgress)
+	} else {
+		if s.findTool(params.Name) == nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + params.Name}
+		}
+		s.logger.Debug(ctx, "MCP tool call", agent.F("tool", params.Name))
+		output, err = s.builder.CallTool(ctx, params.Name, arguments)
+	}
+
+	if err != nil {
+		s.logger.Warn(ctx, "MCP tool call failed", agent.F("tool", params.Name), agent.F("error", err.Error()))
+		return &CallToolResult{Content: []Content{TextContent(err.Error())}, IsError: true}, nil
+	}
+	return &CallToolResult{Content: []Content{TextContent(output)}}, nil
+}
+
+// findTool looks up an exposed builder tool by name
+func (s *Server) findTool(name string) *agent.Tool {
+	for _, tool := range s.agentTools() {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	return nil
+}
+
+// progressNotifier forwards ReAct events as progress notifications
+func (s *Server) progressNotifier(ctx context.Context, token json.RawMessage, notify func(*Message)) func(agent.ReActStreamEvent) {
+	var progress float64
+	return func(event agent.ReActStreamEvent) {
+		progress++
+		msg, err := newNotification(notificationProgress, progressParams{
+			ProgressToken: token,
+			Progress:      progress,
+			Message:       fmt.Sprintf("%s: %s", event.Type, event.Content),
+		})
+		if err != nil {
+			return
+		}
+		notify(msg)
+	}
+}
+
+// ask runs a task through the session's agent and returns its final answer
+func (s *Server) ask(ctx context.Context, session *serverSession, arguments string, onProgress func(agent.ReActStreamEvent)) (string, error) {
+	var args struct {
+		Task string `json:"task"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Task) == "" {
+		return "", fmt.Errorf("task is required")
+	}
+
+	session.askMu.Lock()
+	defer session.askMu.Unlock()
+
+	s.logger.Debug(ctx, "MCP ask", agent.F("task_length", len(args.Task)), agent.F("streaming", onProgress != nil))
+
+	if onProgress != nil {
+		// StreamReAct fails when ReAct is disabled; Execute then falls back to Ask
+		if events, err := session.builder.StreamReAct(ctx, args.Task); err == nil {
+			return streamAnswer(ctx, events, onProgress)
+		}
+	}
+
+	result, err := session.builder.Execute(ctx, args.Task)
+	if err != nil {
+		return "", err
+	}
+	return result.Answer, nil
+}
+
+// streamAnswer forwards events until the stream ends and returns the final answer.
+// Error events for failed tool calls don't end the stream, so the last one is
+// only reported when no answer was produced.
+func streamAnswer(ctx context.Context, events <-chan agent.ReActStreamEvent, onProgress func(agent.ReActStreamEvent)) (string, error) {
+	var (
+		answer  string
+		final   bool
+		lastErr error
+	)
+	for event := range events {
+		onProgress(event)
+		switch event.Type {
+		case "final":
+			answer, final = event.Content, true
+		case "error":
+			lastErr = event.Error
+		}
+	}
+
+	switch {
+	case final:
+		return answer, nil
+	case ctx.Err() != nil:
+		return "", ctx.Err()
+	case lastErr != nil:
+		return "", lastErr
+	default:
+		return "", fmt.Errorf("agent finished without an answer")
+	}
+}
@@ -0,0 +1,220 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taipm/go-deep-agent/agent"
+)
+
+// ServeHTTP implements the MCP streamable HTTP transport, so that a Server
+// can be mounted on any mux:
+//
+//	http.Handle("/mcp", mcp.NewServer(builder, mcp.ServerConfig{}))
+//
+// A session is created on initialize and identified by the Mcp-Session-Id
+// header. Responses are plain JSON, except for tool calls asking for
+// progress, which are answered with an event stream carrying the progress
+// notifications followed by the response. The server sends no unsolicited
+// messages, so GET streams are refused with 405. Sessions idle for longer
+// than SessionIdleTimeout, or beyond MaxSessions, are ended.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodDelete:
+		s.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost handles the messages of one POST
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	msgs := decodeMessages(body)
+	if len(msgs) == 0 {
+		writeJSON(w, http.StatusBadRequest, newErrorResponse(nil, CodeParseError, "invalid JSON-RPC message"))
+		return
+	}
+
+	var requests []*Message
+	initialize, wantsProgress := false, false
+	for _, msg := range msgs {
+		if !msg.IsRequest() {
+			continue
+		}
+		requests = append(requests, msg)
+		switch msg.Method {
+		case methodInitialize:
+			initialize = true
+		case methodToolsCall:
+			var params callToolParams
+			if json.Unmarshal(msg.Params, &params) == nil && params.Meta != nil && len(params.Meta.ProgressToken) > 0 {
+				wantsProgress = true
+			}
+		}
+	}
+
+	sessionID := r.Header.Get(headerSessionID)
+	var session *serverSession
+	switch {
+	case initialize:
+		sessionID = newSessionID()
+		session = newServerSession(s.config.NewBuilder())
+		s.addSession(sessionID, session)
+	case sessionID == "":
+		http.Error(w, "missing "+headerSessionID+" header", http.StatusBadRequest)
+		return
+	default:
+		s.sessionsMu.Lock()
+		session = s.sessions[sessionID]
+		s.sessionsMu.Unlock()
+		if session == nil {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		session.touch()
+	}
+	w.Header().Set(headerSessionID, sessionID)
+
+	for _, msg := range msgs {
+		if msg.IsNotification() {
+			s.handleNotification(session, msg)
+		}
+	}
+	if len(requests) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if wantsProgress && strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.streamResponses(w, r, session, requests)
+		return
+	}
+
+	discard := func(*Message) {}
+	responses := make([]*Message, len(requests))
+	for i, msg := range requests {
+		responses[i] = s.handleRequest(r.Context(), session, msg, discard)
+	}
+	if len(responses) == 1 {
+		writeJSON(w, http.StatusOK, responses[0])
+	} else {
+		writeJSON(w, http.StatusOK, responses)
+	}
+}
+
+// streamResponses answers requests with an event stream, sending progress
+// notifications as they happen and each response when it is ready
+func (s *Server) streamResponses(w http.ResponseWriter, r *http.Request, session *serverSession, requests []*Message) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	var mu sync.Mutex
+	send := func(msg *Message) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, msg := range requests {
+		wg.Add(1)
+		go func(msg *Message) {
+			defer wg.Done()
+			send(s.handleRequest(r.Context(), session, msg, send))
+		}(msg)
+	}
+	wg.Wait()
+}
+
+// handleDelete ends a session and cancels its requests
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(headerSessionID)
+	s.sessionsMu.Lock()
+	session := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.sessionsMu.Unlock()
+
+	if session == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	session.cancelAll()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// addSession registers a session, first ending idle sessions and, at
+// MaxSessions, the least recently used one
+func (s *Server) addSession(id string, session *serverSession) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	var (
+		oldestID string
+		oldest   time.Time
+	)
+	cutoff := time.Now().Add(-s.config.SessionIdleTimeout)
+	for sessionID, existing := range s.sessions {
+		idle := existing.idleSince()
+		if idle.IsZero() {
+			continue // Requests in flight
+		}
+		if idle.Before(cutoff) {
+			s.endSession(sessionID, existing)
+			continue
+		}
+		if oldestID == "" || idle.Before(oldest) {
+			oldestID, oldest = sessionID, idle
+		}
+	}
+	if len(s.sessions) >= s.config.MaxSessions && oldestID != "" {
+		s.endSession(oldestID, s.sessions[oldestID])
+	}
+
+	s.sessions[id] = session
+}
+
+// endSession removes a session and cancels its requests; sessionsMu must be held
+func (s *Server) endSession(id string, session *serverSession) {
+	delete(s.sessions, id)
+	session.cancelAll()
+	s.logger.Debug(context.Background(), "MCP session ended", agent.F("active_sessions", len(s.sessions)))
+}
+
+// writeJSON writes a JSON response body
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// newSessionID returns a random session identifier
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
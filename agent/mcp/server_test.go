@@ -0,0 +1,433 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taipm/go-deep-agent/agent"
+)
+
+// serveBuilder serves a builder over in-process pipes and returns a connected client
+func serveBuilder(t *testing.T, builder *agent.Builder, config ServerConfig) *Client {
+	t.Helper()
+	return connectClient(t, NewServer(builder, config))
+}
+
+// connectClient connects a new client to server over in-process pipes
+func connectClient(t *testing.T, server *Server) *Client {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	client := NewClient(ClientConfig{
+		Name: "agent",
+		Transport: func() (Transport, error) {
+			clientRead, serverWrite := io.Pipe()
+			serverRead, clientWrite := io.Pipe()
+			go server.ServeTransport(ctx, NewStreamTransport(serverRead, serverWrite))
+			return NewStreamTransport(clientRead, clientWrite), nil
+		},
+		RequestTimeout: 5 * time.Second,
+		MaxReconnects:  -1,
+	})
+	t.Cleanup(func() { client.Close() })
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	return client
+}
+
+// echoTool returns its text argument
+func echoTool() *agent.Tool {
+	return agent.NewTool("echo", "Echo the text back").
+		AddParameter("text", "string", "Text to echo", true).
+		WithHandler(func(args string) (string, error) {
+			var params struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal([]byte(args), &params); err != nil {
+				return "", err
+			}
+			return "echo: " + params.Text, nil
+		})
+}
+
+// scriptedLLM is an OpenAI-compatible endpoint answering with canned replies in order
+func scriptedLLM(t *testing.T, replies ...string) *httptest.Server {
+	var mu sync.Mutex
+	next := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reply := "FINAL: out of replies"
+		if next < len(replies) {
+			reply = replies[next]
+			next++
+		}
+		mu.Unlock()
+
+		content, _ := json.Marshal(reply)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test",`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":%s},"finish_reason":"stop"}]}`, content)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// reactBuilder is a text-mode ReAct agent with the echo tool, backed by a scripted LLM
+func reactBuilder(t *testing.T) *agent.Builder {
+	llm := scriptedLLM(t,
+		"THOUGHT: I should echo the word",
+		`ACTION: echo(text="hello")`,
+		"FINAL: The tool said hello",
+	)
+	return agent.NewOllama("test").
+		WithBaseURL(llm.URL + "/v1/").
+		WithTool(echoTool()).
+		WithReActMode(true).
+		WithReActTextMode()
+}
+
+func TestServer_ExposesTools(t *testing.T) {
+	failing := agent.NewTool("fail", "Always fails").
+		WithHandler(func(args string) (string, error) { return "", errors.New("boom") })
+	builder := agent.NewWithAdapter("test", &toolCallingAdapter{}).WithTools(echoTool(), failing)
+
+	client := serveBuilder(t, builder, ServerConfig{Name: "my-agent"})
+	ctx := context.Background()
+
+	if info := client.ServerInfo(); info.Name != "my-agent" {
+		t.Errorf("ServerInfo().Name = %q, want my-agent", info.Name)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	if strings.Join(names, ",") != "ask,echo,fail" {
+		t.Errorf("tools = %v, want [ask echo fail]", names)
+	}
+	if tools[1].InputSchema["type"] != "object" {
+		t.Errorf("echo schema = %v, want object schema", tools[1].InputSchema)
+	}
+
+	result, err := client.CallTool(ctx, "echo", map[string]string{"text": "hi"})
+	if err != nil {
+		t.Fatalf("CallTool(echo) failed: %v", err)
+	}
+	if result.IsError || result.Text() != "echo: hi" {
+		t.Errorf("CallTool(echo) = %+v, want echo: hi", result)
+	}
+
+	result, err = client.CallTool(ctx, "fail", nil)
+	if err != nil {
+		t.Fatalf("CallTool(fail) failed: %v", err)
+	}
+	if !result.IsError || result.Text() != "boom" {
+		t.Errorf("CallTool(fail) = %+v, want error result boom", result)
+	}
+
+	_, err = client.CallTool(ctx, "missing", nil)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("CallTool(missing) error = %v, want invalid params", err)
+	}
+}
+
+func TestServer_ToolVisibility(t *testing.T) {
+	builder := agent.NewWithAdapter("test", &toolCallingAdapter{}).WithTool(echoTool())
+
+	client := serveBuilder(t, builder, ServerConfig{DisableTools: true, AskToolName: "run_agent"})
+	tools, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "run_agent" {
+		t.Errorf("tools = %+v, want only run_agent", tools)
+	}
+
+	client = serveBuilder(t, builder, ServerConfig{DisableAskTool: true})
+	tools, err = client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "echo" {
+		t.Errorf("tools = %+v, want only echo", tools)
+	}
+}
+
+func TestServer_AskWithoutReAct(t *testing.T) {
+	adapter := &toolCallingAdapter{toolName: "echo"}
+	builder := agent.NewWithAdapter("test", adapter).WithTool(echoTool()).WithAutoExecute(true)
+
+	client := serveBuilder(t, builder, ServerConfig{})
+	ctx := context.Background()
+
+	var progress []Progress
+	result, err := client.CallToolWithProgress(ctx, "ask", map[string]string{"task": "Echo something"},
+		func(p Progress) { progress = append(progress, p) })
+	if err != nil {
+		t.Fatalf("ask failed: %v", err)
+	}
+	if result.IsError || result.Text() != "Tool said: echo: from agent" {
+		t.Errorf("ask = %+v, want the agent answer", result)
+	}
+	if len(progress) != 0 {
+		t.Errorf("got %d progress notifications without ReAct, want 0", len(progress))
+	}
+
+	result, err = client.CallTool(ctx, "ask", map[string]string{})
+	if err != nil {
+		t.Fatalf("ask without task failed: %v", err)
+	}
+	if !result.IsError {
+		t.Errorf("ask without task = %+v, want error result", result)
+	}
+}
+
+func TestServer_AskStreamsProgress(t *testing.T) {
+	client := serveBuilder(t, reactBuilder(t), ServerConfig{})
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	result, err := client.CallToolWithProgress(context.Background(), "ask",
+		map[string]string{"task": "Say hello"}, func(p Progress) {
+			mu.Lock()
+			messages = append(messages, p.Message)
+			mu.Unlock()
+		})
+	if err != nil {
+		t.Fatalf("ask failed: %v", err)
+	}
+	if result.IsError || result.Text() != "The tool said hello" {
+		t.Errorf("ask = %+v, want the final answer", result)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	joined := strings.Join(messages, "\n")
+	for _, want := range []string{"start:", "thought: I should echo the word", "action:", "observation: echo: hello", "final: The tool said hello", "complete:"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("progress messages missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestServer_Cancellation(t *testing.T) {
+	cancelled := make(chan struct{})
+	slow := agent.NewTool("slow", "Waits until cancelled").
+		WithContextHandler(func(ctx context.Context, args string) (string, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return "", ctx.Err()
+		})
+	builder := agent.NewWithAdapter("test", &toolCallingAdapter{}).WithTool(slow)
+
+	client := serveBuilder(t, builder, ServerConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.CallTool(ctx, "slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CallTool error = %v, want deadline exceeded", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool context was not cancelled by notifications/cancelled")
+	}
+}
+
+// historyAdapter answers every request, recording the user messages it was sent
+type historyAdapter struct {
+	mu       sync.Mutex
+	requests [][]string
+}
+
+func (a *historyAdapter) Complete(ctx context.Context, req *agent.CompletionRequest) (*agent.CompletionResponse, error) {
+	var contents []string
+	for _, msg := range req.Messages {
+		if msg.Role == "user" {
+			contents = append(contents, msg.Content)
+		}
+	}
+	a.mu.Lock()
+	a.requests = append(a.requests, contents)
+	a.mu.Unlock()
+	return &agent.CompletionResponse{Content: "done"}, nil
+}
+
+func (a *historyAdapter) Stream(ctx context.Context, req *agent.CompletionRequest, onChunk func(string)) (*agent.CompletionResponse, error) {
+	return a.Complete(ctx, req)
+}
+
+func TestServer_SessionsDoNotShareHistory(t *testing.T) {
+	adapter := &historyAdapter{}
+	server := NewServer(agent.NewWithAdapter("test", adapter).WithShortMemory(), ServerConfig{})
+	ctx := context.Background()
+
+	first, second := connectClient(t, server), connectClient(t, server)
+	for _, call := range []struct {
+		client *Client
+		task   string
+	}{{first, "secret of the first client"}, {second, "question of the second client"}, {first, "follow-up"}} {
+		if _, err := call.client.CallTool(ctx, "ask", map[string]string{"task": call.task}); err != nil {
+			t.Fatalf("ask(%q) failed: %v", call.task, err)
+		}
+	}
+
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	if got := strings.Join(adapter.requests[1], "|"); got != "question of the second client" {
+		t.Errorf("second client sent %q, want only its own task", got)
+	}
+	if got := strings.Join(adapter.requests[2], "|"); got != "secret of the first client|follow-up" {
+		t.Errorf("first client sent %q, want its own history", got)
+	}
+}
+
+func TestServer_ToolTimeout(t *testing.T) {
+	slow := agent.NewTool("slow", "Waits until cancelled").
+		WithContextHandler(func(ctx context.Context, args string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+	builder := agent.NewWithAdapter("test", &toolCallingAdapter{}).WithTool(slow).WithToolTimeout(20 * time.Millisecond)
+
+	client := serveBuilder(t, builder, ServerConfig{})
+	result, err := client.CallTool(context.Background(), "slow", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Text(), "deadline exceeded") {
+		t.Errorf("CallTool(slow) = %+v, want a timeout error result", result)
+	}
+}
+
+// postMessage posts a JSON-RPC message in a session and returns the status
+// code and the session ID answered by the server
+func postMessage(t *testing.T, url, sessionID, body string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(headerSessionID, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get(headerSessionID)
+}
+
+func TestServer_HTTPSessionEviction(t *testing.T) {
+	const (
+		initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`
+		ping       = `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+	)
+	server := NewServer(agent.NewWithAdapter("test", &historyAdapter{}), ServerConfig{MaxSessions: 2, SessionIdleTimeout: time.Hour})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	var sessions []string
+	for i := 0; i < 3; i++ {
+		_, id := postMessage(t, httpServer.URL, "", initialize)
+		sessions = append(sessions, id)
+		time.Sleep(time.Millisecond)
+	}
+	if status, _ := postMessage(t, httpServer.URL, sessions[0], ping); status != http.StatusNotFound {
+		t.Errorf("least recently used session: ping = %d, want 404", status)
+	}
+	if status, _ := postMessage(t, httpServer.URL, sessions[2], ping); status != http.StatusOK {
+		t.Errorf("latest session: ping = %d, want 200", status)
+	}
+
+	// Idle sessions are ended when a new one starts
+	server.config.SessionIdleTimeout = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	postMessage(t, httpServer.URL, "", initialize)
+	if status, _ := postMessage(t, httpServer.URL, sessions[2], ping); status != http.StatusNotFound {
+		t.Errorf("idle session: ping = %d, want 404", status)
+	}
+}
+
+func TestServer_HTTP(t *testing.T) {
+	httpServer := httptest.NewServer(NewServer(reactBuilder(t), ServerConfig{}))
+	defer httpServer.Close()
+
+	client := NewClient(ClientConfig{
+		Name:           "agent",
+		URL:            httpServer.URL,
+		RequestTimeout: 5 * time.Second,
+	})
+	defer client.Close()
+	ctx := context.Background()
+
+	result, err := client.CallTool(ctx, "echo", map[string]string{"text": "over http"})
+	if err != nil {
+		t.Fatalf("CallTool(echo) failed: %v", err)
+	}
+	if result.Text() != "echo: over http" {
+		t.Errorf("CallTool(echo) = %q, want echo: over http", result.Text())
+	}
+
+	var (
+		mu    sync.Mutex
+		count int
+	)
+	result, err = client.CallToolWithProgress(ctx, "ask", map[string]string{"task": "Say hello"}, func(p Progress) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("ask failed: %v", err)
+	}
+	if result.Text() != "The tool said hello" {
+		t.Errorf("ask = %q, want the final answer", result.Text())
+	}
+	mu.Lock()
+	if count == 0 {
+		t.Error("no progress notifications received over the event stream")
+	}
+	mu.Unlock()
+
+	// Requests outside of a session are rejected
+	resp, err := http.Post(httpServer.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST without session = %d, want 400", resp.StatusCode)
+	}
+
+	// Unknown or expired sessions are rejected
+	req, _ := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	req.Header.Set(headerSessionID, "unknown")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST with unknown session = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Transport carries JSON-RPC messages between a client and a server.
+//
+// A Transport is single-use: once Done is closed it cannot be restarted.
+// The Client creates a new Transport for every (re)connection.
+type Transport interface {
+	// Start establishes the connection. Incoming messages are passed to handle,
+	// which must not block for long.
+	Start(ctx context.Context, handle func(*Message)) error
+
+	// Send writes a message to the other side
+	Send(ctx context.Context, msg *Message) error
+
+	// Done is closed when the connection is lost or closed
+	Done() <-chan struct{}
+
+	// Close closes the connection and releases its resources
+	Close() error
+}
+
+const (
+	// maxMessageSize is the largest line accepted on stream transports
+	maxMessageSize = 16 << 20
+
+	// stdioShutdownTimeout is how long a server gets to exit after stdin is closed
+	stdioShutdownTimeout = 2 * time.Second
+)
+
+// StreamTransport exchanges newline-delimited JSON messages over a reader and a writer.
+// It is the base of the stdio transport, and can also connect in-process peers
+// through io.Pipe.
+type StreamTransport struct {
+	reader io.ReadCloser
+	writer io.WriteCloser
+
+	writeMu   sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+	onClose   func() error
+}
+
+// NewStreamTransport creates a transport reading messages from r and writing to w
+func NewStreamTransport(r io.ReadCloser, w io.WriteCloser) *StreamTransport {
+	return &StreamTransport{
+		reader: r,
+		writer: w,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start begins reading messages in the background
+func (t *StreamTransport) Start(ctx context.Context, handle func(*Message)) error {
+	go t.readLoop(handle)
+	return nil
+}
+
+// readLoop decodes one message per line until the reader fails
+func (t *StreamTransport) readLoop(handle func(*Message)) {
+	defer t.Close()
+
+	scanner := bufio.NewScanner(t.reader)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		for _, msg := range decodeMessages(line) {
+			handle(msg)
+		}
+	}
+}
+
+// Send writes a message followed by a newline
+func (t *StreamTransport) Send(ctx context.Context, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	select {
+	case <-t.done:
+		return ErrConnectionClosed
+	default:
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.writer.Write(append(data, '\n')); err != nil {
+		t.Close()
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+	return nil
+}
+
+// Done is closed when the stream ends
+func (t *StreamTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close closes both ends of the stream
+func (t *StreamTransport) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.done)
+		t.writer.Close()
+		t.reader.Close()
+		if t.onClose != nil {
+			err = t.onClose()
+		}
+	})
+	return err
+}
+
+// decodeMessages decodes a single message or a JSON-RPC batch.
+// Malformed input is dropped.
+func decodeMessages(data []byte) []*Message {
+	var batch []*Message
+	if err := json.Unmarshal(data, &batch); err == nil {
+		return batch
+	}
+
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+	return []*Message{&msg}
+}
+
+// NewStdioTransport starts command as a subprocess and talks to it over
+// its stdin and stdout. Stderr is forwarded to the parent's stderr.
+// env entries ("KEY=value") are added to the current environment.
+func NewStdioTransport(command string, args []string, env []string) (*StreamTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin of %s: %w", command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout of %s: %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start MCP server %s: %w", command, err)
+	}
+
+	transport := NewStreamTransport(stdout, stdin)
+	transport.onClose = func() error {
+		// Closing stdin asks the server to exit; kill it if it does not
+		exited := make(chan struct{})
+		go func() {
+			cmd.Wait()
+			close(exited)
+		}()
+		select {
+		case <-exited:
+		case <-time.After(stdioShutdownTimeout):
+			cmd.Process.Kill()
+			<-exited
+		}
+		return nil
+	}
+	return transport, nil
+}
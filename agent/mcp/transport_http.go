@@ -0,0 +1,261 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP header names used by the streamable HTTP transport
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "Mcp-Protocol-Version"
+)
+
+// listenRetryDelay is the pause before reopening the server notification stream
+const listenRetryDelay = time.Second
+
+// HTTPTransport implements the MCP streamable HTTP transport for clients.
+//
+// Every message is POSTed to the endpoint. Responses arrive either as a JSON
+// body or as a server-sent event stream. After the session is initialized,
+// a GET stream is kept open to receive server notifications such as tool
+// list changes, when the server supports it.
+type HTTPTransport struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+	handle          func(*Message)
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewHTTPTransport creates a streamable HTTP transport for the given endpoint.
+// headers are added to every request (e.g. Authorization). client may be nil.
+func NewHTTPTransport(url string, headers map[string]string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPTransport{
+		url:     url,
+		client:  client,
+		headers: headers,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// Start registers the message handler. HTTP has no connection to open up front.
+func (t *HTTPTransport) Start(ctx context.Context, handle func(*Message)) error {
+	t.mu.Lock()
+	t.handle = handle
+	t.mu.Unlock()
+	return nil
+}
+
+// setProtocolVersion sets the negotiated version sent on subsequent requests
+func (t *HTTPTransport) setProtocolVersion(version string) {
+	t.mu.Lock()
+	t.protocolVersion = version
+	t.mu.Unlock()
+}
+
+// Send POSTs a message and dispatches the messages of the response
+func (t *HTTPTransport) Send(ctx context.Context, msg *Message) error {
+	select {
+	case <-t.done:
+		return ErrConnectionClosed
+	default:
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionClosed, err)
+	}
+
+	if sessionID := resp.Header.Get(headerSessionID); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && t.hasSession():
+		resp.Body.Close()
+		t.Close()
+		return ErrSessionExpired
+	case resp.StatusCode == http.StatusAccepted:
+		resp.Body.Close()
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return fmt.Errorf("mcp: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		// Requests may stream progress notifications before the response
+		go func() {
+			defer resp.Body.Close()
+			t.readEvents(resp.Body)
+		}()
+	default:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			t.dispatch(decodeMessages(body))
+		}
+	}
+
+	if msg.Method == notificationInitialized {
+		go t.listen()
+	}
+	return nil
+}
+
+// listen keeps a GET event stream open for server-initiated messages.
+// Servers answering 405 don't offer one, and listening stops.
+func (t *HTTPTransport) listen() {
+	for {
+		req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.url, nil)
+		if err != nil {
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		t.setHeaders(req)
+
+		resp, err := t.client.Do(req)
+		if err == nil {
+			if resp.StatusCode != http.StatusOK {
+				resp.Body.Close()
+				return
+			}
+			t.readEvents(resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// readEvents parses a server-sent event stream and dispatches each data payload
+func (t *HTTPTransport) readEvents(body io.Reader) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				t.dispatch(decodeMessages([]byte(data.String())))
+				data.Reset()
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if data.Len() > 0 {
+		t.dispatch(decodeMessages([]byte(data.String())))
+	}
+}
+
+// dispatch passes messages to the handler
+func (t *HTTPTransport) dispatch(msgs []*Message) {
+	t.mu.Lock()
+	handle := t.handle
+	t.mu.Unlock()
+	if handle == nil {
+		return
+	}
+	for _, msg := range msgs {
+		handle(msg)
+	}
+}
+
+// setHeaders adds custom, session and protocol headers to a request
+func (t *HTTPTransport) setHeaders(req *http.Request) {
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(headerProtocolVersion, t.protocolVersion)
+	}
+}
+
+// hasSession reports whether the server assigned a session ID
+func (t *HTTPTransport) hasSession() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID != ""
+}
+
+// Done is closed when the transport is closed or the session expired
+func (t *HTTPTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close terminates the session and stops the notification stream
+func (t *HTTPTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+
+		// Explicitly end the session (best effort)
+		if t.hasSession() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+			if err == nil {
+				t.setHeaders(req)
+				if resp, err := t.client.Do(req); err == nil {
+					resp.Body.Close()
+				}
+			}
+			cancel()
+		}
+
+		t.cancel()
+	})
+	return nil
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Start starts the background compactor, which calls Compact every
+// CompressionInterval until ctx is cancelled or Stop is called.
+// Use it with AutoCompress disabled to keep compression off the request path.
+func (m *Memory) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	interval := m.config.CompressionInterval
+	if interval <= 0 {
+		return fmt.Errorf("compression interval must be positive to start the compactor")
+	}
+	if m.compactorCancel != nil {
+		return fmt.Errorf("compactor already running")
+	}
+
+	if m.lastDecay.IsZero() {
+		m.lastDecay = time.Now()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	m.compactorCancel = cancel
+	m.compactorDone = done
+
+	go m.runCompactor(ctx, interval, done)
+	return nil
+}
+
+// Stop stops the background compactor and waits for it to exit.
+// It is safe to call Stop when the compactor is not running.
+func (m *Memory) Stop() {
+	m.mu.Lock()
+	cancel, done := m.compactorCancel, m.compactorDone
+	m.compactorCancel = nil
+	m.compactorDone = nil
+	m.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Running reports whether the background compactor is running
+func (m *Memory) Running() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.compactorCancel != nil
+}
+
+// runCompactor runs Compact on every tick until ctx is done
+func (m *Memory) runCompactor(ctx context.Context, interval time.Duration, done chan struct{}) {
+	defer func() {
+		// Allow restarting after the parent context was cancelled
+		m.mu.Lock()
+		if m.compactorDone == done {
+			m.compactorCancel()
+			m.compactorCancel = nil
+			m.compactorDone = nil
+		}
+		m.mu.Unlock()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Compact(ctx); err != nil {
+				// Don't stop, compaction is retried on the next tick
+				_ = err
+			}
+		}
+	}
+}
+
+// Compact runs one compaction pass: it compresses working memory once it
+// reaches CompressionThreshold, decays episodic importance by
+// ImportanceHalfLife and prunes episodic memory to EpisodicMaxSize
+// (least important first) and EpisodicMaxAge.
+func (m *Memory) Compact(ctx context.Context) error {
+	m.mu.Lock()
+	config := m.config
+	now := time.Now()
+	var elapsed time.Duration
+	if !m.lastDecay.IsZero() {
+		elapsed = now.Sub(m.lastDecay)
+	}
+	m.lastDecay = now
+	episodic, isImpl := m.episodic.(*EpisodicMemoryImpl)
+	m.mu.Unlock()
+
+	// 1. Compress working memory
+	threshold := config.CompressionThreshold
+	if threshold <= 0 {
+		threshold = config.WorkingCapacity
+	}
+	if m.working.Size() >= threshold {
+		if err := m.Compress(ctx); err != nil {
+			return fmt.Errorf("failed to compress working memory: %w", err)
+		}
+	}
+
+	if !config.EpisodicEnabled || !isImpl {
+		return nil
+	}
+
+	// 2. Decay importance so old episodes lose priority
+	episodic.DecayImportance(elapsed, config.ImportanceHalfLife)
+
+	// 3. Prune episodic memory by importance and age
+	if _, err := episodic.Prune(ctx, config.EpisodicMaxSize, config.EpisodicMaxAge); err != nil {
+		return fmt.Errorf("failed to prune episodic memory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryCompact tests a single compaction pass
+func TestMemoryCompact(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	config.CompressionThreshold = 4
+	config.AutoCompress = false
+	config.EpisodicMaxSize = 3
+	config.EpisodicThreshold = 0
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: fmt.Sprintf("message %d", i)}))
+	}
+	require.Equal(t, 6, mem.episodic.Size())
+	require.Equal(t, 0, mem.Stats(ctx).CompressionCount)
+
+	require.NoError(t, mem.Compact(ctx))
+
+	stats := mem.Stats(ctx)
+	assert.Equal(t, 1, stats.CompressionCount)
+	assert.Equal(t, 3, stats.EpisodicSize)
+	assert.Less(t, stats.WorkingSize, config.WorkingCapacity)
+}
+
+// TestMemoryCompact_Decay tests importance decay between passes
+func TestMemoryCompact_Decay(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.ImportanceHalfLife = time.Hour
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	episodic := mem.episodic.(*EpisodicMemoryImpl)
+	require.NoError(t, episodic.Store(ctx, Message{Role: "user", Content: "remember this", Timestamp: time.Now()}, 0.8))
+
+	// The first pass only sets the reference time
+	require.NoError(t, mem.Compact(ctx))
+	assert.InDelta(t, 0.8, episodic.GetAverageImportance(), 0.001)
+
+	mem.mu.Lock()
+	mem.lastDecay = time.Now().Add(-time.Hour)
+	mem.mu.Unlock()
+
+	require.NoError(t, mem.Compact(ctx))
+	assert.InDelta(t, 0.4, episodic.GetAverageImportance(), 0.001)
+}
+
+// TestMemoryStartStop tests the background compactor lifecycle
+func TestMemoryStartStop(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	config.CompressionThreshold = 4
+	config.AutoCompress = false
+	config.CompressionInterval = 10 * time.Millisecond
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: fmt.Sprintf("message %d", i)}))
+	}
+
+	require.NoError(t, mem.Start(ctx))
+	assert.True(t, mem.Running())
+	assert.Error(t, mem.Start(ctx), "starting twice should fail")
+
+	assert.Eventually(t, func() bool {
+		return mem.Stats(ctx).CompressionCount > 0
+	}, time.Second, 5*time.Millisecond)
+
+	mem.Stop()
+	assert.False(t, mem.Running())
+	mem.Stop() // Stopping twice is a no-op
+
+	// Can be restarted after Stop
+	require.NoError(t, mem.Start(ctx))
+	mem.Stop()
+}
+
+// TestMemoryStart_ContextCancel tests that cancelling the context stops the compactor
+func TestMemoryStart_ContextCancel(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.CompressionInterval = 10 * time.Millisecond
+	mem := NewWithConfig(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, mem.Start(ctx))
+	cancel()
+
+	assert.Eventually(t, func() bool { return !mem.Running() }, time.Second, 5*time.Millisecond)
+	require.NoError(t, mem.Start(context.Background()))
+	mem.Stop()
+}
+
+// TestMemoryStart_Disabled tests that the compactor requires an interval
+func TestMemoryStart_Disabled(t *testing.T) {
+	mem := New()
+	assert.Error(t, mem.Start(context.Background()))
+	assert.False(t, mem.Running())
+}
@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"sync"
 	"time"
@@ -85,6 +86,8 @@ type EpisodicMemoryImpl struct {
 type MessageWithImportance struct {
 	Message    Message
 	Importance float64
+
+	vectorID string // Document ID in the vector store, if stored there
 }
 
 // EpisodicMemoryConfig configures episodic memory behavior
@@ -134,9 +137,11 @@ func (e *EpisodicMemoryImpl) Store(ctx context.Context, msg Message, importance
 	}
 
 	// Add to in-memory storage
+	vectorID := fmt.Sprintf("%d_%s", msg.Timestamp.UnixNano(), msg.Role)
 	e.messages = append(e.messages, MessageWithImportance{
 		Message:    msg,
 		Importance: importance,
+		vectorID:   vectorID,
 	})
 
 	// Enforce max size if configured
@@ -147,7 +152,7 @@ func (e *EpisodicMemoryImpl) Store(ctx context.Context, msg Message, importance
 
 	// Store in vector store if enabled
 	if e.useVectorStore {
-		if err := e.storeInVectorDB(ctx, msg, importance); err != nil {
+		if err := e.storeInVectorDB(ctx, vectorID, msg, importance); err != nil {
 			// Don't fail, just continue with in-memory storage
 			// TODO: Add proper logging
 			_ = err
@@ -158,7 +163,7 @@ func (e *EpisodicMemoryImpl) Store(ctx context.Context, msg Message, importance
 }
 
 // storeInVectorDB stores a message in the vector database
-func (e *EpisodicMemoryImpl) storeInVectorDB(ctx context.Context, msg Message, importance float64) error {
+func (e *EpisodicMemoryImpl) storeInVectorDB(ctx context.Context, id string, msg Message, importance float64) error {
 	// Prepare metadata
 	metadata := make(map[string]interface{})
 	metadata["role"] = msg.Role
@@ -172,7 +177,7 @@ func (e *EpisodicMemoryImpl) storeInVectorDB(ctx context.Context, msg Message, i
 
 	// Create vector document
 	doc := VectorDoc{
-		ID:        fmt.Sprintf("%d_%s", msg.Timestamp.UnixNano(), msg.Role),
+		ID:        id,
 		Content:   msg.Content,
 		Metadata:  metadata,
 		CreatedAt: msg.Timestamp,
@@ -206,6 +211,7 @@ func (e *EpisodicMemoryImpl) StoreBatch(ctx context.Context, messages []Message,
 			e.messages = append(e.messages, MessageWithImportance{
 				Message:    msg,
 				Importance: importances[i],
+				vectorID:   fmt.Sprintf("%d_%s_%d", msg.Timestamp.UnixNano(), msg.Role, len(validMessages)-1),
 			})
 		}
 	}
@@ -596,6 +602,85 @@ func (e *EpisodicMemoryImpl) GetAverageImportance() float64 {
 	return sum / float64(len(e.messages))
 }
 
+// DecayImportance multiplies all importance scores by 0.5^(elapsed/halfLife),
+// so importance halves for every halfLife that passes.
+func (e *EpisodicMemoryImpl) DecayImportance(elapsed, halfLife time.Duration) {
+	if elapsed <= 0 || halfLife <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	factor := math.Pow(0.5, float64(elapsed)/float64(halfLife))
+	for i := range e.messages {
+		e.messages[i].Importance *= factor
+	}
+}
+
+// Prune removes messages older than maxAge and, if more than maxSize remain,
+// the least important ones (oldest first among equal importance).
+// Zero values disable the respective limit. Returns the number of removed messages.
+func (e *EpisodicMemoryImpl) Prune(ctx context.Context, maxSize int, maxAge time.Duration) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	removed := make(map[int]bool)
+
+	// Remove expired messages
+	if maxAge > 0 {
+		for i, m := range e.messages {
+			if now.Sub(m.Message.Timestamp) > maxAge {
+				removed[i] = true
+			}
+		}
+	}
+
+	// Remove least important messages over the size limit
+	if excess := len(e.messages) - len(removed) - maxSize; maxSize > 0 && excess > 0 {
+		candidates := make([]int, 0, len(e.messages)-len(removed))
+		for i := range e.messages {
+			if !removed[i] {
+				candidates = append(candidates, i)
+			}
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return e.messages[candidates[a]].Importance < e.messages[candidates[b]].Importance
+		})
+		for _, i := range candidates[:excess] {
+			removed[i] = true
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	// Keep remaining messages in chronological order
+	kept := make([]MessageWithImportance, 0, len(e.messages)-len(removed))
+	vectorIDs := make([]string, 0, len(removed))
+	for i, m := range e.messages {
+		if removed[i] {
+			if m.vectorID != "" {
+				vectorIDs = append(vectorIDs, m.vectorID)
+			}
+			continue
+		}
+		kept = append(kept, m)
+	}
+	e.messages = kept
+
+	// Delete from vector store if enabled
+	if e.useVectorStore && len(vectorIDs) > 0 {
+		if err := e.vectorStore.Delete(ctx, e.collection, vectorIDs); err != nil {
+			return len(removed), fmt.Errorf("failed to delete from vector store: %w", err)
+		}
+	}
+
+	return len(removed), nil
+}
+
 // hasTags checks if message has all required tags
 func hasTags(msg Message, requiredTags []string) bool {
 	if msg.Metadata == nil {
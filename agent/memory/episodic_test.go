@@ -477,3 +477,72 @@ func TestEpisodicMemory_ConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected size between 1 and 10, got %d", size)
 	}
 }
+
+// TestEpisodicMemory_Prune tests pruning by importance and age
+func TestEpisodicMemory_Prune(t *testing.T) {
+	em := NewEpisodicMemory()
+	ctx := context.Background()
+	now := time.Now()
+
+	entries := []struct {
+		content    string
+		age        time.Duration
+		importance float64
+	}{
+		{"expired but important", 48 * time.Hour, 0.99},
+		{"old low", 3 * time.Hour, 0.5},
+		{"old high", 2 * time.Hour, 0.9},
+		{"recent low", time.Hour, 0.5},
+		{"recent high", 0, 0.8},
+	}
+	for _, e := range entries {
+		msg := Message{Role: "user", Content: e.content, Timestamp: now.Add(-e.age)}
+		if err := em.Store(ctx, msg, e.importance); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+	}
+
+	removed, err := em.Prune(ctx, 3, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 removed, got %d", removed)
+	}
+
+	// The expired message and the oldest of the least important are gone,
+	// the rest stay in chronological order
+	all, _ := em.RetrieveByImportance(ctx, 0, 10)
+	want := []string{"old high", "recent low", "recent high"}
+	if len(all) != len(want) {
+		t.Fatalf("Expected %d messages, got %d", len(want), len(all))
+	}
+	for i, msg := range all {
+		if msg.Content != want[i] {
+			t.Errorf("Message %d: expected %q, got %q", i, want[i], msg.Content)
+		}
+	}
+
+	// No limits, nothing to prune
+	if removed, _ := em.Prune(ctx, 0, 0); removed != 0 {
+		t.Errorf("Expected nothing removed without limits, got %d", removed)
+	}
+}
+
+// TestEpisodicMemory_DecayImportance tests importance decay
+func TestEpisodicMemory_DecayImportance(t *testing.T) {
+	em := NewEpisodicMemory()
+	ctx := context.Background()
+
+	_ = em.Store(ctx, Message{Role: "user", Content: "a", Timestamp: time.Now()}, 0.8)
+
+	em.DecayImportance(2*time.Hour, time.Hour)
+	if got := em.GetAverageImportance(); got < 0.199 || got > 0.201 {
+		t.Errorf("Expected importance 0.2 after two half-lives, got %f", got)
+	}
+
+	em.DecayImportance(time.Hour, 0)
+	if got := em.GetAverageImportance(); got < 0.199 || got > 0.201 {
+		t.Errorf("Expected no decay without half-life, got %f", got)
+	}
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Fact operation actions returned by a FactExtractor
+const (
+	FactActionAdd    = "add"    // Store a new fact
+	FactActionUpdate = "update" // Replace an existing fact (also used to merge duplicates)
+	FactActionDelete = "delete" // Remove a fact that is contradicted or obsolete
+)
+
+// FactOperation is a single change to semantic memory proposed by a FactExtractor
+type FactOperation struct {
+	Action string // FactActionAdd, FactActionUpdate or FactActionDelete
+	FactID string // Target fact for update and delete
+	Fact   Fact   // New fact content for add and update
+}
+
+// FactExtractor extracts durable facts from a conversation turn.
+// Implementations receive the existing facts so they can reconcile new
+// information against them instead of only appending.
+type FactExtractor interface {
+	Extract(ctx context.Context, messages []Message, existing []Fact) ([]FactOperation, error)
+}
+
+const (
+	// DefaultFactMinConfidence is the default minimum confidence for extracted facts
+	DefaultFactMinConfidence = 0.5
+
+	// FactSourceConversation is the Source of facts learned from conversation
+	FactSourceConversation = "conversation"
+
+	factExtractorSystemPrompt = "You extract durable facts about the user from a conversation, " +
+		"such as preferences, personal details, goals and skills. Ignore small talk, questions " +
+		"and anything only relevant to the current request. Reconcile new information with the " +
+		"known facts: add new facts, update a fact when it changes, merge duplicates by updating " +
+		"one and deleting the others, and delete facts that are contradicted.\n" +
+		"Reply with JSON only, in this format:\n" +
+		`{"operations":[{"action":"add|update|delete","id":"<fact id for update/delete>",` +
+		`"content":"<fact as a short sentence>","category":"preference|personal|goal|skill|knowledge",` +
+		`"confidence":0.9}]}` + "\n" +
+		`Reply with {"operations":[]} if there is nothing to change.`
+)
+
+// LLMFactExtractor is a FactExtractor backed by an LLM
+type LLMFactExtractor struct {
+	llm           CompletionAdapter
+	minConfidence float64
+}
+
+// NewLLMFactExtractor creates a fact extractor that uses the given completion adapter
+func NewLLMFactExtractor(llm CompletionAdapter) *LLMFactExtractor {
+	return &LLMFactExtractor{
+		llm:           llm,
+		minConfidence: DefaultFactMinConfidence,
+	}
+}
+
+// WithMinConfidence sets the minimum confidence for added or updated facts (0-1)
+func (e *LLMFactExtractor) WithMinConfidence(minConfidence float64) *LLMFactExtractor {
+	if minConfidence >= 0 && minConfidence <= 1 {
+		e.minConfidence = minConfidence
+	}
+	return e
+}
+
+// extractedOperation is the JSON shape of an operation returned by the model
+type extractedOperation struct {
+	Action     string  `json:"action"`
+	ID         string  `json:"id"`
+	Content    string  `json:"content"`
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Extract implements FactExtractor.Extract
+func (e *LLMFactExtractor) Extract(ctx context.Context, messages []Message, existing []Fact) ([]FactOperation, error) {
+	if e.llm == nil {
+		return nil, fmt.Errorf("fact extractor has no LLM configured")
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Known facts:\n")
+	if len(existing) == 0 {
+		prompt.WriteString("(none)\n")
+	}
+	for _, fact := range existing {
+		prompt.WriteString(fmt.Sprintf("- [%s] (%s) %s\n", fact.ID, fact.Category, fact.Content))
+	}
+
+	prompt.WriteString("\nConversation:\n")
+	for _, msg := range messages {
+		prompt.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+
+	response, err := e.llm.Complete(ctx, factExtractorSystemPrompt, prompt.String())
+	if err != nil {
+		return nil, fmt.Errorf("LLM fact extraction failed: %w", err)
+	}
+
+	var parsed struct {
+		Operations []extractedOperation `json:"operations"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(response)), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse extracted facts: %w", err)
+	}
+
+	known := make(map[string]bool, len(existing))
+	for _, fact := range existing {
+		known[fact.ID] = true
+	}
+
+	operations := make([]FactOperation, 0, len(parsed.Operations))
+	for _, op := range parsed.Operations {
+		action := strings.ToLower(strings.TrimSpace(op.Action))
+		content := strings.TrimSpace(op.Content)
+
+		switch action {
+		case FactActionAdd, FactActionUpdate:
+			if content == "" || op.Confidence < e.minConfidence {
+				continue
+			}
+			if action == FactActionUpdate && !known[op.ID] {
+				// The model referenced a fact we don't know, treat it as new
+				action = FactActionAdd
+			}
+		case FactActionDelete:
+			if !known[op.ID] {
+				continue
+			}
+		default:
+			continue
+		}
+
+		category := strings.ToLower(strings.TrimSpace(op.Category))
+		if category == "" {
+			category = "knowledge"
+		}
+
+		operation := FactOperation{Action: action}
+		if action != FactActionAdd {
+			operation.FactID = op.ID
+		}
+		if action != FactActionDelete {
+			operation.Fact = Fact{
+				Content:    content,
+				Category:   category,
+				Source:     FactSourceConversation,
+				Confidence: clampConfidence(op.Confidence),
+			}
+		}
+		operations = append(operations, operation)
+	}
+
+	return operations, nil
+}
+
+// extractJSON returns the JSON object in a model response,
+// stripping markdown code fences and surrounding prose
+func extractJSON(response string) string {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return strings.TrimSpace(response)
+	}
+	return response[start : end+1]
+}
+
+// clampConfidence clamps a confidence score to [0, 1]
+func clampConfidence(confidence float64) float64 {
+	if confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
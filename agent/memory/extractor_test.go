@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedCompletion returns queued responses in order
+type scriptedCompletion struct {
+	responses []string
+	prompts   []string
+	err       error
+}
+
+func (s *scriptedCompletion) Complete(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	s.prompts = append(s.prompts, prompt)
+	if s.err != nil {
+		return "", s.err
+	}
+	if len(s.responses) == 0 {
+		return `{"operations":[]}`, nil
+	}
+	response := s.responses[0]
+	s.responses = s.responses[1:]
+	return response, nil
+}
+
+// TestLLMFactExtractor_Parse tests parsing and filtering of extracted operations
+func TestLLMFactExtractor_Parse(t *testing.T) {
+	llm := &scriptedCompletion{responses: []string{"```json\n" + `{"operations":[
+		{"action":"add","content":"User prefers dark mode","category":"Preference","confidence":0.9},
+		{"action":"add","content":"User might like cats","category":"preference","confidence":0.2},
+		{"action":"update","id":"fact_1","content":"User lives in Berlin","category":"personal","confidence":1.4},
+		{"action":"update","id":"unknown","content":"User is vegan","confidence":0.8},
+		{"action":"delete","id":"fact_2"},
+		{"action":"delete","id":"missing"},
+		{"action":"rename","id":"fact_1"}
+	]}` + "\n```"}}
+
+	existing := []Fact{
+		{ID: "fact_1", Content: "User lives in Paris", Category: "personal"},
+		{ID: "fact_2", Content: "User has no pets", Category: "personal"},
+	}
+
+	ops, err := NewLLMFactExtractor(llm).Extract(context.Background(), []Message{
+		{Role: "user", Content: "I moved to Berlin with my new dog. Please use dark mode."},
+	}, existing)
+	require.NoError(t, err)
+	require.Len(t, ops, 4)
+
+	assert.Equal(t, FactActionAdd, ops[0].Action)
+	assert.Equal(t, "preference", ops[0].Fact.Category)
+	assert.Equal(t, FactSourceConversation, ops[0].Fact.Source)
+
+	assert.Equal(t, FactActionUpdate, ops[1].Action)
+	assert.Equal(t, "fact_1", ops[1].FactID)
+	assert.Equal(t, 1.0, ops[1].Fact.Confidence)
+
+	// Updates of unknown facts become adds, with the default category
+	assert.Equal(t, FactActionAdd, ops[2].Action)
+	assert.Empty(t, ops[2].FactID)
+	assert.Equal(t, "knowledge", ops[2].Fact.Category)
+
+	assert.Equal(t, FactActionDelete, ops[3].Action)
+	assert.Equal(t, "fact_2", ops[3].FactID)
+
+	// Existing facts are sent with their IDs for reconciliation
+	require.Len(t, llm.prompts, 1)
+	assert.Contains(t, llm.prompts[0], "[fact_1] (personal) User lives in Paris")
+	assert.Contains(t, llm.prompts[0], "user: I moved to Berlin")
+}
+
+// TestLLMFactExtractor_Errors tests error handling
+func TestLLMFactExtractor_Errors(t *testing.T) {
+	ctx := context.Background()
+	msgs := []Message{{Role: "user", Content: "hi"}}
+
+	_, err := NewLLMFactExtractor(nil).Extract(ctx, msgs, nil)
+	assert.Error(t, err)
+
+	_, err = NewLLMFactExtractor(&scriptedCompletion{err: errors.New("boom")}).Extract(ctx, msgs, nil)
+	assert.ErrorContains(t, err, "boom")
+
+	_, err = NewLLMFactExtractor(&scriptedCompletion{responses: []string{"no facts here"}}).Extract(ctx, msgs, nil)
+	assert.Error(t, err)
+
+	ops, err := NewLLMFactExtractor(&scriptedCompletion{}).Extract(ctx, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, ops)
+}
+
+// TestMemoryAutoLearn tests fact extraction and reconciliation after assistant turns
+func TestMemoryAutoLearn(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.SemanticEnabled = true
+	config.SemanticAutoLearn = true
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	llm := &scriptedCompletion{responses: []string{
+		`{"operations":[{"action":"add","content":"User lives in Paris","category":"personal","confidence":0.9},
+			{"action":"add","content":"User prefers tea","category":"preference","confidence":0.8}]}`,
+	}}
+	mem.SetFactExtractor(NewLLMFactExtractor(llm))
+
+	// User messages alone don't trigger extraction
+	require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: "I live in Paris and prefer tea"}))
+	assert.Empty(t, llm.prompts)
+
+	require.NoError(t, mem.Add(ctx, Message{Role: "assistant", Content: "Noted!"}))
+	require.Len(t, llm.prompts, 1)
+	assert.Contains(t, llm.prompts[0], "user: I live in Paris and prefer tea")
+	assert.Contains(t, llm.prompts[0], "assistant: Noted!")
+
+	facts, err := mem.semantic.ListFacts(ctx, "", 0)
+	require.NoError(t, err)
+	require.Len(t, facts, 2)
+
+	var parisID, teaID string
+	for _, fact := range facts {
+		assert.Equal(t, FactSourceConversation, fact.Source)
+		switch fact.Content {
+		case "User lives in Paris":
+			parisID = fact.ID
+		case "User prefers tea":
+			teaID = fact.ID
+		}
+	}
+	require.NotEmpty(t, parisID)
+	require.NotEmpty(t, teaID)
+
+	// A contradicting turn updates one fact and deletes the other
+	llm.responses = []string{`{"operations":[
+		{"action":"update","id":"` + parisID + `","content":"User lives in Berlin","category":"personal","confidence":0.95},
+		{"action":"delete","id":"` + teaID + `"}]}`}
+
+	require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: "I moved to Berlin and quit tea"}))
+	require.NoError(t, mem.Add(ctx, Message{Role: "assistant", Content: "Got it"}))
+
+	facts, err = mem.Facts(ctx, "where", 10)
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	assert.Equal(t, parisID, facts[0].ID)
+	assert.Equal(t, "User lives in Berlin", facts[0].Content)
+	assert.Equal(t, 0.95, facts[0].Confidence)
+}
+
+// TestMemoryAutoLearn_Disabled tests that extraction requires SemanticAutoLearn
+func TestMemoryAutoLearn_Disabled(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.SemanticEnabled = true
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	llm := &scriptedCompletion{}
+	mem.SetFactExtractor(NewLLMFactExtractor(llm))
+
+	require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: "I live in Paris"}))
+	require.NoError(t, mem.Add(ctx, Message{Role: "assistant", Content: "Nice"}))
+	assert.Empty(t, llm.prompts)
+
+	// Manual extraction still works
+	require.NoError(t, mem.LearnFacts(ctx, []Message{{Role: "user", Content: "I live in Paris"}}))
+	assert.Len(t, llm.prompts, 1)
+
+	assert.Error(t, New().LearnFacts(ctx, []Message{{Role: "user", Content: "hi"}}))
+}
@@ -205,18 +205,20 @@ type MemoryConfig struct {
 	SummarizationMode string // "none", "simple", "llm" (default: "simple")
 
 	// Episodic memory config
-	EpisodicEnabled   bool    // Enable episodic memory (default: true)
-	EpisodicThreshold float64 // Min importance to store (default: 0.5)
-	EpisodicMaxSize   int     // Max messages to store (0 = unlimited)
+	EpisodicEnabled   bool          // Enable episodic memory (default: true)
+	EpisodicThreshold float64       // Min importance to store (default: 0.5)
+	EpisodicMaxSize   int           // Max messages to store (0 = unlimited)
+	EpisodicMaxAge    time.Duration // Prune messages older than this during compaction (0 = unlimited)
 
 	// Semantic memory config
 	SemanticEnabled   bool // Enable semantic memory (default: false)
-	SemanticAutoLearn bool // Automatically extract facts (default: false)
+	SemanticAutoLearn bool // Extract facts after each assistant turn, one extractor call per turn inside Add (default: false)
 
 	// Compression config
 	AutoCompress         bool          // Auto-compress when working memory full (default: true)
 	CompressionThreshold int           // Trigger compression at this size (default: WorkingCapacity)
-	CompressionInterval  time.Duration // Auto-compress interval (0 = disabled)
+	CompressionInterval  time.Duration // Auto-compress interval for the background compactor (0 = disabled)
+	ImportanceHalfLife   time.Duration // Episodic importance halves over this period during compaction (0 = no decay)
 
 	// Importance scoring config
 	ImportanceScoring bool // Enable importance scoring (default: true)
@@ -254,6 +256,7 @@ func DefaultMemoryConfig() MemoryConfig {
 		AutoCompress:         true,
 		CompressionThreshold: 10,
 		CompressionInterval:  0,
+		ImportanceHalfLife:   7 * 24 * time.Hour,
 
 		ImportanceScoring: true,
 		ImportanceWeights: DefaultImportanceWeights(),
@@ -3,26 +3,99 @@ package memory
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Default ranking parameters for QueryKnowledge
+const (
+	DefaultFactSimilarityWeight = 0.7
+	DefaultFactConfidenceWeight = 0.2
+	DefaultFactRecencyWeight    = 0.1
+	DefaultFactRecencyHalfLife  = 30 * 24 * time.Hour
+)
+
 // SemanticMemoryImpl implements the SemanticMemory interface
 // Stores long-term facts and knowledge
 type SemanticMemoryImpl struct {
 	facts map[string]Fact // factID -> Fact
 	mu    sync.RWMutex
+
+	// Embedding provider for semantic search (optional)
+	embedding EmbeddingAdapter
+
+	// Vector store backing (optional)
+	vectorStore    VectorStoreAdapter
+	collection     string
+	useVectorStore bool
+
+	// Ranking configuration
+	similarityWeight float64
+	confidenceWeight float64
+	recencyWeight    float64
+	recencyHalfLife  time.Duration
+}
+
+// SemanticMemoryConfig configures semantic memory behavior
+type SemanticMemoryConfig struct {
+	Embedding      EmbeddingAdapter   // Embeds facts on store/update for similarity search (optional)
+	VectorStore    VectorStoreAdapter // Optional vector store backing (requires Embedding)
+	CollectionName string             // Collection name in vector store
+
+	// Ranking weights for QueryKnowledge (all zero = defaults)
+	SimilarityWeight float64
+	ConfidenceWeight float64
+	RecencyWeight    float64
+
+	// RecencyHalfLife is the age at which a fact's recency score halves (0 = 30 days)
+	RecencyHalfLife time.Duration
 }
 
 // NewSemanticMemory creates a new semantic memory
 func NewSemanticMemory() *SemanticMemoryImpl {
-	return &SemanticMemoryImpl{
-		facts: make(map[string]Fact),
+	return NewSemanticMemoryWithConfig(SemanticMemoryConfig{})
+}
+
+// NewSemanticMemoryWithConfig creates semantic memory with custom configuration
+func NewSemanticMemoryWithConfig(config SemanticMemoryConfig) *SemanticMemoryImpl {
+	s := &SemanticMemoryImpl{
+		facts:            make(map[string]Fact),
+		embedding:        config.Embedding,
+		vectorStore:      config.VectorStore,
+		collection:       config.CollectionName,
+		useVectorStore:   config.VectorStore != nil && config.Embedding != nil,
+		similarityWeight: config.SimilarityWeight,
+		confidenceWeight: config.ConfidenceWeight,
+		recencyWeight:    config.RecencyWeight,
+		recencyHalfLife:  config.RecencyHalfLife,
 	}
+
+	// Set default collection name if not provided
+	if s.useVectorStore && s.collection == "" {
+		s.collection = "semantic_memory"
+	}
+
+	if s.similarityWeight == 0 && s.confidenceWeight == 0 && s.recencyWeight == 0 {
+		s.similarityWeight = DefaultFactSimilarityWeight
+		s.confidenceWeight = DefaultFactConfidenceWeight
+		s.recencyWeight = DefaultFactRecencyWeight
+	}
+	if s.recencyHalfLife <= 0 {
+		s.recencyHalfLife = DefaultFactRecencyHalfLife
+	}
+
+	return s
 }
 
 // StoreFact implements SemanticMemory.StoreFact
+// Embeds the fact when an embedding provider is configured.
 func (s *SemanticMemoryImpl) StoreFact(ctx context.Context, fact Fact) error {
+	// Embed outside the lock, embedding providers are usually remote
+	s.embedFact(ctx, &fact)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -38,32 +111,276 @@ func (s *SemanticMemoryImpl) StoreFact(ctx context.Context, fact Fact) error {
 	fact.UpdatedAt = time.Now()
 
 	s.facts[fact.ID] = fact
+
+	// Store in vector store if enabled
+	if s.useVectorStore {
+		if err := s.storeInVectorDB(ctx, fact); err != nil {
+			// Don't fail, just continue with in-memory storage
+			_ = err
+		}
+	}
+
 	return nil
 }
 
 // QueryKnowledge implements SemanticMemory.QueryKnowledge
-// Simple implementation: returns all facts
-// TODO: Implement semantic search with vector embeddings
+// Ranks facts by similarity to the query combined with confidence and recency.
+// Similarity comes from a single source for all facts: the vector store,
+// cosine similarity of embeddings, or keyword overlap without embeddings.
+// Facts found in the vector store but missing locally are loaded.
+// A limit <= 0 returns all facts.
 func (s *SemanticMemoryImpl) QueryKnowledge(ctx context.Context, query string, limit int) ([]Fact, error) {
+	var similarities map[string]float64
+	if query != "" {
+		if s.useVectorStore {
+			similarities = s.searchVectorDB(ctx, query, limit)
+		}
+		if similarities == nil && s.embedding != nil {
+			if queryEmbedding, err := s.embedText(ctx, query); err == nil {
+				similarities = s.embeddingSimilarities(queryEmbedding)
+			}
+		}
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	result := make([]Fact, 0, limit)
-	count := 0
+	type scoredFact struct {
+		fact  Fact
+		score float64
+	}
 
-	for _, fact := range s.facts {
-		result = append(result, fact)
-		count++
-		if count >= limit {
-			break
+	now := time.Now()
+	scored := make([]scoredFact, 0, len(s.facts))
+	for id, fact := range s.facts {
+		similarity := 0.0
+		switch {
+		case query == "":
+		case similarities != nil:
+			// Facts the search did not return are unrelated to the query
+			similarity = similarities[id]
+		default:
+			similarity = textSimilarity(query, fact.Content)
+		}
+
+		score := s.similarityWeight*similarity +
+			s.confidenceWeight*fact.Confidence +
+			s.recencyWeight*s.recencyScore(fact, now)
+
+		scored = append(scored, scoredFact{fact: fact, score: score})
+	}
+
+	// Sort by score (highest first), then by recency
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].fact.UpdatedAt.After(scored[j].fact.UpdatedAt)
+	})
+
+	if limit <= 0 || limit > len(scored) {
+		limit = len(scored)
+	}
+
+	result := make([]Fact, limit)
+	for i := 0; i < limit; i++ {
+		result[i] = scored[i].fact
+	}
+
+	return result, nil
+}
+
+// recencyScore returns a score in (0, 1] that halves every recencyHalfLife
+func (s *SemanticMemoryImpl) recencyScore(fact Fact, now time.Time) float64 {
+	updated := fact.UpdatedAt
+	if updated.IsZero() {
+		updated = fact.CreatedAt
+	}
+	age := now.Sub(updated)
+	if age <= 0 {
+		return 1.0
+	}
+	return math.Pow(0.5, float64(age)/float64(s.recencyHalfLife))
+}
+
+// embeddingSimilarities returns cosine similarity of the query to each embedded
+// fact, or nil if no fact is embedded
+func (s *SemanticMemoryImpl) embeddingSimilarities(queryEmbedding []float64) map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var similarities map[string]float64
+	for id, fact := range s.facts {
+		if len(fact.Embedding) > 0 {
+			if similarities == nil {
+				similarities = make(map[string]float64, len(s.facts))
+			}
+			similarities[id] = cosineSimilarity(queryEmbedding, fact.Embedding)
+		}
+	}
+	return similarities
+}
+
+// searchVectorDB returns similarity scores from the vector store by fact ID,
+// or nil if the search fails. Facts missing from memory, e.g. stored by a
+// previous process, are loaded from the returned documents.
+func (s *SemanticMemoryImpl) searchVectorDB(ctx context.Context, query string, limit int) map[string]float64 {
+	// Score every fact, so that ranking doesn't mix score sources
+	topK := limit
+	if size := s.Size(); size > topK {
+		topK = size
+	}
+	if count, err := s.vectorStore.Count(ctx, s.collection); err == nil && int(count) > topK {
+		topK = int(count)
+	}
+	if topK <= 0 {
+		return nil
+	}
+
+	results, err := s.vectorStore.SearchByText(ctx, TextSearchReq{
+		Collection:      s.collection,
+		Query:           query,
+		TopK:            topK,
+		IncludeMetadata: true,
+		IncludeContent:  true,
+	})
+	if err != nil {
+		// Fallback to in-memory search
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	similarities := make(map[string]float64, len(results))
+	for _, res := range results {
+		doc := res.Document
+		if doc.ID == "" {
+			continue
+		}
+		if _, exists := s.facts[doc.ID]; !exists {
+			if kind, ok := doc.Metadata["type"]; ok && kind != "fact" {
+				continue
+			}
+			s.facts[doc.ID] = factFromVectorDoc(doc)
 		}
+		similarities[doc.ID] = float64(res.Score)
+	}
+	return similarities
+}
+
+// factFromVectorDoc converts a document written by storeInVectorDB back into a fact
+func factFromVectorDoc(doc VectorDoc) Fact {
+	fact := Fact{
+		ID:        doc.ID,
+		Content:   doc.Content,
+		CreatedAt: doc.CreatedAt,
+		UpdatedAt: doc.UpdatedAt,
+	}
+	if len(doc.Embedding) > 0 {
+		fact.Embedding = make([]float64, len(doc.Embedding))
+		for i, v := range doc.Embedding {
+			fact.Embedding[i] = float64(v)
+		}
+	}
+
+	for k, v := range doc.Metadata {
+		switch k {
+		case "type":
+		case "category":
+			fact.Category, _ = v.(string)
+		case "source":
+			fact.Source, _ = v.(string)
+		case "confidence":
+			switch c := v.(type) {
+			case float64:
+				fact.Confidence = c
+			case float32:
+				fact.Confidence = float64(c)
+			case int:
+				fact.Confidence = float64(c)
+			}
+		default:
+			if fact.Metadata == nil {
+				fact.Metadata = make(map[string]interface{})
+			}
+			fact.Metadata[k] = v
+		}
+	}
+	return fact
+}
+
+// storeInVectorDB upserts a fact in the vector database
+func (s *SemanticMemoryImpl) storeInVectorDB(ctx context.Context, fact Fact) error {
+	metadata := make(map[string]interface{})
+	for k, v := range fact.Metadata {
+		metadata[k] = v
+	}
+	metadata["type"] = "fact"
+	metadata["category"] = fact.Category
+	metadata["source"] = fact.Source
+	metadata["confidence"] = fact.Confidence
+
+	embedding := make([]float32, len(fact.Embedding))
+	for i, v := range fact.Embedding {
+		embedding[i] = float32(v)
+	}
+
+	// Delete first so stores without upsert semantics don't reject the update
+	_ = s.vectorStore.Delete(ctx, s.collection, []string{fact.ID})
+
+	_, err := s.vectorStore.Add(ctx, s.collection, []VectorDoc{{
+		ID:        fact.ID,
+		Content:   fact.Content,
+		Embedding: embedding,
+		Metadata:  metadata,
+		CreatedAt: fact.CreatedAt,
+		UpdatedAt: fact.UpdatedAt,
+	}})
+	return err
+}
+
+// embedFact fills in the fact's embedding if it is missing
+func (s *SemanticMemoryImpl) embedFact(ctx context.Context, fact *Fact) {
+	if s.embedding == nil || len(fact.Embedding) > 0 || fact.Content == "" {
+		return
+	}
+	if embedding, err := s.embedText(ctx, fact.Content); err == nil {
+		fact.Embedding = embedding
+	}
+	// On error the fact is stored without embedding and matched by keywords
+}
+
+// embedText embeds text with the embedding provider
+func (s *SemanticMemoryImpl) embedText(ctx context.Context, text string) ([]float64, error) {
+	embedding, err := s.embedding.Embed(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed text: %w", err)
 	}
 
+	result := make([]float64, len(embedding))
+	for i, v := range embedding {
+		result[i] = float64(v)
+	}
 	return result, nil
 }
 
 // UpdateFact implements SemanticMemory.UpdateFact
+// Re-embeds the fact when its content changed.
 func (s *SemanticMemoryImpl) UpdateFact(ctx context.Context, factID string, fact Fact) error {
+	s.mu.RLock()
+	existing, exists := s.facts[factID]
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("fact not found: %s", factID)
+	}
+
+	if fact.Content != existing.Content {
+		fact.Embedding = nil
+	}
+	s.embedFact(ctx, &fact)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -75,6 +392,14 @@ func (s *SemanticMemoryImpl) UpdateFact(ctx context.Context, factID string, fact
 	fact.UpdatedAt = time.Now()
 	s.facts[factID] = fact
 
+	// Store in vector store if enabled
+	if s.useVectorStore {
+		if err := s.storeInVectorDB(ctx, fact); err != nil {
+			// Don't fail, just continue with in-memory storage
+			_ = err
+		}
+	}
+
 	return nil
 }
 
@@ -88,6 +413,14 @@ func (s *SemanticMemoryImpl) DeleteFact(ctx context.Context, factID string) erro
 	}
 
 	delete(s.facts, factID)
+
+	// Delete from vector store if enabled
+	if s.useVectorStore {
+		if err := s.vectorStore.Delete(ctx, s.collection, []string{factID}); err != nil {
+			return fmt.Errorf("failed to delete from vector store: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -121,6 +454,14 @@ func (s *SemanticMemoryImpl) Clear(ctx context.Context) error {
 	defer s.mu.Unlock()
 
 	s.facts = make(map[string]Fact)
+
+	// Clear vector store if enabled
+	if s.useVectorStore {
+		if err := s.vectorStore.Clear(ctx, s.collection); err != nil {
+			return fmt.Errorf("failed to clear vector store: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -152,7 +493,10 @@ func (s *SemanticMemoryImpl) GetCategories() []string {
 	return categories
 }
 
+// factIDCounter keeps fact IDs unique when several facts are stored at once
+var factIDCounter uint64
+
 // generateFactID generates a unique ID for a fact
 func generateFactID() string {
-	return fmt.Sprintf("fact_%d", time.Now().UnixNano())
+	return fmt.Sprintf("fact_%d_%d", time.Now().UnixNano(), atomic.AddUint64(&factIDCounter, 1))
 }
@@ -0,0 +1,335 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicEmbedding embeds text as counts of topic words, so that texts about
+// the same topic are similar even without shared words
+type topicEmbedding struct {
+	calls int
+	err   error
+}
+
+var embeddingTopics = [][]string{
+	{"food", "vegan", "eat", "meal", "dinner", "snack", "peanuts"},
+	{"city", "live", "berlin", "paris", "moved", "where"},
+	{"theme", "dark", "light", "mode", "ui"},
+}
+
+func (e *topicEmbedding) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.calls++
+	if e.err != nil {
+		return nil, e.err
+	}
+	vec := make([]float32, len(embeddingTopics))
+	for _, word := range tokenize(text) {
+		for i, topic := range embeddingTopics {
+			for _, w := range topic {
+				if strings.HasPrefix(word, w) {
+					vec[i]++
+				}
+			}
+		}
+	}
+	return vec, nil
+}
+
+func (e *topicEmbedding) Dimensions() int {
+	return len(embeddingTopics)
+}
+
+// fakeVectorStore is an in-memory VectorStoreAdapter
+type fakeVectorStore struct {
+	docs      map[string]VectorDoc
+	embedding EmbeddingAdapter
+	searchErr error
+	lastTopK  int
+}
+
+func newFakeVectorStore(embedding EmbeddingAdapter) *fakeVectorStore {
+	return &fakeVectorStore{docs: make(map[string]VectorDoc), embedding: embedding}
+}
+
+func (f *fakeVectorStore) Add(ctx context.Context, collection string, docs []VectorDoc) ([]string, error) {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		f.docs[doc.ID] = doc
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+func (f *fakeVectorStore) SearchByText(ctx context.Context, req TextSearchReq) ([]SearchRes, error) {
+	f.lastTopK = req.TopK
+	if f.searchErr != nil {
+		return nil, f.searchErr
+	}
+	query, err := f.embedding.Embed(ctx, req.Query)
+	if err != nil {
+		return nil, err
+	}
+	queryVec := toFloat64(query)
+
+	results := make([]SearchRes, 0, len(f.docs))
+	for _, doc := range f.docs {
+		score := cosineSimilarity(queryVec, toFloat64(doc.Embedding))
+		results = append(results, SearchRes{Document: doc, Score: float32(score)})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if req.TopK > 0 && len(results) > req.TopK {
+		results = results[:req.TopK]
+	}
+	return results, nil
+}
+
+func (f *fakeVectorStore) Delete(ctx context.Context, collection string, ids []string) error {
+	for _, id := range ids {
+		delete(f.docs, id)
+	}
+	return nil
+}
+
+func (f *fakeVectorStore) Count(ctx context.Context, collection string) (int64, error) {
+	return int64(len(f.docs)), nil
+}
+
+func (f *fakeVectorStore) Clear(ctx context.Context, collection string) error {
+	f.docs = make(map[string]VectorDoc)
+	return nil
+}
+
+func toFloat64(vec []float32) []float64 {
+	result := make([]float64, len(vec))
+	for i, v := range vec {
+		result[i] = float64(v)
+	}
+	return result
+}
+
+func storeTestFacts(t *testing.T, sm *SemanticMemoryImpl) {
+	t.Helper()
+	ctx := context.Background()
+	for _, content := range []string{
+		"User is vegan",
+		"User lives in Berlin",
+		"User prefers dark theme",
+	} {
+		require.NoError(t, sm.StoreFact(ctx, Fact{Content: content, Confidence: 0.9}))
+	}
+}
+
+// TestSemanticMemory_QueryKnowledgeEmbedding tests ranking by embedding similarity
+func TestSemanticMemory_QueryKnowledgeEmbedding(t *testing.T) {
+	embedding := &topicEmbedding{}
+	sm := NewSemanticMemoryWithConfig(SemanticMemoryConfig{Embedding: embedding})
+	storeTestFacts(t, sm)
+	ctx := context.Background()
+
+	facts, err := sm.ListFacts(ctx, "", 0)
+	require.NoError(t, err)
+	for _, fact := range facts {
+		assert.Len(t, fact.Embedding, 3, "facts should be embedded on store")
+	}
+
+	// No shared words with "User is vegan", only the topic matches
+	results, err := sm.QueryKnowledge(ctx, "what should I cook for dinner", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "User is vegan", results[0].Content)
+
+	results, err = sm.QueryKnowledge(ctx, "where do I live", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "User lives in Berlin", results[0].Content)
+
+	// Limit <= 0 returns everything
+	results, err = sm.QueryKnowledge(ctx, "anything", 0)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+}
+
+// TestSemanticMemory_UpdateReembeds tests that updates refresh embeddings
+func TestSemanticMemory_UpdateReembeds(t *testing.T) {
+	embedding := &topicEmbedding{}
+	sm := NewSemanticMemoryWithConfig(SemanticMemoryConfig{Embedding: embedding})
+	ctx := context.Background()
+
+	require.NoError(t, sm.StoreFact(ctx, Fact{ID: "f1", Content: "User prefers dark theme", Confidence: 0.9}))
+	facts, _ := sm.ListFacts(ctx, "", 0)
+	stored := facts[0]
+
+	// Unchanged content keeps the embedding
+	stored.Category = "preference"
+	require.NoError(t, sm.UpdateFact(ctx, "f1", stored))
+	assert.Equal(t, 1, embedding.calls)
+
+	stored.Content = "User moved to Paris"
+	require.NoError(t, sm.UpdateFact(ctx, "f1", stored))
+	assert.Equal(t, 2, embedding.calls)
+
+	results, err := sm.QueryKnowledge(ctx, "which city", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, []float64{0, 2, 0}, results[0].Embedding)
+}
+
+// TestSemanticMemory_RankingConfidenceRecency tests confidence and recency in ranking
+func TestSemanticMemory_RankingConfidenceRecency(t *testing.T) {
+	sm := NewSemanticMemory()
+	ctx := context.Background()
+
+	require.NoError(t, sm.StoreFact(ctx, Fact{ID: "low", Content: "User likes jazz", Confidence: 0.3}))
+	require.NoError(t, sm.StoreFact(ctx, Fact{ID: "high", Content: "User likes rock", Confidence: 0.95}))
+
+	results, err := sm.QueryKnowledge(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "high", results[0].ID)
+
+	// Without embeddings, keyword overlap still finds the right fact
+	results, err = sm.QueryKnowledge(ctx, "does the user like jazz", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "low", results[0].ID)
+
+	// Old facts lose to recent ones with equal similarity and confidence
+	recency := NewSemanticMemoryWithConfig(SemanticMemoryConfig{RecencyHalfLife: time.Hour})
+	now := time.Now()
+	recency.facts["old"] = Fact{ID: "old", Content: "User likes tea", Confidence: 0.8, UpdatedAt: now.Add(-48 * time.Hour)}
+	recency.facts["new"] = Fact{ID: "new", Content: "User likes coffee", Confidence: 0.8, UpdatedAt: now}
+	assert.Less(t, recency.recencyScore(recency.facts["old"], now), 0.01)
+
+	results, err = recency.QueryKnowledge(ctx, "", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "new", results[0].ID)
+}
+
+// TestSemanticMemory_EmbeddingErrors tests fallback when embedding fails
+func TestSemanticMemory_EmbeddingErrors(t *testing.T) {
+	embedding := &topicEmbedding{err: errors.New("embedding down")}
+	sm := NewSemanticMemoryWithConfig(SemanticMemoryConfig{Embedding: embedding})
+	ctx := context.Background()
+
+	require.NoError(t, sm.StoreFact(ctx, Fact{Content: "User lives in Berlin", Confidence: 0.9}))
+	require.NoError(t, sm.StoreFact(ctx, Fact{Content: "User is vegan", Confidence: 0.9}))
+
+	results, err := sm.QueryKnowledge(ctx, "lives in Berlin", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "User lives in Berlin", results[0].Content)
+	assert.Empty(t, results[0].Embedding)
+}
+
+// TestSemanticMemory_VectorStore tests vector store backing
+func TestSemanticMemory_VectorStore(t *testing.T) {
+	embedding := &topicEmbedding{}
+	store := newFakeVectorStore(embedding)
+	sm := NewSemanticMemoryWithConfig(SemanticMemoryConfig{
+		Embedding:   embedding,
+		VectorStore: store,
+	})
+	assert.Equal(t, "semantic_memory", sm.collection)
+	storeTestFacts(t, sm)
+	ctx := context.Background()
+
+	require.Len(t, store.docs, 3)
+	for _, doc := range store.docs {
+		assert.Len(t, doc.Embedding, 3)
+		assert.Equal(t, "fact", doc.Metadata["type"])
+	}
+
+	results, err := sm.QueryKnowledge(ctx, "plan a meal", 1)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "User is vegan", results[0].Content)
+
+	// Updates replace the stored document
+	fact := results[0]
+	fact.Content = "User eats fish"
+	require.NoError(t, sm.UpdateFact(ctx, fact.ID, fact))
+	require.Len(t, store.docs, 3)
+	assert.Equal(t, "User eats fish", store.docs[fact.ID].Content)
+
+	// Search failures fall back to in-memory ranking
+	store.searchErr = errors.New("unavailable")
+	results, err = sm.QueryKnowledge(ctx, "where do they live", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "User lives in Berlin", results[0].Content)
+
+	require.NoError(t, sm.DeleteFact(ctx, fact.ID))
+	assert.Len(t, store.docs, 2)
+
+	require.NoError(t, sm.Clear(ctx))
+	assert.Empty(t, store.docs)
+}
+
+// TestSemanticMemory_VectorStoreRanking tests that every fact is ranked by vector
+// similarity and that facts missing locally are loaded from the store
+func TestSemanticMemory_VectorStoreRanking(t *testing.T) {
+	embedding := &topicEmbedding{}
+	store := newFakeVectorStore(embedding)
+	config := SemanticMemoryConfig{Embedding: embedding, VectorStore: store}
+	ctx := context.Background()
+
+	sm := NewSemanticMemoryWithConfig(config)
+	storeTestFacts(t, sm)
+	require.NoError(t, sm.StoreFact(ctx, Fact{
+		Content:    "User likes jazz music",
+		Category:   "preference",
+		Source:     "chat",
+		Confidence: 0.5,
+		Metadata:   map[string]interface{}{"topic": "music"},
+	}))
+
+	// The jazz fact shares words with the query but not its topic
+	results, err := sm.QueryKnowledge(ctx, "User likes jazz music in Paris", 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 4, store.lastTopK, "every fact should be scored by the store")
+	assert.Equal(t, "User lives in Berlin", results[0].Content)
+	assert.NotEqual(t, "User likes jazz music", results[1].Content)
+
+	// A new instance backed by the same store finds the stored facts
+	restored := NewSemanticMemoryWithConfig(config)
+	results, err = restored.QueryKnowledge(ctx, "user likes jazz", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, 4, restored.Size())
+
+	facts, err := restored.ListFacts(ctx, "preference", 0)
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	jazz := facts[0]
+	assert.Equal(t, "User likes jazz music", jazz.Content)
+	assert.Equal(t, "chat", jazz.Source)
+	assert.Equal(t, 0.5, jazz.Confidence)
+	assert.Equal(t, map[string]interface{}{"topic": "music"}, jazz.Metadata)
+	assert.Len(t, jazz.Embedding, 3)
+}
+
+// TestMemorySetSemanticMemory tests replacing the semantic tier
+func TestMemorySetSemanticMemory(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.SemanticEnabled = true
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	sm := NewSemanticMemoryWithConfig(SemanticMemoryConfig{Embedding: &topicEmbedding{}})
+	mem.SetSemanticMemory(sm)
+	storeTestFacts(t, sm)
+
+	facts, err := mem.Facts(ctx, "dark or light ui?", 1)
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	assert.Equal(t, "User prefers dark theme", facts[0].Content)
+
+	assert.Equal(t, 3, mem.Stats(ctx).SemanticSize)
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion is the current version of the Snapshot format
+const SnapshotVersion = 1
+
+// Snapshot is a serializable copy of the whole memory system state.
+// It is used to persist hierarchical memory across restarts.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Working  []Message       `json:"working"`
+	Episodic []EpisodicEntry `json:"episodic"`
+	Facts    []Fact          `json:"facts"`
+
+	// Compression stats
+	CompressionCount int       `json:"compression_count"`
+	LastCompression  time.Time `json:"last_compression"`
+	TotalMessages    int       `json:"total_messages"`
+}
+
+// EpisodicEntry is an episodic message with its importance score
+type EpisodicEntry struct {
+	Message    Message `json:"message"`
+	Importance float64 `json:"importance"`
+	VectorID   string  `json:"vector_id,omitempty"` // Document ID in the vector store
+}
+
+// Snapshot captures the current state of all memory tiers
+func (m *Memory) Snapshot(ctx context.Context) (*Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := &Snapshot{
+		Version:          SnapshotVersion,
+		CreatedAt:        time.Now(),
+		CompressionCount: m.compressionCount,
+		LastCompression:  m.lastCompression,
+		TotalMessages:    m.totalMessages,
+	}
+
+	working, err := m.working.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot working memory: %w", err)
+	}
+	snapshot.Working = working
+
+	if episodic, ok := m.episodic.(*EpisodicMemoryImpl); ok {
+		episodic.mu.RLock()
+		snapshot.Episodic = make([]EpisodicEntry, len(episodic.messages))
+		for i, entry := range episodic.messages {
+			snapshot.Episodic[i] = EpisodicEntry{
+				Message:    entry.Message,
+				Importance: entry.Importance,
+				VectorID:   entry.vectorID,
+			}
+		}
+		episodic.mu.RUnlock()
+	}
+
+	facts, err := m.semantic.ListFacts(ctx, "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot semantic memory: %w", err)
+	}
+	snapshot.Facts = facts
+
+	return snapshot, nil
+}
+
+// Restore replaces the state of all memory tiers with a snapshot.
+// Vector stores are not written to, they are expected to persist on their own.
+func (m *Memory) Restore(ctx context.Context, snapshot *Snapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("snapshot cannot be nil")
+	}
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Working memory
+	if err := m.working.Clear(ctx); err != nil {
+		return fmt.Errorf("failed to clear working memory: %w", err)
+	}
+	for _, msg := range snapshot.Working {
+		if err := m.working.Add(ctx, msg); err != nil {
+			return fmt.Errorf("failed to restore working memory: %w", err)
+		}
+	}
+
+	// Episodic memory
+	if episodic, ok := m.episodic.(*EpisodicMemoryImpl); ok {
+		entries := make([]MessageWithImportance, len(snapshot.Episodic))
+		for i, entry := range snapshot.Episodic {
+			entries[i] = MessageWithImportance{
+				Message:    entry.Message,
+				Importance: entry.Importance,
+				vectorID:   entry.VectorID,
+			}
+		}
+		episodic.mu.Lock()
+		episodic.messages = entries
+		episodic.mu.Unlock()
+	} else {
+		for _, entry := range snapshot.Episodic {
+			if err := m.episodic.Store(ctx, entry.Message, entry.Importance); err != nil {
+				return fmt.Errorf("failed to restore episodic memory: %w", err)
+			}
+		}
+	}
+
+	// Semantic memory
+	if semantic, ok := m.semantic.(*SemanticMemoryImpl); ok {
+		// Keep IDs, timestamps and embeddings as they were
+		facts := make(map[string]Fact, len(snapshot.Facts))
+		for _, fact := range snapshot.Facts {
+			facts[fact.ID] = fact
+		}
+		semantic.mu.Lock()
+		semantic.facts = facts
+		semantic.mu.Unlock()
+	} else {
+		if err := m.semantic.Clear(ctx); err != nil {
+			return fmt.Errorf("failed to clear semantic memory: %w", err)
+		}
+		for _, fact := range snapshot.Facts {
+			if err := m.semantic.StoreFact(ctx, fact); err != nil {
+				return fmt.Errorf("failed to restore semantic memory: %w", err)
+			}
+		}
+	}
+
+	m.compressionCount = snapshot.CompressionCount
+	m.lastCompression = snapshot.LastCompression
+	m.totalMessages = snapshot.TotalMessages
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemorySnapshotRestore tests a JSON round trip of the full memory state
+func TestMemorySnapshotRestore(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	config.EpisodicThreshold = 0
+	config.SemanticEnabled = true
+	mem := NewWithConfig(config)
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: fmt.Sprintf("message %d", i)}))
+	}
+	require.NoError(t, mem.semantic.StoreFact(ctx, Fact{
+		ID:         "fact_1",
+		Content:    "User lives in Berlin",
+		Category:   "personal",
+		Confidence: 0.9,
+		Embedding:  []float64{0.1, 0.2},
+	}))
+
+	snapshot, err := mem.Snapshot(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, SnapshotVersion, snapshot.Version)
+
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	var decoded Snapshot
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	restored := NewWithConfig(config)
+	require.NoError(t, restored.Restore(ctx, &decoded))
+
+	before := mem.Stats(ctx)
+	after := restored.Stats(ctx)
+	assert.Equal(t, before.WorkingSize, after.WorkingSize)
+	assert.Equal(t, before.EpisodicSize, after.EpisodicSize)
+	assert.Equal(t, before.SemanticSize, after.SemanticSize)
+	assert.Equal(t, before.CompressionCount, after.CompressionCount)
+	assert.Equal(t, before.TotalMessages, after.TotalMessages)
+	assert.InDelta(t, before.AverageImportance, after.AverageImportance, 0.0001)
+
+	facts, err := restored.Facts(ctx, "", 0)
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	assert.Equal(t, "fact_1", facts[0].ID)
+	assert.Equal(t, []float64{0.1, 0.2}, facts[0].Embedding)
+
+	// The restored summary keeps counting compressed messages
+	wm := restored.working.(*WorkingMemoryImpl)
+	summary, ok := wm.Summary()
+	require.True(t, ok)
+	previousCount := int(summary.Metadata["compressed_count"].(float64))
+
+	for i := 6; i < 9; i++ {
+		require.NoError(t, restored.Add(ctx, Message{Role: "user", Content: fmt.Sprintf("message %d", i)}))
+	}
+	summary, ok = wm.Summary()
+	require.True(t, ok)
+	assert.Greater(t, summary.Metadata["compressed_count"].(int), previousCount)
+	assert.Equal(t, 1, countSummaries(t, wm))
+}
+
+// TestMemoryRestore_InvalidSnapshot tests snapshot validation
+func TestMemoryRestore_InvalidSnapshot(t *testing.T) {
+	mem := New()
+	ctx := context.Background()
+
+	assert.Error(t, mem.Restore(ctx, nil))
+	assert.Error(t, mem.Restore(ctx, &Snapshot{}))
+	assert.Error(t, mem.Restore(ctx, &Snapshot{Version: SnapshotVersion + 1}))
+}
+
+// TestMemoryRestore_ReplacesState tests that restore replaces instead of merging
+func TestMemoryRestore_ReplacesState(t *testing.T) {
+	mem := New()
+	ctx := context.Background()
+
+	require.NoError(t, mem.Add(ctx, Message{Role: "user", Content: "stale message", Timestamp: time.Now()}))
+
+	require.NoError(t, mem.Restore(ctx, &Snapshot{
+		Version: SnapshotVersion,
+		Working: []Message{{Role: "user", Content: "restored"}},
+		Episodic: []EpisodicEntry{
+			{Message: Message{Role: "user", Content: "important"}, Importance: 0.9},
+		},
+		TotalMessages: 42,
+	}))
+
+	all, err := mem.working.All(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "restored", all[0].Content)
+
+	stats := mem.Stats(ctx)
+	assert.Equal(t, 1, stats.EpisodicSize)
+	assert.Equal(t, 42, stats.TotalMessages)
+}
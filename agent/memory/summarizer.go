@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Summarizer produces rolling summaries for working memory compression.
+// Implementations receive the previous rolling summary (empty on first
+// compression) and the messages being evicted, and must return a single
+// summary that merges both, so summaries never stack up.
+type Summarizer interface {
+	Summarize(ctx context.Context, previousSummary string, messages []Message) (string, error)
+}
+
+// CompletionAdapter is an interface for plain text LLM completions
+// This prevents import cycles with the agent package
+type CompletionAdapter interface {
+	// Complete sends a system prompt and a user prompt and returns the model's text
+	Complete(ctx context.Context, systemPrompt, prompt string) (string, error)
+}
+
+const (
+	// DefaultSummaryMaxWords is the default target length for LLM summaries
+	DefaultSummaryMaxWords = 200
+
+	summarizerSystemPrompt = "You maintain a rolling summary of a conversation between a user and an assistant. " +
+		"Merge the new messages into the existing summary. Keep names, preferences, decisions, open questions " +
+		"and any facts the assistant will need later. Drop greetings and small talk. " +
+		"Reply with the updated summary only, written in the third person."
+)
+
+// LLMSummarizer is a Summarizer backed by an LLM.
+// It incrementally merges evicted messages into the previous summary.
+type LLMSummarizer struct {
+	llm      CompletionAdapter
+	maxWords int
+}
+
+// NewLLMSummarizer creates a summarizer that uses the given completion adapter
+func NewLLMSummarizer(llm CompletionAdapter) *LLMSummarizer {
+	return &LLMSummarizer{
+		llm:      llm,
+		maxWords: DefaultSummaryMaxWords,
+	}
+}
+
+// WithMaxWords sets the target maximum length of the summary in words
+func (s *LLMSummarizer) WithMaxWords(maxWords int) *LLMSummarizer {
+	if maxWords > 0 {
+		s.maxWords = maxWords
+	}
+	return s
+}
+
+// Summarize implements Summarizer.Summarize
+func (s *LLMSummarizer) Summarize(ctx context.Context, previousSummary string, messages []Message) (string, error) {
+	if s.llm == nil {
+		return "", fmt.Errorf("summarizer has no LLM configured")
+	}
+	if len(messages) == 0 {
+		return previousSummary, nil
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Existing summary:\n")
+	if previousSummary == "" {
+		prompt.WriteString("(none)\n")
+	} else {
+		prompt.WriteString(previousSummary)
+		prompt.WriteString("\n")
+	}
+
+	prompt.WriteString("\nNew messages:\n")
+	for _, msg := range messages {
+		prompt.WriteString(fmt.Sprintf("%s: %s\n", msg.Role, msg.Content))
+	}
+
+	prompt.WriteString(fmt.Sprintf("\nWrite the updated summary in at most %d words.", s.maxWords))
+
+	summary, err := s.llm.Complete(ctx, summarizerSystemPrompt, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("LLM summarization failed: %w", err)
+	}
+
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return "", fmt.Errorf("LLM summarization returned empty summary")
+	}
+
+	return summary, nil
+}
+
+// isSummary reports whether a message is a working memory summary
+func isSummary(msg Message) bool {
+	if msg.Metadata == nil {
+		return false
+	}
+	t, ok := msg.Metadata["type"].(string)
+	return ok && t == "summary"
+}
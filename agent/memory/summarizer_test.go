@@ -0,0 +1,209 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCompletion records prompts and returns canned completions
+type fakeCompletion struct {
+	prompts []string
+	err     error
+}
+
+func (f *fakeCompletion) Complete(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	if f.err != nil {
+		return "", f.err
+	}
+	return fmt.Sprintf("summary #%d", len(f.prompts)), nil
+}
+
+func addMessages(t *testing.T, mem *Memory, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		err := mem.Add(context.Background(), Message{Role: "user", Content: fmt.Sprintf("message %d", i)})
+		require.NoError(t, err)
+	}
+}
+
+func countSummaries(t *testing.T, wm *WorkingMemoryImpl) int {
+	t.Helper()
+	all, err := wm.All(context.Background())
+	require.NoError(t, err)
+	count := 0
+	for _, msg := range all {
+		if isSummary(msg) {
+			count++
+		}
+	}
+	return count
+}
+
+// TestLLMSummarizer_Prompt tests prompt construction for incremental merging
+func TestLLMSummarizer_Prompt(t *testing.T) {
+	llm := &fakeCompletion{}
+	summarizer := NewLLMSummarizer(llm).WithMaxWords(50)
+
+	summary, err := summarizer.Summarize(context.Background(), "User is Alice", []Message{
+		{Role: "user", Content: "I need a refund"},
+		{Role: "assistant", Content: "Sure, what is the order number?"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "summary #1", summary)
+
+	require.Len(t, llm.prompts, 1)
+	prompt := llm.prompts[0]
+	assert.Contains(t, prompt, "Existing summary:\nUser is Alice")
+	assert.Contains(t, prompt, "user: I need a refund")
+	assert.Contains(t, prompt, "assistant: Sure, what is the order number?")
+	assert.Contains(t, prompt, "at most 50 words")
+}
+
+// TestLLMSummarizer_Errors tests error handling
+func TestLLMSummarizer_Errors(t *testing.T) {
+	ctx := context.Background()
+	msgs := []Message{{Role: "user", Content: "hi"}}
+
+	_, err := NewLLMSummarizer(nil).Summarize(ctx, "", msgs)
+	assert.Error(t, err)
+
+	_, err = NewLLMSummarizer(&fakeCompletion{err: errors.New("boom")}).Summarize(ctx, "", msgs)
+	assert.ErrorContains(t, err, "boom")
+
+	// Nothing to merge returns the previous summary unchanged
+	summary, err := NewLLMSummarizer(&fakeCompletion{}).Summarize(ctx, "previous", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", summary)
+}
+
+// TestMemorySummarizer_RollingSummary tests that summaries are merged, not stacked
+func TestMemorySummarizer_RollingSummary(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 6
+	config.SummarizationMode = "llm"
+	mem := NewWithConfig(config)
+
+	llm := &fakeCompletion{}
+	mem.SetSummarizer(NewLLMSummarizer(llm))
+
+	addMessages(t, mem, 0, 20)
+
+	wm := mem.working.(*WorkingMemoryImpl)
+	assert.Equal(t, 1, countSummaries(t, wm), "summaries should not stack")
+	assert.LessOrEqual(t, wm.Size(), config.WorkingCapacity)
+
+	summary, ok := wm.Summary()
+	require.True(t, ok)
+	assert.Equal(t, "llm", summary.Metadata["summarizer"])
+	assert.Equal(t, fmt.Sprintf("summary #%d", len(llm.prompts)), mem.Summary(context.Background()))
+
+	// Each compression after the first merges into the previous summary
+	require.Greater(t, len(llm.prompts), 1)
+	assert.Contains(t, llm.prompts[0], "Existing summary:\n(none)")
+	for i := 1; i < len(llm.prompts); i++ {
+		assert.Contains(t, llm.prompts[i], fmt.Sprintf("Existing summary:\nsummary #%d", i))
+	}
+
+	// Oldest messages were handed to the summarizer, not silently dropped
+	assert.Contains(t, llm.prompts[0], "message 0")
+	assert.Equal(t, mem.Stats(context.Background()).CompressionCount, len(llm.prompts))
+}
+
+// TestMemorySummarizer_Fallback tests fallback when the LLM fails
+func TestMemorySummarizer_Fallback(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	mem := NewWithConfig(config)
+
+	llm := &fakeCompletion{}
+	mem.SetSummarizer(NewLLMSummarizer(llm))
+	addMessages(t, mem, 0, 5)
+	require.Equal(t, "summary #1", mem.Summary(context.Background()))
+
+	// A failing LLM keeps the previous summary instead of discarding it
+	llm.err = errors.New("unavailable")
+	addMessages(t, mem, 5, 10)
+
+	wm := mem.working.(*WorkingMemoryImpl)
+	summary, ok := wm.Summary()
+	require.True(t, ok)
+	assert.True(t, strings.HasPrefix(summary.Content, "summary #1"))
+	assert.Contains(t, summary.Metadata["summary_error"], "unavailable")
+	assert.Equal(t, 1, countSummaries(t, wm))
+}
+
+// TestMemorySummarizer_None tests that simple summaries are not exposed
+func TestMemorySummarizer_None(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	mem := NewWithConfig(config)
+
+	addMessages(t, mem, 0, 10)
+
+	wm := mem.working.(*WorkingMemoryImpl)
+	summary, ok := wm.Summary()
+	require.True(t, ok)
+	assert.Contains(t, summary.Content, "older messages]")
+	assert.Equal(t, 1, countSummaries(t, wm))
+	assert.Empty(t, mem.Summary(context.Background()))
+}
+
+// blockingSummarizer signals when it starts and waits for release before answering
+type blockingSummarizer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingSummarizer) Summarize(ctx context.Context, previousSummary string, messages []Message) (string, error) {
+	close(b.started)
+	<-b.release
+	return fmt.Sprintf("summary of %d messages", len(messages)), nil
+}
+
+// TestMemorySummarizer_Unlocked tests that memory stays usable while the summarizer runs
+func TestMemorySummarizer_Unlocked(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.WorkingCapacity = 4
+	config.AutoCompress = false
+	config.SummarizationMode = "llm"
+	mem := NewWithConfig(config)
+	addMessages(t, mem, 0, 4)
+
+	summarizer := &blockingSummarizer{started: make(chan struct{}), release: make(chan struct{})}
+	mem.SetSummarizer(summarizer)
+	done := make(chan error, 1)
+	go func() { done <- mem.Compress(context.Background()) }()
+	<-summarizer.started
+
+	// Reads and writes proceed during the model call; the write evicts message 0
+	accessed := make(chan struct{})
+	go func() {
+		defer close(accessed)
+		mem.Stats(context.Background())
+		addMessages(t, mem, 4, 5)
+	}()
+	select {
+	case <-accessed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("memory was locked while summarizing")
+	}
+
+	close(summarizer.release)
+	require.NoError(t, <-done)
+
+	all, err := mem.working.All(context.Background())
+	require.NoError(t, err)
+	contents := make([]string, len(all))
+	for i, msg := range all {
+		contents[i] = msg.Content
+	}
+	assert.Equal(t, []string{"summary of 2 messages", "message 2", "message 3", "message 4"}, contents)
+}
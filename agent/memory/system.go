@@ -15,6 +15,14 @@ type Memory struct {
 	semantic SemanticMemory
 	config   MemoryConfig
 
+	// extractor extracts facts into semantic memory when SemanticAutoLearn is enabled
+	extractor FactExtractor
+
+	// Background compactor state (see Start/Stop)
+	compactorCancel context.CancelFunc
+	compactorDone   chan struct{}
+	lastDecay       time.Time
+
 	// Stats tracking
 	compressionCount int
 	lastCompression  time.Time
@@ -92,11 +100,122 @@ func (m *Memory) Add(ctx context.Context, msg Message) error {
 		}
 	}
 
+	learn := msg.Role == "assistant" && m.config.SemanticEnabled && m.config.SemanticAutoLearn && m.extractor != nil
 	m.mu.Unlock()
 
+	// Learn facts from the completed turn (user message + assistant reply).
+	// This runs synchronously, so Add waits for the extractor.
+	if learn {
+		turn, err := m.working.Recent(ctx, 2)
+		if err == nil {
+			if err := m.LearnFacts(ctx, turn); err != nil {
+				// Don't fail, fact extraction is optional
+				_ = err
+			}
+		}
+	}
+
+	return nil
+}
+
+// maxFactsForExtraction caps how many existing facts are sent to the extractor
+const maxFactsForExtraction = 100
+
+// LearnFacts extracts facts from messages with the configured FactExtractor
+// and reconciles them with semantic memory (add, update, merge or delete).
+func (m *Memory) LearnFacts(ctx context.Context, messages []Message) error {
+	m.mu.RLock()
+	extractor := m.extractor
+	m.mu.RUnlock()
+
+	if extractor == nil {
+		return fmt.Errorf("no fact extractor configured")
+	}
+
+	conversation := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if !isSummary(msg) {
+			conversation = append(conversation, msg)
+		}
+	}
+	if len(conversation) == 0 {
+		return nil
+	}
+
+	existing, err := m.semantic.ListFacts(ctx, "", maxFactsForExtraction)
+	if err != nil {
+		return fmt.Errorf("failed to list facts: %w", err)
+	}
+
+	operations, err := extractor.Extract(ctx, conversation, existing)
+	if err != nil {
+		return err
+	}
+
+	existingByID := make(map[string]Fact, len(existing))
+	for _, fact := range existing {
+		existingByID[fact.ID] = fact
+	}
+
+	for _, op := range operations {
+		switch op.Action {
+		case FactActionAdd:
+			if err := m.semantic.StoreFact(ctx, op.Fact); err != nil {
+				return fmt.Errorf("failed to store fact: %w", err)
+			}
+		case FactActionUpdate:
+			fact, ok := existingByID[op.FactID]
+			if !ok {
+				continue
+			}
+			fact.Content = op.Fact.Content
+			fact.Category = op.Fact.Category
+			fact.Source = op.Fact.Source
+			fact.Confidence = op.Fact.Confidence
+			if err := m.semantic.UpdateFact(ctx, op.FactID, fact); err != nil {
+				return fmt.Errorf("failed to update fact: %w", err)
+			}
+		case FactActionDelete:
+			if err := m.semantic.DeleteFact(ctx, op.FactID); err != nil {
+				return fmt.Errorf("failed to delete fact: %w", err)
+			}
+		}
+	}
+
 	return nil
 }
 
+// SetSemanticMemory replaces the semantic memory tier, e.g. with one created by
+// NewSemanticMemoryWithConfig for embedding search or vector store backing.
+// Facts stored in the previous semantic memory are not carried over.
+func (m *Memory) SetSemanticMemory(semantic SemanticMemory) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if semantic != nil {
+		m.semantic = semantic
+	}
+}
+
+// SetFactExtractor sets the extractor used when SemanticAutoLearn is enabled
+func (m *Memory) SetFactExtractor(extractor FactExtractor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.extractor = extractor
+}
+
+// Facts returns facts from semantic memory relevant to the query
+func (m *Memory) Facts(ctx context.Context, query string, limit int) ([]Fact, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.config.SemanticEnabled {
+		return []Fact{}, nil
+	}
+	return m.semantic.QueryKnowledge(ctx, query, limit)
+}
+
 // Recall implements MemorySystem.Recall
 // Retrieves relevant messages from all memory tiers
 func (m *Memory) Recall(ctx context.Context, query string, opts RecallOptions) ([]Message, error) {
@@ -172,19 +291,25 @@ func (m *Memory) Recall(ctx context.Context, query string, opts RecallOptions) (
 // Compress implements MemorySystem.Compress
 // Compresses working memory by summarizing old messages
 func (m *Memory) Compress(ctx context.Context) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.mu.RLock()
+	mode := m.config.SummarizationMode
+	working := m.working
+	m.mu.RUnlock()
 
-	if m.config.SummarizationMode == "none" {
+	if mode == "none" {
 		return nil
 	}
 
-	// Trigger compression on working memory
-	summary, compressed, err := m.working.Compress(ctx)
+	// Trigger compression on working memory. The summarizer may call an LLM,
+	// so the lock is only taken again to record the result.
+	summary, compressed, err := working.Compress(ctx)
 	if err != nil {
 		return fmt.Errorf("compression failed: %w", err)
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// Store compressed messages in episodic if enabled
 	if m.config.EpisodicEnabled && len(compressed) > 0 {
 		importances := make([]float64, len(compressed))
@@ -202,10 +327,13 @@ func (m *Memory) Compress(ctx context.Context) error {
 		}
 	}
 
-	// Add summary back to working memory if it'm not empty
+	// The summary stays pinned at the head of working memory (see WorkingMemoryImpl.Compress).
+	// Custom working memory implementations get it appended instead.
 	if summary.Content != "" {
-		if err := m.working.Add(ctx, summary); err != nil {
-			return fmt.Errorf("failed to add summary: %w", err)
+		if _, ok := working.(*WorkingMemoryImpl); !ok {
+			if err := working.Add(ctx, summary); err != nil {
+				return fmt.Errorf("failed to add summary: %w", err)
+			}
 		}
 	}
 
@@ -215,6 +343,31 @@ func (m *Memory) Compress(ctx context.Context) error {
 	return nil
 }
 
+// SetSummarizer sets the summarizer used to compress working memory.
+// Used with SummarizationMode "llm"; pass nil to restore simple summaries.
+func (m *Memory) SetSummarizer(summarizer Summarizer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if wm, ok := m.working.(*WorkingMemoryImpl); ok {
+		wm.SetSummarizer(summarizer)
+	}
+}
+
+// Summary returns the current rolling summary produced by the Summarizer.
+// Returns an empty string if nothing has been summarized yet.
+func (m *Memory) Summary(ctx context.Context) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if wm, ok := m.working.(*WorkingMemoryImpl); ok {
+		if summary, found := wm.Summary(); found && summary.Metadata["summarizer"] != nil {
+			return summary.Content
+		}
+	}
+	return ""
+}
+
 // Clear implements MemorySystem.Clear
 func (m *Memory) Clear(ctx context.Context) error {
 	m.mu.Lock()
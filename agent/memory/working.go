@@ -10,9 +10,11 @@ import (
 // WorkingMemoryImpl implements the WorkingMemory interface
 // Uses FIFO with importance-based retention
 type WorkingMemoryImpl struct {
-	messages []Message
-	capacity int
-	mu       sync.RWMutex
+	messages   []Message
+	capacity   int
+	summarizer Summarizer // Optional; nil uses simple count-based summaries
+	generation int        // Incremented when messages are cleared or compressed
+	mu         sync.RWMutex
 }
 
 // NewWorkingMemory creates a new working memory with specified capacity
@@ -38,8 +40,12 @@ func (w *WorkingMemoryImpl) Add(ctx context.Context, msg Message) error {
 	}
 
 	// Enforce capacity limit using FIFO eviction
-	// Remove oldest messages if at capacity
+	// Remove oldest messages if at capacity, keeping the rolling summary pinned
 	for len(w.messages) >= w.capacity {
+		if len(w.messages) > 1 && isSummary(w.messages[0]) {
+			w.messages = append(w.messages[:1], w.messages[2:]...)
+			continue
+		}
 		w.messages = w.messages[1:] // Remove oldest (FIFO)
 	}
 
@@ -86,36 +92,125 @@ func (w *WorkingMemoryImpl) Clear(ctx context.Context) error {
 	defer w.mu.Unlock()
 
 	w.messages = make([]Message, 0, w.capacity)
+	w.generation++
 	return nil
 }
 
 // Compress implements WorkingMemory.Compress
-// Summarizes old messages when capacity is exceeded
+// Summarizes the oldest half of working memory once capacity is reached.
+// The summary is kept pinned at the head of working memory and merged with
+// the previous summary on subsequent compressions instead of stacking.
+//
+// The summarizer runs without the lock held, so that reads and writes are not
+// blocked for the whole model call. Messages added meanwhile are kept; the
+// summary is dropped if working memory was cleared or compressed meanwhile.
 func (w *WorkingMemoryImpl) Compress(ctx context.Context) (Message, []Message, error) {
+	w.mu.RLock()
+	previous, toCompress := w.compressible()
+	summarizer := w.summarizer
+	generation := w.generation
+	w.mu.RUnlock()
+
+	if len(toCompress) == 0 {
+		// No compression needed
+		return Message{}, []Message{}, nil
+	}
+
+	summary := createSummary(ctx, summarizer, previous, toCompress)
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if len(w.messages) <= w.capacity {
-		// No compression needed
+	if w.generation != generation {
 		return Message{}, []Message{}, nil
 	}
 
-	// Calculate how many messages to compress
-	excess := len(w.messages) - w.capacity
+	// Rebuild working memory: summary first, then the newer messages.
+	// Messages being compressed may have been evicted by Add meanwhile.
+	regular := w.messages
+	if previous != nil {
+		regular = regular[1:]
+	}
+	regular = regular[compressedPrefix(regular, toCompress):]
+
+	remaining := make([]Message, 0, w.capacity)
+	remaining = append(remaining, summary)
+	remaining = append(remaining, regular...)
+	w.messages = remaining
+	w.generation++
+
+	return summary, toCompress, nil
+}
+
+// compressible returns the previous rolling summary, if any, and a copy of the
+// messages to compress, none if working memory is below capacity
+func (w *WorkingMemoryImpl) compressible() (*Message, []Message) {
+	if len(w.messages) < w.capacity {
+		return nil, nil
+	}
+
+	// Separate the previous rolling summary from regular messages
+	var previous *Message
+	regular := w.messages
+	if len(regular) > 0 && isSummary(regular[0]) {
+		summary := regular[0]
+		previous = &summary
+		regular = regular[1:]
+	}
+
+	// Keep the newest half of the capacity, compress the rest
+	keep := w.capacity / 2
+	if keep < 1 {
+		keep = 1
+	}
+	excess := len(regular) - keep
 	if excess <= 0 {
-		return Message{}, []Message{}, nil
+		return nil, nil
 	}
 
-	// Take oldest messages to compress
-	toCompress := w.messages[:excess]
+	toCompress := make([]Message, excess)
+	copy(toCompress, regular[:excess])
+	return previous, toCompress
+}
 
-	// Create simple summary
-	summary := w.createSummary(toCompress)
+// compressedPrefix returns how many messages at the head of regular are among
+// the compressed ones. Add evicts the oldest messages first, so regular starts
+// with a suffix of compressed, or with none of them.
+func compressedPrefix(regular, compressed []Message) int {
+	if len(regular) == 0 {
+		return 0
+	}
+	for i, msg := range compressed {
+		if sameMessage(regular[0], msg) {
+			return min(len(compressed)-i, len(regular))
+		}
+	}
+	return 0
+}
 
-	// Keep newer messages
-	w.messages = w.messages[excess:]
+// sameMessage reports whether two messages are the same entry of working memory
+func sameMessage(a, b Message) bool {
+	return a.Role == b.Role && a.Content == b.Content && a.Timestamp.Equal(b.Timestamp)
+}
 
-	return summary, toCompress, nil
+// SetSummarizer sets the summarizer used during compression.
+// Pass nil to restore simple count-based summaries.
+func (w *WorkingMemoryImpl) SetSummarizer(summarizer Summarizer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.summarizer = summarizer
+}
+
+// Summary returns the current rolling summary, if any
+func (w *WorkingMemoryImpl) Summary() (Message, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if len(w.messages) > 0 && isSummary(w.messages[0]) {
+		return w.messages[0], true
+	}
+	return Message{}, false
 }
 
 // Size implements WorkingMemory.Size
@@ -131,24 +226,63 @@ func (w *WorkingMemoryImpl) Capacity() int {
 	return w.capacity
 }
 
-// createSummary creates a simple summary message from multiple messages
-func (w *WorkingMemoryImpl) createSummary(messages []Message) Message {
-	if len(messages) == 0 {
-		return Message{}
+// createSummary creates a summary message from the previous summary and the
+// messages being compressed. Uses the summarizer when available and falls
+// back to a simple count-based summary if it is missing or fails.
+func createSummary(ctx context.Context, summarizer Summarizer, previous *Message, messages []Message) Message {
+	compressedCount := len(messages)
+	compressedFrom := messages[0].Timestamp
+	previousContent := ""
+	if previous != nil {
+		previousContent = previous.Content
+		// Metadata restored from a JSON snapshot holds float64 and string values
+		switch count := previous.Metadata["compressed_count"].(type) {
+		case int:
+			compressedCount += count
+		case float64:
+			compressedCount += int(count)
+		}
+		switch from := previous.Metadata["compressed_from"].(type) {
+		case time.Time:
+			if !from.IsZero() {
+				compressedFrom = from
+			}
+		case string:
+			if parsed, err := time.Parse(time.RFC3339Nano, from); err == nil {
+				compressedFrom = parsed
+			}
+		}
+	}
+
+	metadata := map[string]interface{}{
+		"type":             "summary",
+		"compressed_count": compressedCount,
+		"compressed_from":  compressedFrom,
+		"compressed_to":    messages[len(messages)-1].Timestamp,
 	}
 
 	// Simple summarization: indicate how many messages were compressed
-	summary := Message{
+	content := fmt.Sprintf("[Compressed %d older messages]", compressedCount)
+
+	if summarizer != nil {
+		summarized, err := summarizer.Summarize(ctx, previousContent, messages)
+		if err == nil {
+			content = summarized
+			metadata["summarizer"] = "llm"
+		} else {
+			// Keep what we already know rather than losing the previous summary
+			metadata["summary_error"] = err.Error()
+			if previous != nil && previous.Metadata["summarizer"] == "llm" {
+				content = fmt.Sprintf("%s\n[Compressed %d more messages]", previousContent, len(messages))
+				metadata["summarizer"] = "llm"
+			}
+		}
+	}
+
+	return Message{
 		Role:      "system",
-		Content:   fmt.Sprintf("[Compressed %d older messages]", len(messages)),
+		Content:   content,
 		Timestamp: time.Now(),
-		Metadata: map[string]interface{}{
-			"type":             "summary",
-			"compressed_count": len(messages),
-			"compressed_from":  messages[0].Timestamp,
-			"compressed_to":    messages[len(messages)-1].Timestamp,
-		},
+		Metadata:  metadata,
 	}
-
-	return summary
 }
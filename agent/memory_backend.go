@@ -7,6 +7,8 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+
+	"github.com/taipm/go-deep-agent/agent/memory"
 )
 
 // MemoryBackend defines the interface for long-term memory persistence backends.
@@ -38,6 +40,18 @@ type MemoryBackend interface {
 	List(ctx context.Context) ([]string, error)
 }
 
+// MemorySnapshotBackend is implemented by backends that can also persist the full
+// hierarchical memory state (working, episodic, semantic and compression stats).
+// WithLongMemory uses it automatically when the backend supports it.
+type MemorySnapshotBackend interface {
+	// LoadSnapshot retrieves the memory snapshot for a given memory ID.
+	// Returns nil if no snapshot exists (first time).
+	LoadSnapshot(ctx context.Context, memoryID string) (*memory.Snapshot, error)
+
+	// SaveSnapshot stores the memory snapshot for a given memory ID.
+	SaveSnapshot(ctx context.Context, memoryID string, snapshot *memory.Snapshot) error
+}
+
 // FileBackend implements MemoryBackend using local file storage.
 // It stores long-term memories as JSON files in a configurable directory.
 // Default path: ~/.go-deep-agent/memories/
@@ -183,6 +197,78 @@ func (f *FileBackend) Delete(ctx context.Context, memoryID string) error {
 		return fmt.Errorf("failed to delete memory file: %w", err)
 	}
 
+	// Remove snapshot file (ignore NotExist error)
+	if err := os.Remove(f.getSnapshotPath(memoryID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete memory snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot retrieves the hierarchical memory snapshot from a file.
+//
+// Returns:
+//   - nil, nil if snapshot file doesn't exist (first time)
+//   - snapshot, nil if successfully loaded
+//   - nil, error if file read or JSON parsing fails
+func (f *FileBackend) LoadSnapshot(ctx context.Context, memoryID string) (*memory.Snapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Validate memory ID
+	if memoryID == "" {
+		return nil, fmt.Errorf("memory ID cannot be empty")
+	}
+
+	data, err := os.ReadFile(f.getSnapshotPath(memoryID))
+	if os.IsNotExist(err) {
+		// Snapshot doesn't exist yet - this is normal for first time
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory snapshot file: %w", err)
+	}
+
+	var snapshot memory.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse memory snapshot JSON: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// SaveSnapshot stores the hierarchical memory snapshot to a file.
+//
+// Uses the same atomic write strategy as Save.
+func (f *FileBackend) SaveSnapshot(ctx context.Context, memoryID string, snapshot *memory.Snapshot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Validate memory ID
+	if memoryID == "" {
+		return fmt.Errorf("memory ID cannot be empty")
+	}
+	if snapshot == nil {
+		return fmt.Errorf("snapshot cannot be nil")
+	}
+
+	filePath := f.getSnapshotPath(memoryID)
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory snapshot to JSON: %w", err)
+	}
+
+	// Write atomically: temp file + rename
+	tempPath := filePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp memory snapshot file: %w", err)
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename temp memory snapshot file: %w", err)
+	}
+
 	return nil
 }
 
@@ -228,6 +314,12 @@ func (f *FileBackend) getFilePath(memoryID string) string {
 	return filepath.Join(f.basePath, memoryID+".json")
 }
 
+// getSnapshotPath constructs the full file path for a memory snapshot.
+// Uses a different extension so snapshots don't show up in List.
+func (f *FileBackend) getSnapshotPath(memoryID string) string {
+	return filepath.Join(f.basePath, memoryID+".snapshot")
+}
+
 // GetBasePath returns the base directory path used for memory storage.
 // Useful for debugging and testing.
 func (f *FileBackend) GetBasePath() string {
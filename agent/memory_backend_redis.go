@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/taipm/go-deep-agent/agent/memory"
 )
 
 // RedisBackend stores long-term memories (conversation history) in Redis.
@@ -371,8 +373,8 @@ func (r *RedisBackend) Delete(ctx context.Context, memoryID string) error {
 	// Construct Redis key
 	key := r.prefix + memoryID
 
-	// Delete from Redis (ignore NotFound)
-	if err := r.client.Del(ctx, key).Err(); err != nil {
+	// Delete history and snapshot from Redis (ignore NotFound)
+	if err := r.client.Del(ctx, key, key+redisSnapshotSuffix).Err(); err != nil {
 		return fmt.Errorf("failed to delete memory from Redis: %w", err)
 	}
 
@@ -391,6 +393,11 @@ func (r *RedisBackend) List(ctx context.Context) ([]string, error) {
 	iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
 		key := iter.Val()
+		// Skip snapshot keys, they belong to a memory ID
+		if strings.HasSuffix(key, redisSnapshotSuffix) {
+			continue
+		}
+
 		// Extract memory ID by removing prefix
 		if len(key) > len(r.prefix) {
 			memoryID := key[len(r.prefix):]
@@ -405,6 +412,60 @@ func (r *RedisBackend) List(ctx context.Context) ([]string, error) {
 	return memoryIDs, nil
 }
 
+// redisSnapshotSuffix is appended to a memory key to store its snapshot
+const redisSnapshotSuffix = ":snapshot"
+
+// LoadSnapshot retrieves the hierarchical memory snapshot from Redis.
+//
+// Returns:
+//   - nil, nil if snapshot doesn't exist (first time)
+//   - snapshot, nil if successfully loaded
+//   - nil, error if Redis operation fails
+func (r *RedisBackend) LoadSnapshot(ctx context.Context, memoryID string) (*memory.Snapshot, error) {
+	// Validate memory ID
+	if memoryID == "" {
+		return nil, fmt.Errorf("memory ID cannot be empty")
+	}
+
+	data, err := r.client.Get(ctx, r.prefix+memoryID+redisSnapshotSuffix).Result()
+	if err == redis.Nil {
+		// Key doesn't exist - this is normal for first time
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory snapshot from Redis: %w", err)
+	}
+
+	var snapshot memory.Snapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse memory snapshot JSON: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// SaveSnapshot stores the hierarchical memory snapshot to Redis with the same TTL as history.
+func (r *RedisBackend) SaveSnapshot(ctx context.Context, memoryID string, snapshot *memory.Snapshot) error {
+	// Validate memory ID
+	if memoryID == "" {
+		return fmt.Errorf("memory ID cannot be empty")
+	}
+	if snapshot == nil {
+		return fmt.Errorf("snapshot cannot be nil")
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory snapshot to JSON: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.prefix+memoryID+redisSnapshotSuffix, data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save memory snapshot to Redis: %w", err)
+	}
+
+	return nil
+}
+
 // Ping checks if Redis connection is healthy.
 //
 // Returns nil if connection is OK, error otherwise.
@@ -9,6 +9,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/taipm/go-deep-agent/agent/memory"
 )
 
 // setupTestRedis creates a miniredis server for testing
@@ -390,3 +391,49 @@ func TestRedisBackend_Integration_WithBuilder(t *testing.T) {
 	assert.Equal(t, "What's the weather?", builder2.messages[0].Content)
 	assert.Equal(t, "It's sunny today.", builder2.messages[1].Content)
 }
+
+func TestRedisBackend_Snapshot(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	backend := NewRedisBackend(mr.Addr()).WithTTL(time.Hour)
+	ctx := context.Background()
+	memoryID := "user-snapshot"
+
+	// Missing snapshot is not an error
+	snapshot, err := backend.LoadSnapshot(ctx, memoryID)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	require.NoError(t, backend.Save(ctx, memoryID, []Message{User("Hello")}))
+	require.NoError(t, backend.SaveSnapshot(ctx, memoryID, &memory.Snapshot{
+		Version: memory.SnapshotVersion,
+		Episodic: []memory.EpisodicEntry{
+			{Message: memory.Message{Role: "user", Content: "important"}, Importance: 0.9},
+		},
+	}))
+
+	snapshot, err = backend.LoadSnapshot(ctx, memoryID)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	require.Len(t, snapshot.Episodic, 1)
+	assert.Equal(t, 0.9, snapshot.Episodic[0].Importance)
+
+	// Same TTL as the conversation history
+	assert.Equal(t, time.Hour, mr.TTL("go-deep-agent:memories:"+memoryID+":snapshot"))
+
+	// Snapshot keys are not listed as memories
+	memoryIDs, err := backend.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{memoryID}, memoryIDs)
+
+	// Delete removes both keys
+	require.NoError(t, backend.Delete(ctx, memoryID))
+	snapshot, err = backend.LoadSnapshot(ctx, memoryID)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	assert.Error(t, backend.SaveSnapshot(ctx, "", &memory.Snapshot{}))
+	assert.Error(t, backend.SaveSnapshot(ctx, memoryID, nil))
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+
+	"github.com/taipm/go-deep-agent/agent/memory"
 )
 
 // Test NewFileBackend with default path
@@ -470,6 +472,129 @@ func TestFileBackend_CorruptedJSON(t *testing.T) {
 	}
 }
 
+// Test SaveSnapshot and LoadSnapshot round trip
+func TestFileBackend_Snapshot(t *testing.T) {
+	tempDir := t.TempDir()
+	backend, err := NewFileBackend(tempDir)
+	if err != nil {
+		t.Fatalf("NewFileBackend failed: %v", err)
+	}
+
+	ctx := context.Background()
+	sessionID := "snapshot-session"
+
+	// Missing snapshot is not an error
+	snapshot, err := backend.LoadSnapshot(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+	if snapshot != nil {
+		t.Error("Expected nil snapshot for new session")
+	}
+
+	err = backend.Save(ctx, sessionID, []Message{User("Hello")})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	err = backend.SaveSnapshot(ctx, sessionID, &memory.Snapshot{
+		Version:       memory.SnapshotVersion,
+		Facts:         []memory.Fact{{ID: "fact_1", Content: "User lives in Berlin"}},
+		TotalMessages: 7,
+	})
+	if err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	snapshot, err = backend.LoadSnapshot(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+	if snapshot == nil || len(snapshot.Facts) != 1 || snapshot.TotalMessages != 7 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+
+	// Snapshots don't show up as separate memories
+	ids, err := backend.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != sessionID {
+		t.Errorf("Expected only %s in list, got %v", sessionID, ids)
+	}
+
+	// Delete removes the snapshot too
+	if err := backend.Delete(ctx, sessionID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	snapshot, err = backend.LoadSnapshot(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+	if snapshot != nil {
+		t.Error("Expected snapshot to be deleted")
+	}
+
+	if err := backend.SaveSnapshot(ctx, "", &memory.Snapshot{}); err == nil {
+		t.Error("Expected error for empty memory ID")
+	}
+	if err := backend.SaveSnapshot(ctx, sessionID, nil); err == nil {
+		t.Error("Expected error for nil snapshot")
+	}
+}
+
+// Test that hierarchical memory survives a restart through the builder
+func TestFileBackend_HierarchicalMemoryPersistence(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileBackend failed: %v", err)
+	}
+
+	ctx := context.Background()
+	config := memory.DefaultMemoryConfig()
+	config.SemanticEnabled = true
+
+	builder := NewOpenAI("gpt-4o-mini", "test-key").
+		UsingBackend(backend).
+		WithHierarchicalMemory(config).
+		WithLongMemory("user-restart")
+
+	semantic := memory.NewSemanticMemory()
+	builder.memory.SetSemanticMemory(semantic)
+	if err := semantic.StoreFact(ctx, memory.Fact{ID: "fact_1", Content: "User is vegan", Confidence: 0.9}); err != nil {
+		t.Fatalf("StoreFact failed: %v", err)
+	}
+	if err := builder.memory.Add(ctx, memory.Message{Role: "user", Content: "Remember: my birthday is important!"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	before := builder.memory.Stats(ctx)
+
+	if err := builder.SaveLongMemory(ctx); err != nil {
+		t.Fatalf("SaveLongMemory failed: %v", err)
+	}
+
+	// A new builder with the same ID restores everything on creation
+	restored := NewOpenAI("gpt-4o-mini", "test-key").
+		UsingBackend(backend).
+		WithHierarchicalMemory(config).
+		WithLongMemory("user-restart")
+
+	after := restored.memory.Stats(ctx)
+	if after.WorkingSize != before.WorkingSize || after.EpisodicSize != before.EpisodicSize {
+		t.Errorf("Expected stats %+v, got %+v", before, after)
+	}
+	if after.TotalMessages != before.TotalMessages {
+		t.Errorf("Expected %d total messages, got %d", before.TotalMessages, after.TotalMessages)
+	}
+
+	facts, err := restored.memory.Facts(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("Facts failed: %v", err)
+	}
+	if len(facts) != 1 || facts[0].Content != "User is vegan" {
+		t.Errorf("Expected restored fact, got %+v", facts)
+	}
+}
+
 // Helper function for string contains check
 func containsSubstring(s, substr string) bool {
 	for i := 0; i <= len(s)-len(substr); i++ {
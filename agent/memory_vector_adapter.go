@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/taipm/go-deep-agent/agent/memory"
+)
+
+// memoryVectorStoreAdapter adapts a VectorStore to memory.VectorStoreAdapter
+type memoryVectorStoreAdapter struct {
+	store VectorStore
+}
+
+// NewMemoryVectorStoreAdapter wraps a VectorStore (e.g. Qdrant or Chroma) so it can
+// back hierarchical memory tiers via memory.SemanticMemoryConfig or
+// memory.EpisodicMemoryConfig.
+//
+// Example:
+//
+//	store, _ := NewQdrantStore("http://localhost:6333")
+//	store.WithEmbedding(embedding)
+//	agent := NewOpenAI("gpt-4o-mini", apiKey).
+//	    WithSemanticMemoryConfig(memory.SemanticMemoryConfig{
+//	        Embedding:   embedding,
+//	        VectorStore: NewMemoryVectorStoreAdapter(store),
+//	    })
+func NewMemoryVectorStoreAdapter(store VectorStore) memory.VectorStoreAdapter {
+	return &memoryVectorStoreAdapter{store: store}
+}
+
+// Add implements memory.VectorStoreAdapter
+func (a *memoryVectorStoreAdapter) Add(ctx context.Context, collection string, docs []memory.VectorDoc) ([]string, error) {
+	converted := make([]*VectorDocument, len(docs))
+	for i, doc := range docs {
+		converted[i] = &VectorDocument{
+			ID:        doc.ID,
+			Content:   doc.Content,
+			Embedding: doc.Embedding,
+			Metadata:  doc.Metadata,
+			CreatedAt: doc.CreatedAt,
+			UpdatedAt: doc.UpdatedAt,
+		}
+	}
+	return a.store.Add(ctx, collection, converted)
+}
+
+// SearchByText implements memory.VectorStoreAdapter
+func (a *memoryVectorStoreAdapter) SearchByText(ctx context.Context, req memory.TextSearchReq) ([]memory.SearchRes, error) {
+	results, err := a.store.SearchByText(ctx, &TextSearchRequest{
+		Collection:      req.Collection,
+		Query:           req.Query,
+		TopK:            req.TopK,
+		Filter:          req.Filter,
+		IncludeMetadata: req.IncludeMetadata,
+		IncludeContent:  req.IncludeContent,
+		MinScore:        req.MinScore,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]memory.SearchRes, 0, len(results))
+	for _, res := range results {
+		if res == nil || res.Document == nil {
+			continue
+		}
+		converted = append(converted, memory.SearchRes{
+			Document: memory.VectorDoc{
+				ID:        res.Document.ID,
+				Content:   res.Document.Content,
+				Embedding: res.Document.Embedding,
+				Metadata:  res.Document.Metadata,
+				CreatedAt: res.Document.CreatedAt,
+				UpdatedAt: res.Document.UpdatedAt,
+			},
+			Score: res.Score,
+			Rank:  res.Rank,
+		})
+	}
+	return converted, nil
+}
+
+// Delete implements memory.VectorStoreAdapter
+func (a *memoryVectorStoreAdapter) Delete(ctx context.Context, collection string, ids []string) error {
+	return a.store.Delete(ctx, collection, ids)
+}
+
+// Count implements memory.VectorStoreAdapter
+func (a *memoryVectorStoreAdapter) Count(ctx context.Context, collection string) (int64, error) {
+	return a.store.Count(ctx, collection)
+}
+
+// Clear implements memory.VectorStoreAdapter
+func (a *memoryVectorStoreAdapter) Clear(ctx context.Context, collection string) error {
+	return a.store.Clear(ctx, collection)
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/taipm/go-deep-agent/agent/memory"
+)
+
+// recordingVectorStore records calls made through the memory adapter.
+// Unused VectorStore methods panic via the embedded nil interface.
+type recordingVectorStore struct {
+	VectorStore
+	added   []*VectorDocument
+	deleted []string
+	query   *TextSearchRequest
+	cleared string
+}
+
+func (s *recordingVectorStore) Add(ctx context.Context, collection string, docs []*VectorDocument) ([]string, error) {
+	s.added = append(s.added, docs...)
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+func (s *recordingVectorStore) SearchByText(ctx context.Context, req *TextSearchRequest) ([]*SearchResult, error) {
+	s.query = req
+	results := make([]*SearchResult, 0, len(s.added))
+	for i, doc := range s.added {
+		results = append(results, &SearchResult{Document: doc, Score: 0.9, Rank: i + 1})
+	}
+	return append(results, nil), nil
+}
+
+func (s *recordingVectorStore) Delete(ctx context.Context, collection string, ids []string) error {
+	s.deleted = append(s.deleted, ids...)
+	return nil
+}
+
+func (s *recordingVectorStore) Count(ctx context.Context, collection string) (int64, error) {
+	return int64(len(s.added)), nil
+}
+
+func (s *recordingVectorStore) Clear(ctx context.Context, collection string) error {
+	s.cleared = collection
+	return nil
+}
+
+func TestMemoryVectorStoreAdapter(t *testing.T) {
+	store := &recordingVectorStore{}
+	adapter := NewMemoryVectorStoreAdapter(store)
+	ctx := context.Background()
+
+	ids, err := adapter.Add(ctx, "facts", []memory.VectorDoc{{
+		ID:        "fact_1",
+		Content:   "User is vegan",
+		Embedding: []float32{0.1, 0.2},
+		Metadata:  map[string]interface{}{"category": "preference"},
+	}})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != "fact_1" {
+		t.Errorf("Unexpected IDs: %v", ids)
+	}
+	if len(store.added) != 1 || len(store.added[0].Embedding) != 2 {
+		t.Fatalf("Expected document with embedding to be added, got %+v", store.added)
+	}
+
+	results, err := adapter.SearchByText(ctx, memory.TextSearchReq{
+		Collection: "facts",
+		Query:      "food",
+		TopK:       3,
+		MinScore:   0.5,
+	})
+	if err != nil {
+		t.Fatalf("SearchByText failed: %v", err)
+	}
+	if store.query.Collection != "facts" || store.query.TopK != 3 || store.query.MinScore != 0.5 {
+		t.Errorf("Search request not converted: %+v", store.query)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected nil results to be skipped, got %d results", len(results))
+	}
+	if results[0].Document.Metadata["category"] != "preference" || results[0].Score != 0.9 || results[0].Rank != 1 {
+		t.Errorf("Unexpected result: %+v", results[0])
+	}
+
+	if err := adapter.Delete(ctx, "facts", []string{"fact_1"}); err != nil || len(store.deleted) != 1 {
+		t.Errorf("Delete not forwarded: %v", err)
+	}
+	if count, err := adapter.Count(ctx, "facts"); err != nil || count != 1 {
+		t.Errorf("Unexpected count %d: %v", count, err)
+	}
+	if err := adapter.Clear(ctx, "facts"); err != nil || store.cleared != "facts" {
+		t.Errorf("Clear not forwarded: %v", err)
+	}
+}
+
+func TestBuilder_WithSemanticMemoryConfig(t *testing.T) {
+	store := &recordingVectorStore{}
+	builder := NewOpenAI("gpt-4o-mini", "test-key").
+		WithSemanticMemoryConfig(memory.SemanticMemoryConfig{
+			Embedding:   NewMockEmbeddingProvider("mock", 8),
+			VectorStore: NewMemoryVectorStoreAdapter(store),
+		})
+
+	mem := builder.GetMemory()
+	if !mem.GetConfig().SemanticEnabled {
+		t.Fatal("Expected semantic memory to be enabled")
+	}
+
+	ctx := context.Background()
+	if err := mem.LearnFacts(ctx, nil); err == nil {
+		t.Error("Expected error without fact extractor")
+	}
+
+	builder.WithFactExtractor(staticFactExtractor{content: "User is vegan"})
+	if err := mem.LearnFacts(ctx, []memory.Message{{Role: "user", Content: "I'm vegan"}}); err != nil {
+		t.Fatalf("LearnFacts failed: %v", err)
+	}
+	if len(store.added) != 1 || store.added[0].Content != "User is vegan" {
+		t.Fatalf("Expected learned fact in vector store, got %+v", store.added)
+	}
+	if len(store.added[0].Embedding) != 8 {
+		t.Errorf("Expected embedded fact, got %d dimensions", len(store.added[0].Embedding))
+	}
+
+	facts, err := mem.Facts(ctx, "dinner ideas", 5)
+	if err != nil || len(facts) != 1 {
+		t.Fatalf("Expected 1 fact, got %d (err: %v)", len(facts), err)
+	}
+}
+
+// staticFactExtractor always adds the same fact
+type staticFactExtractor struct {
+	content string
+}
+
+func (e staticFactExtractor) Extract(ctx context.Context, messages []memory.Message, existing []memory.Fact) ([]memory.FactOperation, error) {
+	return []memory.FactOperation{{
+		Action: memory.FactActionAdd,
+		Fact:   memory.Fact{Content: e.content, Confidence: 0.9, Source: memory.FactSourceConversation},
+	}}, nil
+}
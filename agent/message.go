@@ -5,10 +5,11 @@ import "github.com/openai/openai-go/v3"
 // Message represents a chat message in the conversation.
 // This is our own type to avoid users needing to import openai-go.
 type Message struct {
-	Role       string     // "system", "user", "assistant", or "tool"
-	Content    string     // The message content
-	ToolCalls  []ToolCall // Tool calls made by assistant (only for assistant messages)
-	ToolCallID string     // ID of the tool call this message is responding to (only for tool messages)
+	Role       string         // "system", "user", "assistant", or "tool"
+	Content    string         // The message content
+	ToolCalls  []ToolCall     // Tool calls made by assistant (only for assistant messages)
+	ToolCallID string         // ID of the tool call this message is responding to (only for tool messages)
+	Images     []ImageContent // Images attached to the message (only for user messages)
 }
 
 // System creates a system message.
@@ -62,9 +63,11 @@ func convertMessages(messages []Message) []openai.ChatCompletionMessageParamUnio
 		case "system":
 			result[i] = openai.SystemMessage(msg.Content)
 		case "user":
-			result[i] = openai.UserMessage(msg.Content)
+			result[i] = convertUserMessage(msg)
 		case "assistant":
-			result[i] = openai.AssistantMessage(msg.Content)
+			result[i] = convertAssistantMessage(msg)
+		case "tool":
+			result[i] = openai.ToolMessage(msg.Content, msg.ToolCallID)
 		default:
 			// Default to user message if role is unknown
 			result[i] = openai.UserMessage(msg.Content)
@@ -73,3 +76,47 @@ func convertMessages(messages []Message) []openai.ChatCompletionMessageParamUnio
 
 	return result
 }
+
+// convertAssistantMessage converts an assistant message, keeping the tool
+// calls it made so that the tool results that follow can be matched to them.
+func convertAssistantMessage(msg Message) openai.ChatCompletionMessageParamUnion {
+	if len(msg.ToolCalls) == 0 {
+		return openai.AssistantMessage(msg.Content)
+	}
+
+	assistant := openai.ChatCompletionAssistantMessageParam{}
+	if msg.Content != "" {
+		assistant.Content.OfString = openai.String(msg.Content)
+	}
+	for _, toolCall := range msg.ToolCalls {
+		assistant.ToolCalls = append(assistant.ToolCalls, openai.ChatCompletionMessageToolCallUnionParam{
+			OfFunction: &openai.ChatCompletionMessageFunctionToolCallParam{
+				ID: toolCall.ID,
+				Function: openai.ChatCompletionMessageFunctionToolCallFunctionParam{
+					Name:      toolCall.Name,
+					Arguments: toolCall.Arguments,
+				},
+			},
+		})
+	}
+	return openai.ChatCompletionMessageParamUnion{OfAssistant: &assistant}
+}
+
+// convertUserMessage converts a user message, with its images as content parts
+func convertUserMessage(msg Message) openai.ChatCompletionMessageParamUnion {
+	if len(msg.Images) == 0 {
+		return openai.UserMessage(msg.Content)
+	}
+
+	parts := []openai.ChatCompletionContentPartUnionParam{}
+	if msg.Content != "" {
+		parts = append(parts, openai.TextContentPart(msg.Content))
+	}
+	for _, img := range msg.Images {
+		parts = append(parts, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{
+			URL:    img.URL,
+			Detail: string(img.Detail),
+		}))
+	}
+	return openai.UserMessage(parts)
+}
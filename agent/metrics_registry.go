@@ -0,0 +1,387 @@
+// Package agent implements a metrics registry with a Prometheus exporter
+// This file contains the registry abstraction, the Prometheus text format handler
+// and the MultiProvider metrics wiring
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsRegistry records metrics identified by a name and a set of labels.
+// Builder and MultiProvider push their metrics into a registry set with
+// WithMetricsRegistry; values kept elsewhere (cache statistics, circuit
+// breaker states) are read by collectors when the registry is scraped.
+type MetricsRegistry interface {
+	// AddCounter adds delta to a counter
+	AddCounter(name string, labels map[string]string, delta float64)
+
+	// SetCounter sets a counter maintained elsewhere, such as CacheStats.Hits
+	SetCounter(name string, labels map[string]string, value float64)
+
+	// SetGauge sets a gauge
+	SetGauge(name string, labels map[string]string, value float64)
+
+	// ObserveHistogram records a value in a histogram
+	ObserveHistogram(name string, labels map[string]string, value float64)
+
+	// RegisterCollector registers a function called before each scrape
+	RegisterCollector(collect func(MetricsRegistry))
+}
+
+// Metric names recorded by Builder and MultiProvider
+const (
+	MetricRequestsTotal          = "agent_requests_total"
+	MetricRequestDuration        = "agent_request_duration_seconds"
+	MetricTokensTotal            = "agent_tokens_total"
+	MetricCacheHitsTotal         = "agent_cache_hits_total"
+	MetricCacheMissesTotal       = "agent_cache_misses_total"
+	MetricCacheHitRatio          = "agent_cache_hit_ratio"
+	MetricRateLimitWait          = "agent_rate_limit_wait_seconds"
+	MetricCircuitBreakerState    = "agent_circuit_breaker_state"
+	MetricToolDuration           = "agent_tool_duration_seconds"
+	MetricHedgedRequestsTotal    = "agent_hedged_requests_total"
+	MetricHedgeWinsTotal         = "agent_hedge_wins_total"
+	MetricHedgeWastedTokensTotal = "agent_hedge_wasted_tokens_total"
+)
+
+// metricKind is the type of a metric family
+type metricKind string
+
+const (
+	metricCounter   metricKind = "counter"
+	metricGauge     metricKind = "gauge"
+	metricHistogram metricKind = "histogram"
+)
+
+// builtinMetricHelp describes the metrics recorded by Builder and MultiProvider
+var builtinMetricHelp = map[string]string{
+	MetricRequestsTotal:          "Total number of LLM requests by provider, model and status.",
+	MetricRequestDuration:        "Duration of LLM requests in seconds.",
+	MetricTokensTotal:            "Total number of tokens used by type (prompt, completion).",
+	MetricCacheHitsTotal:         "Total number of response cache hits.",
+	MetricCacheMissesTotal:       "Total number of response cache misses.",
+	MetricCacheHitRatio:          "Ratio of cache hits to cache lookups.",
+	MetricRateLimitWait:          "Time spent waiting for the rate limiter in seconds.",
+	MetricCircuitBreakerState:    "Circuit breaker state by provider (0 = closed, 1 = open, 2 = half-open).",
+	MetricToolDuration:           "Duration of tool executions in seconds.",
+	MetricHedgedRequestsTotal:    "Total number of requests hedged to a second provider.",
+	MetricHedgeWinsTotal:         "Total number of hedged requests won by the second provider.",
+	MetricHedgeWastedTokensTotal: "Estimated tokens spent on cancelled hedged attempts.",
+}
+
+// DefaultLatencyBuckets are the histogram buckets in seconds, sized for LLM latencies
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// PrometheusRegistry is an in-memory MetricsRegistry exported in the
+// Prometheus text exposition format
+type PrometheusRegistry struct {
+	mu         sync.Mutex
+	families   map[string]*metricFamily
+	help       map[string]string
+	buckets    map[string][]float64
+	collectors []func(MetricsRegistry)
+}
+
+// metricFamily holds the series of a metric
+type metricFamily struct {
+	kind   metricKind
+	series map[string]*metricSeries // By encoded labels
+}
+
+// metricSeries holds the value of a metric for a set of labels
+type metricSeries struct {
+	labels  string // Encoded labels, e.g. `model="gpt-4o",provider="openai"`
+	value   float64
+	buckets []float64 // Upper bounds of the histogram buckets
+	counts  []uint64  // Observations per bucket (not cumulative)
+	count   uint64
+}
+
+// NewPrometheusRegistry creates an empty registry describing the built-in metrics
+func NewPrometheusRegistry() *PrometheusRegistry {
+	help := make(map[string]string, len(builtinMetricHelp))
+	for name, text := range builtinMetricHelp {
+		help[name] = text
+	}
+	return &PrometheusRegistry{
+		families: make(map[string]*metricFamily),
+		help:     help,
+		buckets:  make(map[string][]float64),
+	}
+}
+
+// Describe sets the help text of a metric
+func (r *PrometheusRegistry) Describe(name, help string) *PrometheusRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.help[name] = help
+	return r
+}
+
+// WithBuckets sets the histogram buckets of a metric (default: DefaultLatencyBuckets).
+// It only affects series created afterwards.
+func (r *PrometheusRegistry) WithBuckets(name string, buckets []float64) *PrometheusRegistry {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buckets[name] = sorted
+	return r
+}
+
+// AddCounter adds delta to a counter. Negative deltas are ignored.
+func (r *PrometheusRegistry) AddCounter(name string, labels map[string]string, delta float64) {
+	if delta < 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if series := r.getSeries(name, metricCounter, labels); series != nil {
+		series.value += delta
+	}
+}
+
+// SetCounter sets a counter maintained elsewhere
+func (r *PrometheusRegistry) SetCounter(name string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if series := r.getSeries(name, metricCounter, labels); series != nil {
+		series.value = value
+	}
+}
+
+// SetGauge sets a gauge
+func (r *PrometheusRegistry) SetGauge(name string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if series := r.getSeries(name, metricGauge, labels); series != nil {
+		series.value = value
+	}
+}
+
+// ObserveHistogram records a value in a histogram
+func (r *PrometheusRegistry) ObserveHistogram(name string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	series := r.getSeries(name, metricHistogram, labels)
+	if series == nil {
+		return
+	}
+
+	series.value += value
+	series.count++
+	for i, bound := range series.buckets {
+		if value <= bound {
+			series.counts[i]++
+			return
+		}
+	}
+}
+
+// RegisterCollector registers a function called before each scrape
+func (r *PrometheusRegistry) RegisterCollector(collect func(MetricsRegistry)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collect)
+}
+
+// getSeries returns the series of a metric for the labels, creating it if
+// needed. It returns nil when the metric was first recorded with another type.
+// Must be called with r.mu held.
+func (r *PrometheusRegistry) getSeries(name string, kind metricKind, labels map[string]string) *metricSeries {
+	family, exists := r.families[name]
+	if !exists {
+		family = &metricFamily{kind: kind, series: make(map[string]*metricSeries)}
+		r.families[name] = family
+	}
+	if family.kind != kind {
+		return nil
+	}
+
+	key := encodeLabels(labels)
+	series, exists := family.series[key]
+	if !exists {
+		series = &metricSeries{labels: key}
+		if kind == metricHistogram {
+			series.buckets = r.buckets[name]
+			if series.buckets == nil {
+				series.buckets = DefaultLatencyBuckets
+			}
+			series.counts = make([]uint64, len(series.buckets))
+		}
+		family.series[key] = series
+	}
+	return series
+}
+
+// WriteText runs the collectors and writes all metrics in the Prometheus text format
+func (r *PrometheusRegistry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := make([]func(MetricsRegistry), len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.Unlock()
+
+	// Collectors record into the registry, so they run without the lock held
+	for _, collect := range collectors {
+		collect(r)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := bufio.NewWriter(w)
+	for _, name := range names {
+		family := r.families[name]
+		if help := r.help[name]; help != "" {
+			fmt.Fprintf(out, "# HELP %s %s\n", name, escapeHelp(help))
+		}
+		fmt.Fprintf(out, "# TYPE %s %s\n", name, family.kind)
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			series := family.series[key]
+			if family.kind != metricHistogram {
+				fmt.Fprintf(out, "%s%s %s\n", name, wrapLabels(series.labels), formatFloat(series.value))
+				continue
+			}
+
+			var cumulative uint64
+			for i, bound := range series.buckets {
+				cumulative += series.counts[i]
+				fmt.Fprintf(out, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(series.labels, `le="`+formatFloat(bound)+`"`)), cumulative)
+			}
+			fmt.Fprintf(out, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(series.labels, `le="+Inf"`)), series.count)
+			fmt.Fprintf(out, "%s_sum%s %s\n", name, wrapLabels(series.labels), formatFloat(series.value))
+			fmt.Fprintf(out, "%s_count%s %d\n", name, wrapLabels(series.labels), series.count)
+		}
+	}
+	return out.Flush()
+}
+
+// Handler returns an HTTP handler serving the metrics for Prometheus scrapes
+func (r *PrometheusRegistry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WriteText(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// encodeLabels encodes labels sorted by name; empty values are dropped
+func encodeLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name, value := range labels {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + `="` + escapeLabelValue(labels[name]) + `"`
+	}
+	return strings.Join(parts, ",")
+}
+
+// joinLabels appends a label to encoded labels
+func joinLabels(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+// wrapLabels wraps encoded labels in braces, if any
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(value string) string { return labelValueEscaper.Replace(value) }
+
+func escapeHelp(help string) string { return helpEscaper.Replace(help) }
+
+// formatFloat formats a value as Prometheus expects it
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// WithMetricsRegistry records request counts, latencies, token usage, hedged
+// requests and circuit breaker states into registry, labelled by provider
+func (mp *MultiProvider) WithMetricsRegistry(registry MetricsRegistry) *MultiProvider {
+	mp.metrics.SetRegistry(registry)
+	if registry != nil {
+		registry.RegisterCollector(mp.collectMetrics)
+	}
+	return mp
+}
+
+// collectMetrics records the circuit breaker states at scrape time
+func (mp *MultiProvider) collectMetrics(registry MetricsRegistry) {
+	for _, provider := range mp.providers {
+		state := mp.fallback.getCircuitBreaker(provider.Name).State()
+		registry.SetGauge(MetricCircuitBreakerState, map[string]string{"provider": provider.Name}, float64(state))
+	}
+}
+
+// recordAttempts wraps fn to record the metrics of each provider attempt
+func (mp *MultiProvider) recordAttempts(requestType string, fn func(*ProviderConfig) (string, error)) func(*ProviderConfig) (string, error) {
+	return func(provider *ProviderConfig) (string, error) {
+		start := time.Now()
+		content, err := fn(provider)
+
+		metrics := &RequestMetrics{
+			Provider:     provider.Name,
+			Model:        provider.Model,
+			RequestType:  requestType,
+			StartTime:    start,
+			EndTime:      time.Now(),
+			ResponseTime: time.Since(start),
+			Success:      err == nil,
+		}
+		if err != nil {
+			metrics.Error = err.Error()
+		}
+		mp.metrics.RecordRequest(metrics)
+		return content, err
+	}
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape fetches the metrics of a registry over HTTP
+func scrape(t *testing.T, registry *PrometheusRegistry) string {
+	t.Helper()
+	server := httptest.NewServer(registry.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+// assertMetrics checks that every line appears in the scraped metrics
+func assertMetrics(t *testing.T, metrics string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, metrics)
+		}
+	}
+}
+
+func TestPrometheusRegistry_TextFormat(t *testing.T) {
+	registry := NewPrometheusRegistry().
+		Describe("jobs_total", "Jobs done.").
+		WithBuckets("job_seconds", []float64{1, 0.1})
+
+	registry.AddCounter("jobs_total", map[string]string{"queue": "a", "kind": `say "hi"`}, 2)
+	registry.AddCounter("jobs_total", map[string]string{"queue": "a", "kind": `say "hi"`}, 1)
+	registry.AddCounter("jobs_total", nil, -5) // Ignored
+	registry.SetGauge("jobs_total", nil, 7)    // Wrong type, ignored
+	registry.SetGauge("queue_length", map[string]string{"queue": "a", "empty": ""}, 4)
+	registry.ObserveHistogram("job_seconds", nil, 0.05)
+	registry.ObserveHistogram("job_seconds", nil, 0.5)
+	registry.ObserveHistogram("job_seconds", nil, 3)
+	registry.RegisterCollector(func(r MetricsRegistry) {
+		r.SetCounter("collected_total", nil, 42)
+	})
+
+	assertMetrics(t, scrape(t, registry),
+		"# HELP jobs_total Jobs done.",
+		"# TYPE jobs_total counter",
+		`jobs_total{kind="say \"hi\"",queue="a"} 3`,
+		"# TYPE queue_length gauge",
+		`queue_length{queue="a"} 4`,
+		"# TYPE job_seconds histogram",
+		`job_seconds_bucket{le="0.1"} 1`,
+		`job_seconds_bucket{le="1"} 2`,
+		`job_seconds_bucket{le="+Inf"} 3`,
+		"job_seconds_sum 3.55",
+		"job_seconds_count 3",
+		"collected_total 42",
+	)
+}
+
+func TestBuilder_WithMetricsRegistry(t *testing.T) {
+	adapter := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		if call == 1 {
+			return &CompletionResponse{ToolCalls: []ToolCall{{ID: "a", Name: "lookup", Arguments: `{"city":"Tokyo"}`}}}, nil
+		}
+		return &CompletionResponse{Content: "14 million", Usage: TokenUsage{PromptTokens: 12, CompletionTokens: 3}}, nil
+	}}
+
+	registry := NewPrometheusRegistry()
+	cache := NewMemoryCache(10, time.Minute)
+	var toolCalls int
+	ai := NewWithAdapter("test-model", adapter).
+		WithCache(cache).
+		WithTools(countingTool(&toolCalls)).
+		WithAutoExecute(true).
+		WithMetricsRegistry(registry)
+
+	if _, err := ai.Ask(context.Background(), "Population of Tokyo?"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if answer, err := ai.Ask(context.Background(), "Population of Tokyo?"); err != nil || answer != "14 million" {
+		t.Fatalf("Ask = %q, %v, want the cached answer", answer, err)
+	}
+
+	assertMetrics(t, scrape(t, registry),
+		`agent_requests_total{model="test-model",status="success"} 2`,
+		`agent_request_duration_seconds_count{model="test-model"} 2`,
+		`agent_cache_hits_total{model="test-model"} 1`,
+		`agent_cache_misses_total{model="test-model"} 1`,
+		`agent_cache_hit_ratio{model="test-model"} 0.5`,
+		`agent_tool_duration_seconds_count{status="success",tool="lookup"} 1`,
+	)
+}
+
+func TestMultiProvider_WithMetricsRegistry(t *testing.T) {
+	primary := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return nil, errors.New("503 service unavailable")
+	}}
+	backup := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: "ok", Usage: TokenUsage{PromptTokens: 10, CompletionTokens: 2}}, nil
+	}}
+
+	mp, err := NewMultiProvider(&MultiProviderConfig{
+		Providers: []ProviderConfig{
+			{Name: "primary", Type: "adapter", Model: "model-a", Weight: 2, Adapter: primary},
+			{Name: "backup", Type: "adapter", Model: "model-b", Weight: 1, Adapter: backup},
+		},
+		SelectionStrategy:       StrategyPriority,
+		FallbackStrategy:        FallbackStrategyFailFast,
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerTimeout:   time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewMultiProvider failed: %v", err)
+	}
+	registry := NewPrometheusRegistry()
+	mp.WithMetricsRegistry(registry)
+
+	if _, err := mp.AskWithTools(context.Background(), "hi"); err != nil {
+		t.Fatalf("AskWithTools failed: %v", err)
+	}
+
+	assertMetrics(t, scrape(t, registry),
+		`agent_requests_total{model="model-a",provider="primary",status="error"} 1`,
+		`agent_requests_total{model="model-b",provider="backup",status="success"} 1`,
+		`agent_request_duration_seconds_count{model="model-b",provider="backup"} 1`,
+		`agent_tokens_total{model="model-b",provider="backup",type="prompt"} 10`,
+		`agent_tokens_total{model="model-b",provider="backup",type="completion"} 2`,
+		`agent_circuit_breaker_state{provider="primary"} 1`,
+		`agent_circuit_breaker_state{provider="backup"} 0`,
+	)
+}
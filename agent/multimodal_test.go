@@ -293,7 +293,7 @@ func TestBuildMessagesClearsImagesLogic(t *testing.T) {
 	}
 
 	// Call buildMessages to get multimodal content
-	result := builder.buildMessages("Test message")
+	result := builder.buildMessages(context.Background(), "Test message")
 
 	// Result should be messages array
 	if result == nil {
@@ -42,7 +42,7 @@ func (ps ProviderStatus) String() string {
 type ProviderConfig struct {
 	// Core identification
 	Name     string `json:"name"`
-	Type     string `json:"type"` // "openai", "ollama", "adapter", "custom"
+	Type     string `json:"type"` // "openai", "ollama", "gemini", "anthropic", "adapter", "custom"
 	Model    string `json:"model"`
 
 	// Connection details
@@ -74,6 +74,10 @@ type ProviderConfig struct {
 	// Rate limiting
 	RequestsPerMinute int `json:"requests_per_minute"`
 
+	// Pricing and capabilities (used by StrategyCheapestCapable)
+	Pricing      ProviderPricing      `json:"pricing"`
+	Capabilities ProviderCapabilities `json:"capabilities"`
+
 	// Builder instance (for direct providers)
 	Builder *Builder `json:"-"`
 }
@@ -111,6 +115,7 @@ const (
 	StrategyRandom
 	StrategyPriority
 	StrategyCustom
+	StrategyCheapestCapable // Cheapest provider whose capabilities fit the request
 )
 
 // FallbackStrategy determines how fallbacks are handled
@@ -145,6 +150,12 @@ type MultiProviderConfig struct {
 	CircuitBreakerThreshold int           `json:"circuit_breaker_threshold"`
 	CircuitBreakerTimeout   time.Duration   `json:"circuit_breaker_timeout"`
 
+	// Hedged request settings: when enabled, Ask and Stream send the request to
+	// a second provider if the first has not produced a first token within
+	// HedgeDelay (default 500ms). The first response wins and the other is cancelled.
+	EnableHedging bool          `json:"enable_hedging"`
+	HedgeDelay    time.Duration `json:"hedge_delay"`
+
 	// Monitoring settings
 	EnableMetrics        bool          `json:"enable_metrics"`
 	MetricsInterval      time.Duration `json:"metrics_interval"`
@@ -165,6 +176,12 @@ type MultiProvider struct {
 	metrics      *MetricsCollector
 	logger       Logger
 
+	// Tool calling (see AskWithTools and Execute)
+	tools         []*Tool
+	systemPrompt  string
+	maxToolRounds int
+	onStream      func(string)
+
 	// Runtime state
 	mu           sync.RWMutex
 	shutdown     chan struct{}
@@ -275,6 +292,20 @@ func (mp *MultiProvider) createBuilderForProvider(config *ProviderConfig) (*Buil
 		config.Type = "adapter"
 		return nil, nil
 
+	case "anthropic":
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("API key is required for Anthropic provider %s", config.Name)
+		}
+		anthropicAdapter, err := NewAnthropicAdapter(config.APIKey, config.Model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Anthropic adapter for provider %s: %w", config.Name, err)
+		}
+		anthropicAdapter.WithBaseURL(config.BaseURL)
+		// Return as custom adapter provider
+		config.Adapter = anthropicAdapter
+		config.Type = "adapter"
+		return nil, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", config.Type)
 	}
@@ -282,54 +313,111 @@ func (mp *MultiProvider) createBuilderForProvider(config *ProviderConfig) (*Buil
 
 // Ask executes a request using MultiProvider logic
 func (mp *MultiProvider) Ask(ctx context.Context, message string) (string, error) {
-	return mp.executeWithFallback(ctx, func(provider *ProviderConfig) (string, error) {
-		// Use adapter if available, otherwise use builder
-		if provider.Adapter != nil {
-			req := &CompletionRequest{
-				Model:    provider.Model,
-				Messages: []Message{{Role: "user", Content: message}},
-			}
-			resp, err := provider.Adapter.Complete(ctx, req)
-			if err != nil {
-				return "", err
-			}
-			return resp.Content, nil
-		}
-
-		if provider.Builder == nil {
-			return "", fmt.Errorf("provider %s has no builder or adapter", provider.Name)
-		}
+	req := &CompletionRequest{Messages: []Message{User(message)}}
+	if mp.config.EnableHedging {
+		return mp.executeHedged(ctx, req, "ask", func(ctx context.Context, provider *ProviderConfig, onChunk func(string)) (string, error) {
+			return mp.ask(ctx, provider, message)
+		}, nil)
+	}
 
-		return provider.Builder.Ask(ctx, message)
-	}, message)
+	return mp.executeWithFallback(ctx, "ask", func(provider *ProviderConfig) (string, error) {
+		return mp.ask(ctx, provider, message)
+	}, req)
 }
 
-// Stream executes a streaming request using MultiProvider logic
+// Stream executes a streaming request using MultiProvider logic.
+// Content chunks are passed to the OnStream callback, if set.
 func (mp *MultiProvider) Stream(ctx context.Context, message string) (string, error) {
-	return mp.executeWithFallback(ctx, func(provider *ProviderConfig) (string, error) {
-		// Use adapter if available, otherwise use builder
-		if provider.Adapter != nil {
-			req := &CompletionRequest{
-				Model:    provider.Model,
-				Messages: []Message{{Role: "user", Content: message}},
-			}
-			resp, err := provider.Adapter.Stream(ctx, req, nil)
-			if err != nil {
-				return "", err
-			}
-			return resp.Content, nil
+	mp.mu.RLock()
+	onStream := mp.onStream
+	mp.mu.RUnlock()
+
+	req := &CompletionRequest{Messages: []Message{User(message)}}
+	if mp.config.EnableHedging {
+		// Only the chunks of the winning provider reach the callback
+		return mp.executeHedged(ctx, req, "stream", func(ctx context.Context, provider *ProviderConfig, onChunk func(string)) (string, error) {
+			return mp.stream(ctx, provider, message, onChunk)
+		}, onStream)
+	}
+
+	return mp.executeWithFallback(ctx, "stream", func(provider *ProviderConfig) (string, error) {
+		return mp.stream(ctx, provider, message, onStream)
+	}, req)
+}
+
+// OnStream sets a callback receiving the content chunks of Stream
+func (mp *MultiProvider) OnStream(callback func(string)) *MultiProvider {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.onStream = callback
+	return mp
+}
+
+// ask sends a message to a provider
+func (mp *MultiProvider) ask(ctx context.Context, provider *ProviderConfig, message string) (string, error) {
+	// Use adapter if available, otherwise use builder
+	if provider.Adapter != nil {
+		req := &CompletionRequest{
+			Model:    provider.Model,
+			Messages: []Message{{Role: "user", Content: message}},
 		}
+		resp, err := provider.Adapter.Complete(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return resp.Content, nil
+	}
 
-		if provider.Builder == nil {
-			return "", fmt.Errorf("provider %s has no builder or adapter", provider.Name)
+	if provider.Builder == nil {
+		return "", fmt.Errorf("provider %s has no builder or adapter", provider.Name)
+	}
+
+	return provider.Builder.Ask(ctx, message)
+}
+
+// stream streams a message from a provider, passing content chunks to onChunk if not nil
+func (mp *MultiProvider) stream(ctx context.Context, provider *ProviderConfig, message string, onChunk func(string)) (string, error) {
+	// Use adapter if available, otherwise use builder
+	if provider.Adapter != nil {
+		req := &CompletionRequest{
+			Model:    provider.Model,
+			Messages: []Message{{Role: "user", Content: message}},
+		}
+		resp, err := provider.Adapter.Stream(ctx, req, onChunk)
+		if err != nil {
+			return "", err
 		}
+		return resp.Content, nil
+	}
 
+	if provider.Builder == nil {
+		return "", fmt.Errorf("provider %s has no builder or adapter", provider.Name)
+	}
+	if onChunk == nil {
 		return provider.Builder.Stream(ctx, message)
-	}, message)
+	}
+
+	// A copy of the builder, so that concurrent requests get their own callback
+	builder := *provider.Builder
+	builder.onStream = onChunk
+	return builder.Stream(ctx, message)
 }
 
-// executeWithFallback executes a function with fallback handling
-func (mp *MultiProvider) executeWithFallback(ctx context.Context, fn func(*ProviderConfig) (string, error), message string) (string, error) {
+// executeWithFallback executes a function with fallback handling.
+// The request describes what the providers must support for StrategyCheapestCapable.
+func (mp *MultiProvider) executeWithFallback(ctx context.Context, requestType string, fn func(*ProviderConfig) (string, error), req *CompletionRequest) (string, error) {
+	message := lastMessageContent(req.Messages)
+	fn = mp.recordAttempts(requestType, fn)
+
+	// Route to the cheapest capable provider, falling back to the next cheapest ones
+	if mp.config.SelectionStrategy == StrategyCheapestCapable {
+		candidates, err := mp.selector.RankCheapestCapable(mp.providers, RequirementsFor(req))
+		if err != nil {
+			return "", fmt.Errorf("failed to select provider: %w", err)
+		}
+		return mp.fallback.ExecuteWithFallback(ctx, candidates[0], candidates, fn, message)
+	}
+
 	// Select provider based on strategy
 	provider, err := mp.selector.SelectProvider(mp.providers, mp.config.SelectionStrategy)
 	if err != nil {
@@ -352,6 +440,14 @@ func (mp *MultiProvider) executeWithFallback(ctx context.Context, fn func(*Provi
 	return response, err
 }
 
+// lastMessageContent returns the content of the last message, if any
+func lastMessageContent(messages []Message) string {
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1].Content
+}
+
 // GetMetrics returns metrics for all providers
 func (mp *MultiProvider) GetMetrics() map[string]*ProviderMetrics {
 	return mp.metrics.GetAllMetrics()
@@ -346,6 +346,25 @@ func (lb *LoadBalancer) StartRequest(providerName string) {
 	atomic.AddInt64(lb.activeRequests[providerName], 1)
 }
 
+// tryStartRequest marks the start of a request if the provider has capacity
+// left under its MaxConcurrency, and reports whether it did
+func (lb *LoadBalancer) tryStartRequest(provider *ProviderConfig) bool {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	active, exists := lb.activeRequests[provider.Name]
+	if !exists {
+		active = new(int64)
+		lb.activeRequests[provider.Name] = active
+	}
+	if provider.MaxConcurrency > 0 && atomic.LoadInt64(active) >= int64(provider.MaxConcurrency) {
+		return false
+	}
+
+	atomic.AddInt64(active, 1)
+	return true
+}
+
 // EndRequest marks the end of a request and records response time
 func (lb *LoadBalancer) EndRequest(providerName string, responseTime time.Duration, success bool) {
 	lb.mu.Lock()
@@ -33,8 +33,11 @@ func NewFallbackHandler(config *MultiProviderConfig) *FallbackHandler {
 
 // ExecuteWithFallback executes a request with automatic fallback to other providers
 func (fh *FallbackHandler) ExecuteWithFallback(ctx context.Context, primaryProvider *ProviderConfig, providers []*ProviderConfig, executeFunc func(*ProviderConfig) (string, error), message string) (string, error) {
-	// Filter providers to exclude disabled ones
+	// Filter providers to exclude disabled ones; the primary provider is tried first
 	availableProviders := fh.getAvailableProviders(providers, primaryProvider)
+	if primaryProvider != nil && primaryProvider.Status != ProviderStatusDisabled {
+		availableProviders = append([]*ProviderConfig{primaryProvider}, availableProviders...)
+	}
 	if len(availableProviders) == 0 {
 		return "", fmt.Errorf("no available providers for fallback")
 	}
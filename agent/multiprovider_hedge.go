@@ -0,0 +1,224 @@
+// Package agent implements hedged requests for MultiProvider
+// This file contains speculative execution across providers to cut tail latency
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// defaultHedgeDelay is the default delay before a request is hedged to a second provider
+const defaultHedgeDelay = 500 * time.Millisecond
+
+// hedgeAttempt sends a request to a provider, passing content chunks to onChunk
+type hedgeAttempt func(ctx context.Context, provider *ProviderConfig, onChunk func(string)) (string, error)
+
+// hedgeAttemptState tracks an attempt of a hedged request
+type hedgeAttemptState struct {
+	provider  *ProviderConfig
+	cancel    context.CancelFunc
+	startTime time.Time
+	output    atomic.Int64 // Bytes of content received
+	done      bool
+	cancelled bool
+}
+
+// hedgeResult is the result of an attempt of a hedged request
+type hedgeResult struct {
+	index   int
+	content string
+	err     error
+}
+
+// hedgeCandidates returns the providers a hedged request may use: the selected
+// provider first, then the fallback providers in the order of the fallback
+// strategy. Providers with an open circuit breaker are skipped.
+func (mp *MultiProvider) hedgeCandidates(req *CompletionRequest) ([]*ProviderConfig, error) {
+	var candidates []*ProviderConfig
+	if mp.config.SelectionStrategy == StrategyCheapestCapable {
+		ranked, err := mp.selector.RankCheapestCapable(mp.providers, RequirementsFor(req))
+		if err != nil {
+			return nil, err
+		}
+		candidates = ranked
+	} else {
+		primary, err := mp.selector.SelectProvider(mp.providers, mp.config.SelectionStrategy)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append([]*ProviderConfig{primary}, mp.fallback.getAvailableProviders(mp.providers, primary)...)
+	}
+
+	var available []*ProviderConfig
+	for _, provider := range candidates {
+		if !mp.fallback.getCircuitBreaker(provider.Name).IsOpen() {
+			available = append(available, provider)
+		}
+	}
+	if len(available) == 0 {
+		return nil, fmt.Errorf("all providers are unavailable")
+	}
+	return available, nil
+}
+
+// executeHedged sends a request to the first candidate provider and, if it
+// has not produced a first token within the hedge delay, to a second one.
+// The first provider to produce a token (or, without streaming, a response)
+// wins, and the other attempts are cancelled. Providers failing before their
+// first token fall back to the next candidate. Only the chunks of the winner
+// are passed to onChunk. Each provider's MaxConcurrency is respected.
+func (mp *MultiProvider) executeHedged(ctx context.Context, req *CompletionRequest, requestType string, attempt hedgeAttempt, onChunk func(string)) (string, error) {
+	candidates, err := mp.hedgeCandidates(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to select provider: %w", err)
+	}
+
+	delay := mp.config.HedgeDelay
+	if delay <= 0 {
+		delay = defaultHedgeDelay
+	}
+	promptTokens := RequirementsFor(req).PromptTokens
+
+	hedgeCtx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+
+	var (
+		attempts []*hedgeAttemptState
+		winner   atomic.Int32 // Index of the winning attempt, -1 until decided
+		next     int
+		running  int
+		lastErr  error
+	)
+	winner.Store(-1)
+	results := make(chan hedgeResult, len(candidates))
+	firstTokens := make(chan int, len(candidates))
+
+	// launch starts an attempt with the next candidate that has capacity left
+	launch := func() bool {
+		for next < len(candidates) {
+			provider := candidates[next]
+			next++
+			if !mp.balancer.tryStartRequest(provider) {
+				continue
+			}
+
+			attemptCtx, cancel := context.WithCancel(hedgeCtx)
+			state := &hedgeAttemptState{provider: provider, cancel: cancel, startTime: time.Now()}
+			index := len(attempts)
+			attempts = append(attempts, state)
+			running++
+
+			go func() {
+				content, err := attempt(attemptCtx, provider, func(chunk string) {
+					state.output.Add(int64(len(chunk)))
+					if winner.CompareAndSwap(-1, int32(index)) {
+						firstTokens <- index
+					}
+					if onChunk != nil && winner.Load() == int32(index) {
+						onChunk(chunk)
+					}
+				})
+				mp.balancer.EndRequest(provider.Name, time.Since(state.startTime), err == nil)
+				results <- hedgeResult{index: index, content: content, err: err}
+			}()
+			return true
+		}
+		return false
+	}
+
+	outcome := &HedgeOutcome{}
+	defer func() { mp.metrics.RecordHedge(outcome) }()
+
+	// cancelLosers cancels the attempts other than the winner and counts the tokens they wasted
+	cancelLosers := func(winnerIndex int) {
+		for i, state := range attempts {
+			if i == winnerIndex || state.done || state.cancelled {
+				continue
+			}
+			state.cancel()
+			state.cancelled = true
+			// The prompt was sent in full; the output is estimated at 4 bytes per token
+			outcome.WastedTokens += promptTokens + int((state.output.Load()+3)/4)
+		}
+	}
+
+	if !launch() {
+		return "", fmt.Errorf("all providers are at their maximum concurrency")
+	}
+	outcome.Primary = attempts[0].provider.Name
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for running > 0 {
+		select {
+		case <-timer.C:
+			if winner.Load() < 0 && launch() {
+				outcome.Hedge = attempts[len(attempts)-1].provider.Name
+				mp.fallback.logger.Debug(ctx, "Hedging request to a second provider",
+					F("primary", outcome.Primary),
+					F("hedge", outcome.Hedge),
+					F("delay", delay))
+			}
+
+		case index := <-firstTokens:
+			cancelLosers(index)
+
+		case result := <-results:
+			running--
+			state := attempts[result.index]
+			state.done = true
+			if state.cancelled {
+				continue
+			}
+
+			cb := mp.fallback.getCircuitBreaker(state.provider.Name)
+			metrics := &RequestMetrics{
+				Provider:      state.provider.Name,
+				Model:         state.provider.Model,
+				RequestType:   requestType,
+				StartTime:     state.startTime,
+				EndTime:       time.Now(),
+				ResponseTime:  time.Since(state.startTime),
+				Success:       result.err == nil,
+				FallbackUsed:  result.index > 0,
+				FallbackCount: result.index,
+			}
+
+			if result.err == nil && (winner.CompareAndSwap(-1, int32(result.index)) || winner.Load() == int32(result.index)) {
+				cancelLosers(result.index)
+				cb.RecordSuccess()
+				mp.metrics.RecordRequest(metrics)
+				outcome.Winner = state.provider.Name
+				return result.content, nil
+			}
+			if result.err == nil {
+				continue
+			}
+
+			cb.RecordFailure()
+			metrics.Error = result.err.Error()
+			mp.metrics.RecordRequest(metrics)
+			if winner.Load() == int32(result.index) {
+				// Chunks were already passed on, so another provider can't take over
+				return "", fmt.Errorf("provider %s failed while streaming: %w", state.provider.Name, result.err)
+			}
+
+			// Failed before its first token: fall back to the next candidate
+			lastErr = result.err
+			if winner.Load() < 0 {
+				launch()
+			}
+
+		case <-ctx.Done():
+			return "", fmt.Errorf("request cancelled: %w", ctx.Err())
+		}
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no provider answered")
+	}
+	return "", fmt.Errorf("all providers failed. Last error: %w", lastErr)
+}
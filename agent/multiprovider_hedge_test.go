@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// latencyAdapter streams its words after a delay, unless cancelled first
+type latencyAdapter struct {
+	delay     time.Duration
+	content   string
+	err       error
+	calls     atomic.Int32
+	cancelled atomic.Bool
+}
+
+func (a *latencyAdapter) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	return a.Stream(ctx, req, nil)
+}
+
+func (a *latencyAdapter) Stream(ctx context.Context, req *CompletionRequest, onChunk func(string)) (*CompletionResponse, error) {
+	a.calls.Add(1)
+	select {
+	case <-time.After(a.delay):
+	case <-ctx.Done():
+		a.cancelled.Store(true)
+		return nil, ctx.Err()
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	if onChunk != nil {
+		for _, word := range strings.SplitAfter(a.content, " ") {
+			onChunk(word)
+		}
+	}
+	return &CompletionResponse{Content: a.content}, nil
+}
+
+// newHedgedMultiProvider creates a hedging MultiProvider trying primary, then backup
+func newHedgedMultiProvider(t *testing.T, primary, backup *latencyAdapter, backupConcurrency int) *MultiProvider {
+	t.Helper()
+	mp, err := NewMultiProvider(&MultiProviderConfig{
+		Providers: []ProviderConfig{
+			{Name: "primary", Type: "adapter", Model: "a", Weight: 2, Adapter: primary},
+			{Name: "backup", Type: "adapter", Model: "b", Weight: 1, Adapter: backup, MaxConcurrency: backupConcurrency},
+		},
+		SelectionStrategy:       StrategyPriority,
+		FallbackStrategy:        FallbackStrategyFailFast,
+		CircuitBreakerThreshold: 5,
+		EnableHedging:           true,
+		HedgeDelay:              20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewMultiProvider failed: %v", err)
+	}
+	return mp
+}
+
+func TestMultiProvider_HedgedStream(t *testing.T) {
+	primary := &latencyAdapter{delay: 2 * time.Second, content: "slow answer"}
+	backup := &latencyAdapter{delay: 10 * time.Millisecond, content: "fast answer"}
+
+	var mu sync.Mutex
+	var chunks []string
+	mp := newHedgedMultiProvider(t, primary, backup, 0).OnStream(func(chunk string) {
+		mu.Lock()
+		chunks = append(chunks, chunk)
+		mu.Unlock()
+	})
+
+	start := time.Now()
+	answer, err := mp.Stream(context.Background(), "Hello there")
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if answer != "fast answer" || time.Since(start) > time.Second {
+		t.Errorf("Stream = %q after %v, want the hedge's answer without waiting for the primary", answer, time.Since(start))
+	}
+	if got := strings.Join(chunks, ""); got != "fast answer" {
+		t.Errorf("chunks = %q, want only the winner's", got)
+	}
+
+	// The loser is cancelled through its context
+	deadline := time.Now().Add(time.Second)
+	for !primary.cancelled.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !primary.cancelled.Load() {
+		t.Error("the primary should be cancelled once the hedge wins")
+	}
+
+	global := mp.metrics.GetGlobalMetrics()
+	if global.HedgedRequests != 1 || global.HedgeWins != 1 || global.WastedTokens <= 0 {
+		t.Errorf("hedge metrics = %d hedged, %d wins, %d wasted tokens", global.HedgedRequests, global.HedgeWins, global.WastedTokens)
+	}
+}
+
+func TestMultiProvider_HedgedAsk(t *testing.T) {
+	t.Run("primary answers within the delay", func(t *testing.T) {
+		primary := &latencyAdapter{content: "primary answer"}
+		backup := &latencyAdapter{content: "backup answer"}
+		mp := newHedgedMultiProvider(t, primary, backup, 0)
+
+		if answer, err := mp.Ask(context.Background(), "Hi"); err != nil || answer != "primary answer" {
+			t.Errorf("Ask = %q, %v", answer, err)
+		}
+		if backup.calls.Load() != 0 || mp.metrics.GetGlobalMetrics().HedgedRequests != 0 {
+			t.Error("a fast primary should not be hedged")
+		}
+	})
+
+	t.Run("primary fails before the delay", func(t *testing.T) {
+		primary := &latencyAdapter{err: errors.New("503 service unavailable")}
+		backup := &latencyAdapter{content: "backup answer"}
+		mp := newHedgedMultiProvider(t, primary, backup, 0)
+
+		if answer, err := mp.Ask(context.Background(), "Hi"); err != nil || answer != "backup answer" {
+			t.Errorf("Ask = %q, %v, want the fallback's answer", answer, err)
+		}
+	})
+
+	t.Run("hedge provider at max concurrency", func(t *testing.T) {
+		primary := &latencyAdapter{delay: 60 * time.Millisecond, content: "primary answer"}
+		backup := &latencyAdapter{content: "backup answer"}
+		mp := newHedgedMultiProvider(t, primary, backup, 1)
+		mp.balancer.StartRequest("backup")
+
+		if answer, err := mp.Ask(context.Background(), "Hi"); err != nil || answer != "primary answer" {
+			t.Errorf("Ask = %q, %v", answer, err)
+		}
+		if backup.calls.Load() != 0 {
+			t.Error("the hedge should respect the backup's MaxConcurrency")
+		}
+	})
+
+	t.Run("all providers fail", func(t *testing.T) {
+		failing := &latencyAdapter{err: errors.New("503 service unavailable")}
+		mp := newHedgedMultiProvider(t, failing, failing, 0)
+		if _, err := mp.Ask(context.Background(), "Hi"); err == nil || !strings.Contains(err.Error(), "503") {
+			t.Errorf("Ask error = %v, want the last provider error", err)
+		}
+	})
+}
@@ -749,4 +749,38 @@ func TestMultiProviderIntegration(t *testing.T) {
 			t.Errorf("Expected health status for 2 providers after remove, got: %d", len(healthAfterRemove))
 		}
 	})
-}
\ No newline at end of file
+}
+// TestFallbackHandler_PrimaryFirst tests that the primary provider is tried
+// before the fallback providers, and skipped when disabled
+func TestFallbackHandler_PrimaryFirst(t *testing.T) {
+	providers := []*ProviderConfig{{Name: "first"}, {Name: "primary"}, {Name: "last"}}
+
+	run := func(primary *ProviderConfig, failing string) ([]string, string, error) {
+		var attempts []string
+		handler := NewFallbackHandler(&MultiProviderConfig{FallbackStrategy: FallbackStrategyFailFast})
+		result, err := handler.ExecuteWithFallback(context.Background(), primary, providers, func(provider *ProviderConfig) (string, error) {
+			attempts = append(attempts, provider.Name)
+			if provider.Name == failing {
+				return "", errors.New("unavailable")
+			}
+			return "answer from " + provider.Name, nil
+		}, "test message")
+		return attempts, result, err
+	}
+
+	attempts, result, err := run(providers[1], "")
+	if err != nil || result != "answer from primary" || strings.Join(attempts, ",") != "primary" {
+		t.Errorf("got %q, %v after attempts %v, want the primary's answer", result, err, attempts)
+	}
+
+	attempts, result, err = run(providers[1], "primary")
+	if err != nil || result != "answer from first" || strings.Join(attempts, ",") != "primary,first" {
+		t.Errorf("got %q, %v after attempts %v, want a fallback after the primary failed", result, err, attempts)
+	}
+
+	disabled := &ProviderConfig{Name: "primary", Status: ProviderStatusDisabled}
+	attempts, _, _ = run(disabled, "")
+	if strings.Join(attempts, ",") != "first" {
+		t.Errorf("attempts = %v, want the disabled primary skipped", attempts)
+	}
+}
@@ -19,6 +19,9 @@ type MetricsCollector struct {
 	globalMetrics   *GlobalMetrics
 	mu              sync.RWMutex
 
+	// Registry receiving request metrics (nil = disabled)
+	registry MetricsRegistry
+
 	// Aggregation state
 	interval     time.Duration
 	shutdown     chan struct{}
@@ -55,12 +58,26 @@ type GlobalMetrics struct {
 	// Active metrics
 	ActiveRequests        int64          `json:"active_requests"`
 	ConcurrentConnections int64          `json:"concurrent_connections"`
+
+	// Hedged request metrics
+	HedgedRequests        int64          `json:"hedged_requests"` // Requests sent to a second provider
+	HedgeWins             int64          `json:"hedge_wins"`      // Hedged requests won by the second provider
+	WastedTokens          int64          `json:"wasted_tokens"`   // Estimated tokens of cancelled attempts
+}
+
+// HedgeOutcome describes the outcome of a hedged request
+type HedgeOutcome struct {
+	Primary      string `json:"primary"`
+	Hedge        string `json:"hedge,omitempty"`  // Empty when the primary answered within the hedge delay
+	Winner       string `json:"winner,omitempty"` // Empty when all attempts failed
+	WastedTokens int    `json:"wasted_tokens"`    // Estimated tokens spent on cancelled attempts
 }
 
 // RequestMetrics contains metrics for a single request
 type RequestMetrics struct {
 	Provider       string        `json:"provider"`
-	RequestType    string        `json:"request_type"` // "ask", "stream"
+	Model          string        `json:"model,omitempty"`
+	RequestType    string        `json:"request_type"` // "ask", "stream", "tools"
 	StartTime      time.Time     `json:"start_time"`
 	EndTime        time.Time     `json:"end_time"`
 	ResponseTime   time.Duration `json:"response_time"`
@@ -149,12 +166,72 @@ func (mc *MetricsCollector) RecordRequest(metrics *RequestMetrics) {
 	// Update global metrics
 	mc.updateGlobalMetrics(metrics)
 
+	if mc.registry != nil {
+		labels := map[string]string{"provider": metrics.Provider, "model": metrics.Model}
+		mc.registry.ObserveHistogram(MetricRequestDuration, labels, metrics.ResponseTime.Seconds())
+		labels["status"] = "success"
+		if !metrics.Success {
+			labels["status"] = "error"
+		}
+		mc.registry.AddCounter(MetricRequestsTotal, labels, 1)
+		recordTokens(mc.registry, map[string]string{"provider": metrics.Provider, "model": metrics.Model}, metrics.TokenUsage)
+	}
+
 	mc.logger.Debug(nil, "Recorded request metrics",
 		F("provider", metrics.Provider),
 		F("response_time", metrics.ResponseTime),
 		F("success", metrics.Success))
 }
 
+// RecordHedge records the outcome of a hedged request
+func (mc *MetricsCollector) RecordHedge(outcome *HedgeOutcome) {
+	if outcome == nil || outcome.Hedge == "" {
+		return
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.globalMetrics.HedgedRequests++
+	if outcome.Winner != "" && outcome.Winner == outcome.Hedge {
+		mc.globalMetrics.HedgeWins++
+	}
+	mc.globalMetrics.WastedTokens += int64(outcome.WastedTokens)
+
+	if mc.registry != nil {
+		labels := map[string]string{"provider": outcome.Hedge}
+		mc.registry.AddCounter(MetricHedgedRequestsTotal, labels, 1)
+		if outcome.Winner != "" && outcome.Winner == outcome.Hedge {
+			mc.registry.AddCounter(MetricHedgeWinsTotal, labels, 1)
+		}
+		mc.registry.AddCounter(MetricHedgeWastedTokensTotal, nil, float64(outcome.WastedTokens))
+	}
+
+	mc.logger.Debug(nil, "Recorded hedged request",
+		F("primary", outcome.Primary),
+		F("hedge", outcome.Hedge),
+		F("winner", outcome.Winner),
+		F("wasted_tokens", outcome.WastedTokens))
+}
+
+// RecordTokens records the tokens used by a request to a provider
+func (mc *MetricsCollector) RecordTokens(provider *ProviderConfig, usage TokenUsage) {
+	mc.mu.RLock()
+	registry := mc.registry
+	mc.mu.RUnlock()
+
+	if registry != nil {
+		recordTokens(registry, map[string]string{"provider": provider.Name, "model": provider.Model}, usage)
+	}
+}
+
+// SetRegistry sets the registry receiving request metrics
+func (mc *MetricsCollector) SetRegistry(registry MetricsRegistry) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.registry = registry
+}
+
 // GetMetrics returns current metrics for all providers
 func (mc *MetricsCollector) GetAllMetrics() map[string]*ProviderMetrics {
 	mc.mu.RLock()
@@ -513,4 +590,4 @@ func (mc *MetricsCollector) ExportMetrics() map[string]interface{} {
 	}
 
 	return export
-}
\ No newline at end of file
+}
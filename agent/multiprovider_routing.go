@@ -0,0 +1,148 @@
+// Package agent implements cost-aware and capability-aware routing for MultiProvider
+// This file contains provider pricing, capabilities and the cheapest-capable selection
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// defaultEstimatedOutputTokens is the output size assumed for requests without MaxTokens
+const defaultEstimatedOutputTokens = 500
+
+// ProviderPricing is the price of a provider in USD per million tokens.
+// Local models are free and keep the zero value.
+type ProviderPricing struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// ProviderCapabilities describes what the model of a provider supports.
+// Providers without capabilities are assumed to serve plain text only.
+type ProviderCapabilities struct {
+	Vision        bool `json:"vision"`         // Images in messages
+	Tools         bool `json:"tools"`          // Tool (function) calling
+	JSONSchema    bool `json:"json_schema"`    // Structured outputs with a JSON schema
+	ContextWindow int  `json:"context_window"` // Maximum prompt + output tokens (0 = unknown)
+}
+
+// RequestRequirements describes what a provider needs to support to serve a request
+type RequestRequirements struct {
+	Vision          bool
+	Tools           bool
+	JSONSchema      bool
+	PromptTokens    int // Estimated prompt tokens
+	MaxOutputTokens int // Expected output tokens
+}
+
+// RequirementsFor derives the requirements of a completion request: images
+// in messages need vision, tools need tool calling and a JSON schema response
+// format needs structured outputs. Prompt tokens are estimated from the text.
+func RequirementsFor(req *CompletionRequest) RequestRequirements {
+	needs := RequestRequirements{
+		Tools:           len(req.Tools) > 0,
+		JSONSchema:      requiresJSONSchema(req.ResponseFormat),
+		PromptTokens:    estimateTokens(req.System),
+		MaxOutputTokens: req.MaxTokens,
+	}
+	if needs.MaxOutputTokens <= 0 {
+		needs.MaxOutputTokens = defaultEstimatedOutputTokens
+	}
+
+	for _, msg := range req.Messages {
+		needs.Vision = needs.Vision || len(msg.Images) > 0
+		needs.PromptTokens += estimateTokens(msg.Content)
+		for _, toolCall := range msg.ToolCalls {
+			needs.PromptTokens += estimateTokens(toolCall.Name + toolCall.Arguments)
+		}
+	}
+	for _, tool := range req.Tools {
+		definition, _ := json.Marshal(tool.Parameters)
+		needs.PromptTokens += estimateTokens(tool.Name + tool.Description + string(definition))
+	}
+	return needs
+}
+
+// requiresJSONSchema reports whether a response format asks for a JSON schema
+func requiresJSONSchema(format interface{}) bool {
+	switch f := format.(type) {
+	case openai.ChatCompletionNewParamsResponseFormatUnion:
+		return f.OfJSONSchema != nil
+	case *openai.ChatCompletionNewParamsResponseFormatUnion:
+		return f != nil && f.OfJSONSchema != nil
+	case map[string]interface{}:
+		return f["type"] == "json_schema"
+	default:
+		return false
+	}
+}
+
+// CanServe reports whether the provider's capabilities fit the requirements
+func (p *ProviderConfig) CanServe(needs RequestRequirements) bool {
+	caps := p.Capabilities
+	if needs.Vision && !caps.Vision {
+		return false
+	}
+	if needs.Tools && !caps.Tools {
+		return false
+	}
+	if needs.JSONSchema && !caps.JSONSchema {
+		return false
+	}
+	if caps.ContextWindow > 0 && needs.PromptTokens+needs.MaxOutputTokens > caps.ContextWindow {
+		return false
+	}
+	return true
+}
+
+// EstimateCost estimates the cost in USD of serving the requirements with the provider
+func (p *ProviderConfig) EstimateCost(needs RequestRequirements) float64 {
+	usage := TokenUsage{PromptTokens: needs.PromptTokens, CompletionTokens: needs.MaxOutputTokens}
+	return usage.EstimateCost(p.Pricing.InputPerMillion, p.Pricing.OutputPerMillion)
+}
+
+// RankCheapestCapable returns the available providers able to serve the
+// requirements, cheapest first. Providers of the same cost are ordered by
+// weight (higher first), then by name.
+func (ps *ProviderSelector) RankCheapestCapable(providers []*ProviderConfig, needs RequestRequirements) ([]*ProviderConfig, error) {
+	var capable []*ProviderConfig
+	for _, provider := range ps.getAvailableProviders(providers) {
+		if provider.CanServe(needs) {
+			capable = append(capable, provider)
+		}
+	}
+	if len(capable) == 0 {
+		return nil, fmt.Errorf("no provider can serve the request (vision: %v, tools: %v, json schema: %v, ~%d tokens)",
+			needs.Vision, needs.Tools, needs.JSONSchema, needs.PromptTokens+needs.MaxOutputTokens)
+	}
+
+	sort.SliceStable(capable, func(i, j int) bool {
+		costI, costJ := capable[i].EstimateCost(needs), capable[j].EstimateCost(needs)
+		if costI != costJ {
+			return costI < costJ
+		}
+		if capable[i].Weight != capable[j].Weight {
+			return capable[i].Weight > capable[j].Weight
+		}
+		return capable[i].Name < capable[j].Name
+	})
+
+	ps.logger.Debug(nil, "Selected provider using cheapest capable",
+		F("provider", capable[0].Name),
+		F("estimated_cost", capable[0].EstimateCost(needs)),
+		F("candidates", len(capable)))
+
+	return capable, nil
+}
+
+// selectCheapestCapable implements cheapest-capable selection for a plain text request
+func (ps *ProviderSelector) selectCheapestCapable(providers []*ProviderConfig) (*ProviderConfig, error) {
+	ranked, err := ps.RankCheapestCapable(providers, RequestRequirements{MaxOutputTokens: defaultEstimatedOutputTokens})
+	if err != nil {
+		return nil, err
+	}
+	return ranked[0], nil
+}
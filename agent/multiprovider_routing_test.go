@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// routingProviders returns a free local model, a cheap hosted model with tools
+// and an expensive model supporting everything
+func routingProviders() []*ProviderConfig {
+	return []*ProviderConfig{
+		{
+			Name: "big", Weight: 1,
+			Pricing:      ProviderPricing{InputPerMillion: 2.5, OutputPerMillion: 10},
+			Capabilities: ProviderCapabilities{Vision: true, Tools: true, JSONSchema: true, ContextWindow: 128000},
+		},
+		{
+			Name: "small", Weight: 1,
+			Pricing:      ProviderPricing{InputPerMillion: 0.15, OutputPerMillion: 0.6},
+			Capabilities: ProviderCapabilities{Tools: true, ContextWindow: 16000},
+		},
+		{
+			Name: "local", Weight: 1,
+			Capabilities: ProviderCapabilities{ContextWindow: 4096},
+		},
+	}
+}
+
+func TestRequirementsFor(t *testing.T) {
+	needs := RequirementsFor(&CompletionRequest{
+		System:   strings.Repeat("a", 400),
+		Messages: []Message{{Role: "user", Content: strings.Repeat("b", 400), Images: []ImageContent{{URL: "https://example.com/cat.png"}}}},
+	})
+	if !needs.Vision || needs.Tools || needs.JSONSchema {
+		t.Errorf("needs = %+v, want vision only", needs)
+	}
+	if needs.PromptTokens != 200 || needs.MaxOutputTokens != defaultEstimatedOutputTokens {
+		t.Errorf("tokens = %d prompt, %d output", needs.PromptTokens, needs.MaxOutputTokens)
+	}
+
+	needs = RequirementsFor(&CompletionRequest{
+		Messages:  []Message{User("hi")},
+		Tools:     []*Tool{NewTool("lookup", "Look up a city")},
+		MaxTokens: 100,
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{},
+		},
+	})
+	if !needs.Tools || !needs.JSONSchema || needs.Vision || needs.MaxOutputTokens != 100 {
+		t.Errorf("needs = %+v, want tools and JSON schema", needs)
+	}
+
+	if !requiresJSONSchema(map[string]interface{}{"type": "json_schema"}) || requiresJSONSchema(map[string]interface{}{"type": "json_object"}) {
+		t.Error("JSON schema detection of map response formats is wrong")
+	}
+}
+
+func TestRankCheapestCapable(t *testing.T) {
+	selector := NewProviderSelector(&MultiProviderConfig{})
+	providers := routingProviders()
+
+	tests := []struct {
+		name  string
+		needs RequestRequirements
+		want  string
+	}{
+		{"plain text goes local", RequestRequirements{PromptTokens: 100, MaxOutputTokens: 500}, "local,small,big"},
+		{"tools skip the local model", RequestRequirements{Tools: true, PromptTokens: 100, MaxOutputTokens: 500}, "small,big"},
+		{"long prompts exceed the local context window", RequestRequirements{PromptTokens: 8000, MaxOutputTokens: 500}, "small,big"},
+		{"vision needs the big model", RequestRequirements{Vision: true, PromptTokens: 100, MaxOutputTokens: 500}, "big"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked, err := selector.RankCheapestCapable(providers, tt.needs)
+			if err != nil {
+				t.Fatalf("RankCheapestCapable failed: %v", err)
+			}
+			var names []string
+			for _, provider := range ranked {
+				names = append(names, provider.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("ranking = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := selector.RankCheapestCapable(providers, RequestRequirements{Vision: true, PromptTokens: 200000}); err == nil {
+		t.Error("expected an error when no provider can serve the request")
+	}
+
+	// Disabled providers are not routed to
+	providers[2].Status = ProviderStatusDisabled
+	if selected, _ := selector.SelectProvider(providers, StrategyCheapestCapable); selected.Name != "small" {
+		t.Errorf("selected %s, want small with local disabled", selected.Name)
+	}
+}
+
+func TestProviderConfig_EstimateCost(t *testing.T) {
+	provider := routingProviders()[1]
+	cost := provider.EstimateCost(RequestRequirements{PromptTokens: 1_000_000, MaxOutputTokens: 1_000_000})
+	if cost < 0.749 || cost > 0.751 {
+		t.Errorf("cost = %f, want 0.75", cost)
+	}
+}
+
+func TestMultiProvider_CheapestCapableRouting(t *testing.T) {
+	answer := func(name string) *stepAdapter {
+		return &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+			return &CompletionResponse{Content: "answer from " + name}, nil
+		}}
+	}
+	local, small := answer("local"), &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return nil, errors.New("503 service unavailable")
+	}}
+	big := answer("big")
+
+	config := &MultiProviderConfig{
+		SelectionStrategy:       StrategyCheapestCapable,
+		FallbackStrategy:        FallbackStrategyFailFast,
+		CircuitBreakerThreshold: 5,
+	}
+	for _, provider := range routingProviders() {
+		provider.Type = "adapter"
+		provider.Model = provider.Name + "-model"
+		config.Providers = append(config.Providers, *provider)
+	}
+	config.Providers[0].Adapter, config.Providers[1].Adapter, config.Providers[2].Adapter = big, small, local
+
+	mp, err := NewMultiProvider(config)
+	if err != nil {
+		t.Fatalf("NewMultiProvider failed: %v", err)
+	}
+
+	// Simple prompts go to the free local model
+	if got, err := mp.Ask(context.Background(), "Say hello"); err != nil || got != "answer from local" {
+		t.Errorf("Ask = %q, %v, want the local model", got, err)
+	}
+
+	// Tool requests skip the local model; the failing cheap model falls back
+	// to the next capable one, never to the local model
+	var toolCalls int
+	got, err := mp.WithTools(countingTool(&toolCalls)).AskWithTools(context.Background(), "Population of Tokyo?")
+	if err != nil || got != "answer from big" {
+		t.Errorf("AskWithTools = %q, %v, want the big model after the small one failed", got, err)
+	}
+	if len(small.requests) != 1 || len(local.requests) != 1 {
+		t.Errorf("requests = %d small, %d local, want 1 and 1", len(small.requests), len(local.requests))
+	}
+}
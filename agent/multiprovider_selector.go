@@ -54,6 +54,8 @@ func (ps *ProviderSelector) SelectProvider(providers []*ProviderConfig, strategy
 		return ps.selectRandom(availableProviders)
 	case StrategyPriority:
 		return ps.selectPriority(availableProviders)
+	case StrategyCheapestCapable:
+		return ps.selectCheapestCapable(availableProviders)
 	default:
 		return ps.selectRandom(availableProviders)
 	}
@@ -0,0 +1,232 @@
+// Package agent implements tool calling for MultiProvider
+// This file contains the tool loop and ReAct execution with per-step failover
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// defaultMultiProviderToolRounds is the default maximum number of LLM steps of a tool loop
+const defaultMultiProviderToolRounds = 10
+
+// WithTools registers tools that AskWithTools and Execute offer to the model.
+//
+// Example:
+//
+//	mp.WithTools(weatherTool, searchTool)
+//	answer, err := mp.AskWithTools(ctx, "What's the weather in Paris?")
+func (mp *MultiProvider) WithTools(tools ...*Tool) *MultiProvider {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.tools = append(mp.tools, tools...)
+	return mp
+}
+
+// WithSystem sets the system prompt of AskWithTools and Execute
+func (mp *MultiProvider) WithSystem(prompt string) *MultiProvider {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.systemPrompt = prompt
+	return mp
+}
+
+// WithMaxToolRounds sets the maximum number of LLM steps of AskWithTools and Execute (default 10)
+func (mp *MultiProvider) WithMaxToolRounds(max int) *MultiProvider {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	mp.maxToolRounds = max
+	return mp
+}
+
+// AskWithTools sends a message with the registered tools and executes the
+// tools the model calls until it answers.
+//
+// Every LLM step goes through provider selection and fallback on its own: when
+// a provider fails mid-loop, only that step moves to another provider, which
+// receives the whole conversation including earlier tool calls and results.
+// Tools that already ran are never executed again. Tool errors are returned
+// to the model as the tool result so that it can recover.
+func (mp *MultiProvider) AskWithTools(ctx context.Context, message string) (string, error) {
+	return mp.runToolLoop(ctx, message, nil)
+}
+
+// Execute runs a task in ReAct style with the registered tools, using native
+// function calling: reasoning that accompanies tool calls is recorded as
+// THOUGHT steps, tool calls as ACTION and OBSERVATION steps, and the answer
+// as the FINAL step. Failover works as in AskWithTools.
+//
+// Example:
+//
+//	result, err := mp.WithTools(searchTool).Execute(ctx, "Find the population of Tokyo")
+//	for _, step := range result.Steps {
+//	    fmt.Printf("%s: %s\n", step.Type, step.Content)
+//	}
+func (mp *MultiProvider) Execute(ctx context.Context, task string) (*ReActResult, error) {
+	result := &ReActResult{
+		Steps:   []ReActStep{},
+		Metrics: NewReActMetrics(),
+	}
+
+	answer, err := mp.runToolLoop(ctx, task, result)
+	result.Metrics.Finalize()
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+
+	result.Answer = answer
+	result.Success = true
+	result.Steps = append(result.Steps, ReActStep{Type: StepTypeFinal, Content: answer, Timestamp: time.Now()})
+	return result, nil
+}
+
+// runToolLoop runs the tool loop of a message, recording the steps in result if not nil
+func (mp *MultiProvider) runToolLoop(ctx context.Context, message string, result *ReActResult) (string, error) {
+	mp.mu.RLock()
+	tools := append([]*Tool(nil), mp.tools...)
+	systemPrompt := mp.systemPrompt
+	maxRounds := mp.maxToolRounds
+	mp.mu.RUnlock()
+	if maxRounds <= 0 {
+		maxRounds = defaultMultiProviderToolRounds
+	}
+
+	messages := []Message{}
+	if systemPrompt != "" {
+		messages = append(messages, System(systemPrompt))
+	}
+	messages = append(messages, User(message))
+
+	for round := 0; round < maxRounds; round++ {
+		if result != nil {
+			result.Iterations = round + 1
+			result.Metrics.TotalIterations = round + 1
+		}
+
+		resp, err := mp.completeWithFallback(ctx, messages, tools)
+		if err != nil {
+			return "", fmt.Errorf("LLM step %d failed: %w", round+1, err)
+		}
+		if result != nil {
+			result.Metrics.TokensUsed += resp.Usage.TotalTokens
+		}
+
+		if len(resp.ToolCalls) == 0 {
+			return resp.Content, nil
+		}
+
+		// Record the tool calls before executing them, so that a later step
+		// on any provider sees the complete history
+		toolCalls := normalizeToolCalls(resp.ToolCalls, round)
+		messages = append(messages, Message{Role: "assistant", Content: resp.Content, ToolCalls: toolCalls})
+		if result != nil && resp.Content != "" {
+			result.Steps = append(result.Steps, ReActStep{Type: StepTypeThought, Content: resp.Content, Timestamp: time.Now()})
+		}
+
+		for _, toolCall := range toolCalls {
+			output, toolErr := callTool(ctx, tools, toolCall)
+			if toolErr != nil {
+				output = fmt.Sprintf("Error: %v", toolErr)
+			}
+			messages = append(messages, Message{Role: "tool", Content: output, ToolCallID: toolCall.ID})
+
+			if result != nil {
+				recordToolSteps(result, toolCall, output, toolErr)
+			}
+		}
+	}
+
+	return "", fmt.Errorf("max tool rounds (%d) exceeded", maxRounds)
+}
+
+// completeWithFallback performs a single LLM step of a tool loop with fallback handling
+func (mp *MultiProvider) completeWithFallback(ctx context.Context, messages []Message, tools []*Tool) (*CompletionResponse, error) {
+	var resp *CompletionResponse
+	_, err := mp.executeWithFallback(ctx, "tools", func(provider *ProviderConfig) (string, error) {
+		stepResp, err := mp.completeStep(ctx, provider, messages, tools)
+		if err != nil {
+			return "", err
+		}
+		mp.metrics.RecordTokens(provider, stepResp.Usage)
+		resp = stepResp
+		return stepResp.Content, nil
+	}, &CompletionRequest{Messages: messages, Tools: tools})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// completeStep performs a single LLM step with a provider
+func (mp *MultiProvider) completeStep(ctx context.Context, provider *ProviderConfig, messages []Message, tools []*Tool) (*CompletionResponse, error) {
+	if provider.Adapter != nil {
+		return provider.Adapter.Complete(ctx, &CompletionRequest{
+			Model:    provider.Model,
+			Messages: messages,
+			Tools:    tools,
+		})
+	}
+
+	if provider.Builder == nil {
+		return nil, fmt.Errorf("provider %s has no builder or adapter", provider.Name)
+	}
+
+	return provider.Builder.completeMessages(ctx, messages, tools)
+}
+
+// normalizeToolCalls gives an ID to tool calls without one, as some providers
+// require tool results to reference the call they answer
+func normalizeToolCalls(toolCalls []ToolCall, round int) []ToolCall {
+	normalized := make([]ToolCall, len(toolCalls))
+	for i, toolCall := range toolCalls {
+		if toolCall.ID == "" {
+			toolCall.ID = fmt.Sprintf("call_%d_%d", round+1, i+1)
+		}
+		if toolCall.Type == "" {
+			toolCall.Type = "function"
+		}
+		normalized[i] = toolCall
+	}
+	return normalized
+}
+
+// callTool executes a tool call with the matching registered tool
+func callTool(ctx context.Context, tools []*Tool, toolCall ToolCall) (string, error) {
+	for _, tool := range tools {
+		if tool.Name == toolCall.Name {
+			return tool.call(ctx, toolCall.Arguments)
+		}
+	}
+	return "", fmt.Errorf("tool not found: %s", toolCall.Name)
+}
+
+// recordToolSteps records a tool call as ACTION and OBSERVATION steps
+func recordToolSteps(result *ReActResult, toolCall ToolCall, output string, toolErr error) {
+	var args map[string]interface{}
+	_ = json.Unmarshal([]byte(toolCall.Arguments), &args)
+
+	now := time.Now()
+	result.Steps = append(result.Steps,
+		ReActStep{
+			Type:      StepTypeAction,
+			Content:   fmt.Sprintf("%s(%s)", toolCall.Name, toolCall.Arguments),
+			Tool:      toolCall.Name,
+			Args:      args,
+			Timestamp: now,
+		},
+		ReActStep{
+			Type:      StepTypeObservation,
+			Content:   output,
+			Timestamp: now,
+			Error:     toolErr,
+		},
+	)
+
+	result.Metrics.ToolCalls++
+	if toolErr != nil {
+		result.Metrics.Errors++
+	}
+}
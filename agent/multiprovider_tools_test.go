@@ -0,0 +1,224 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stepAdapter answers each completion with complete and records the requests
+type stepAdapter struct {
+	mu       sync.Mutex
+	complete func(call int, req *CompletionRequest) (*CompletionResponse, error)
+	requests []*CompletionRequest
+}
+
+func (a *stepAdapter) Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests = append(a.requests, req)
+	return a.complete(len(a.requests), req)
+}
+
+func (a *stepAdapter) Stream(ctx context.Context, req *CompletionRequest, onChunk func(string)) (*CompletionResponse, error) {
+	return a.Complete(ctx, req)
+}
+
+// newToolMultiProvider creates a MultiProvider trying primary, then backup
+func newToolMultiProvider(t *testing.T, primary, backup LLMAdapter) *MultiProvider {
+	t.Helper()
+	mp, err := NewMultiProvider(&MultiProviderConfig{
+		Providers: []ProviderConfig{
+			{Name: "primary", Type: "adapter", Model: "model-a", Weight: 2, Adapter: primary},
+			{Name: "backup", Type: "adapter", Model: "model-b", Weight: 1, Adapter: backup},
+		},
+		SelectionStrategy:       StrategyPriority,
+		FallbackStrategy:        FallbackStrategyFailFast,
+		CircuitBreakerThreshold: 5,
+	})
+	if err != nil {
+		t.Fatalf("NewMultiProvider failed: %v", err)
+	}
+	return mp
+}
+
+// countingTool returns a lookup tool counting its executions
+func countingTool(calls *int) *Tool {
+	return NewTool("lookup", "Look up a city").
+		AddParameter("city", "string", "City name", true).
+		WithHandler(func(args string) (string, error) {
+			*calls++
+			return "population 14 million", nil
+		})
+}
+
+func TestMultiProvider_AskWithTools_FailoverMidLoop(t *testing.T) {
+	primary := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		if call == 1 {
+			// A tool call without ID, as some providers return
+			return &CompletionResponse{ToolCalls: []ToolCall{{Name: "lookup", Arguments: `{"city":"Tokyo"}`}}}, nil
+		}
+		return nil, errors.New("503 service unavailable")
+	}}
+	backup := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{Content: "Tokyo has 14 million people"}, nil
+	}}
+
+	var toolCalls int
+	mp := newToolMultiProvider(t, primary, backup).WithSystem("Be brief").WithTools(countingTool(&toolCalls))
+
+	answer, err := mp.AskWithTools(context.Background(), "How many people live in Tokyo?")
+	if err != nil {
+		t.Fatalf("AskWithTools failed: %v", err)
+	}
+	if answer != "Tokyo has 14 million people" {
+		t.Errorf("answer = %q", answer)
+	}
+	if toolCalls != 1 {
+		t.Errorf("tool executed %d times, want once", toolCalls)
+	}
+	if len(primary.requests) != 2 || len(backup.requests) != 1 {
+		t.Fatalf("requests = %d primary, %d backup, want 2 and 1", len(primary.requests), len(backup.requests))
+	}
+
+	// The backup continues the conversation, including the tool call and its result
+	req := backup.requests[0]
+	if req.Model != "model-b" || len(req.Tools) != 1 {
+		t.Errorf("backup request = model %s with %d tools", req.Model, len(req.Tools))
+	}
+	messages := req.Messages
+	if len(messages) != 4 || messages[0].Role != "system" || messages[1].Role != "user" {
+		t.Fatalf("backup messages = %+v", messages)
+	}
+	call := messages[2]
+	if call.Role != "assistant" || len(call.ToolCalls) != 1 || call.ToolCalls[0].ID != "call_1_1" {
+		t.Errorf("assistant message = %+v, want the tool call with a generated ID", call)
+	}
+	if result := messages[3]; result.Role != "tool" || result.ToolCallID != "call_1_1" || result.Content != "population 14 million" {
+		t.Errorf("tool message = %+v", result)
+	}
+}
+
+func TestMultiProvider_Execute(t *testing.T) {
+	adapter := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		switch call {
+		case 1:
+			return &CompletionResponse{
+				Content:   "I should look up both cities",
+				ToolCalls: []ToolCall{{ID: "a", Name: "lookup", Arguments: `{"city":"Tokyo"}`}, {ID: "b", Name: "weather", Arguments: `{}`}},
+				Usage:     TokenUsage{TotalTokens: 30},
+			}, nil
+		default:
+			return &CompletionResponse{Content: "Tokyo is bigger", Usage: TokenUsage{TotalTokens: 20}}, nil
+		}
+	}}
+
+	var toolCalls int
+	mp := newToolMultiProvider(t, adapter, &stepAdapter{}).WithTools(countingTool(&toolCalls))
+
+	result, err := mp.Execute(context.Background(), "Which city is bigger?")
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Success || result.Answer != "Tokyo is bigger" || result.Iterations != 2 {
+		t.Errorf("result = success %v, answer %q, %d iterations", result.Success, result.Answer, result.Iterations)
+	}
+
+	var types []string
+	for _, step := range result.Steps {
+		types = append(types, step.Type)
+	}
+	if got := strings.Join(types, ","); got != "THOUGHT,ACTION,OBSERVATION,ACTION,OBSERVATION,FINAL" {
+		t.Errorf("steps = %s", got)
+	}
+	if action := result.Steps[1]; action.Tool != "lookup" || action.Args["city"] != "Tokyo" {
+		t.Errorf("action = %+v", action)
+	}
+	// The unknown tool is reported to the model instead of aborting
+	if observation := result.Steps[4]; observation.Error == nil || !strings.HasPrefix(observation.Content, "Error:") {
+		t.Errorf("observation of unknown tool = %+v", observation)
+	}
+	if m := result.Metrics; m.ToolCalls != 2 || m.Errors != 1 || m.TokensUsed != 50 {
+		t.Errorf("metrics = %d tool calls, %d errors, %d tokens", m.ToolCalls, m.Errors, m.TokensUsed)
+	}
+}
+
+func TestMultiProvider_ToolLoopErrors(t *testing.T) {
+	failing := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return nil, errors.New("503 service unavailable")
+	}}
+	mp := newToolMultiProvider(t, failing, failing)
+	result, err := mp.Execute(context.Background(), "task")
+	if err == nil || result.Success || result.Error == nil {
+		t.Errorf("Execute = %+v, %v, want a failure when all providers fail", result, err)
+	}
+
+	looping := &stepAdapter{complete: func(call int, req *CompletionRequest) (*CompletionResponse, error) {
+		return &CompletionResponse{ToolCalls: []ToolCall{{Name: "lookup", Arguments: `{}`}}}, nil
+	}}
+	var toolCalls int
+	mp = newToolMultiProvider(t, looping, looping).WithTools(countingTool(&toolCalls)).WithMaxToolRounds(3)
+	if _, err := mp.AskWithTools(context.Background(), "task"); err == nil || !strings.Contains(err.Error(), "max tool rounds (3)") {
+		t.Errorf("AskWithTools error = %v, want max tool rounds exceeded", err)
+	}
+	if toolCalls != 3 {
+		t.Errorf("tool executed %d times, want 3", toolCalls)
+	}
+}
+
+func TestMultiProvider_AskWithTools_BuilderProvider(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []json.RawMessage `json:"messages"`
+			Tools    []json.RawMessage `json:"tools"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ := json.Marshal(req)
+		bodies = append(bodies, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(req.Messages) == 1 {
+			fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test","choices":[{"index":0,`+
+				`"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_x","type":"function","function":{"name":"lookup","arguments":"{\"city\":\"Tokyo\"}"}}]},`+
+				`"finish_reason":"tool_calls"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"chatcmpl-2","object":"chat.completion","created":1,"model":"test","choices":[{"index":0,`+
+			`"message":{"role":"assistant","content":"14 million"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	mp, err := NewMultiProvider(&MultiProviderConfig{
+		Providers: []ProviderConfig{{Name: "local", Type: "ollama", Model: "llama3", BaseURL: server.URL + "/v1/"}},
+	})
+	if err != nil {
+		t.Fatalf("NewMultiProvider failed: %v", err)
+	}
+
+	var toolCalls int
+	answer, err := mp.WithTools(countingTool(&toolCalls)).AskWithTools(context.Background(), "Population of Tokyo?")
+	if err != nil {
+		t.Fatalf("AskWithTools failed: %v", err)
+	}
+	if answer != "14 million" || toolCalls != 1 {
+		t.Errorf("answer = %q after %d tool calls", answer, toolCalls)
+	}
+
+	if len(bodies) != 2 || !strings.Contains(bodies[0], `"lookup"`) {
+		t.Fatalf("requests = %q, want two with the tool offered", bodies)
+	}
+	// The follow-up request carries the tool call and the matching tool result
+	if !strings.Contains(bodies[1], `"tool_calls"`) || !strings.Contains(bodies[1], `"tool_call_id":"call_x"`) {
+		t.Errorf("follow-up request lost the tool history: %s", bodies[1])
+	}
+}
@@ -51,6 +51,21 @@ type PersonaExample struct {
 	Response string `yaml:"response" json:"response"`
 }
 
+// clone returns a copy of the persona that shares no slices or configs with p
+func (p *Persona) clone() *Persona {
+	clone := *p
+	clone.Personality.Traits = append([]string(nil), p.Personality.Traits...)
+	clone.Guidelines = append([]string(nil), p.Guidelines...)
+	clone.Constraints = append([]string(nil), p.Constraints...)
+	clone.KnowledgeAreas = append([]string(nil), p.KnowledgeAreas...)
+	clone.Examples = append([]PersonaExample(nil), p.Examples...)
+	if p.FewShot != nil {
+		clone.FewShot = p.FewShot.clone()
+	}
+	clone.TechnicalConfig = clonePtr(p.TechnicalConfig)
+	return &clone
+}
+
 // ToSystemPrompt generates a system prompt from the persona configuration.
 // This converts the structured persona into a natural language prompt.
 func (p *Persona) ToSystemPrompt() string {
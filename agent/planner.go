@@ -56,7 +56,8 @@ const (
 	// TaskStatusFailed indicates the task failed with an error.
 	TaskStatusFailed TaskStatus = "failed"
 
-	// TaskStatusSkipped indicates the task was skipped due to dependency failure.
+	// TaskStatusSkipped indicates the task was skipped due to dependency failure,
+	// or cancelled because the goal was achieved before it ran.
 	TaskStatusSkipped TaskStatus = "skipped"
 )
 
@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/taipm/go-deep-agent/agent/memory"
+)
+
+// builderLLMGenerator adapts a Builder to the llmGenerator interface used by the Decomposer.
+// Decomposition prompts are stateless and don't touch the conversation history.
+type builderLLMGenerator struct {
+	builder *Builder
+}
+
+// Generate implements the llmGenerator interface
+func (g *builderLLMGenerator) Generate(ctx context.Context, prompt string, opts *ChatOptions) (string, error) {
+	return g.builder.completeText(ctx, "", prompt)
+}
+
+// builderTaskRunner runs planner tasks through a Builder.
+// With ReAct enabled, each task runs through the Builder's Execute loop with
+// its registered tools, and the ReAct steps are returned as the task trace.
+// Otherwise each task is a plain Ask.
+type builderTaskRunner struct {
+	builder     *Builder
+	reactConfig *ReActConfig // nil runs tasks without ReAct
+}
+
+// newBuilderTaskRunner creates a task runner. When useReAct is set, ReAct is
+// enabled for tasks even if the Builder itself has it disabled, keeping the
+// Builder's ReAct settings (mode, iterations, callbacks) otherwise.
+func newBuilderTaskRunner(b *Builder, useReAct bool) *builderTaskRunner {
+	runner := &builderTaskRunner{builder: b}
+	if useReAct {
+		config := NewReActConfig()
+		if b.reactConfig != nil {
+			*config = *b.reactConfig
+		}
+		config.Enabled = true
+		runner.reactConfig = config
+	}
+	return runner
+}
+
+// fork returns a copy of the Builder to run one task on. The copy shares the
+// client, tools, memory and logger, but has its own conversation history, so
+// that concurrent tasks don't race on it.
+func (r *builderTaskRunner) fork() *Builder {
+	task := *r.builder
+	task.messages = append([]Message(nil), r.builder.messages...)
+	task.reactConfig = r.reactConfig
+	if r.reactConfig == nil && r.builder.reactConfig != nil {
+		disabled := *r.builder.reactConfig
+		disabled.Enabled = false
+		task.reactConfig = &disabled
+	}
+	return &task
+}
+
+// runTask implements taskRunner
+func (r *builderTaskRunner) runTask(ctx context.Context, task *Task, prompt string) (string, []ReActStep, error) {
+	logger := r.builder.getLogger()
+	logger.Debug(ctx, "Planner task started",
+		F("task_id", task.ID),
+		F("react", r.reactConfig != nil))
+
+	result, err := r.fork().Execute(ctx, prompt)
+	if err == nil && !result.Success {
+		err = result.Error
+		if err == nil {
+			err = fmt.Errorf("task finished without an answer")
+		}
+	}
+
+	var steps []ReActStep
+	if result != nil {
+		steps = result.Steps
+	}
+	if err != nil {
+		logger.Warn(ctx, "Planner task failed", F("task_id", task.ID), F("error", err.Error()))
+		return "", steps, err
+	}
+
+	// Plain Ask already stores the exchange in memory; ReAct runs don't
+	if r.reactConfig != nil {
+		r.remember(ctx, task, result.Answer)
+	}
+
+	logger.Debug(ctx, "Planner task completed",
+		F("task_id", task.ID),
+		F("steps", len(steps)))
+	return result.Answer, steps, nil
+}
+
+// remember stores a task and its answer in the Builder's hierarchical memory
+func (r *builderTaskRunner) remember(ctx context.Context, task *Task, answer string) {
+	b := r.builder
+	if !b.memoryEnabled || b.memory == nil {
+		return
+	}
+
+	metadata := map[string]interface{}{"planner_task": task.ID}
+	_ = b.memory.Add(ctx, memory.Message{Role: "user", Content: task.Description, Timestamp: time.Now(), Metadata: metadata})
+	_ = b.memory.Add(ctx, memory.Message{Role: "assistant", Content: answer, Timestamp: time.Now(), Metadata: metadata})
+}
+
+// NewBuilderExecutor creates an Executor that runs every task through the Builder.
+// With config.ReActEnabled, tasks run through the Builder's ReAct loop (Execute)
+// with its registered tools, and each TaskResult records the ReAct steps.
+// Goal criteria are judged by the Builder's model unless config.GoalEvaluator is set.
+// If config is nil, it uses DefaultPlannerConfig().
+func NewBuilderExecutor(config *PlannerConfig, b *Builder) *Executor {
+	if config == nil {
+		config = DefaultPlannerConfig()
+	}
+	return &Executor{
+		config:    config,
+		runner:    newBuilderTaskRunner(b, config.ReActEnabled),
+		evaluator: NewLLMGoalEvaluator(&builderLLMGenerator{builder: b}),
+	}
+}
+
+// PlanAndExecute decomposes a goal into tasks with the Builder's model and
+// executes them through the Builder, using its tools, memory and logger.
+//
+// With config.ReActEnabled (the default), every task runs through the ReAct
+// loop and its steps are recorded in the task results. With
+// config.FallbackToReAct, a goal that can't be decomposed is run as a single
+// ReAct task instead of failing. If config is nil, DefaultPlannerConfig() is used.
+//
+// The success criteria emitted by the decomposition are judged by the Builder's
+// model (or config.GoalEvaluator) every GoalCheckInterval tasks, and the
+// remaining tasks are cancelled as soon as they are all met. With
+// config.MaxReplans set, failed tasks are replanned into alternative subtasks
+// up to that many times.
+//
+// Example:
+//
+//	result, err := agent.NewOpenAI("gpt-4o", apiKey).
+//	    WithTools(searchTool, calculatorTool).
+//	    PlanAndExecute(ctx, "Research the top 3 EV makers and compare their margins", nil)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, task := range result.Plan.Tasks {
+//	    fmt.Printf("%s: %v (%d steps)\n", task.Description, task.Result, len(task.ReActSteps))
+//	}
+func (b *Builder) PlanAndExecute(ctx context.Context, goal string, config *PlannerConfig) (*PlanResult, error) {
+	if config == nil {
+		config = DefaultPlannerConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid planner config: %w", err)
+	}
+
+	// Initialize the client once, before tasks may run concurrently
+	if b.adapter == nil {
+		if err := b.ensureClient(); err != nil {
+			return nil, fmt.Errorf("failed to initialize client: %w", err)
+		}
+	}
+
+	logger := b.getLogger()
+	logger.Info(ctx, "Planning started",
+		F("strategy", string(config.Strategy)),
+		F("react", config.ReActEnabled))
+
+	decomposeStart := time.Now()
+	decomposer := NewDecomposer(config, &builderLLMGenerator{builder: b})
+	executor := NewBuilderExecutor(config, b).WithReplanning(decomposer)
+
+	plan, err := decomposer.Decompose(ctx, goal)
+	if err != nil {
+		if !config.FallbackToReAct {
+			return nil, fmt.Errorf("goal decomposition failed: %w", err)
+		}
+
+		// Run the whole goal as a single ReAct task
+		logger.Warn(ctx, "Goal decomposition failed, falling back to ReAct", F("error", err.Error()))
+		plan = NewDecomposer(config, nil).createSimplePlan(goal)
+		plan.Metadata["fallback_to_react"] = err.Error()
+		executor.runner = newBuilderTaskRunner(b, true)
+	}
+	decompositionTime := time.Since(decomposeStart)
+
+	result, err := executor.executePlan(ctx, plan)
+	if result != nil {
+		result.Metrics.DecompositionTime = decompositionTime
+	}
+	if err != nil {
+		logger.Error(ctx, "Plan execution failed", F("plan_id", plan.ID), F("error", err.Error()))
+		return result, fmt.Errorf("plan execution failed: %w", err)
+	}
+
+	logger.Info(ctx, "Plan completed",
+		F("plan_id", plan.ID),
+		F("status", string(result.Status)),
+		F("tasks", result.Metrics.TaskCount))
+	return result, nil
+}
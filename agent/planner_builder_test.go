@@ -0,0 +1,192 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// chatMessage is a message of a chat completion request seen by the fake server
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// newFakeChatServer starts an OpenAI-compatible endpoint answering every
+// chat completion with respond(messages)
+func newFakeChatServer(t *testing.T, respond func(messages []chatMessage) string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []chatMessage `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		reply := respond(req.Messages)
+		mu.Unlock()
+
+		content, _ := json.Marshal(reply)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"test",`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":%s},"finish_reason":"stop"}]}`, content)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// lastMessage returns the content of the last message with the given role
+func lastMessage(messages []chatMessage, role string) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == role {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// isReActRequest reports whether the request carries the text ReAct system prompt
+func isReActRequest(messages []chatMessage) bool {
+	return len(messages) > 0 && messages[0].Role == "system" && strings.Contains(messages[0].Content, "THOUGHT")
+}
+
+const testPlanJSON = `{"tasks": [
+	{"id": "task_1", "description": "Look up the sales figure", "type": "observation", "dependencies": []},
+	{"id": "task_2", "description": "Summarize the sales figure", "type": "aggregate", "dependencies": ["task_1"]}
+]}`
+
+// plannerLLM answers decomposition prompts with plan and ReAct task prompts with a scripted trace
+func plannerLLM(plan string) func(messages []chatMessage) string {
+	return func(messages []chatMessage) string {
+		first := messages[0].Content
+		if len(messages) > 1 {
+			first = messages[1].Content
+		}
+		switch {
+		case strings.Contains(first, "task planning expert"):
+			return plan
+		case !isReActRequest(messages):
+			return "plain answer to: " + lastMessage(messages, "user")
+		case strings.HasPrefix(lastMessage(messages, "user"), "OBSERVATION:"):
+			return "FINAL: sales are " + strings.TrimPrefix(lastMessage(messages, "user"), "OBSERVATION: ")
+		case strings.HasPrefix(first, "Look up"):
+			return `ACTION: lookup(item="sales")`
+		default:
+			return "FINAL: done with " + first
+		}
+	}
+}
+
+// lookupTool returns a fixed sales figure and counts its calls
+func lookupTool(calls *int) *Tool {
+	return NewTool("lookup", "Look up a figure").
+		AddParameter("item", "string", "What to look up", true).
+		WithHandler(func(args string) (string, error) {
+			*calls++
+			return "42 units", nil
+		})
+}
+
+const testPlanGoal = "Research the sales data and then summarize it for the team"
+
+func TestBuilder_PlanAndExecute_ReAct(t *testing.T) {
+	llm := newFakeChatServer(t, plannerLLM(testPlanJSON))
+	calls := 0
+	builder := NewOllama("test").
+		WithBaseURL(llm.URL + "/v1/").
+		WithTool(lookupTool(&calls)).
+		WithReActTextMode()
+
+	result, err := builder.PlanAndExecute(context.Background(), testPlanGoal, nil)
+	if err != nil {
+		t.Fatalf("PlanAndExecute failed: %v", err)
+	}
+	if result.Status != PlanStatusCompleted {
+		t.Errorf("Status = %s, want completed", result.Status)
+	}
+	if calls != 1 {
+		t.Errorf("lookup tool called %d times, want 1", calls)
+	}
+
+	tasks := result.Plan.Tasks
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(tasks))
+	}
+	if tasks[0].Result != "sales are 42 units" {
+		t.Errorf("task_1 result = %v, want sales are 42 units", tasks[0].Result)
+	}
+
+	var types []string
+	for _, step := range tasks[0].ReActSteps {
+		types = append(types, step.Type)
+	}
+	if got := strings.Join(types, ","); got != "ACTION,OBSERVATION,FINAL" {
+		t.Errorf("task_1 steps = %s, want ACTION,OBSERVATION,FINAL", got)
+	}
+	// The aggregate task saw task_1's output and its answer is the plan's answer
+	final, _ := result.FinalResult.(string)
+	if !strings.Contains(final, "sales are 42 units") || final != tasks[1].Result {
+		t.Errorf("FinalResult = %v, want task_2 output built on task_1", result.FinalResult)
+	}
+	if builder.reactConfig.Enabled {
+		t.Error("PlanAndExecute should not enable ReAct on the builder itself")
+	}
+}
+
+func TestBuilder_PlanAndExecute_ReActDisabled(t *testing.T) {
+	llm := newFakeChatServer(t, plannerLLM(testPlanJSON))
+	calls := 0
+	builder := NewOllama("test").WithBaseURL(llm.URL + "/v1/").WithTool(lookupTool(&calls))
+
+	config := DefaultPlannerConfig()
+	config.ReActEnabled = false
+	result, err := builder.PlanAndExecute(context.Background(), testPlanGoal, config)
+	if err != nil {
+		t.Fatalf("PlanAndExecute failed: %v", err)
+	}
+
+	task := result.Plan.Tasks[0]
+	if task.Result != "plain answer to: Look up the sales figure" {
+		t.Errorf("task_1 result = %v, want a plain answer", task.Result)
+	}
+	if len(task.ReActSteps) != 1 || task.ReActSteps[0].Type != StepTypeFinal {
+		t.Errorf("task_1 steps = %+v, want a single final step", task.ReActSteps)
+	}
+	if calls != 0 {
+		t.Errorf("lookup tool called %d times without ReAct, want 0", calls)
+	}
+}
+
+func TestBuilder_PlanAndExecute_FallbackToReAct(t *testing.T) {
+	llm := newFakeChatServer(t, plannerLLM("this is not a plan"))
+	builder := NewOllama("test").WithBaseURL(llm.URL + "/v1/").WithReActTextMode()
+
+	result, err := builder.PlanAndExecute(context.Background(), testPlanGoal, nil)
+	if err != nil {
+		t.Fatalf("PlanAndExecute failed: %v", err)
+	}
+	if len(result.Plan.Tasks) != 1 || result.Plan.Tasks[0].Description != testPlanGoal {
+		t.Errorf("tasks = %+v, want the goal as a single task", result.Plan.Tasks)
+	}
+	if _, ok := result.Plan.Metadata["fallback_to_react"]; !ok {
+		t.Error("plan metadata should record the fallback")
+	}
+	if len(result.Plan.Tasks[0].ReActSteps) == 0 {
+		t.Error("fallback task should record ReAct steps")
+	}
+
+	config := DefaultPlannerConfig()
+	config.FallbackToReAct = false
+	if _, err := builder.PlanAndExecute(context.Background(), testPlanGoal, config); err == nil {
+		t.Error("PlanAndExecute should fail without FallbackToReAct")
+	}
+}
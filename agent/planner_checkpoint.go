@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// strategyRunner returns the execution loop of a planning strategy.
+func (e *Executor) strategyRunner(strategy PlanningStrategy) func(context.Context, *executionContext) (*PlanResult, error) {
+	switch strategy {
+	case StrategyParallel:
+		return e.runParallel
+	case StrategyAdaptive:
+		return e.runAdaptive
+	default:
+		return e.runSequential
+	}
+}
+
+// executePlan runs a plan with the execution loop of its strategy.
+func (e *Executor) executePlan(ctx context.Context, plan *Plan) (*PlanResult, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("plan cannot be nil")
+	}
+	return e.run(ctx, newExecutionContext(plan), e.strategyRunner(plan.Strategy))
+}
+
+// run executes a plan with an execution loop and checkpoints its state
+// before it starts and once it finishes.
+func (e *Executor) run(ctx context.Context, execCtx *executionContext, loop func(context.Context, *executionContext) (*PlanResult, error)) (*PlanResult, error) {
+	e.checkpoint(ctx, execCtx, PlanStatusRunning)
+	result, err := loop(ctx, execCtx)
+	if result != nil {
+		e.checkpoint(ctx, execCtx, result.Status)
+	}
+	return result, err
+}
+
+// checkpoint saves the state of the plan to the PlanStore, if one is configured.
+// Save failures are recorded in the timeline and don't stop the execution.
+func (e *Executor) checkpoint(ctx context.Context, execCtx *executionContext, status PlanStatus) {
+	store := e.config.PlanStore
+	if store == nil {
+		return
+	}
+
+	execCtx.checkpointMu.Lock()
+	defer execCtx.checkpointMu.Unlock()
+
+	// Save even when the plan was cancelled, so that it can be resumed
+	if err := store.Save(context.WithoutCancel(ctx), e.snapshot(execCtx, status)); err != nil {
+		execCtx.addEvent("checkpoint_failed", "", fmt.Sprintf("Failed to save checkpoint: %v", err))
+	}
+}
+
+// snapshot captures the state of a plan execution. Task state is taken from
+// the results, which are guarded, rather than from the tasks, which running
+// tasks update without locking.
+func (e *Executor) snapshot(execCtx *executionContext, status PlanStatus) *PlanCheckpoint {
+	plan := execCtx.plan
+
+	execCtx.mu.RLock()
+	results := make(map[string]*TaskResult, len(execCtx.results))
+	for id, result := range execCtx.results {
+		copied := *result
+		results[id] = &copied
+	}
+	retries := make(map[string]int, len(execCtx.retries))
+	for id, count := range execCtx.retries {
+		retries[id] = count
+	}
+	// Replanning changes the task tree under the same lock
+	tasks := snapshotTasks(plan.Tasks, results)
+	execCtx.mu.RUnlock()
+
+	execCtx.goalMu.Lock()
+	goal := plan.GoalState
+	goal.Criteria = append([]GoalCriterion(nil), goal.Criteria...)
+	execCtx.goalMu.Unlock()
+
+	execCtx.timelineMu.Lock()
+	timeline := append([]PlanEvent(nil), execCtx.timeline...)
+	tasksExecuted := execCtx.tasksExecuted
+	execCtx.timelineMu.Unlock()
+
+	return &PlanCheckpoint{
+		Plan: &Plan{
+			ID:            plan.ID,
+			Goal:          plan.Goal,
+			GoalState:     goal,
+			Tasks:         tasks,
+			Strategy:      plan.Strategy,
+			CreatedAt:     plan.CreatedAt,
+			EstimatedCost: plan.EstimatedCost,
+			Metadata:      plan.Metadata,
+		},
+		Results:       results,
+		Status:        status,
+		Timeline:      timeline,
+		TasksExecuted: tasksExecuted,
+		Retries:       retries,
+		SavedAt:       time.Now(),
+	}
+}
+
+// snapshotTasks copies a task tree with the state recorded in results.
+// Tasks without a result have not started yet.
+func snapshotTasks(tasks []Task, results map[string]*TaskResult) []Task {
+	copied := make([]Task, len(tasks))
+	for i, task := range tasks {
+		copied[i] = Task{
+			ID:           task.ID,
+			ParentID:     task.ParentID,
+			Description:  task.Description,
+			Type:         task.Type,
+			Dependencies: task.Dependencies,
+			Status:       TaskStatusPending,
+			Subtasks:     snapshotTasks(task.Subtasks, results),
+			Depth:        task.Depth,
+		}
+
+		result, exists := results[task.ID]
+		if !exists {
+			continue
+		}
+		copied[i].Status = result.Status
+		copied[i].ReActSteps = result.ReActSteps
+		copied[i].Error = result.Error
+		copied[i].StartedAt = result.StartTime
+		copied[i].CompletedAt = result.EndTime
+		copied[i].Duration = result.Duration
+		if result.Status == TaskStatusCompleted {
+			copied[i].Result = result.Output
+		}
+	}
+	return copied
+}
+
+// Resume continues a plan from its last checkpoint in PlannerConfig.PlanStore.
+//
+// Completed and skipped tasks are not run again and their outputs feed the
+// tasks that depend on them. Tasks interrupted while running start over.
+// Failed tasks are retried up to ResumeMaxRetries times across resumes, and
+// stay failed after that. The plan continues with its original strategy.
+//
+// Example:
+//
+//	store, _ := agent.NewFilePlanStore("")
+//	config := agent.DefaultPlannerConfig()
+//	config.PlanStore = store
+//	result, err := agent.NewBuilderExecutor(config, builder).Resume(ctx, planID)
+func (e *Executor) Resume(ctx context.Context, planID string) (*PlanResult, error) {
+	store := e.config.PlanStore
+	if store == nil {
+		return nil, fmt.Errorf("cannot resume plan %s: no PlanStore configured", planID)
+	}
+
+	checkpoint, err := store.Load(ctx, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan checkpoint: %w", err)
+	}
+	if checkpoint == nil {
+		return nil, fmt.Errorf("no checkpoint found for plan %s", planID)
+	}
+	if checkpoint.Status == PlanStatusCompleted {
+		return nil, fmt.Errorf("plan %s already completed", planID)
+	}
+
+	execCtx := e.restore(checkpoint)
+	execCtx.addEvent("plan_resumed", "", fmt.Sprintf("Resumed from checkpoint saved at %s", checkpoint.SavedAt.Format(time.RFC3339)))
+	return e.run(ctx, execCtx, e.strategyRunner(checkpoint.Plan.Strategy))
+}
+
+// restore rebuilds the execution context of a checkpointed plan and resets
+// the tasks that should run again.
+func (e *Executor) restore(checkpoint *PlanCheckpoint) *executionContext {
+	execCtx := newExecutionContext(checkpoint.Plan)
+	execCtx.timeline = append(execCtx.timeline, checkpoint.Timeline...)
+	execCtx.tasksExecuted = checkpoint.TasksExecuted
+	for id, result := range checkpoint.Results {
+		execCtx.results[id] = result
+	}
+	for id, count := range checkpoint.Retries {
+		execCtx.retries[id] = count
+	}
+
+	e.resetTasks(checkpoint.Plan.Tasks, execCtx)
+	return execCtx
+}
+
+// resetTasks marks interrupted tasks, and failed tasks with retries left, as pending.
+func (e *Executor) resetTasks(tasks []Task, execCtx *executionContext) {
+	for i := range tasks {
+		task := &tasks[i]
+		switch task.Status {
+		case TaskStatusRunning:
+			execCtx.addEvent("task_restarted", task.ID, "Task was interrupted and starts over")
+			resetTask(task, execCtx)
+		case TaskStatusFailed:
+			if execCtx.retries[task.ID] >= e.config.ResumeMaxRetries {
+				continue
+			}
+			execCtx.retries[task.ID]++
+			execCtx.addEvent("task_retried", task.ID, fmt.Sprintf("Retrying failed task (retry %d of %d): %v",
+				execCtx.retries[task.ID], e.config.ResumeMaxRetries, task.Error))
+			resetTask(task, execCtx)
+		}
+		e.resetTasks(task.Subtasks, execCtx)
+	}
+}
+
+// resetTask clears the state of a task so that it runs again
+func resetTask(task *Task, execCtx *executionContext) {
+	task.Status = TaskStatusPending
+	task.Result = nil
+	task.Error = nil
+	task.ReActSteps = nil
+	task.StartedAt = time.Time{}
+	task.CompletedAt = time.Time{}
+	task.Duration = 0
+	delete(execCtx.results, task.ID)
+}
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// countingPlanStore counts the checkpoints saved to a FilePlanStore
+type countingPlanStore struct {
+	*FilePlanStore
+	mu       sync.Mutex
+	statuses []PlanStatus
+}
+
+func (s *countingPlanStore) Save(ctx context.Context, checkpoint *PlanCheckpoint) error {
+	s.mu.Lock()
+	s.statuses = append(s.statuses, checkpoint.Status)
+	s.mu.Unlock()
+	return s.FilePlanStore.Save(ctx, checkpoint)
+}
+
+func newCountingPlanStore(t *testing.T) *countingPlanStore {
+	t.Helper()
+	store, err := NewFilePlanStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFilePlanStore failed: %v", err)
+	}
+	return &countingPlanStore{FilePlanStore: store}
+}
+
+// pipelinePlan returns a plan of three tasks depending on each other
+func pipelinePlan(strategy PlanningStrategy) *Plan {
+	plan := NewPlan("Produce the quarterly report", strategy)
+	plan.AddTask(Task{ID: "fetch", Description: "Fetch the sales data", Status: TaskStatusPending})
+	plan.AddTask(Task{ID: "analyze", Description: "Analyze the sales data", Status: TaskStatusPending, Dependencies: []string{"fetch"}})
+	plan.AddTask(Task{ID: "write", Description: "Write the report", Status: TaskStatusPending, Dependencies: []string{"analyze"}})
+	return plan
+}
+
+// failingAgent fails the prompts ending with one of the given tasks and records all prompts
+func failingAgent(prompts *[]string, failing ...string) *mockAgent {
+	return &mockAgent{chatFunc: func(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error) {
+		*prompts = append(*prompts, message)
+		for _, task := range failing {
+			if strings.HasSuffix(message, task) || strings.Contains(message, "YOUR TASK: "+task) {
+				return nil, errors.New("model unavailable")
+			}
+		}
+		return &ChatResult{Content: "output of " + message[strings.LastIndex(message, ":")+1:]}, nil
+	}}
+}
+
+func TestExecute_Checkpoints(t *testing.T) {
+	store := newCountingPlanStore(t)
+	config := DefaultPlannerConfig()
+	config.PlanStore = store
+
+	var prompts []string
+	plan := pipelinePlan(StrategySequential)
+	if _, err := NewExecutor(config, failingAgent(&prompts)).Execute(context.Background(), plan); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// Start, then a start and a completion per task, then the final state
+	if len(store.statuses) != 8 {
+		t.Errorf("saved %d checkpoints, want 8", len(store.statuses))
+	}
+	if last := store.statuses[len(store.statuses)-1]; last != PlanStatusCompleted {
+		t.Errorf("last checkpoint status = %s, want completed", last)
+	}
+
+	checkpoint, err := store.Load(context.Background(), plan.ID)
+	if err != nil || checkpoint == nil {
+		t.Fatalf("Load = %v, %v", checkpoint, err)
+	}
+	for _, task := range checkpoint.Plan.Tasks {
+		if task.Status != TaskStatusCompleted || task.Result == nil {
+			t.Errorf("checkpointed task %s = %s (%v), want completed with its result", task.ID, task.Status, task.Result)
+		}
+	}
+
+	if _, err := NewExecutor(config, &mockAgent{}).Resume(context.Background(), plan.ID); err == nil {
+		t.Error("resuming a completed plan should fail")
+	}
+}
+
+func TestResume_RetriesFailedTask(t *testing.T) {
+	store := newCountingPlanStore(t)
+	config := DefaultPlannerConfig()
+	config.PlanStore = store
+
+	var prompts []string
+	plan := pipelinePlan(StrategySequential)
+	if _, err := NewExecutor(config, failingAgent(&prompts, "Analyze the sales data")).Execute(context.Background(), plan); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+
+	// A new process resumes the plan from the checkpoint
+	prompts = nil
+	result, err := NewExecutor(config, failingAgent(&prompts)).Resume(context.Background(), plan.ID)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if result.Status != PlanStatusCompleted {
+		t.Errorf("Status = %s, want completed", result.Status)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("got %d prompts, want analyze and write only: %q", len(prompts), prompts)
+	}
+	// The retried task gets the output of the task completed before the crash
+	if !strings.Contains(prompts[0], "output of Fetch the sales data") {
+		t.Errorf("retried task should see the checkpointed dependency output:\n%s", prompts[0])
+	}
+
+	var retried, resumed bool
+	for _, event := range result.Timeline {
+		retried = retried || (event.Type == "task_retried" && event.TaskID == "analyze")
+		resumed = resumed || event.Type == "plan_resumed"
+	}
+	if !retried || !resumed {
+		t.Errorf("timeline should record the resume and the retry (retried: %v, resumed: %v)", retried, resumed)
+	}
+
+	// The retry is recorded, so a further failure is not retried again
+	checkpoint, _ := store.Load(context.Background(), plan.ID)
+	if checkpoint.Retries["analyze"] != 1 {
+		t.Errorf("retries = %v, want analyze retried once", checkpoint.Retries)
+	}
+}
+
+func TestResume_RetryPolicy(t *testing.T) {
+	store := newCountingPlanStore(t)
+	config := DefaultPlannerConfig()
+	config.PlanStore = store
+	config.ResumeMaxRetries = 0
+
+	var prompts []string
+	plan := pipelinePlan(StrategySequential)
+	NewExecutor(config, failingAgent(&prompts, "Analyze the sales data")).Execute(context.Background(), plan)
+
+	prompts = nil
+	result, err := NewExecutor(config, failingAgent(&prompts)).Resume(context.Background(), plan.ID)
+	if err == nil || result.Status != PlanStatusFailed {
+		t.Errorf("Resume = %v, %v, want the plan to stay failed", result.Status, err)
+	}
+	if len(prompts) != 0 {
+		t.Errorf("no task should run without retries, got %q", prompts)
+	}
+}
+
+func TestResume_InterruptedTaskAndStrategy(t *testing.T) {
+	store := newCountingPlanStore(t)
+	config := DefaultPlannerConfig()
+	config.PlanStore = store
+
+	// A parallel plan that died while "analyze" was running
+	checkpoint := &PlanCheckpoint{
+		Plan: pipelinePlan(StrategyParallel),
+		Results: map[string]*TaskResult{
+			"fetch":   {TaskID: "fetch", Status: TaskStatusCompleted, Output: "1200 units"},
+			"analyze": {TaskID: "analyze", Status: TaskStatusRunning},
+		},
+		Status: PlanStatusRunning,
+	}
+	checkpoint.Plan.Tasks = snapshotTasks(checkpoint.Plan.Tasks, checkpoint.Results)
+	if err := store.Save(context.Background(), checkpoint); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var prompts []string
+	result, err := NewExecutor(config, failingAgent(&prompts)).Resume(context.Background(), checkpoint.Plan.ID)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if result.Plan.Strategy != StrategyParallel {
+		t.Errorf("Strategy = %s, want the original parallel strategy", result.Plan.Strategy)
+	}
+	if len(prompts) != 2 || !strings.Contains(prompts[0], "1200 units") {
+		t.Errorf("prompts = %q, want analyze (with the fetched data) and write", prompts)
+	}
+	if result.Plan.Tasks[0].Result != "1200 units" {
+		t.Errorf("completed task result = %v, want it kept from the checkpoint", result.Plan.Tasks[0].Result)
+	}
+}
+
+func TestResume_Errors(t *testing.T) {
+	if _, err := NewExecutor(DefaultPlannerConfig(), &mockAgent{}).Resume(context.Background(), "plan"); err == nil {
+		t.Error("expected an error without PlanStore")
+	}
+
+	config := DefaultPlannerConfig()
+	config.PlanStore = newCountingPlanStore(t)
+	if _, err := NewExecutor(config, &mockAgent{}).Resume(context.Background(), "unknown"); err == nil {
+		t.Error("expected an error for an unknown plan")
+	}
+
+	config.ResumeMaxRetries = -1
+	if err := config.Validate(); err == nil {
+		t.Error("expected a negative ResumeMaxRetries to be invalid")
+	}
+}
@@ -36,6 +36,46 @@ type PlannerConfig struct {
 
 	// FallbackToReAct allows falling back to ReAct if planning fails (default: true).
 	FallbackToReAct bool
+
+	// DependencyPromptTemplate builds the prompt of a task that depends on other tasks.
+	// Placeholders: {{.Goal}}, {{.Task}}, {{.Dependencies}} (default: DefaultDependencyPromptTemplate).
+	// This is not a text/template: only these literal tokens are replaced, and
+	// any other template syntax is sent to the model as is.
+	DependencyPromptTemplate string
+
+	// SynthesisPromptTemplate builds the prompt of aggregate tasks, which merge the
+	// outputs of their dependencies and subtasks.
+	// Placeholders: {{.Goal}}, {{.Task}}, {{.Results}} (default: DefaultSynthesisPromptTemplate).
+	// Like DependencyPromptTemplate, only these literal tokens are replaced.
+	SynthesisPromptTemplate string
+
+	// DependencyTokenBudget is the estimated number of tokens of dependency outputs
+	// injected into a prompt; longer outputs are truncated (default: 2000, 0 = no limit).
+	DependencyTokenBudget int
+
+	// GoalEvaluator scores the goal criteria during execution, e.g. a PredicateEvaluator.
+	// When the criteria are met, the remaining tasks are cancelled. If nil, PlanAndExecute
+	// uses an LLMGoalEvaluator with the planning model, and executors created with
+	// NewExecutor rely on the Satisfied flags of the criteria.
+	GoalEvaluator GoalEvaluator
+
+	// PlanStore saves a checkpoint of the plan after every task transition, so that
+	// an interrupted execution can continue with Executor.Resume (default: nil, no checkpoints).
+	PlanStore PlanStore
+
+	// ResumeMaxRetries is the number of times Resume retries a failed task
+	// (default: 1, 0 = failed tasks stay failed).
+	ResumeMaxRetries int
+
+	// MaxReplans is the maximum number of times failed tasks are replanned during
+	// a plan execution (default: 0, disabled). Replanning asks the Decomposer
+	// for alternative subtasks, at the cost of extra LLM calls; see
+	// Executor.WithReplanning.
+	MaxReplans int
+
+	// ReplanTokenBudget is the estimated number of tokens that replanning prompts
+	// and responses may use per plan execution (default: 8000, 0 = no limit).
+	ReplanTokenBudget int
 }
 
 // DefaultPlannerConfig returns a configuration with sensible defaults.
@@ -51,6 +91,12 @@ func DefaultPlannerConfig() *PlannerConfig {
 		GoalTimeout:       5 * time.Minute,
 		ReActEnabled:      true,
 		FallbackToReAct:   true,
+
+		DependencyPromptTemplate: DefaultDependencyPromptTemplate,
+		SynthesisPromptTemplate:  DefaultSynthesisPromptTemplate,
+		DependencyTokenBudget:    2000,
+		ResumeMaxRetries:         1,
+		ReplanTokenBudget:        8000,
 	}
 }
 
@@ -77,6 +123,18 @@ func (c *PlannerConfig) Validate() error {
 	if c.GoalTimeout <= 0 {
 		return errors.New("GoalTimeout must be greater than 0")
 	}
+	if c.DependencyTokenBudget < 0 {
+		return errors.New("DependencyTokenBudget cannot be negative")
+	}
+	if c.ResumeMaxRetries < 0 {
+		return errors.New("ResumeMaxRetries cannot be negative")
+	}
+	if c.MaxReplans < 0 {
+		return errors.New("MaxReplans cannot be negative")
+	}
+	if c.ReplanTokenBudget < 0 {
+		return errors.New("ReplanTokenBudget cannot be negative")
+	}
 
 	// Validate strategy
 	switch c.Strategy {
@@ -28,6 +28,7 @@ func TestDefaultPlannerConfig(t *testing.T) {
 		{"GoalTimeout", config.GoalTimeout, 5 * time.Minute},
 		{"ReActEnabled", config.ReActEnabled, true},
 		{"FallbackToReAct", config.FallbackToReAct, true},
+		{"MaxReplans", config.MaxReplans, 0},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultDependencyPromptTemplate is the prompt of a task that depends on other tasks.
+const DefaultDependencyPromptTemplate = `You are working on one step of a larger plan.
+
+OVERALL GOAL: {{.Goal}}
+
+RESULTS OF PREVIOUS STEPS:
+{{.Dependencies}}
+
+YOUR TASK: {{.Task}}
+
+Use the results of the previous steps where relevant instead of redoing their work.`
+
+// DefaultSynthesisPromptTemplate is the prompt of an aggregate task merging the results of other tasks.
+const DefaultSynthesisPromptTemplate = `You are combining the results of several steps of a plan into a final answer.
+
+OVERALL GOAL: {{.Goal}}
+
+STEP RESULTS:
+{{.Results}}
+
+YOUR TASK: {{.Task}}
+
+Merge these results into one complete and consistent answer to the goal.
+Resolve overlaps and contradictions, and do not describe the steps themselves.`
+
+// truncationMarker is appended to dependency outputs cut to fit the token budget
+const truncationMarker = "\n... [truncated]"
+
+// estimateTokens approximates the number of tokens in text (about 4 characters per token).
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// taskInput is the output of a completed task consumed by another task.
+type taskInput struct {
+	ID          string
+	Description string
+	Output      string
+}
+
+// taskInputs collects the outputs a task consumes: those of its dependencies
+// and, for aggregate tasks, those of its subtasks. Tasks that did not
+// complete are left out.
+func (e *Executor) taskInputs(task *Task, execCtx *executionContext) []taskInput {
+	ids := append([]string{}, task.Dependencies...)
+	if task.Type == TaskTypeAggregate {
+		for _, subtask := range task.Subtasks {
+			ids = append(ids, subtask.ID)
+		}
+	}
+
+	execCtx.mu.RLock()
+	defer execCtx.mu.RUnlock()
+
+	inputs := make([]taskInput, 0, len(ids))
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		result, exists := execCtx.results[id]
+		if seen[id] || !exists || result.Status != TaskStatusCompleted {
+			continue
+		}
+		seen[id] = true
+
+		description := id
+		if source := execCtx.plan.GetTaskByID(id); source != nil {
+			description = source.Description
+		}
+		inputs = append(inputs, taskInput{ID: id, Description: description, Output: result.Output})
+	}
+	return inputs
+}
+
+// buildTaskPrompt returns the prompt sent for a task. Tasks without inputs
+// are sent as their description. Tasks with dependency outputs get them
+// injected through DependencyPromptTemplate, and aggregate tasks get a
+// synthesis prompt through SynthesisPromptTemplate.
+func (e *Executor) buildTaskPrompt(task *Task, execCtx *executionContext) string {
+	inputs := e.taskInputs(task, execCtx)
+	if len(inputs) == 0 {
+		return task.Description
+	}
+	results := formatTaskInputs(inputs, e.config.DependencyTokenBudget)
+
+	// Placeholders are literal tokens, replaced in a single pass so that
+	// outputs containing placeholders are left alone
+	if task.Type == TaskTypeAggregate {
+		template := e.config.SynthesisPromptTemplate
+		if template == "" {
+			template = DefaultSynthesisPromptTemplate
+		}
+		return strings.NewReplacer(
+			"{{.Goal}}", execCtx.plan.Goal,
+			"{{.Task}}", task.Description,
+			"{{.Results}}", results,
+		).Replace(template)
+	}
+
+	template := e.config.DependencyPromptTemplate
+	if template == "" {
+		template = DefaultDependencyPromptTemplate
+	}
+	return strings.NewReplacer(
+		"{{.Goal}}", execCtx.plan.Goal,
+		"{{.Task}}", task.Description,
+		"{{.Dependencies}}", results,
+	).Replace(template)
+}
+
+// formatTaskInputs renders task outputs as labeled sections. When the outputs
+// exceed budget tokens (0 = no limit), the budget is shared fairly: short
+// outputs are kept whole and the longest ones are truncated.
+func formatTaskInputs(inputs []taskInput, budget int) string {
+	outputs := make([]string, len(inputs))
+	for i, input := range inputs {
+		outputs[i] = strings.TrimSpace(input.Output)
+	}
+
+	total := 0
+	for _, output := range outputs {
+		total += estimateTokens(output)
+	}
+
+	if budget > 0 && total > budget {
+		// Hand out the budget from the shortest output up
+		order := make([]int, len(outputs))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return len(outputs[order[a]]) < len(outputs[order[b]])
+		})
+
+		remaining := budget * 4
+		for n, i := range order {
+			share := remaining / (len(order) - n)
+			if len(outputs[i]) > share {
+				outputs[i] = truncateOutput(outputs[i], share)
+				remaining -= share
+			} else {
+				remaining -= len(outputs[i])
+			}
+		}
+	}
+
+	sections := make([]string, len(inputs))
+	for i, input := range inputs {
+		sections[i] = fmt.Sprintf("[%s] %s:\n%s", input.ID, input.Description, outputs[i])
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// truncateOutput cuts text to at most size bytes on a rune boundary and marks the cut
+func truncateOutput(text string, size int) string {
+	for size > 0 && size < len(text) && !utf8.RuneStart(text[size]) {
+		size--
+	}
+	return strings.TrimSpace(text[:size]) + truncationMarker
+}
+
+// finalResult returns the final answer of a plan: the output of the last
+// top-level aggregate task that no other task depends on. Plans without one
+// report the goal check reason.
+func (e *Executor) finalResult(execCtx *executionContext, reason string) interface{} {
+	plan := execCtx.plan
+
+	dependedOn := make(map[string]bool)
+	for _, task := range plan.Tasks {
+		for _, depID := range task.Dependencies {
+			dependedOn[depID] = true
+		}
+	}
+
+	execCtx.mu.RLock()
+	defer execCtx.mu.RUnlock()
+
+	for i := len(plan.Tasks) - 1; i >= 0; i-- {
+		task := plan.Tasks[i]
+		if task.Type != TaskTypeAggregate || dependedOn[task.ID] {
+			continue
+		}
+		if result, exists := execCtx.results[task.ID]; exists && result.Status == TaskStatusCompleted {
+			return result.Output
+		}
+	}
+	return reason
+}
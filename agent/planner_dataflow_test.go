@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingAgent answers every prompt with a fixed reply per task and records the prompts
+type recordingAgent struct {
+	mu      sync.Mutex
+	prompts []string
+	replies map[string]string // keyed by a substring of the prompt
+}
+
+func (r *recordingAgent) Chat(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prompts = append(r.prompts, message)
+	for key, reply := range r.replies {
+		if strings.HasSuffix(message, key) || strings.Contains(message, "YOUR TASK: "+key) {
+			return &ChatResult{Content: reply}, nil
+		}
+	}
+	return &ChatResult{Content: "ok"}, nil
+}
+
+func TestBuildTaskPrompt(t *testing.T) {
+	plan := NewPlan("Write a sales report", StrategySequential)
+	plan.AddTask(Task{ID: "data", Description: "Gather sales data"})
+	plan.AddTask(Task{ID: "report", Description: "Write the report", Dependencies: []string{"data"}})
+
+	execCtx := newExecutionContext(plan)
+	executor := NewExecutor(DefaultPlannerConfig(), &mockAgent{})
+
+	// Dependencies that haven't completed are not injected
+	if prompt := executor.buildTaskPrompt(&plan.Tasks[1], execCtx); prompt != "Write the report" {
+		t.Errorf("prompt without dependency output = %q, want the description", prompt)
+	}
+
+	execCtx.results["data"] = &TaskResult{TaskID: "data", Status: TaskStatusCompleted, Output: "Q3 sales: {{.Goal}} 1200 units"}
+	prompt := executor.buildTaskPrompt(&plan.Tasks[1], execCtx)
+	for _, want := range []string{
+		"OVERALL GOAL: Write a sales report",
+		"[data] Gather sales data:\nQ3 sales: {{.Goal}} 1200 units",
+		"YOUR TASK: Write the report",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	executor.config.DependencyPromptTemplate = "{{.Task}} using:\n{{.Dependencies}}"
+	prompt = executor.buildTaskPrompt(&plan.Tasks[1], execCtx)
+	if !strings.HasPrefix(prompt, "Write the report using:\n[data]") {
+		t.Errorf("custom template prompt = %q", prompt)
+	}
+}
+
+func TestFormatTaskInputs_TokenBudget(t *testing.T) {
+	short := "short result"
+	long := strings.Repeat("long result ", 200)
+	inputs := []taskInput{
+		{ID: "a", Description: "A", Output: long},
+		{ID: "b", Description: "B", Output: short},
+		{ID: "c", Description: "C", Output: long},
+	}
+
+	unlimited := formatTaskInputs(inputs, 0)
+	if strings.Contains(unlimited, truncationMarker) {
+		t.Error("outputs should not be truncated without a budget")
+	}
+
+	formatted := formatTaskInputs(inputs, 100)
+	if !strings.Contains(formatted, "[b] B:\n"+short) {
+		t.Errorf("short output should be kept whole:\n%s", formatted)
+	}
+	if strings.Count(formatted, truncationMarker) != 2 {
+		t.Errorf("long outputs should both be truncated:\n%s", formatted)
+	}
+
+	var outputs strings.Builder
+	for _, section := range strings.Split(formatted, "\n\n") {
+		_, output, _ := strings.Cut(section, ":\n")
+		outputs.WriteString(strings.TrimSuffix(output, truncationMarker))
+	}
+	if tokens := estimateTokens(outputs.String()); tokens > 100 {
+		t.Errorf("outputs use %d tokens, want at most the budget of 100", tokens)
+	}
+}
+
+func TestExecute_AggregateSynthesis(t *testing.T) {
+	plan := NewPlan("Compare two vendors", StrategySequential)
+	plan.AddTask(Task{
+		ID:          "compare",
+		Description: "Compare the vendors",
+		Type:        TaskTypeAggregate,
+		Status:      TaskStatusPending,
+		Subtasks: []Task{
+			{ID: "v1", ParentID: "compare", Description: "Research vendor one", Status: TaskStatusPending, Depth: 1},
+			{ID: "v2", ParentID: "compare", Description: "Research vendor two", Status: TaskStatusPending, Depth: 1},
+		},
+	})
+
+	agent := &recordingAgent{replies: map[string]string{
+		"Research vendor one": "Vendor one is cheap",
+		"Research vendor two": "Vendor two is fast",
+		"Compare the vendors": "Pick vendor two for speed",
+	}}
+	executor := NewExecutor(DefaultPlannerConfig(), agent)
+
+	result, err := executor.Execute(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if len(agent.prompts) != 3 {
+		t.Fatalf("got %d prompts, want 3: %q", len(agent.prompts), agent.prompts)
+	}
+	synthesis := agent.prompts[2]
+	for _, want := range []string{"Vendor one is cheap", "Vendor two is fast", "YOUR TASK: Compare the vendors"} {
+		if !strings.Contains(synthesis, want) {
+			t.Errorf("synthesis prompt missing %q:\n%s", want, synthesis)
+		}
+	}
+	if result.FinalResult != "Pick vendor two for speed" {
+		t.Errorf("FinalResult = %v, want the synthesized answer", result.FinalResult)
+	}
+}
@@ -108,6 +108,12 @@ Please decompose this into subtasks following these rules:
 5. Aim for {{.MinSubtasks}} to {{.MaxSubtasks}} subtasks per level
 6. Use these task types: "action" (execute tool/action), "decision" (make choice), "observation" (gather info), "aggregate" (combine results)
 
+Also define 1 to 3 measurable success criteria for the goal. Each criterion has:
+- "name": a checkable condition on the results (e.g. "Report compares at least 3 vendors")
+- "operator": one of ==, !=, >, <, >=, <=, contains, matches
+- "expected": the target value
+- "weight": its importance from 0.0 to 1.0
+
 Output ONLY valid JSON in this exact format (no markdown, no extra text):
 {
   "tasks": [
@@ -125,6 +131,14 @@ Output ONLY valid JSON in this exact format (no markdown, no extra text):
       "dependencies": ["task_1"],
       "subtasks": []
     }
+  ],
+  "criteria": [
+    {
+      "name": "Measurable condition the final result must meet",
+      "operator": ">=",
+      "expected": 3,
+      "weight": 1.0
+    }
   ]
 }
 
@@ -150,24 +164,35 @@ type taskJSON struct {
 	Subtasks     []taskJSON `json:"subtasks"`
 }
 
+// criterionJSON represents the JSON structure for goal criterion parsing.
+type criterionJSON struct {
+	Name     string      `json:"name"`
+	Operator string      `json:"operator"`
+	Expected interface{} `json:"expected"`
+	Weight   float64     `json:"weight"`
+}
+
 // tasksResponse represents the LLM response structure.
 type tasksResponse struct {
-	Tasks []taskJSON `json:"tasks"`
+	Tasks    []taskJSON      `json:"tasks"`
+	Criteria []criterionJSON `json:"criteria"`
 }
 
-// parseTasks parses the LLM JSON response into a slice of Task structs.
-// It handles markdown code blocks and validates the JSON structure.
-// Returns an error if the response is malformed or contains no tasks.
-func (d *Decomposer) parseTasks(response string) ([]Task, error) {
-	// Clean up response - remove markdown code blocks if present
+// cleanJSONResponse removes markdown code blocks around a JSON response.
+func cleanJSONResponse(response string) string {
 	response = strings.TrimSpace(response)
 	response = strings.TrimPrefix(response, "```json")
 	response = strings.TrimPrefix(response, "```")
 	response = strings.TrimSuffix(response, "```")
-	response = strings.TrimSpace(response)
+	return strings.TrimSpace(response)
+}
 
+// parseTasks parses the LLM JSON response into a slice of Task structs.
+// It handles markdown code blocks and validates the JSON structure.
+// Returns an error if the response is malformed or contains no tasks.
+func (d *Decomposer) parseTasks(response string) ([]Task, error) {
 	var resp tasksResponse
-	if err := json.Unmarshal([]byte(response), &resp); err != nil {
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &resp); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
 	}
 
@@ -178,6 +203,33 @@ func (d *Decomposer) parseTasks(response string) ([]Task, error) {
 	return d.convertJSONToTasks(resp.Tasks, "", 0), nil
 }
 
+// parseCriteria parses the goal criteria of the LLM JSON response.
+// Criteria without a name are dropped and a missing weight defaults to 1.0.
+func (d *Decomposer) parseCriteria(response string) []GoalCriterion {
+	var resp tasksResponse
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &resp); err != nil {
+		return nil
+	}
+
+	criteria := make([]GoalCriterion, 0, len(resp.Criteria))
+	for _, c := range resp.Criteria {
+		if strings.TrimSpace(c.Name) == "" {
+			continue
+		}
+		weight := c.Weight
+		if weight <= 0 {
+			weight = 1.0
+		}
+		criteria = append(criteria, GoalCriterion{
+			Name:     c.Name,
+			Operator: c.Operator,
+			Expected: c.Expected,
+			Weight:   weight,
+		})
+	}
+	return criteria
+}
+
 // convertJSONToTasks recursively converts taskJSON to Task structs.
 func (d *Decomposer) convertJSONToTasks(jsonTasks []taskJSON, parentID string, depth int) []Task {
 	tasks := make([]Task, 0, len(jsonTasks))
@@ -384,11 +436,15 @@ func (d *Decomposer) Decompose(ctx context.Context, goal string) (*Plan, error)
 	// Step 7: Identify dependencies (already done by LLM)
 	d.identifyDependencies(tasks)
 
-	// Step 8: Create and return plan
+	// Step 8: Create and return plan with its success criteria
 	plan := NewPlan(goal, d.config.Strategy)
 	for _, task := range tasks {
 		plan.AddTask(task)
 	}
+	plan.GoalState = GoalState{
+		Description: goal,
+		Criteria:    d.parseCriteria(result),
+	}
 
 	return plan, nil
 }
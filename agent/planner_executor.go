@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/openai/openai-go/v3"
@@ -15,23 +16,38 @@ type agentExecutor interface {
 	Chat(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error)
 }
 
+// taskRunner runs a single task prompt and returns its output and ReAct trace.
+// It replaces agentExecutor.Chat when tasks run through a Builder.
+type taskRunner interface {
+	runTask(ctx context.Context, task *Task, prompt string) (string, []ReActStep, error)
+}
+
 // Executor executes plans by orchestrating task execution through ReAct cycles.
 // It manages dependencies, tracks progress, and evaluates goal completion.
 type Executor struct {
-	config *PlannerConfig
-	agent  agentExecutor
+	config    *PlannerConfig
+	agent     agentExecutor
+	runner    taskRunner
+	evaluator GoalEvaluator // used when config.GoalEvaluator is nil
+	replanner *Decomposer   // replans failed tasks when set
 }
 
 // NewExecutor creates a new Executor with the given configuration and agent.
-// If config is nil, it uses DefaultPlannerConfig().
+// If config is nil, it uses DefaultPlannerConfig(). Goal criteria, such as
+// those set by Decomposer.Decompose, are judged by the agent unless
+// config.GoalEvaluator is set.
 func NewExecutor(config *PlannerConfig, agent agentExecutor) *Executor {
 	if config == nil {
 		config = DefaultPlannerConfig()
 	}
-	return &Executor{
+	executor := &Executor{
 		config: config,
 		agent:  agent,
 	}
+	if agent != nil {
+		executor.evaluator = NewLLMGoalEvaluator(&AgentLLMWrapper{agent: agent})
+	}
+	return executor
 }
 
 // executionContext tracks the state during plan execution.
@@ -48,6 +64,19 @@ type executionContext struct {
 	timeline      []PlanEvent
 	timelineMu    sync.Mutex
 	tasksExecuted int // Counter for periodic goal checking
+
+	// Early termination once the goal is achieved
+	goalMu       sync.Mutex // Serializes goal evaluation and protects plan.GoalState
+	goalAchieved atomic.Bool
+	cancel       context.CancelFunc // Cancels the tasks still running
+
+	// Checkpointing and resume
+	checkpointMu sync.Mutex     // Orders checkpoint saves
+	retries      map[string]int // Number of times Resume retried each failed task, protected by mu
+
+	// Replanning of failed tasks, protected by mu
+	replans      int
+	replanTokens int
 }
 
 // performanceTracker monitors execution performance for adaptive strategy switching.
@@ -165,6 +194,7 @@ func newExecutionContext(plan *Plan) *executionContext {
 		startTime:     time.Now(),
 		timeline:      []PlanEvent{},
 		tasksExecuted: 0,
+		retries:       make(map[string]int),
 	}
 }
 
@@ -190,11 +220,39 @@ func (ctx *executionContext) incrementTaskCounter() int {
 	return ctx.tasksExecuted
 }
 
+// stop marks the goal as achieved and cancels the tasks still running.
+func (ctx *executionContext) stop() {
+	ctx.goalAchieved.Store(true)
+	if ctx.cancel != nil {
+		ctx.cancel()
+	}
+}
+
+// withCancel derives the context tasks run with, cancelled by stop.
+func (ctx *executionContext) withCancel(parent context.Context) (context.Context, context.CancelFunc) {
+	runCtx, cancel := context.WithCancel(parent)
+	ctx.cancel = cancel
+	return runCtx, cancel
+}
+
+// isFinished reports whether a task already ran, e.g. before a plan was resumed.
+func isFinished(task *Task) bool {
+	return task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed || task.Status == TaskStatusSkipped
+}
+
+// finishedTaskError reports the failure of a task that ran before a resume, as a level error.
+func finishedTaskError(task *Task, levelIdx int) error {
+	if task.Status != TaskStatusFailed {
+		return nil
+	}
+	return fmt.Errorf("level %d task %s failed: %w", levelIdx, task.ID, task.Error)
+}
+
 // canExecute checks if a task's dependencies are satisfied and it's ready to run.
 // A task can execute if all its dependencies have completed successfully.
 func (e *Executor) canExecute(task *Task, ctx *executionContext) bool {
-	// Already completed or failed
-	if task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed {
+	// Already completed, failed or skipped
+	if task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed || task.Status == TaskStatusSkipped {
 		return false
 	}
 
@@ -392,6 +450,11 @@ func (e *Executor) groupByDependencyLevel(tasks []Task) ([][]Task, error) {
 
 // executeTask executes a single task using the agent's ReAct capabilities.
 func (e *Executor) executeTask(ctx context.Context, task *Task, execCtx *executionContext) error {
+	// The goal was achieved while this task was waiting; it is skipped with the rest
+	if execCtx.goalAchieved.Load() {
+		return nil
+	}
+
 	startTime := time.Now()
 
 	// Record task start event
@@ -408,21 +471,48 @@ func (e *Executor) executeTask(ctx context.Context, task *Task, execCtx *executi
 		StartTime: startTime,
 	}
 
-	// Build chat options for ReAct execution
-	opts := &ChatOptions{
-		Tools: []openai.ChatCompletionToolUnionParam{}, // Tools would be populated from agent config
+	// Checkpoint the start, with a copy so that the result can be filled in unlocked
+	running := *result
+	execCtx.mu.Lock()
+	execCtx.results[task.ID] = &running
+	execCtx.mu.Unlock()
+	e.checkpoint(ctx, execCtx, PlanStatusRunning)
+
+	// Aggregate tasks synthesize their children, so the children run first
+	var err error
+	if task.Type == TaskTypeAggregate && len(task.Subtasks) > 0 {
+		err = e.executeSubtasks(ctx, task, execCtx)
 	}
 
-	// Execute task through agent
-	chatResult, err := e.agent.Chat(ctx, task.Description, opts)
+	// Execute task through the builder or agent, with the outputs it depends on
+	var (
+		output string
+		steps  []ReActStep
+	)
+	if err == nil {
+		output, steps, err = e.runTask(ctx, task, e.buildTaskPrompt(task, execCtx))
+	}
+	if err != nil && execCtx.goalAchieved.Load() {
+		// Cancelled because another task achieved the goal
+		task.ReActSteps = steps
+		e.skipTask(task, execCtx, "Task cancelled: goal achieved")
+		e.checkpoint(ctx, execCtx, PlanStatusRunning)
+		return nil
+	}
+	if err != nil && e.replan(ctx, task, err, execCtx) {
+		// Run the task again, now as an aggregate of the alternatives
+		return e.executeTask(ctx, task, execCtx)
+	}
 	if err != nil {
 		task.Status = TaskStatusFailed
 		task.Error = err
+		task.ReActSteps = steps
 		task.CompletedAt = time.Now()
 		task.Duration = time.Since(startTime)
 
 		result.Status = TaskStatusFailed
 		result.Error = err
+		result.ReActSteps = steps
 		result.EndTime = time.Now()
 		result.Duration = time.Since(startTime)
 
@@ -432,23 +522,26 @@ func (e *Executor) executeTask(ctx context.Context, task *Task, execCtx *executi
 
 		// Record task failure event
 		execCtx.addEvent("task_failed", task.ID, fmt.Sprintf("Task failed: %v", err))
+		e.checkpoint(ctx, execCtx, PlanStatusRunning)
 
 		return fmt.Errorf("task %s failed: %w", task.ID, err)
 	}
 
 	// Update task with results
 	task.Status = TaskStatusCompleted
-	task.Result = chatResult.Content
+	task.Result = output
 	task.CompletedAt = time.Now()
 	task.Duration = time.Since(startTime)
 
-	// Extract ReAct steps if available (placeholder for future ReAct integration)
-	// For now, store empty ReActSteps as the structure exists in Task
-	task.ReActSteps = []ReActStep{}
-	result.ReActSteps = []ReActStep{}
+	// Record the ReAct trace (empty when the task ran as a plain chat)
+	if steps == nil {
+		steps = []ReActStep{}
+	}
+	task.ReActSteps = steps
+	result.ReActSteps = steps
 
 	result.Status = TaskStatusCompleted
-	result.Output = chatResult.Content
+	result.Output = output
 	result.EndTime = time.Now()
 	result.Duration = time.Since(startTime)
 
@@ -461,23 +554,41 @@ func (e *Executor) executeTask(ctx context.Context, task *Task, execCtx *executi
 
 	// Record task completion event
 	execCtx.addEvent("task_completed", task.ID, fmt.Sprintf("Task completed in %v", result.Duration))
+	e.checkpoint(ctx, execCtx, PlanStatusRunning)
 
 	// Increment task counter and check if we should perform goal check
 	taskCount := execCtx.incrementTaskCounter()
 	if e.config.GoalCheckInterval > 0 && taskCount%e.config.GoalCheckInterval == 0 {
 		// Perform periodic goal check
-		goalMet, reason := e.checkGoalCompletion(execCtx)
+		goalMet, reason := e.checkGoal(ctx, execCtx)
 		execCtx.addEvent("goal_checked", "", fmt.Sprintf("Goal check at task %d: %s (met: %v)", taskCount, reason, goalMet))
 
-		if goalMet {
-			// Goal achieved early - mark for termination
+		if goalMet && !execCtx.goalAchieved.Load() {
+			// Goal achieved early - cancel the remaining tasks
 			execCtx.addEvent("goal_achieved", "", fmt.Sprintf("Goal achieved early after %d tasks", taskCount))
+			execCtx.stop()
 		}
 	}
 
 	return nil
 }
 
+// runTask sends a task prompt through the runner if set, otherwise through the agent's Chat.
+func (e *Executor) runTask(ctx context.Context, task *Task, prompt string) (string, []ReActStep, error) {
+	if e.runner != nil {
+		return e.runner.runTask(ctx, task, prompt)
+	}
+
+	opts := &ChatOptions{
+		Tools: []openai.ChatCompletionToolUnionParam{},
+	}
+	chatResult, err := e.agent.Chat(ctx, prompt, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	return chatResult.Content, nil, nil
+}
+
 // executeSubtasks recursively executes all subtasks of a task.
 func (e *Executor) executeSubtasks(ctx context.Context, task *Task, execCtx *executionContext) error {
 	if len(task.Subtasks) == 0 {
@@ -488,7 +599,7 @@ func (e *Executor) executeSubtasks(ctx context.Context, task *Task, execCtx *exe
 		subtask := &task.Subtasks[i]
 
 		// Check if subtask can execute
-		if !e.canExecute(subtask, execCtx) {
+		if execCtx.goalAchieved.Load() || !e.canExecute(subtask, execCtx) {
 			continue
 		}
 
@@ -506,7 +617,8 @@ func (e *Executor) executeSubtasks(ctx context.Context, task *Task, execCtx *exe
 	return nil
 }
 
-// checkGoalCompletion evaluates whether the plan's goal criteria are satisfied.
+// checkGoalCompletion reports whether the plan's goal criteria are satisfied.
+// Criteria are scored beforehand by evaluateGoal (see checkGoal).
 func (e *Executor) checkGoalCompletion(execCtx *executionContext) (bool, string) {
 	plan := execCtx.plan
 
@@ -527,8 +639,6 @@ func (e *Executor) checkGoalCompletion(execCtx *executionContext) (bool, string)
 
 	// Evaluate each criterion
 	for _, criterion := range plan.GoalState.Criteria {
-		// Check if criterion is satisfied
-		// In a full implementation, this would evaluate Expected vs Actual
 		if !criterion.Satisfied {
 			return false, fmt.Sprintf("Criterion not met: %s", criterion.Name)
 		}
@@ -543,9 +653,12 @@ func (e *Executor) Execute(ctx context.Context, plan *Plan) (*PlanResult, error)
 	if plan == nil {
 		return nil, fmt.Errorf("plan cannot be nil")
 	}
+	return e.run(ctx, newExecutionContext(plan), e.runSequential)
+}
 
-	// Create execution context
-	execCtx := newExecutionContext(plan)
+// runSequential executes the tasks of a plan one at a time in dependency order.
+func (e *Executor) runSequential(ctx context.Context, execCtx *executionContext) (*PlanResult, error) {
+	plan := execCtx.plan
 	startTime := time.Now()
 
 	// Create result object to track execution
@@ -555,6 +668,10 @@ func (e *Executor) Execute(ctx context.Context, plan *Plan) (*PlanResult, error)
 		StartedAt: startTime,
 	}
 
+	// Tasks run with a context cancelled when the goal is achieved early
+	ctx, cancel := execCtx.withCancel(ctx)
+	defer cancel()
+
 	// Main execution loop
 	maxIterations := e.config.MaxSubtasks * 10 // Prevent infinite loops
 	iteration := 0
@@ -562,6 +679,10 @@ func (e *Executor) Execute(ctx context.Context, plan *Plan) (*PlanResult, error)
 	for iteration < maxIterations {
 		iteration++
 
+		if execCtx.goalAchieved.Load() {
+			return e.completeEarly(result, execCtx, startTime), nil
+		}
+
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
@@ -578,15 +699,16 @@ func (e *Executor) Execute(ctx context.Context, plan *Plan) (*PlanResult, error)
 		task := e.selectNextTask(execCtx)
 		if task == nil {
 			// No more executable tasks - check if we're done
-			goalMet, reason := e.checkGoalCompletion(execCtx)
+			goalMet, reason := e.checkGoal(ctx, execCtx)
 			if goalMet {
 				result.Status = PlanStatusCompleted
 				result.CompletedAt = time.Now()
 				result.Duration = time.Since(startTime)
-				result.FinalResult = reason
+				result.FinalResult = e.finalResult(execCtx, reason)
 				result.Metrics = e.buildMetrics(execCtx)
 				result.Metrics.GoalAchieved = true
 				result.Timeline = execCtx.timeline
+				e.recordGoal(result, execCtx)
 				return result, nil
 			}
 
@@ -606,6 +728,7 @@ func (e *Executor) Execute(ctx context.Context, plan *Plan) (*PlanResult, error)
 				result.Error = fmt.Errorf("some tasks failed")
 				result.Metrics = e.buildMetrics(execCtx)
 				result.Timeline = execCtx.timeline
+				e.recordGoal(result, execCtx)
 				return result, fmt.Errorf("plan execution failed: some tasks failed")
 			}
 
@@ -633,6 +756,7 @@ func (e *Executor) Execute(ctx context.Context, plan *Plan) (*PlanResult, error)
 	result.Error = fmt.Errorf("execution did not complete within iteration limit")
 	result.Metrics = e.buildMetrics(execCtx)
 	result.Timeline = execCtx.timeline
+	e.recordGoal(result, execCtx)
 	return result, fmt.Errorf("execution failed to complete")
 }
 
@@ -683,9 +807,13 @@ func (e *Executor) executeParallel(ctx context.Context, plan *Plan) (*PlanResult
 	if plan == nil {
 		return nil, fmt.Errorf("plan cannot be nil")
 	}
+	return e.run(ctx, newExecutionContext(plan), e.runParallel)
+}
 
-	// Create execution context
-	execCtx := newExecutionContext(plan)
+// runParallel executes the tasks of a plan level by level, running the tasks
+// of a level concurrently.
+func (e *Executor) runParallel(ctx context.Context, execCtx *executionContext) (*PlanResult, error) {
+	plan := execCtx.plan
 	startTime := time.Now()
 
 	// Create result object
@@ -705,8 +833,16 @@ func (e *Executor) executeParallel(ctx context.Context, plan *Plan) (*PlanResult
 		return result, err
 	}
 
+	// Tasks run with a context cancelled when the goal is achieved early
+	ctx, cancel := execCtx.withCancel(ctx)
+	defer cancel()
+
 	// Execute level by level
 	for levelIdx, levelTasks := range levels {
+		if execCtx.goalAchieved.Load() {
+			return e.completeEarly(result, execCtx, startTime), nil
+		}
+
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
@@ -739,6 +875,12 @@ func (e *Executor) executeParallel(ctx context.Context, plan *Plan) (*PlanResult
 				continue
 			}
 
+			// Tasks that ran before the plan was resumed don't run again
+			if isFinished(task) {
+				levelErrors <- finishedTaskError(task, levelIdx)
+				continue
+			}
+
 			// Acquire semaphore slot
 			semaphore <- struct{}{}
 
@@ -771,7 +913,7 @@ func (e *Executor) executeParallel(ctx context.Context, plan *Plan) (*PlanResult
 		}
 
 		// If any task in level failed, stop execution
-		if levelFailed {
+		if levelFailed && !execCtx.goalAchieved.Load() {
 			result.Status = PlanStatusFailed
 			result.CompletedAt = time.Now()
 			result.Duration = time.Since(startTime)
@@ -781,17 +923,22 @@ func (e *Executor) executeParallel(ctx context.Context, plan *Plan) (*PlanResult
 		}
 	}
 
+	if execCtx.goalAchieved.Load() {
+		return e.completeEarly(result, execCtx, startTime), nil
+	}
+
 	// Check goal completion
-	goalMet, reason := e.checkGoalCompletion(execCtx)
+	goalMet, reason := e.checkGoal(ctx, execCtx)
 	if goalMet {
 		result.Status = PlanStatusCompleted
-		result.FinalResult = reason
+		result.FinalResult = e.finalResult(execCtx, reason)
 		result.Metrics = e.buildMetrics(execCtx)
 		result.Metrics.GoalAchieved = true
 	} else {
 		result.Status = PlanStatusFailed
 		result.Error = fmt.Errorf("goal not achieved: %s", reason)
 	}
+	e.recordGoal(result, execCtx)
 
 	result.CompletedAt = time.Now()
 	result.Duration = time.Since(startTime)
@@ -841,9 +988,13 @@ func (e *Executor) executeAdaptive(ctx context.Context, plan *Plan) (*PlanResult
 	if plan == nil {
 		return nil, fmt.Errorf("plan cannot be nil")
 	}
+	return e.run(ctx, newExecutionContext(plan), e.runAdaptive)
+}
 
-	// Create execution context
-	execCtx := newExecutionContext(plan)
+// runAdaptive executes the tasks of a plan level by level, switching between
+// sequential and parallel batches.
+func (e *Executor) runAdaptive(ctx context.Context, execCtx *executionContext) (*PlanResult, error) {
+	plan := execCtx.plan
 	startTime := time.Now()
 
 	// Create result object
@@ -874,8 +1025,16 @@ func (e *Executor) executeAdaptive(ctx context.Context, plan *Plan) (*PlanResult
 		return result, err
 	}
 
+	// Tasks run with a context cancelled when the goal is achieved early
+	ctx, cancel := execCtx.withCancel(ctx)
+	defer cancel()
+
 	// Execute level by level with adaptive strategy
 	for levelIdx, levelTasks := range levels {
+		if execCtx.goalAchieved.Load() {
+			return e.completeEarly(result, execCtx, startTime), nil
+		}
+
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
@@ -899,7 +1058,7 @@ func (e *Executor) executeAdaptive(ctx context.Context, plan *Plan) (*PlanResult
 			batchErr = e.executeBatchSequential(ctx, levelTasks, plan, execCtx)
 		}
 
-		if batchErr != nil {
+		if batchErr != nil && !execCtx.goalAchieved.Load() {
 			result.Status = PlanStatusFailed
 			result.CompletedAt = time.Now()
 			result.Duration = time.Since(startTime)
@@ -930,17 +1089,22 @@ func (e *Executor) executeAdaptive(ctx context.Context, plan *Plan) (*PlanResult
 		}
 	}
 
+	if execCtx.goalAchieved.Load() {
+		return e.completeEarly(result, execCtx, startTime), nil
+	}
+
 	// Check goal completion
-	goalMet, reason := e.checkGoalCompletion(execCtx)
+	goalMet, reason := e.checkGoal(ctx, execCtx)
 	if goalMet {
 		result.Status = PlanStatusCompleted
-		result.FinalResult = reason
+		result.FinalResult = e.finalResult(execCtx, reason)
 		result.Metrics = e.buildMetrics(execCtx)
 		result.Metrics.GoalAchieved = true
 	} else {
 		result.Status = PlanStatusFailed
 		result.Error = fmt.Errorf("goal not achieved: %s", reason)
 	}
+	e.recordGoal(result, execCtx)
 
 	// Merge timeline from execCtx with result timeline
 	result.Timeline = append(result.Timeline, execCtx.timeline...)
@@ -953,6 +1117,10 @@ func (e *Executor) executeAdaptive(ctx context.Context, plan *Plan) (*PlanResult
 // executeBatchSequential executes a batch of tasks sequentially.
 func (e *Executor) executeBatchSequential(ctx context.Context, tasks []Task, plan *Plan, execCtx *executionContext) error {
 	for i := range tasks {
+		if execCtx.goalAchieved.Load() {
+			return nil
+		}
+
 		taskID := tasks[i].ID
 
 		// Find task pointer from plan
@@ -968,6 +1136,14 @@ func (e *Executor) executeBatchSequential(ctx context.Context, tasks []Task, pla
 			continue
 		}
 
+		// Tasks that ran before the plan was resumed don't run again
+		if isFinished(task) {
+			if task.Status == TaskStatusFailed {
+				return fmt.Errorf("task %s failed: %w", task.ID, task.Error)
+			}
+			continue
+		}
+
 		// Execute task
 		if err := e.executeTask(ctx, task, execCtx); err != nil {
 			return fmt.Errorf("task %s failed: %w", task.ID, err)
@@ -1003,6 +1179,12 @@ func (e *Executor) executeBatchParallel(ctx context.Context, tasks []Task, plan
 			continue
 		}
 
+		// Tasks that ran before the plan was resumed don't run again
+		if isFinished(task) {
+			levelErrors <- finishedTaskError(task, levelIdx)
+			continue
+		}
+
 		// Acquire semaphore slot
 		semaphore <- struct{}{}
 
@@ -0,0 +1,360 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CriterionResult is the outcome of evaluating a single goal criterion.
+type CriterionResult struct {
+	// Satisfied indicates whether the criterion is met.
+	Satisfied bool
+
+	// Score is the partial progress toward the criterion (0.0 to 1.0).
+	Score float64
+
+	// Actual is the value observed in the task results.
+	Actual interface{}
+
+	// Reason explains the verdict.
+	Reason string
+}
+
+// GoalEvaluator scores goal criteria against the results of the tasks executed so far.
+// The Executor calls it every GoalCheckInterval tasks and once more when no task is left.
+type GoalEvaluator interface {
+	Evaluate(ctx context.Context, plan *Plan, criterion GoalCriterion, results []TaskResult) (CriterionResult, error)
+}
+
+// CriterionPredicate evaluates a criterion programmatically.
+type CriterionPredicate func(criterion GoalCriterion, results []TaskResult) CriterionResult
+
+// PredicateEvaluator evaluates criteria with Go predicates registered by criterion name.
+// Criteria without a predicate are checked against the task outputs with their
+// operator ("contains", "matches" or "==" on the output text), or passed to the
+// fallback evaluator when one is set.
+type PredicateEvaluator struct {
+	predicates map[string]CriterionPredicate
+	fallback   GoalEvaluator
+}
+
+// NewPredicateEvaluator creates an evaluator without predicates.
+func NewPredicateEvaluator() *PredicateEvaluator {
+	return &PredicateEvaluator{predicates: make(map[string]CriterionPredicate)}
+}
+
+// WithPredicate registers the predicate of the criterion with the given name.
+func (p *PredicateEvaluator) WithPredicate(name string, predicate CriterionPredicate) *PredicateEvaluator {
+	p.predicates[name] = predicate
+	return p
+}
+
+// WithFallback sets the evaluator used for criteria without a predicate,
+// typically an LLMGoalEvaluator.
+func (p *PredicateEvaluator) WithFallback(evaluator GoalEvaluator) *PredicateEvaluator {
+	p.fallback = evaluator
+	return p
+}
+
+// Evaluate implements GoalEvaluator
+func (p *PredicateEvaluator) Evaluate(ctx context.Context, plan *Plan, criterion GoalCriterion, results []TaskResult) (CriterionResult, error) {
+	if predicate, exists := p.predicates[criterion.Name]; exists {
+		return predicate(criterion, results), nil
+	}
+	if p.fallback != nil {
+		return p.fallback.Evaluate(ctx, plan, criterion, results)
+	}
+
+	expected := fmt.Sprintf("%v", criterion.Expected)
+	var match func(output string) bool
+	switch criterion.Operator {
+	case "contains":
+		match = func(output string) bool {
+			return strings.Contains(strings.ToLower(output), strings.ToLower(expected))
+		}
+	case "matches":
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return CriterionResult{}, fmt.Errorf("invalid pattern for criterion %s: %w", criterion.Name, err)
+		}
+		match = re.MatchString
+	case "==":
+		match = func(output string) bool {
+			return strings.TrimSpace(output) == expected
+		}
+	default:
+		return CriterionResult{}, fmt.Errorf("no predicate for criterion %s (operator %q)", criterion.Name, criterion.Operator)
+	}
+
+	for _, result := range results {
+		if match(result.Output) {
+			return CriterionResult{
+				Satisfied: true,
+				Score:     1.0,
+				Actual:    result.Output,
+				Reason:    fmt.Sprintf("output of task %s %s %q", result.TaskID, criterion.Operator, expected),
+			}, nil
+		}
+	}
+	return CriterionResult{Reason: fmt.Sprintf("no task output %s %q", criterion.Operator, expected)}, nil
+}
+
+// goalJudgePromptTemplate asks an LLM whether a criterion is met.
+const goalJudgePromptTemplate = `You are evaluating whether a plan has met one of its success criteria.
+
+GOAL: {{.Goal}}
+
+SUCCESS CRITERION: {{.Criterion}}
+
+RESULTS SO FAR:
+{{.Results}}
+
+Judge the criterion only from the results above.
+Output ONLY valid JSON in this exact format (no markdown, no extra text):
+{"satisfied": false, "score": 0.5, "actual": "value observed in the results", "reason": "short explanation"}
+
+"score" is the progress toward the criterion, from 0.0 (not started) to 1.0 (met).`
+
+// goalJudgeTokenBudget is the estimated number of tokens of task outputs shown to the judge.
+const goalJudgeTokenBudget = 4000
+
+// LLMGoalEvaluator evaluates criteria by asking an LLM to judge the task results.
+type LLMGoalEvaluator struct {
+	llm llmGenerator
+}
+
+// NewLLMGoalEvaluator creates an evaluator judging criteria with the given LLM.
+func NewLLMGoalEvaluator(llm llmGenerator) *LLMGoalEvaluator {
+	return &LLMGoalEvaluator{llm: llm}
+}
+
+// goalVerdict is the JSON verdict returned by the judge
+type goalVerdict struct {
+	Satisfied bool        `json:"satisfied"`
+	Score     *float64    `json:"score"`
+	Actual    interface{} `json:"actual"`
+	Reason    string      `json:"reason"`
+}
+
+// Evaluate implements GoalEvaluator
+func (l *LLMGoalEvaluator) Evaluate(ctx context.Context, plan *Plan, criterion GoalCriterion, results []TaskResult) (CriterionResult, error) {
+	inputs := make([]taskInput, 0, len(results))
+	for _, result := range results {
+		description := result.TaskID
+		if task := plan.GetTaskByID(result.TaskID); task != nil {
+			description = task.Description
+		}
+		inputs = append(inputs, taskInput{ID: result.TaskID, Description: description, Output: result.Output})
+	}
+
+	formatted := "(no results yet)"
+	if len(inputs) > 0 {
+		formatted = formatTaskInputs(inputs, goalJudgeTokenBudget)
+	}
+
+	prompt := strings.NewReplacer(
+		"{{.Goal}}", plan.Goal,
+		"{{.Criterion}}", describeCriterion(criterion),
+		"{{.Results}}", formatted,
+	).Replace(goalJudgePromptTemplate)
+
+	response, err := l.llm.Generate(ctx, prompt, nil)
+	if err != nil {
+		return CriterionResult{}, fmt.Errorf("goal evaluation failed: %w", err)
+	}
+
+	var verdict goalVerdict
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &verdict); err != nil {
+		return CriterionResult{}, fmt.Errorf("failed to parse goal verdict: %w", err)
+	}
+
+	score := 0.0
+	if verdict.Satisfied {
+		score = 1.0
+	}
+	if verdict.Score != nil {
+		score = clampScore(*verdict.Score)
+	}
+	return CriterionResult{
+		Satisfied: verdict.Satisfied,
+		Score:     score,
+		Actual:    verdict.Actual,
+		Reason:    verdict.Reason,
+	}, nil
+}
+
+// describeCriterion renders a criterion for the judge prompt
+func describeCriterion(criterion GoalCriterion) string {
+	if criterion.Operator == "" || criterion.Expected == nil {
+		return criterion.Name
+	}
+	return fmt.Sprintf("%s (%s %v)", criterion.Name, criterion.Operator, criterion.Expected)
+}
+
+// clampScore bounds a score to [0.0, 1.0]
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+// goalEvaluator returns the evaluator from the config, or the executor's default.
+func (e *Executor) goalEvaluator() GoalEvaluator {
+	if e.config.GoalEvaluator != nil {
+		return e.config.GoalEvaluator
+	}
+	return e.evaluator
+}
+
+// completedResults returns the results of completed tasks in plan order.
+func (e *Executor) completedResults(execCtx *executionContext) []TaskResult {
+	execCtx.mu.RLock()
+	defer execCtx.mu.RUnlock()
+
+	var results []TaskResult
+	var collect func(tasks []Task)
+	collect = func(tasks []Task) {
+		for i := range tasks {
+			collect(tasks[i].Subtasks)
+			if result, exists := execCtx.results[tasks[i].ID]; exists && result.Status == TaskStatusCompleted {
+				results = append(results, *result)
+			}
+		}
+	}
+	collect(execCtx.plan.Tasks)
+	return results
+}
+
+// evaluateGoal scores the unmet goal criteria against the results so far and
+// updates the plan's GoalState. Without an evaluator, criteria keep the
+// Satisfied flags set by the caller. Plans without criteria progress with
+// the share of completed tasks.
+// The caller must hold execCtx.goalMu.
+func (e *Executor) evaluateGoal(ctx context.Context, execCtx *executionContext) {
+	goal := &execCtx.plan.GoalState
+	results := e.completedResults(execCtx)
+	evaluator := e.goalEvaluator()
+
+	if len(goal.Criteria) == 0 {
+		execCtx.mu.RLock()
+		total := countTasks(execCtx.plan.Tasks)
+		execCtx.mu.RUnlock()
+		if total > 0 {
+			goal.Progress = float64(len(results)) / float64(total)
+		}
+		goal.CheckedAt = time.Now()
+		return
+	}
+
+	var weighted, totalWeight float64
+	satisfied := true
+	for i := range goal.Criteria {
+		criterion := &goal.Criteria[i]
+		weight := criterion.Weight
+		if weight <= 0 {
+			weight = 1.0
+		}
+		totalWeight += weight
+
+		score := 0.0
+		if !criterion.Satisfied && evaluator != nil {
+			verdict, err := evaluator.Evaluate(ctx, execCtx.plan, *criterion, results)
+			if err != nil {
+				execCtx.addEvent("goal_evaluation_failed", "", fmt.Sprintf("Criterion %s: %v", criterion.Name, err))
+			} else {
+				criterion.Satisfied = verdict.Satisfied
+				criterion.Actual = verdict.Actual
+				score = verdict.Score
+			}
+		}
+		if criterion.Satisfied {
+			score = 1.0
+		} else {
+			satisfied = false
+		}
+		weighted += weight * score
+	}
+
+	goal.Progress = weighted / totalWeight
+	goal.Satisfied = satisfied
+	goal.CheckedAt = time.Now()
+}
+
+// checkGoal evaluates the goal criteria and reports whether the goal is met.
+func (e *Executor) checkGoal(ctx context.Context, execCtx *executionContext) (bool, string) {
+	execCtx.goalMu.Lock()
+	defer execCtx.goalMu.Unlock()
+
+	e.evaluateGoal(ctx, execCtx)
+	return e.checkGoalCompletion(execCtx)
+}
+
+// countTasks counts the tasks of a task tree
+func countTasks(tasks []Task) int {
+	count := len(tasks)
+	for _, task := range tasks {
+		count += countTasks(task.Subtasks)
+	}
+	return count
+}
+
+// recordGoal copies the goal state and progress into the result.
+func (e *Executor) recordGoal(result *PlanResult, execCtx *executionContext) {
+	execCtx.goalMu.Lock()
+	defer execCtx.goalMu.Unlock()
+
+	result.Plan.GoalState = execCtx.plan.GoalState
+	result.Progress = execCtx.plan.GoalState.Progress
+	result.Metrics.GoalProgress = execCtx.plan.GoalState.Progress
+}
+
+// skipTask marks a task that won't run, or was cancelled, as skipped.
+func (e *Executor) skipTask(task *Task, execCtx *executionContext, reason string) {
+	task.Status = TaskStatusSkipped
+	task.CompletedAt = time.Now()
+
+	execCtx.mu.Lock()
+	execCtx.results[task.ID] = &TaskResult{TaskID: task.ID, Status: TaskStatusSkipped, EndTime: task.CompletedAt}
+	execCtx.mu.Unlock()
+
+	execCtx.addEvent("task_cancelled", task.ID, reason)
+}
+
+// skipPendingTasks marks the tasks of a tree that did not run as skipped.
+// It returns the number of skipped tasks.
+func (e *Executor) skipPendingTasks(tasks []Task, execCtx *executionContext) int {
+	skipped := 0
+	for i := range tasks {
+		if tasks[i].Status == TaskStatusPending {
+			e.skipTask(&tasks[i], execCtx, "Task cancelled: goal already achieved")
+			skipped++
+		}
+		skipped += e.skipPendingTasks(tasks[i].Subtasks, execCtx)
+	}
+	return skipped
+}
+
+// completeEarly finishes a plan whose goal was achieved before all tasks ran.
+// The remaining tasks are skipped and the plan completes successfully.
+func (e *Executor) completeEarly(result *PlanResult, execCtx *executionContext, startTime time.Time) *PlanResult {
+	skipped := e.skipPendingTasks(execCtx.plan.Tasks, execCtx)
+	execCtx.addEvent("plan_terminated_early", "", fmt.Sprintf("Goal achieved, %d remaining tasks cancelled", skipped))
+
+	result.Status = PlanStatusCompleted
+	result.CompletedAt = time.Now()
+	result.Duration = time.Since(startTime)
+	result.FinalResult = e.finalResult(execCtx, "Goal achieved early")
+	result.Metrics = e.buildMetrics(execCtx)
+	result.Metrics.GoalAchieved = true
+	result.Timeline = append(result.Timeline, execCtx.timeline...)
+	e.recordGoal(result, execCtx)
+	return result
+}
@@ -0,0 +1,352 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPredicateEvaluator(t *testing.T) {
+	plan := NewPlan("Find the release date", StrategySequential)
+	results := []TaskResult{
+		{TaskID: "t1", Status: TaskStatusCompleted, Output: "The release is planned for March"},
+		{TaskID: "t2", Status: TaskStatusCompleted, Output: "Version 2.4.1"},
+	}
+
+	evaluator := NewPredicateEvaluator().
+		WithPredicate("two results", func(c GoalCriterion, results []TaskResult) CriterionResult {
+			return CriterionResult{Satisfied: len(results) >= 2, Score: 1.0, Actual: len(results)}
+		})
+
+	tests := []struct {
+		criterion GoalCriterion
+		satisfied bool
+	}{
+		{GoalCriterion{Name: "mentions month", Operator: "contains", Expected: "march"}, true},
+		{GoalCriterion{Name: "mentions year", Operator: "contains", Expected: "2025"}, false},
+		{GoalCriterion{Name: "has version", Operator: "matches", Expected: `\d+\.\d+\.\d+`}, true},
+		{GoalCriterion{Name: "exact version", Operator: "==", Expected: "Version 2.4.1"}, true},
+		{GoalCriterion{Name: "two results", Operator: ">=", Expected: 2}, true},
+	}
+	for _, tt := range tests {
+		verdict, err := evaluator.Evaluate(context.Background(), plan, tt.criterion, results)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.criterion.Name, err)
+			continue
+		}
+		if verdict.Satisfied != tt.satisfied {
+			t.Errorf("%s: Satisfied = %v, want %v", tt.criterion.Name, verdict.Satisfied, tt.satisfied)
+		}
+	}
+
+	// Comparisons need a predicate or a fallback
+	count := GoalCriterion{Name: "count", Operator: ">", Expected: 3}
+	if _, err := evaluator.Evaluate(context.Background(), plan, count, results); err == nil {
+		t.Error("expected an error for an operator without predicate")
+	}
+	evaluator.WithFallback(NewLLMGoalEvaluator(&mockLLMGenerator{response: `{"satisfied": true}`}))
+	if verdict, err := evaluator.Evaluate(context.Background(), plan, count, results); err != nil || !verdict.Satisfied {
+		t.Errorf("fallback verdict = %+v, %v, want satisfied", verdict, err)
+	}
+}
+
+func TestLLMGoalEvaluator(t *testing.T) {
+	plan := NewPlan("Count the vendors", StrategySequential)
+	plan.AddTask(Task{ID: "t1", Description: "List vendors"})
+	results := []TaskResult{{TaskID: "t1", Status: TaskStatusCompleted, Output: "Acme, Globex"}}
+	criterion := GoalCriterion{Name: "At least 3 vendors", Operator: ">=", Expected: 3}
+
+	llm := &mockLLMGenerator{response: "```json\n{\"satisfied\": false, \"score\": 0.66, \"actual\": 2, \"reason\": \"only two\"}\n```"}
+	verdict, err := NewLLMGoalEvaluator(llm).Evaluate(context.Background(), plan, criterion, results)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if verdict.Satisfied || verdict.Score != 0.66 || verdict.Actual != float64(2) || verdict.Reason != "only two" {
+		t.Errorf("verdict = %+v", verdict)
+	}
+
+	// A verdict without score scores by satisfaction, and scores are clamped
+	llm.response = `{"satisfied": true}`
+	if verdict, _ := NewLLMGoalEvaluator(llm).Evaluate(context.Background(), plan, criterion, results); verdict.Score != 1.0 {
+		t.Errorf("Score = %v, want 1.0", verdict.Score)
+	}
+	llm.response = `{"satisfied": false, "score": 7}`
+	if verdict, _ := NewLLMGoalEvaluator(llm).Evaluate(context.Background(), plan, criterion, results); verdict.Score != 1.0 {
+		t.Errorf("Score = %v, want clamped to 1.0", verdict.Score)
+	}
+
+	llm.response = "looks good to me"
+	if _, err := NewLLMGoalEvaluator(llm).Evaluate(context.Background(), plan, criterion, results); err == nil {
+		t.Error("expected an error for a verdict that isn't JSON")
+	}
+	llm.err = errors.New("model unavailable")
+	if _, err := NewLLMGoalEvaluator(llm).Evaluate(context.Background(), plan, criterion, results); err == nil {
+		t.Error("expected the LLM error")
+	}
+}
+
+func TestEvaluateGoal_Progress(t *testing.T) {
+	plan := NewPlan("Write a report", StrategySequential)
+	plan.AddTask(Task{ID: "t1", Description: "Gather data"})
+	plan.AddTask(Task{ID: "t2", Description: "Write report"})
+	plan.GoalState.Criteria = []GoalCriterion{
+		{Name: "has data", Operator: "contains", Expected: "data", Weight: 0.75},
+		{Name: "has report", Operator: "contains", Expected: "report", Weight: 0.25},
+	}
+
+	config := DefaultPlannerConfig()
+	config.GoalEvaluator = NewPredicateEvaluator()
+	executor := NewExecutor(config, &mockAgent{})
+	execCtx := newExecutionContext(plan)
+	execCtx.results["t1"] = &TaskResult{TaskID: "t1", Status: TaskStatusCompleted, Output: "data: 42"}
+
+	met, _ := executor.checkGoal(context.Background(), execCtx)
+	if met {
+		t.Error("goal should not be met with one criterion left")
+	}
+	if plan.GoalState.Progress != 0.75 {
+		t.Errorf("Progress = %v, want 0.75", plan.GoalState.Progress)
+	}
+	if !plan.GoalState.Criteria[0].Satisfied || plan.GoalState.Criteria[0].Actual != "data: 42" {
+		t.Errorf("first criterion = %+v, want satisfied by t1", plan.GoalState.Criteria[0])
+	}
+	if plan.GoalState.CheckedAt.IsZero() {
+		t.Error("CheckedAt should be set")
+	}
+
+	execCtx.results["t2"] = &TaskResult{TaskID: "t2", Status: TaskStatusCompleted, Output: "final report"}
+	met, _ = executor.checkGoal(context.Background(), execCtx)
+	if !met || !plan.GoalState.Satisfied || plan.GoalState.Progress != 1.0 {
+		t.Errorf("goal = %+v, want satisfied with full progress", plan.GoalState)
+	}
+
+	// Without criteria, progress is the share of completed tasks
+	plain := NewPlan("Plain", StrategySequential)
+	plain.AddTask(Task{ID: "a"})
+	plain.AddTask(Task{ID: "b", Subtasks: []Task{{ID: "b1"}, {ID: "b2"}}})
+	plainCtx := newExecutionContext(plain)
+	plainCtx.results["a"] = &TaskResult{TaskID: "a", Status: TaskStatusCompleted}
+	executor.checkGoal(context.Background(), plainCtx)
+	if plain.GoalState.Progress != 0.25 {
+		t.Errorf("Progress = %v, want 0.25", plain.GoalState.Progress)
+	}
+}
+
+func TestExecute_EarlyTermination(t *testing.T) {
+	plan := NewPlan("Find the answer", StrategySequential)
+	for _, id := range []string{"t1", "t2", "t3", "t4"} {
+		plan.AddTask(Task{ID: id, Description: "Search source " + id, Status: TaskStatusPending})
+	}
+	plan.GoalState.Criteria = []GoalCriterion{{Name: "answer found", Operator: "contains", Expected: "answer"}}
+
+	var calls int32
+	agent := &mockAgent{chatFunc: func(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error) {
+		if atomic.AddInt32(&calls, 1) == 2 {
+			return &ChatResult{Content: "the answer is 42"}, nil
+		}
+		return &ChatResult{Content: "nothing here"}, nil
+	}}
+
+	config := DefaultPlannerConfig()
+	config.GoalCheckInterval = 1
+	config.GoalEvaluator = NewPredicateEvaluator()
+	executor := NewExecutor(config, agent)
+
+	result, err := executor.Execute(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("agent called %d times, want 2", calls)
+	}
+	if result.Status != PlanStatusCompleted || !result.Metrics.GoalAchieved {
+		t.Errorf("Status = %s, GoalAchieved = %v, want completed with goal achieved", result.Status, result.Metrics.GoalAchieved)
+	}
+	if result.Progress != 1.0 || result.Metrics.GoalProgress != 1.0 || !result.Plan.GoalState.Satisfied {
+		t.Errorf("Progress = %v, GoalState = %+v, want a satisfied goal", result.Progress, result.Plan.GoalState)
+	}
+	for _, task := range plan.Tasks[2:] {
+		if task.Status != TaskStatusSkipped {
+			t.Errorf("task %s status = %s, want skipped", task.ID, task.Status)
+		}
+	}
+
+	var cancelled int
+	for _, event := range result.Timeline {
+		if event.Type == "task_cancelled" {
+			cancelled++
+		}
+	}
+	if cancelled != 2 {
+		t.Errorf("got %d task_cancelled events, want 2", cancelled)
+	}
+}
+
+func TestExecuteParallel_EarlyTerminationCancelsRunningTasks(t *testing.T) {
+	plan := NewPlan("Find the answer", StrategyParallel)
+	plan.AddTask(Task{ID: "fast", Description: "fast search", Status: TaskStatusPending})
+	plan.AddTask(Task{ID: "slow", Description: "slow search", Status: TaskStatusPending})
+	plan.AddTask(Task{ID: "next", Description: "follow up", Status: TaskStatusPending, Dependencies: []string{"slow"}})
+	plan.GoalState.Criteria = []GoalCriterion{{Name: "answer found", Operator: "contains", Expected: "answer"}}
+
+	agent := &mockAgent{chatFunc: func(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error) {
+		if message == "slow search" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &ChatResult{Content: "the answer is 42"}, nil
+	}}
+
+	config := DefaultPlannerConfig()
+	config.GoalCheckInterval = 1
+	config.GoalEvaluator = NewPredicateEvaluator()
+	executor := NewExecutor(config, agent)
+
+	result, err := executor.executeParallel(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("executeParallel failed: %v", err)
+	}
+	if result.Status != PlanStatusCompleted || !result.Metrics.GoalAchieved {
+		t.Errorf("Status = %s, want completed with goal achieved", result.Status)
+	}
+	for _, id := range []string{"slow", "next"} {
+		if task := plan.GetTaskByID(id); task.Status != TaskStatusSkipped {
+			t.Errorf("task %s status = %s, want skipped", id, task.Status)
+		}
+	}
+}
+
+func TestDecompose_Criteria(t *testing.T) {
+	llm := &mockLLMGenerator{response: `{
+		"tasks": [
+			{"id": "task_1", "description": "Research vendors", "type": "observation", "dependencies": []},
+			{"id": "task_2", "description": "Compare vendors", "type": "aggregate", "dependencies": ["task_1"]}
+		],
+		"criteria": [
+			{"name": "Compares at least 3 vendors", "operator": ">=", "expected": 3, "weight": 0.8},
+			{"name": "Recommends a vendor", "operator": "contains", "expected": "recommend"},
+			{"name": "", "operator": "==", "expected": 1}
+		]
+	}`}
+	decomposer := NewDecomposer(DefaultPlannerConfig(), llm)
+
+	plan, err := decomposer.Decompose(context.Background(), "Research several vendors and compare them, then recommend one")
+	if err != nil {
+		t.Fatalf("Decompose failed: %v", err)
+	}
+
+	criteria := plan.GoalState.Criteria
+	if len(criteria) != 2 {
+		t.Fatalf("got %d criteria, want 2: %+v", len(criteria), criteria)
+	}
+	if criteria[0].Operator != ">=" || criteria[0].Expected != float64(3) || criteria[0].Weight != 0.8 {
+		t.Errorf("first criterion = %+v", criteria[0])
+	}
+	if criteria[1].Weight != 1.0 {
+		t.Errorf("missing weight = %v, want 1.0", criteria[1].Weight)
+	}
+	if !strings.Contains(decomposer.buildDecompositionPrompt("goal"), `"criteria"`) {
+		t.Error("decomposition prompt should ask for criteria")
+	}
+}
+
+func TestNewExecutor_JudgesDecomposedCriteria(t *testing.T) {
+	llm := &mockLLMGenerator{response: `{
+		"tasks": [
+			{"id": "task_1", "description": "Research vendors", "type": "observation", "dependencies": []},
+			{"id": "task_2", "description": "Compare vendors", "type": "action", "dependencies": ["task_1"]}
+		],
+		"criteria": [{"name": "Recommends a vendor", "operator": "contains", "expected": "recommend"}]
+	}`}
+
+	for _, strategy := range []PlanningStrategy{StrategySequential, StrategyParallel, StrategyAdaptive} {
+		t.Run(string(strategy), func(t *testing.T) {
+			config := DefaultPlannerConfig()
+			config.Strategy = strategy
+			plan, err := NewDecomposer(config, llm).Decompose(context.Background(), "Research several vendors and compare them, then recommend one")
+			if err != nil {
+				t.Fatalf("Decompose failed: %v", err)
+			}
+			if len(plan.GoalState.Criteria) != 1 {
+				t.Fatalf("got %d criteria, want 1", len(plan.GoalState.Criteria))
+			}
+
+			// The agent judges the criteria, as no evaluator is configured
+			judged := 0
+			agent := &mockAgent{chatFunc: func(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error) {
+				if strings.Contains(message, "SUCCESS CRITERION: Recommends a vendor") {
+					judged++
+					return &ChatResult{Content: `{"satisfied": true, "score": 1}`}, nil
+				}
+				return &ChatResult{Content: "We recommend vendor B"}, nil
+			}}
+			executor := NewExecutor(config, agent)
+
+			var result *PlanResult
+			switch strategy {
+			case StrategyParallel:
+				result, err = executor.executeParallel(context.Background(), plan)
+			case StrategyAdaptive:
+				result, err = executor.executeAdaptive(context.Background(), plan)
+			default:
+				result, err = executor.Execute(context.Background(), plan)
+			}
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.Status != PlanStatusCompleted || !result.Plan.GoalState.Satisfied {
+				t.Errorf("Status = %s, goal satisfied = %v, want a completed plan meeting its goal", result.Status, result.Plan.GoalState.Satisfied)
+			}
+			if judged == 0 {
+				t.Error("the agent should judge the goal criteria")
+			}
+		})
+	}
+}
+
+func TestBuilder_PlanAndExecute_GoalJudge(t *testing.T) {
+	planJSON := `{"tasks": [
+		{"id": "task_1", "description": "Look up the sales figure", "type": "observation", "dependencies": []},
+		{"id": "task_2", "description": "Double-check the sales figure", "type": "observation", "dependencies": ["task_1"]}
+	], "criteria": [{"name": "Sales figure is known", "operator": "contains", "expected": "units"}]}`
+
+	judged := 0
+	answer := plannerLLM(planJSON)
+	llm := newFakeChatServer(t, func(messages []chatMessage) string {
+		prompt := lastMessage(messages, "user")
+		if strings.Contains(prompt, "SUCCESS CRITERION: Sales figure is known") {
+			judged++
+			if strings.Contains(prompt, "42 units") {
+				return `{"satisfied": true, "score": 1, "actual": "42 units", "reason": "figure found"}`
+			}
+			return `{"satisfied": false, "score": 0}`
+		}
+		return answer(messages)
+	})
+
+	calls := 0
+	config := DefaultPlannerConfig()
+	config.GoalCheckInterval = 1
+	result, err := NewOllama("test").
+		WithBaseURL(llm.URL+"/v1/").
+		WithTool(lookupTool(&calls)).
+		WithReActTextMode().
+		PlanAndExecute(context.Background(), testPlanGoal, config)
+	if err != nil {
+		t.Fatalf("PlanAndExecute failed: %v", err)
+	}
+	if judged != 1 {
+		t.Errorf("judge called %d times, want 1", judged)
+	}
+	if !result.Metrics.GoalAchieved || result.Progress != 1.0 {
+		t.Errorf("GoalAchieved = %v, Progress = %v, want the goal achieved", result.Metrics.GoalAchieved, result.Progress)
+	}
+	if got := result.Plan.GoalState.Criteria[0].Actual; got != "42 units" {
+		t.Errorf("Actual = %v, want 42 units", got)
+	}
+	if result.Plan.Tasks[1].Status != TaskStatusSkipped {
+		t.Errorf("task_2 status = %s, want skipped once the goal is met", result.Plan.Tasks[1].Status)
+	}
+}
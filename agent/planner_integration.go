@@ -8,7 +8,7 @@ import (
 // AgentLLMWrapper wraps an Agent to implement the llmGenerator interface.
 // This allows the Decomposer to use the Agent's LLM capabilities for task decomposition.
 type AgentLLMWrapper struct {
-	agent *Agent
+	agent agentExecutor
 }
 
 // NewAgentLLMWrapper creates a new wrapper around an Agent.
@@ -55,8 +55,8 @@ func (a *Agent) PlanAndExecute(ctx context.Context, goal string) (*PlanResult, e
 		return nil, fmt.Errorf("goal decomposition failed: %w", err)
 	}
 
-	// Create executor
-	executor := NewExecutor(plannerConfig, a)
+	// Create executor, judging the goal criteria with the agent's model
+	executor := NewExecutor(plannerConfig, a).WithReplanning(decomposer)
 
 	// Execute the plan
 	result, err := executor.Execute(ctx, plan)
@@ -85,8 +85,8 @@ func (a *Agent) PlanAndExecuteWithConfig(ctx context.Context, goal string, confi
 		return nil, fmt.Errorf("goal decomposition failed: %w", err)
 	}
 
-	// Create executor with custom config
-	executor := NewExecutor(config, a)
+	// Create executor with custom config, judging the goal criteria with the agent's model
+	executor := NewExecutor(config, a).WithReplanning(decomposer)
 
 	// Execute the plan
 	result, err := executor.Execute(ctx, plan)
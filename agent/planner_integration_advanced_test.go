@@ -361,7 +361,9 @@ func TestIntegration_ComplexDependencies(t *testing.T) {
 			},
 		}
 
-		executor := NewExecutor(DefaultPlannerConfig(), mock)
+		config := DefaultPlannerConfig()
+		config.DependencyPromptTemplate = "{{.Task}}" // the mock identifies tasks by description
+		executor := NewExecutor(config, mock)
 		ctx := context.Background()
 		result, err := executor.executeParallel(ctx, plan)
 
@@ -317,6 +317,7 @@ func TestExecuteParallel(t *testing.T) {
 
 			config := DefaultPlannerConfig()
 			config.MaxParallel = 3
+			config.DependencyPromptTemplate = "{{.Task}}" // the mock answers by task description
 			executor := NewExecutor(config, mock)
 
 			ctx := context.Background()
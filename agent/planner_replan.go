@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// replanPromptTemplate is the template for asking an alternative to a failed task.
+const replanPromptTemplate = `You are a task planning expert. A step of a plan failed and needs a different approach.
+
+GOAL: {{.Goal}}
+
+FAILED STEP: {{.Task}}
+FAILURE REASON: {{.Error}}
+
+COMPLETED STEPS:
+{{.Results}}
+
+Propose {{.MinSubtasks}} to {{.MaxSubtasks}} alternative subtasks that achieve what the failed step was meant to achieve while avoiding the cause of the failure.
+Do not redo the completed steps; subtasks may depend on them by ID or on each other.
+Use these task types: "action" (execute tool/action), "decision" (make choice), "observation" (gather info), "aggregate" (combine results)
+
+Output ONLY valid JSON in this exact format (no markdown, no extra text):
+{
+  "tasks": [
+    {
+      "id": "alt_1",
+      "description": "Clear description of what to do",
+      "type": "action",
+      "dependencies": [],
+      "subtasks": []
+    }
+  ]
+}`
+
+// buildReplanPrompt renders the replanning prompt for a failed task.
+func (d *Decomposer) buildReplanPrompt(plan *Plan, failed *Task, reason error, completed []TaskResult) string {
+	inputs := make([]taskInput, 0, len(completed))
+	for _, result := range completed {
+		description := result.TaskID
+		if task := plan.GetTaskByID(result.TaskID); task != nil {
+			description = task.Description
+		}
+		inputs = append(inputs, taskInput{ID: result.TaskID, Description: description, Output: result.Output})
+	}
+
+	results := "(none)"
+	if len(inputs) > 0 {
+		results = formatTaskInputs(inputs, d.config.DependencyTokenBudget)
+	}
+
+	return strings.NewReplacer(
+		"{{.Goal}}", plan.Goal,
+		"{{.Task}}", failed.Description,
+		"{{.Error}}", errorString(reason),
+		"{{.Results}}", results,
+		"{{.MinSubtasks}}", fmt.Sprintf("%d", d.config.MinSubtaskSplit),
+		"{{.MaxSubtasks}}", fmt.Sprintf("%d", d.config.MaxSubtasks),
+	).Replace(replanPromptTemplate)
+}
+
+// replan asks the LLM for alternative subtasks with a replanning prompt.
+// It returns the subtasks and the estimated number of tokens of the response.
+func (d *Decomposer) replan(ctx context.Context, prompt string) ([]Task, int, error) {
+	response, err := d.llm.Generate(ctx, prompt, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("LLM generation failed: %w", err)
+	}
+
+	tasks, err := d.parseTasks(response)
+	if err != nil {
+		return nil, estimateTokens(response), fmt.Errorf("task parsing failed: %w", err)
+	}
+	return tasks, estimateTokens(response), nil
+}
+
+// WithReplanning lets the executor recover from failed tasks: when a task fails,
+// the decomposer is asked for alternative subtasks, which replace the failed
+// attempt before the task runs again as an aggregate of them. Replanning is
+// bounded by PlannerConfig.MaxReplans, which must be set to enable it, and
+// ReplanTokenBudget.
+//
+// Example:
+//
+//	config.MaxReplans = 2
+//	decomposer := agent.NewDecomposer(config, agent.NewAgentLLMWrapper(a))
+//	executor := agent.NewExecutor(config, a).WithReplanning(decomposer)
+func (e *Executor) WithReplanning(decomposer *Decomposer) *Executor {
+	e.replanner = decomposer
+	return e
+}
+
+// replan replaces a failed task with alternative subtasks from the decomposer.
+// It reports whether the task was replanned and should run again.
+func (e *Executor) replan(ctx context.Context, task *Task, reason error, execCtx *executionContext) bool {
+	if e.replanner == nil || e.config.MaxReplans <= 0 || ctx.Err() != nil {
+		return false
+	}
+
+	completed := e.completedResults(execCtx)
+	execCtx.mu.RLock()
+	prompt := e.replanner.buildReplanPrompt(execCtx.plan, task, reason, completed)
+	execCtx.mu.RUnlock()
+	promptTokens := estimateTokens(prompt)
+
+	// Reserve the replan before calling the LLM, as tasks may fail concurrently
+	execCtx.mu.Lock()
+	if execCtx.replans >= e.config.MaxReplans {
+		execCtx.mu.Unlock()
+		execCtx.addEvent("replan_skipped", task.ID, fmt.Sprintf("Replan limit of %d reached", e.config.MaxReplans))
+		return false
+	}
+	if budget := e.config.ReplanTokenBudget; budget > 0 && execCtx.replanTokens+promptTokens > budget {
+		execCtx.mu.Unlock()
+		execCtx.addEvent("replan_skipped", task.ID, fmt.Sprintf("Replan token budget of %d exhausted", budget))
+		return false
+	}
+	execCtx.replans++
+	execCtx.replanTokens += promptTokens
+	replan := execCtx.replans
+	execCtx.mu.Unlock()
+
+	alternatives, responseTokens, err := e.replanner.replan(ctx, prompt)
+
+	execCtx.mu.Lock()
+	defer execCtx.mu.Unlock()
+
+	execCtx.replanTokens += responseTokens
+	if err != nil {
+		execCtx.addEvent("replan_failed", task.ID, fmt.Sprintf("Replanning failed: %v", err))
+		return false
+	}
+
+	// Splice the alternatives in as subtasks of the failed task and
+	// validate the whole tree, restoring the task if it is rejected
+	prefix := fmt.Sprintf("%s_alt%d_", task.ID, replan)
+	previousType, previousSubtasks := task.Type, task.Subtasks
+	task.Type = TaskTypeAggregate
+	task.Subtasks = e.renameAlternatives(alternatives, prefix, task, execCtx)
+	if err := e.replanner.validateTaskTree(execCtx.plan.Tasks); err != nil {
+		task.Type, task.Subtasks = previousType, previousSubtasks
+		execCtx.addEvent("replan_rejected", task.ID, fmt.Sprintf("Alternative plan rejected: %v", err))
+		return false
+	}
+
+	delete(execCtx.results, task.ID)
+	execCtx.addEvent("task_replanned", task.ID, fmt.Sprintf("Replanned into %d alternative subtasks after failure: %v (replan %d of %d)",
+		len(task.Subtasks), reason, replan, e.config.MaxReplans))
+	return true
+}
+
+// renameAlternatives prefixes the IDs of alternative subtasks so that they
+// don't collide with existing tasks, and places them under parent.
+// Dependencies on other alternatives are renamed, dependencies on existing
+// tasks are kept if those completed, and others are dropped.
+// The caller must hold execCtx.mu.
+func (e *Executor) renameAlternatives(tasks []Task, prefix string, parent *Task, execCtx *executionContext) []Task {
+	ids := make(map[string]bool)
+	var collect func(tasks []Task)
+	collect = func(tasks []Task) {
+		for _, task := range tasks {
+			ids[task.ID] = true
+			collect(task.Subtasks)
+		}
+	}
+	collect(tasks)
+
+	var rename func(tasks []Task, parentID string, depth int) []Task
+	rename = func(tasks []Task, parentID string, depth int) []Task {
+		renamed := make([]Task, len(tasks))
+		for i, task := range tasks {
+			var dependencies []string
+			for _, depID := range task.Dependencies {
+				if ids[depID] {
+					dependencies = append(dependencies, prefix+depID)
+				} else if result, exists := execCtx.results[depID]; exists && result.Status == TaskStatusCompleted {
+					dependencies = append(dependencies, depID)
+				}
+			}
+
+			task.ID = prefix + task.ID
+			task.ParentID = parentID
+			task.Depth = depth
+			task.Dependencies = dependencies
+			task.Status = TaskStatusPending
+			task.Subtasks = rename(task.Subtasks, task.ID, depth+1)
+			renamed[i] = task
+		}
+		return renamed
+	}
+	return rename(tasks, parent.ID, parent.Depth+1)
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// replanLLM returns the same alternatives for every replan and records the prompts
+type replanLLM struct {
+	mu       sync.Mutex
+	response string
+	prompts  []string
+}
+
+func (m *replanLLM) Generate(ctx context.Context, prompt string, opts *ChatOptions) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	return m.response, nil
+}
+
+const alternativesResponse = `{"tasks": [
+	{"id": "alt_1", "description": "Sample the sales data", "type": "observation", "dependencies": ["fetch"]},
+	{"id": "alt_2", "description": "Summarize the sample", "type": "action", "dependencies": ["alt_1", "unknown"]}
+]}`
+
+// analyzeFailingAgent fails the original attempt at analyzing, which gets the
+// fetched data as a dependency, but not the synthesis of alternatives
+func analyzeFailingAgent() *mockAgent {
+	return &mockAgent{chatFunc: func(ctx context.Context, message string, opts *ChatOptions) (*ChatResult, error) {
+		if strings.Contains(message, "RESULTS OF PREVIOUS STEPS") && strings.Contains(message, "YOUR TASK: Analyze the sales data") {
+			return nil, errors.New("context window exceeded")
+		}
+		return &ChatResult{Content: "output of " + message[strings.LastIndex(message, ":")+1:]}, nil
+	}}
+}
+
+func hasEvent(timeline []PlanEvent, eventType, taskID string) bool {
+	for _, event := range timeline {
+		if event.Type == eventType && event.TaskID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExecute_ReplansFailedTask(t *testing.T) {
+	config := DefaultPlannerConfig()
+	config.MaxReplans = 2
+	llm := &replanLLM{response: alternativesResponse}
+	executor := NewExecutor(config, analyzeFailingAgent()).WithReplanning(NewDecomposer(config, llm))
+
+	result, err := executor.Execute(context.Background(), pipelinePlan(StrategySequential))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != PlanStatusCompleted {
+		t.Errorf("Status = %s, want completed", result.Status)
+	}
+
+	if len(llm.prompts) != 1 {
+		t.Fatalf("got %d replan prompts, want 1", len(llm.prompts))
+	}
+	prompt := llm.prompts[0]
+	if !strings.Contains(prompt, "FAILED STEP: Analyze the sales data") ||
+		!strings.Contains(prompt, "context window exceeded") ||
+		!strings.Contains(prompt, "output of Fetch the sales data") {
+		t.Errorf("replan prompt should describe the failure and the completed steps:\n%s", prompt)
+	}
+
+	analyze := result.Plan.GetTaskByID("analyze")
+	if analyze.Type != TaskTypeAggregate || len(analyze.Subtasks) != 2 || analyze.Status != TaskStatusCompleted {
+		t.Fatalf("analyze = %s/%s with %d subtasks, want a completed aggregate of 2", analyze.Type, analyze.Status, len(analyze.Subtasks))
+	}
+	first, second := analyze.Subtasks[0], analyze.Subtasks[1]
+	if first.ID != "analyze_alt1_alt_1" || first.ParentID != "analyze" || first.Depth != 1 {
+		t.Errorf("first alternative = %s (parent %s, depth %d)", first.ID, first.ParentID, first.Depth)
+	}
+	// Dependencies on alternatives are renamed and unknown ones dropped
+	if len(second.Dependencies) != 1 || second.Dependencies[0] != first.ID {
+		t.Errorf("second alternative dependencies = %v, want [%s]", second.Dependencies, first.ID)
+	}
+	if first.Status != TaskStatusCompleted || second.Status != TaskStatusCompleted {
+		t.Errorf("alternatives = %s, %s, want completed", first.Status, second.Status)
+	}
+
+	if !hasEvent(result.Timeline, "task_replanned", "analyze") {
+		t.Error("timeline should record the replan")
+	}
+	if write := result.Plan.GetTaskByID("write"); write.Status != TaskStatusCompleted {
+		t.Errorf("dependent task = %s, want completed", write.Status)
+	}
+}
+
+func TestExecute_ReplanLimits(t *testing.T) {
+	var prompts []string
+
+	t.Run("MaxReplans", func(t *testing.T) {
+		config := DefaultPlannerConfig()
+		config.MaxReplans = 1
+		llm := &replanLLM{response: alternativesResponse}
+		// The alternatives fail as well, and the limit is reached
+		executor := NewExecutor(config, failingAgent(&prompts, "Analyze the sales data", "Sample the sales data")).
+			WithReplanning(NewDecomposer(config, llm))
+
+		result, err := executor.Execute(context.Background(), pipelinePlan(StrategySequential))
+		if err == nil || result.Status != PlanStatusFailed {
+			t.Errorf("Execute = %s, %v, want the plan to fail", result.Status, err)
+		}
+		if len(llm.prompts) != 1 {
+			t.Errorf("got %d replan prompts, want 1", len(llm.prompts))
+		}
+		if !hasEvent(result.Timeline, "replan_skipped", "analyze_alt1_alt_1") {
+			t.Error("timeline should record the skipped replan")
+		}
+	})
+
+	t.Run("ReplanTokenBudget", func(t *testing.T) {
+		config := DefaultPlannerConfig()
+		config.MaxReplans = 2
+		config.ReplanTokenBudget = 10
+		llm := &replanLLM{response: alternativesResponse}
+		executor := NewExecutor(config, failingAgent(&prompts, "Analyze the sales data")).
+			WithReplanning(NewDecomposer(config, llm))
+
+		result, _ := executor.Execute(context.Background(), pipelinePlan(StrategySequential))
+		if result.Status != PlanStatusFailed || len(llm.prompts) != 0 {
+			t.Errorf("Status = %s after %d replan prompts, want failed without replanning", result.Status, len(llm.prompts))
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		config := DefaultPlannerConfig()
+		config.MaxReplans = 0
+		llm := &replanLLM{response: alternativesResponse}
+		executor := NewExecutor(config, failingAgent(&prompts, "Analyze the sales data")).
+			WithReplanning(NewDecomposer(config, llm))
+
+		if _, err := executor.Execute(context.Background(), pipelinePlan(StrategyParallel)); err == nil || len(llm.prompts) != 0 {
+			t.Errorf("Execute = %v after %d replan prompts, want a failure without replanning", err, len(llm.prompts))
+		}
+	})
+}
+
+func TestExecute_ReplanRejected(t *testing.T) {
+	config := DefaultPlannerConfig()
+	config.MaxDepth = 1
+	config.MaxReplans = 2
+	// Nested alternatives exceed the maximum depth once spliced under the task
+	llm := &replanLLM{response: `{"tasks": [
+		{"id": "alt_1", "description": "Sample", "type": "aggregate", "subtasks": [
+			{"id": "alt_1a", "description": "Sample the first half", "type": "observation"},
+			{"id": "alt_1b", "description": "Sample the second half", "type": "observation"}
+		]},
+		{"id": "alt_2", "description": "Summarize", "type": "action"}
+	]}`}
+	var prompts []string
+	executor := NewExecutor(config, failingAgent(&prompts, "Analyze the sales data")).
+		WithReplanning(NewDecomposer(config, llm))
+
+	result, err := executor.Execute(context.Background(), pipelinePlan(StrategySequential))
+	if err == nil {
+		t.Fatal("expected the plan to fail")
+	}
+	if !hasEvent(result.Timeline, "replan_rejected", "analyze") {
+		t.Error("timeline should record the rejected replan")
+	}
+	analyze := result.Plan.GetTaskByID("analyze")
+	if analyze.Type == TaskTypeAggregate || len(analyze.Subtasks) != 0 || analyze.Status != TaskStatusFailed {
+		t.Errorf("analyze = %s/%s with %d subtasks, want the failed task restored", analyze.Type, analyze.Status, len(analyze.Subtasks))
+	}
+}
+
+func TestPlannerConfig_ReplanValidation(t *testing.T) {
+	config := DefaultPlannerConfig()
+	config.MaxReplans = -1
+	if err := config.Validate(); err == nil {
+		t.Error("expected a negative MaxReplans to be invalid")
+	}
+
+	config = DefaultPlannerConfig()
+	config.ReplanTokenBudget = -1
+	if err := config.Validate(); err == nil {
+		t.Error("expected a negative ReplanTokenBudget to be invalid")
+	}
+}
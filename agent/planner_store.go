@@ -0,0 +1,494 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// PlanStore defines the interface for plan checkpoint persistence backends.
+// The Executor saves a checkpoint after every task transition when
+// PlannerConfig.PlanStore is set, and Executor.Resume continues from it.
+//
+// Example usage:
+//
+//	store, _ := NewFilePlanStore("")  // Uses default path
+//	config := DefaultPlannerConfig()
+//	config.PlanStore = store
+//	result, err := builder.PlanAndExecute(ctx, goal, config)
+//	// After a crash:
+//	result, err = NewBuilderExecutor(config, builder).Resume(ctx, planID)
+type PlanStore interface {
+	// Load retrieves the checkpoint of a plan.
+	// Returns nil if no checkpoint exists.
+	// Returns error only for actual failures (not for missing checkpoints).
+	Load(ctx context.Context, planID string) (*PlanCheckpoint, error)
+
+	// Save stores the checkpoint of a plan, replacing the previous one.
+	// Should use atomic writes to prevent corruption.
+	Save(ctx context.Context, checkpoint *PlanCheckpoint) error
+
+	// Delete removes the checkpoint of a plan.
+	// Returns nil if the checkpoint doesn't exist.
+	Delete(ctx context.Context, planID string) error
+
+	// List returns the IDs of all checkpointed plans.
+	// Returns empty slice if no checkpoints exist.
+	List(ctx context.Context) ([]string, error)
+}
+
+// PlanCheckpoint is the saved state of a plan execution.
+// Task statuses, results and errors in Plan reflect the state at SavedAt.
+type PlanCheckpoint struct {
+	// Plan is the plan with the state of all its tasks.
+	Plan *Plan
+
+	// Results contains the result of every task that started, by task ID.
+	Results map[string]*TaskResult
+
+	// Status is the plan status when the checkpoint was saved.
+	Status PlanStatus
+
+	// Timeline contains the execution events so far.
+	Timeline []PlanEvent
+
+	// TasksExecuted is the number of completed tasks, used for periodic goal checks.
+	TasksExecuted int
+
+	// Retries counts how many times Resume retried each failed task.
+	Retries map[string]int
+
+	// SavedAt is when the checkpoint was saved.
+	SavedAt time.Time
+}
+
+// checkpointVersion is the version of the checkpoint JSON format
+const checkpointVersion = 1
+
+// checkpointJSON is the JSON form of a PlanCheckpoint. Errors are stored as
+// their messages because error values don't survive a JSON round trip.
+type checkpointJSON struct {
+	Version       int                       `json:"version"`
+	Plan          planJSON                  `json:"plan"`
+	Results       map[string]taskResultJSON `json:"results"`
+	Status        PlanStatus                `json:"status"`
+	Timeline      []PlanEvent               `json:"timeline,omitempty"`
+	TasksExecuted int                       `json:"tasks_executed"`
+	Retries       map[string]int            `json:"retries,omitempty"`
+	SavedAt       time.Time                 `json:"saved_at"`
+}
+
+// planJSON is the JSON form of a Plan
+type planJSON struct {
+	ID            string                 `json:"id"`
+	Goal          string                 `json:"goal"`
+	GoalState     GoalState              `json:"goal_state"`
+	Tasks         []taskStateJSON        `json:"tasks"`
+	Strategy      PlanningStrategy       `json:"strategy"`
+	CreatedAt     time.Time              `json:"created_at"`
+	EstimatedCost float64                `json:"estimated_cost,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// taskStateJSON is the JSON form of a Task
+type taskStateJSON struct {
+	ID           string          `json:"id"`
+	ParentID     string          `json:"parent_id,omitempty"`
+	Description  string          `json:"description"`
+	Type         TaskType        `json:"type"`
+	Dependencies []string        `json:"dependencies,omitempty"`
+	Status       TaskStatus      `json:"status"`
+	ReActSteps   []reactStepJSON `json:"react_steps,omitempty"`
+	Result       interface{}     `json:"result,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	StartedAt    time.Time       `json:"started_at"`
+	CompletedAt  time.Time       `json:"completed_at"`
+	Duration     time.Duration   `json:"duration"`
+	Subtasks     []taskStateJSON `json:"subtasks,omitempty"`
+	Depth        int             `json:"depth"`
+}
+
+// taskResultJSON is the JSON form of a TaskResult
+type taskResultJSON struct {
+	TaskID     string          `json:"task_id"`
+	Status     TaskStatus      `json:"status"`
+	Output     string          `json:"output,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	ReActSteps []reactStepJSON `json:"react_steps,omitempty"`
+	StartTime  time.Time       `json:"start_time"`
+	EndTime    time.Time       `json:"end_time"`
+	Duration   time.Duration   `json:"duration"`
+}
+
+// reactStepJSON is the JSON form of a ReActStep
+type reactStepJSON struct {
+	Type      string                 `json:"type"`
+	Content   string                 `json:"content"`
+	Tool      string                 `json:"tool,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// errorString returns the message of err, or "" if err is nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// stringError restores an error from its message
+func stringError(message string) error {
+	if message == "" {
+		return nil
+	}
+	return errors.New(message)
+}
+
+func encodeSteps(steps []ReActStep) []reactStepJSON {
+	if steps == nil {
+		return nil
+	}
+	encoded := make([]reactStepJSON, len(steps))
+	for i, step := range steps {
+		encoded[i] = reactStepJSON{
+			Type:      step.Type,
+			Content:   step.Content,
+			Tool:      step.Tool,
+			Args:      step.Args,
+			Timestamp: step.Timestamp,
+			Error:     errorString(step.Error),
+		}
+	}
+	return encoded
+}
+
+func decodeSteps(steps []reactStepJSON) []ReActStep {
+	if steps == nil {
+		return nil
+	}
+	decoded := make([]ReActStep, len(steps))
+	for i, step := range steps {
+		decoded[i] = ReActStep{
+			Type:      step.Type,
+			Content:   step.Content,
+			Tool:      step.Tool,
+			Args:      step.Args,
+			Timestamp: step.Timestamp,
+			Error:     stringError(step.Error),
+		}
+	}
+	return decoded
+}
+
+func encodeTasks(tasks []Task) []taskStateJSON {
+	encoded := make([]taskStateJSON, len(tasks))
+	for i, task := range tasks {
+		encoded[i] = taskStateJSON{
+			ID:           task.ID,
+			ParentID:     task.ParentID,
+			Description:  task.Description,
+			Type:         task.Type,
+			Dependencies: task.Dependencies,
+			Status:       task.Status,
+			ReActSteps:   encodeSteps(task.ReActSteps),
+			Result:       task.Result,
+			Error:        errorString(task.Error),
+			StartedAt:    task.StartedAt,
+			CompletedAt:  task.CompletedAt,
+			Duration:     task.Duration,
+			Subtasks:     encodeTasks(task.Subtasks),
+			Depth:        task.Depth,
+		}
+	}
+	return encoded
+}
+
+func decodeTasks(tasks []taskStateJSON) []Task {
+	decoded := make([]Task, len(tasks))
+	for i, task := range tasks {
+		decoded[i] = Task{
+			ID:           task.ID,
+			ParentID:     task.ParentID,
+			Description:  task.Description,
+			Type:         task.Type,
+			Dependencies: task.Dependencies,
+			Status:       task.Status,
+			ReActSteps:   decodeSteps(task.ReActSteps),
+			Result:       task.Result,
+			Error:        stringError(task.Error),
+			StartedAt:    task.StartedAt,
+			CompletedAt:  task.CompletedAt,
+			Duration:     task.Duration,
+			Subtasks:     decodeTasks(task.Subtasks),
+			Depth:        task.Depth,
+		}
+	}
+	return decoded
+}
+
+// MarshalJSON implements json.Marshaler, storing errors as their messages.
+func (c *PlanCheckpoint) MarshalJSON() ([]byte, error) {
+	if c.Plan == nil {
+		return nil, fmt.Errorf("checkpoint has no plan")
+	}
+
+	results := make(map[string]taskResultJSON, len(c.Results))
+	for id, result := range c.Results {
+		results[id] = taskResultJSON{
+			TaskID:     result.TaskID,
+			Status:     result.Status,
+			Output:     result.Output,
+			Error:      errorString(result.Error),
+			ReActSteps: encodeSteps(result.ReActSteps),
+			StartTime:  result.StartTime,
+			EndTime:    result.EndTime,
+			Duration:   result.Duration,
+		}
+	}
+
+	return json.Marshal(checkpointJSON{
+		Version: checkpointVersion,
+		Plan: planJSON{
+			ID:            c.Plan.ID,
+			Goal:          c.Plan.Goal,
+			GoalState:     c.Plan.GoalState,
+			Tasks:         encodeTasks(c.Plan.Tasks),
+			Strategy:      c.Plan.Strategy,
+			CreatedAt:     c.Plan.CreatedAt,
+			EstimatedCost: c.Plan.EstimatedCost,
+			Metadata:      c.Plan.Metadata,
+		},
+		Results:       results,
+		Status:        c.Status,
+		Timeline:      c.Timeline,
+		TasksExecuted: c.TasksExecuted,
+		Retries:       c.Retries,
+		SavedAt:       c.SavedAt,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *PlanCheckpoint) UnmarshalJSON(data []byte) error {
+	var cp checkpointJSON
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return err
+	}
+	if cp.Version > checkpointVersion {
+		return fmt.Errorf("unsupported checkpoint version %d", cp.Version)
+	}
+
+	metadata := cp.Plan.Metadata
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	results := make(map[string]*TaskResult, len(cp.Results))
+	for id, result := range cp.Results {
+		results[id] = &TaskResult{
+			TaskID:     result.TaskID,
+			Status:     result.Status,
+			Output:     result.Output,
+			Error:      stringError(result.Error),
+			ReActSteps: decodeSteps(result.ReActSteps),
+			StartTime:  result.StartTime,
+			EndTime:    result.EndTime,
+			Duration:   result.Duration,
+		}
+	}
+	retries := cp.Retries
+	if retries == nil {
+		retries = make(map[string]int)
+	}
+
+	*c = PlanCheckpoint{
+		Plan: &Plan{
+			ID:            cp.Plan.ID,
+			Goal:          cp.Plan.Goal,
+			GoalState:     cp.Plan.GoalState,
+			Tasks:         decodeTasks(cp.Plan.Tasks),
+			Strategy:      cp.Plan.Strategy,
+			CreatedAt:     cp.Plan.CreatedAt,
+			EstimatedCost: cp.Plan.EstimatedCost,
+			Metadata:      metadata,
+		},
+		Results:       results,
+		Status:        cp.Status,
+		Timeline:      cp.Timeline,
+		TasksExecuted: cp.TasksExecuted,
+		Retries:       retries,
+		SavedAt:       cp.SavedAt,
+	}
+	return nil
+}
+
+// FilePlanStore implements PlanStore using local file storage.
+// It stores each plan checkpoint as a JSON file in a configurable directory.
+// Default path: ~/.go-deep-agent/plans/
+//
+// Like FileBackend, it writes atomically (temp file + rename), so a crash
+// during a save leaves the previous checkpoint intact.
+//
+// Example:
+//
+//	store, err := NewFilePlanStore("")  // Uses default path
+//	store, err := NewFilePlanStore("/var/lib/myapp/plans")  // Custom path
+type FilePlanStore struct {
+	basePath string
+	mu       sync.RWMutex
+}
+
+// NewFilePlanStore creates a new file-based plan checkpoint store.
+//
+// If basePath is empty, uses default: ~/.go-deep-agent/plans/
+// Creates directory if it doesn't exist.
+//
+// Returns error if directory creation fails.
+func NewFilePlanStore(basePath string) (*FilePlanStore, error) {
+	// Use default path if not specified
+	if basePath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		basePath = filepath.Join(home, ".go-deep-agent", "plans")
+	}
+
+	// Create directory if not exists
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create plans directory: %w", err)
+	}
+
+	return &FilePlanStore{
+		basePath: basePath,
+	}, nil
+}
+
+// Load retrieves a plan checkpoint from a JSON file.
+//
+// Returns:
+//   - nil, nil if checkpoint file doesn't exist
+//   - checkpoint, nil if successfully loaded
+//   - nil, error if file read or JSON parsing fails
+func (f *FilePlanStore) Load(ctx context.Context, planID string) (*PlanCheckpoint, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	// Validate plan ID
+	if planID == "" {
+		return nil, fmt.Errorf("plan ID cannot be empty")
+	}
+
+	data, err := os.ReadFile(f.getFilePath(planID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan checkpoint file: %w", err)
+	}
+
+	var checkpoint PlanCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse plan checkpoint JSON: %w", err)
+	}
+
+	return &checkpoint, nil
+}
+
+// Save stores a plan checkpoint to a JSON file.
+//
+// Uses atomic write strategy (temp file + rename) to prevent corruption.
+func (f *FilePlanStore) Save(ctx context.Context, checkpoint *PlanCheckpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Validate checkpoint
+	if checkpoint == nil || checkpoint.Plan == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if checkpoint.Plan.ID == "" {
+		return fmt.Errorf("plan ID cannot be empty")
+	}
+
+	filePath := f.getFilePath(checkpoint.Plan.ID)
+
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan checkpoint to JSON: %w", err)
+	}
+
+	// Write atomically: temp file + rename
+	tempPath := filePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp plan checkpoint file: %w", err)
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename temp plan checkpoint file: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a plan checkpoint.
+//
+// Returns nil if checkpoint file doesn't exist (idempotent).
+func (f *FilePlanStore) Delete(ctx context.Context, planID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Validate plan ID
+	if planID == "" {
+		return fmt.Errorf("plan ID cannot be empty")
+	}
+
+	if err := os.Remove(f.getFilePath(planID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete plan checkpoint file: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the IDs of all checkpointed plans.
+//
+// Returns empty slice if plans directory doesn't exist or is empty.
+func (f *FilePlanStore) List(ctx context.Context) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	entries, err := os.ReadDir(f.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read plans directory: %w", err)
+	}
+
+	planIDs := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		planIDs = append(planIDs, name[:len(name)-5])
+	}
+
+	return planIDs, nil
+}
+
+// getFilePath constructs the full file path for a plan ID.
+func (f *FilePlanStore) getFilePath(planID string) string {
+	return filepath.Join(f.basePath, planID+".json")
+}
+
+// GetBasePath returns the base directory path used for checkpoint storage.
+func (f *FilePlanStore) GetBasePath() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.basePath
+}
@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPlanStore stores plan checkpoints in Redis.
+// It lets a plan started on one machine be resumed on another.
+//
+// Default Configuration:
+//   - TTL: 7 days
+//   - Prefix: "go-deep-agent:plans:"
+//   - DB: 0
+//   - Pool: 10 connections
+//
+// Example:
+//
+//	store := NewRedisPlanStore("localhost:6379").
+//	    WithPassword("secret").
+//	    WithTTL(24 * time.Hour)
+//	defer store.Close()
+//
+//	config := DefaultPlannerConfig()
+//	config.PlanStore = store
+//
+// For Redis Cluster/Sentinel, use NewRedisPlanStoreWithClient().
+type RedisPlanStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisPlanStore creates a Redis plan store with smart defaults.
+func NewRedisPlanStore(addr string) *RedisPlanStore {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		PoolSize: 10,
+	})
+
+	return NewRedisPlanStoreWithClient(client)
+}
+
+// NewRedisPlanStoreWithClient creates a Redis plan store with a custom client,
+// e.g. a cluster client or a client shared with a RedisBackend.
+func NewRedisPlanStoreWithClient(client redis.UniversalClient) *RedisPlanStore {
+	return &RedisPlanStore{
+		client: client,
+		prefix: "go-deep-agent:plans:",
+		ttl:    7 * 24 * time.Hour,
+	}
+}
+
+// WithPassword sets the Redis authentication password.
+// Default: "" (no authentication)
+func (r *RedisPlanStore) WithPassword(password string) *RedisPlanStore {
+	// Recreate client with password
+	if client, ok := r.client.(*redis.Client); ok {
+		opts := client.Options()
+		opts.Password = password
+		r.client = redis.NewClient(opts)
+	}
+	return r
+}
+
+// WithDB sets the Redis database number (0-15).
+// Default: 0
+func (r *RedisPlanStore) WithDB(db int) *RedisPlanStore {
+	// Recreate client with new DB
+	if client, ok := r.client.(*redis.Client); ok {
+		opts := client.Options()
+		opts.DB = db
+		r.client = redis.NewClient(opts)
+	}
+	return r
+}
+
+// WithTTL sets how long checkpoints are kept before auto-expiration.
+// The TTL is extended on every save, so running plans never expire.
+// Default: 7 days. Set to 0 for no expiration.
+func (r *RedisPlanStore) WithTTL(ttl time.Duration) *RedisPlanStore {
+	r.ttl = ttl
+	return r
+}
+
+// WithPrefix sets the Redis key prefix for namespacing.
+// Default: "go-deep-agent:plans:"
+//
+// Key format: {prefix}{planID}
+func (r *RedisPlanStore) WithPrefix(prefix string) *RedisPlanStore {
+	r.prefix = prefix
+	return r
+}
+
+// Load retrieves a plan checkpoint from Redis.
+//
+// Returns:
+//   - nil, nil if checkpoint doesn't exist
+//   - checkpoint, nil if successfully loaded
+//   - nil, error if Redis operation fails
+func (r *RedisPlanStore) Load(ctx context.Context, planID string) (*PlanCheckpoint, error) {
+	// Validate plan ID
+	if planID == "" {
+		return nil, fmt.Errorf("plan ID cannot be empty")
+	}
+
+	data, err := r.client.Get(ctx, r.prefix+planID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan checkpoint from Redis: %w", err)
+	}
+
+	var checkpoint PlanCheckpoint
+	if err := json.Unmarshal([]byte(data), &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse plan checkpoint JSON: %w", err)
+	}
+
+	return &checkpoint, nil
+}
+
+// Save stores a plan checkpoint to Redis with TTL.
+func (r *RedisPlanStore) Save(ctx context.Context, checkpoint *PlanCheckpoint) error {
+	// Validate checkpoint
+	if checkpoint == nil || checkpoint.Plan == nil {
+		return fmt.Errorf("checkpoint cannot be nil")
+	}
+	if checkpoint.Plan.ID == "" {
+		return fmt.Errorf("plan ID cannot be empty")
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan checkpoint to JSON: %w", err)
+	}
+
+	if err := r.client.Set(ctx, r.prefix+checkpoint.Plan.ID, data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save plan checkpoint to Redis: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a plan checkpoint from Redis.
+//
+// Returns nil if checkpoint doesn't exist (idempotent).
+func (r *RedisPlanStore) Delete(ctx context.Context, planID string) error {
+	// Validate plan ID
+	if planID == "" {
+		return fmt.Errorf("plan ID cannot be empty")
+	}
+
+	if err := r.client.Del(ctx, r.prefix+planID).Err(); err != nil {
+		return fmt.Errorf("failed to delete plan checkpoint from Redis: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the IDs of all checkpointed plans in Redis.
+//
+// Uses SCAN for safe iteration (doesn't block Redis).
+func (r *RedisPlanStore) List(ctx context.Context) ([]string, error) {
+	planIDs := []string{}
+
+	iter := r.client.Scan(ctx, 0, r.prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if len(key) > len(r.prefix) {
+			planIDs = append(planIDs, key[len(r.prefix):])
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan Redis keys: %w", err)
+	}
+
+	return planIDs, nil
+}
+
+// Ping checks if Redis connection is healthy.
+func (r *RedisPlanStore) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close closes the Redis connection.
+func (r *RedisPlanStore) Close() error {
+	return r.client.Close()
+}
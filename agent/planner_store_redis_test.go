@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisPlanStore_Defaults(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	store := NewRedisPlanStore(mr.Addr())
+	defer store.Close()
+
+	assert.Equal(t, "go-deep-agent:plans:", store.prefix)
+	assert.Equal(t, 7*24*time.Hour, store.ttl)
+	assert.NoError(t, store.Ping(context.Background()))
+}
+
+func TestRedisPlanStore_SaveLoad(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	store := NewRedisPlanStore(mr.Addr()).WithPrefix("test:plans:").WithTTL(time.Hour)
+
+	checkpoint, err := store.Load(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	want := testCheckpoint()
+	require.NoError(t, store.Save(ctx, want))
+
+	key := "test:plans:" + want.Plan.ID
+	assert.True(t, mr.Exists(key))
+	assert.Equal(t, time.Hour, mr.TTL(key))
+
+	got, err := store.Load(ctx, want.Plan.ID)
+	require.NoError(t, err)
+	assertCheckpoint(t, got, want)
+}
+
+func TestRedisPlanStore_ListDelete(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	ctx := context.Background()
+	store := NewRedisPlanStoreWithClient(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	first, second := testCheckpoint(), testCheckpoint()
+	require.NoError(t, store.Save(ctx, first))
+	require.NoError(t, store.Save(ctx, second))
+
+	ids, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first.Plan.ID, second.Plan.ID}, ids)
+
+	require.NoError(t, store.Delete(ctx, first.Plan.ID))
+	require.NoError(t, store.Delete(ctx, first.Plan.ID), "Delete should be idempotent")
+
+	ids, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{second.Plan.ID}, ids)
+
+	assert.Error(t, store.Save(ctx, nil))
+	_, err = store.Load(ctx, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCheckpoint returns a checkpoint of a plan with a completed and a failed task
+func testCheckpoint() *PlanCheckpoint {
+	plan := NewPlan("Write a report", StrategyParallel)
+	plan.GoalState.Criteria = []GoalCriterion{{Name: "has numbers", Operator: "contains", Expected: "units", Weight: 1}}
+	plan.Metadata["owner"] = "finance"
+	plan.AddTask(Task{
+		ID:          "data",
+		Description: "Gather data",
+		Type:        TaskTypeObservation,
+		Status:      TaskStatusCompleted,
+		Result:      "42 units",
+		ReActSteps: []ReActStep{
+			{Type: StepTypeAction, Tool: "lookup", Args: map[string]interface{}{"item": "sales"}, Error: errors.New("timeout")},
+		},
+	})
+	plan.AddTask(Task{
+		ID:           "report",
+		Description:  "Write the report",
+		Type:         TaskTypeAggregate,
+		Dependencies: []string{"data"},
+		Status:       TaskStatusFailed,
+		Error:        errors.New("model unavailable"),
+		Subtasks:     []Task{{ID: "draft", ParentID: "report", Description: "Draft", Status: TaskStatusPending, Depth: 1}},
+	})
+
+	return &PlanCheckpoint{
+		Plan: plan,
+		Results: map[string]*TaskResult{
+			"data":   {TaskID: "data", Status: TaskStatusCompleted, Output: "42 units", Duration: time.Second},
+			"report": {TaskID: "report", Status: TaskStatusFailed, Error: errors.New("model unavailable")},
+		},
+		Status:        PlanStatusFailed,
+		Timeline:      []PlanEvent{{Type: "task_completed", TaskID: "data"}},
+		TasksExecuted: 1,
+		Retries:       map[string]int{"report": 1},
+		SavedAt:       time.Now(),
+	}
+}
+
+// assertCheckpoint checks that a loaded checkpoint matches testCheckpoint
+func assertCheckpoint(t *testing.T, got *PlanCheckpoint, want *PlanCheckpoint) {
+	t.Helper()
+	if got == nil {
+		t.Fatal("checkpoint not found")
+	}
+	if got.Plan.ID != want.Plan.ID || got.Plan.Strategy != StrategyParallel || got.Status != PlanStatusFailed {
+		t.Errorf("plan = %s/%s, status %s", got.Plan.ID, got.Plan.Strategy, got.Status)
+	}
+	if got.Plan.Metadata["owner"] != "finance" || got.Plan.GoalState.Criteria[0].Name != "has numbers" {
+		t.Errorf("plan metadata or goal state lost: %+v", got.Plan)
+	}
+
+	data := got.Plan.GetTaskByID("data")
+	if data.Result != "42 units" || len(data.ReActSteps) != 1 || data.ReActSteps[0].Error == nil || data.ReActSteps[0].Error.Error() != "timeout" {
+		t.Errorf("data task = %+v", data)
+	}
+	report := got.Plan.GetTaskByID("report")
+	if report.Status != TaskStatusFailed || report.Error == nil || report.Error.Error() != "model unavailable" {
+		t.Errorf("report task = %+v, want the failure restored", report)
+	}
+	if draft := got.Plan.GetTaskByID("draft"); draft == nil || draft.Depth != 1 {
+		t.Errorf("subtask not restored: %+v", draft)
+	}
+
+	if got.Results["data"].Output != "42 units" || got.Results["data"].Duration != time.Second {
+		t.Errorf("data result = %+v", got.Results["data"])
+	}
+	if got.Results["report"].Error.Error() != "model unavailable" {
+		t.Errorf("report result error = %v", got.Results["report"].Error)
+	}
+	if got.TasksExecuted != 1 || got.Retries["report"] != 1 || len(got.Timeline) != 1 {
+		t.Errorf("execution state = %d tasks, retries %v, %d events", got.TasksExecuted, got.Retries, len(got.Timeline))
+	}
+}
+
+func TestPlanCheckpoint_JSONRoundTrip(t *testing.T) {
+	want := testCheckpoint()
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var got PlanCheckpoint
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	assertCheckpoint(t, &got, want)
+
+	if err := json.Unmarshal([]byte(`{"version": 99, "plan": {}}`), &got); err == nil {
+		t.Error("expected an error for a newer checkpoint version")
+	}
+	if _, err := json.Marshal(&PlanCheckpoint{}); err == nil {
+		t.Error("expected an error for a checkpoint without plan")
+	}
+}
+
+func TestFilePlanStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "plans")
+	store, err := NewFilePlanStore(dir)
+	if err != nil {
+		t.Fatalf("NewFilePlanStore failed: %v", err)
+	}
+	if store.GetBasePath() != dir {
+		t.Errorf("base path = %s, want %s", store.GetBasePath(), dir)
+	}
+
+	// Missing checkpoints are not an error
+	checkpoint, err := store.Load(ctx, "missing")
+	if err != nil || checkpoint != nil {
+		t.Errorf("Load(missing) = %v, %v, want nil, nil", checkpoint, err)
+	}
+
+	want := testCheckpoint()
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := store.Load(ctx, want.Plan.ID)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	assertCheckpoint(t, got, want)
+
+	ids, err := store.List(ctx)
+	if err != nil || len(ids) != 1 || ids[0] != want.Plan.ID {
+		t.Errorf("List = %v, %v, want [%s]", ids, err, want.Plan.ID)
+	}
+
+	if err := store.Delete(ctx, want.Plan.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, want.Plan.ID); err != nil {
+		t.Errorf("Delete should be idempotent: %v", err)
+	}
+	if ids, _ := store.List(ctx); len(ids) != 0 {
+		t.Errorf("List after delete = %v", ids)
+	}
+
+	if err := store.Save(ctx, nil); err == nil {
+		t.Error("expected an error for a nil checkpoint")
+	}
+	if _, err := store.Load(ctx, ""); err == nil {
+		t.Error("expected an error for an empty plan ID")
+	}
+}
@@ -3,10 +3,10 @@ package agent
 import (
 	"context"
 	"fmt"
-	"math"
 	"regexp"
-	"sort"
 	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Document represents a document chunk for RAG
@@ -14,6 +14,10 @@ type Document struct {
 	Content  string            // Document text content
 	Metadata map[string]string // Optional metadata (source, page, etc.)
 	Score    float64           // Relevance score (set during retrieval)
+
+	// Scores holds the score of each retrieval source (ScoreSourceBM25,
+	// ScoreSourceVector and, for hybrid retrieval, ScoreSourceRRF)
+	Scores map[string]float64
 }
 
 // RAGConfig configures RAG behavior
@@ -35,6 +39,29 @@ type RAGConfig struct {
 
 	// IncludeScores adds relevance scores to the context (default: false)
 	IncludeScores bool
+
+	// Hybrid combines BM25 keyword search over the RAG documents with vector
+	// store search, fusing both rankings with reciprocal rank fusion
+	// (default: false). Requires WithVectorRAG.
+	Hybrid bool
+
+	// KeywordWeight and VectorWeight weigh the BM25 and vector rankings in
+	// hybrid retrieval (default: 1.0 each)
+	KeywordWeight float64
+	VectorWeight  float64
+
+	// RRFConstant dampens the advantage of top ranks in fusion (default: 60)
+	RRFConstant float64
+
+	// CandidateK is the number of results taken from each source before
+	// fusion (default: 4 × TopK)
+	CandidateK int
+
+	// BM25K1 controls term frequency saturation (default: 1.2)
+	BM25K1 float64
+
+	// BM25B controls document length normalization (default: 0.75)
+	BM25B float64
 }
 
 // DefaultRAGConfig returns default RAG configuration
@@ -46,6 +73,11 @@ func DefaultRAGConfig() *RAGConfig {
 		MinScore:      0.0,
 		Separator:     "\n\n---\n\n",
 		IncludeScores: false,
+		KeywordWeight: 1.0,
+		VectorWeight:  1.0,
+		RRFConstant:   defaultRRFConstant,
+		BM25K1:        defaultBM25K1,
+		BM25B:         defaultBM25B,
 	}
 }
 
@@ -69,6 +101,7 @@ func (b *Builder) WithRAG(documents ...string) *Builder {
 	}
 
 	b.ragDocuments = docs
+	b.ragIndex = nil
 	b.ragEnabled = true
 
 	if b.ragConfig == nil {
@@ -85,6 +118,7 @@ func (b *Builder) WithRAGDocuments(documents ...Document) *Builder {
 	}
 
 	b.ragDocuments = documents
+	b.ragIndex = nil
 	b.ragEnabled = true
 
 	if b.ragConfig == nil {
@@ -109,6 +143,7 @@ func (b *Builder) WithRAGRetriever(retriever RAGRetriever) *Builder {
 // WithRAGConfig sets custom RAG configuration
 func (b *Builder) WithRAGConfig(config *RAGConfig) *Builder {
 	b.ragConfig = config
+	b.ragIndex = nil
 	return b
 }
 
@@ -137,6 +172,7 @@ func (b *Builder) WithRAGChunkSize(size int) *Builder {
 	}
 
 	b.ragConfig.ChunkSize = size
+	b.ragIndex = nil
 	return b
 }
 
@@ -212,11 +248,20 @@ func (b *Builder) retrieveRelevantDocs(ctx context.Context, query string) ([]Doc
 	logger := b.getLogger()
 	logger.Debug(ctx, "RAG retrieval started", F("query_length", len(query)))
 
-	// If vector store is configured, use vector search
+	config := b.ragConfig
+	if config == nil {
+		config = DefaultRAGConfig()
+	}
+
+	// If vector store is configured, use vector search, fused with keyword search in hybrid mode
 	if b.vectorStore != nil && b.embeddingProvider != nil {
 		logger.Debug(ctx, "Using vector store for retrieval",
 			F("provider", fmt.Sprintf("%T", b.embeddingProvider)),
-			F("store", fmt.Sprintf("%T", b.vectorStore)))
+			F("store", fmt.Sprintf("%T", b.vectorStore)),
+			F("hybrid", config.Hybrid))
+		if config.Hybrid {
+			return b.retrieveHybrid(ctx, query, config)
+		}
 		return b.retrieveFromVector(ctx, query)
 	}
 
@@ -238,58 +283,92 @@ func (b *Builder) retrieveRelevantDocs(ctx context.Context, query string) ([]Doc
 		return []Document{}, nil
 	}
 
-	logger.Debug(ctx, "Using TF-IDF fallback retrieval", F("total_docs", len(b.ragDocuments)))
+	results := b.retrieveKeyword(ctx, query, config, config.TopK)
 
-	config := b.ragConfig
-	if config == nil {
-		config = DefaultRAGConfig()
+	logger.Info(ctx, "RAG retrieval completed",
+		F("results", len(results)),
+		F("top_k", config.TopK),
+		F("min_score", config.MinScore))
+
+	return results, nil
+}
+
+// bm25Index returns the BM25 index of the chunked RAG documents, building it on first use
+func (b *Builder) bm25Index(ctx context.Context, config *RAGConfig) *BM25Index {
+	if b.ragIndex != nil {
+		return b.ragIndex
 	}
 
-	// Chunk all documents (TF-IDF fallback)
 	var allChunks []Document
 	for _, doc := range b.ragDocuments {
 		chunks := ChunkDocument(doc.Content, config.ChunkSize, config.ChunkOverlap)
 		for i, chunk := range chunks {
-			chunkDoc := Document{
-				Content:  chunk,
-				Metadata: doc.Metadata,
-			}
-			// Add chunk index to metadata
-			if chunkDoc.Metadata == nil {
-				chunkDoc.Metadata = make(map[string]string)
+			// Add chunk index to a copy of the document metadata
+			metadata := make(map[string]string, len(doc.Metadata)+1)
+			for k, v := range doc.Metadata {
+				metadata[k] = v
 			}
-			chunkDoc.Metadata["chunk"] = fmt.Sprintf("%d", i)
-			allChunks = append(allChunks, chunkDoc)
+			metadata["chunk"] = fmt.Sprintf("%d", i)
+			allChunks = append(allChunks, Document{Content: chunk, Metadata: metadata})
 		}
 	}
 
-	logger.Debug(ctx, "Documents chunked",
+	b.ragIndex = NewBM25Index(allChunks, config.BM25K1, config.BM25B)
+	b.getLogger().Debug(ctx, "BM25 index built",
+		F("total_docs", len(b.ragDocuments)),
 		F("total_chunks", len(allChunks)),
 		F("chunk_size", config.ChunkSize),
 		F("chunk_overlap", config.ChunkOverlap))
+	return b.ragIndex
+}
 
-	// Calculate relevance scores using simple similarity
-	for i := range allChunks {
-		allChunks[i].Score = calculateSimilarity(query, allChunks[i].Content)
+// retrieveKeyword ranks the RAG documents against query with BM25
+func (b *Builder) retrieveKeyword(ctx context.Context, query string, config *RAGConfig, topK int) []Document {
+	var results []Document
+	for _, doc := range b.bm25Index(ctx, config).Search(query, topK) {
+		if doc.Score >= config.MinScore {
+			results = append(results, doc)
+		}
 	}
+	return results
+}
 
-	// Sort by score (descending)
-	sort.Slice(allChunks, func(i, j int) bool {
-		return allChunks[i].Score > allChunks[j].Score
-	})
+// retrieveHybrid fuses BM25 and vector search results with reciprocal rank fusion
+func (b *Builder) retrieveHybrid(ctx context.Context, query string, config *RAGConfig) ([]Document, error) {
+	logger := b.getLogger()
 
-	// Filter by minimum score and take top K
-	var results []Document
-	for i := 0; i < len(allChunks) && i < config.TopK; i++ {
-		if allChunks[i].Score >= config.MinScore {
-			results = append(results, allChunks[i])
-		}
+	candidates := config.CandidateK
+	if candidates <= 0 {
+		candidates = 4 * config.TopK
+	}
+	keywordWeight, vectorWeight := config.KeywordWeight, config.VectorWeight
+	if keywordWeight == 0 && vectorWeight == 0 {
+		keywordWeight, vectorWeight = 1.0, 1.0
+	}
+	k := config.RRFConstant
+	if k <= 0 {
+		k = defaultRRFConstant
 	}
 
-	logger.Info(ctx, "RAG retrieval completed",
+	vector, err := b.searchVector(ctx, query, config, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var keyword []Document
+	if len(b.ragDocuments) > 0 {
+		keyword = b.retrieveKeyword(ctx, query, config, candidates)
+	}
+
+	results := fuseRankings(k, []float64{keywordWeight, vectorWeight}, keyword, vector)
+	if len(results) > config.TopK {
+		results = results[:config.TopK]
+	}
+
+	logger.Info(ctx, "Hybrid retrieval completed",
+		F("keyword_results", len(keyword)),
+		F("vector_results", len(vector)),
 		F("results", len(results)),
-		F("top_k", config.TopK),
-		F("min_score", config.MinScore))
+		F("top_k", config.TopK))
 
 	return results, nil
 }
@@ -318,56 +397,6 @@ func (b *Builder) buildRAGContext(docs []Document) string {
 	return strings.Join(parts, config.Separator)
 }
 
-// calculateSimilarity calculates similarity between query and document
-// Uses simple word overlap + TF-IDF-like scoring
-func calculateSimilarity(query, document string) float64 {
-	queryWords := tokenize(strings.ToLower(query))
-	docWords := tokenize(strings.ToLower(document))
-
-	if len(queryWords) == 0 || len(docWords) == 0 {
-		return 0.0
-	}
-
-	// Count word frequencies
-	queryFreq := make(map[string]int)
-	docFreq := make(map[string]int)
-
-	for _, word := range queryWords {
-		queryFreq[word]++
-	}
-
-	for _, word := range docWords {
-		docFreq[word]++
-	}
-
-	// Calculate overlap score
-	overlap := 0.0
-	for word, qFreq := range queryFreq {
-		if dFreq, exists := docFreq[word]; exists {
-			// Weight by inverse frequency (rare words matter more)
-			weight := 1.0 / math.Log(float64(dFreq+2))
-			overlap += float64(min(qFreq, dFreq)) * weight
-		}
-	}
-
-	// Normalize by query length
-	score := overlap / float64(len(queryWords))
-
-	// Bonus for exact phrase matches
-	queryLower := strings.ToLower(query)
-	docLower := strings.ToLower(document)
-	if strings.Contains(docLower, queryLower) {
-		score += 0.5
-	}
-
-	// Cap at 1.0
-	if score > 1.0 {
-		score = 1.0
-	}
-
-	return score
-}
-
 // tokenize splits text into words
 func tokenize(text string) []string {
 	// Remove punctuation and split on whitespace
@@ -394,15 +423,8 @@ func tokenize(text string) []string {
 	return filtered
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// GetLastRetrievedDocs returns the documents retrieved in the last RAG query
+// GetLastRetrievedDocs returns the documents retrieved in the last RAG query.
+// Document.Scores holds the score of each retrieval source.
 func (b *Builder) GetLastRetrievedDocs() []Document {
 	return b.lastRetrievedDocs
 }
@@ -411,6 +433,7 @@ func (b *Builder) GetLastRetrievedDocs() []Document {
 func (b *Builder) ClearRAG() *Builder {
 	b.ragEnabled = false
 	b.ragDocuments = nil
+	b.ragIndex = nil
 	b.ragRetriever = nil
 	b.ragConfig = nil
 	b.lastRetrievedDocs = nil
@@ -484,21 +507,33 @@ func (b *Builder) retrieveFromVector(ctx context.Context, query string) ([]Docum
 		config = DefaultRAGConfig()
 	}
 
+	return b.searchVector(ctx, query, config, config.TopK)
+}
+
+// searchVector returns the topK documents of the vector store most similar to query
+func (b *Builder) searchVector(ctx context.Context, query string, config *RAGConfig, topK int) ([]Document, error) {
+	logger := b.getLogger()
+
 	// Perform semantic search
 	searchReq := &TextSearchRequest{
 		Collection:      b.vectorCollection,
 		Query:           query,
-		TopK:            config.TopK,
+		TopK:            topK,
 		MinScore:        float32(config.MinScore),
 		IncludeContent:  true,
 		IncludeMetadata: true,
 	}
 
 	logger.Debug(ctx, "Executing vector search",
-		F("top_k", config.TopK),
+		F("top_k", topK),
 		F("min_score", config.MinScore))
 
-	results, err := b.vectorStore.SearchByText(ctx, searchReq)
+	searchCtx, searchSpan := b.startSpan(ctx, "search "+b.vectorCollection, trace.SpanKindClient,
+		attrDBOperationName.String("search"),
+		attrDBCollectionName.String(b.vectorCollection))
+	results, err := b.vectorStore.SearchByText(searchCtx, searchReq)
+	searchSpan.SetAttributes(attrDBReturnedRows.Int(len(results)))
+	endSpan(searchSpan, err)
 	if err != nil {
 		logger.Error(ctx, "Vector search failed", F("error", err.Error()))
 		return nil, fmt.Errorf("vector search failed: %w", err)
@@ -523,6 +558,7 @@ func (b *Builder) retrieveFromVector(ctx context.Context, query string) ([]Docum
 			Content:  result.Document.Content,
 			Metadata: metadata,
 			Score:    float64(result.Score),
+			Scores:   map[string]float64{ScoreSourceVector: float64(result.Score)},
 		}
 	}
 
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Retrieval sources reported in Document.Scores
+const (
+	ScoreSourceBM25   = "bm25"   // Okapi BM25 keyword score
+	ScoreSourceVector = "vector" // Vector store similarity
+	ScoreSourceRRF    = "rrf"    // Reciprocal rank fusion of the sources
+)
+
+const (
+	defaultBM25K1      = 1.2
+	defaultBM25B       = 0.75
+	defaultRRFConstant = 60
+)
+
+// BM25Index is an inverted index ranking documents with Okapi BM25
+type BM25Index struct {
+	docs     []Document
+	postings map[string][]bm25Posting // Documents containing each term
+	lengths  []int                    // Number of terms of each document
+	avgLen   float64
+	k1       float64
+	b        float64
+}
+
+// bm25Posting is the frequency of a term in a document
+type bm25Posting struct {
+	doc  int
+	freq int
+}
+
+// NewBM25Index indexes documents. k1 controls term frequency saturation
+// (default 1.2) and b document length normalization (default 0.75).
+func NewBM25Index(docs []Document, k1, b float64) *BM25Index {
+	if k1 <= 0 {
+		k1 = defaultBM25K1
+	}
+	if b <= 0 || b > 1 {
+		b = defaultBM25B
+	}
+
+	idx := &BM25Index{
+		docs:     docs,
+		postings: make(map[string][]bm25Posting),
+		lengths:  make([]int, len(docs)),
+		k1:       k1,
+		b:        b,
+	}
+
+	total := 0
+	for i, doc := range docs {
+		terms := tokenize(strings.ToLower(doc.Content))
+		idx.lengths[i] = len(terms)
+		total += len(terms)
+
+		freqs := make(map[string]int)
+		for _, term := range terms {
+			freqs[term]++
+		}
+		for term, freq := range freqs {
+			idx.postings[term] = append(idx.postings[term], bm25Posting{doc: i, freq: freq})
+		}
+	}
+	if len(docs) > 0 {
+		idx.avgLen = float64(total) / float64(len(docs))
+	}
+
+	return idx
+}
+
+// Len returns the number of indexed documents
+func (idx *BM25Index) Len() int {
+	return len(idx.docs)
+}
+
+// Search returns the topK documents matching query, best first. Score is the
+// BM25 score normalized by the best match (0-1); Scores holds the raw score.
+func (idx *BM25Index) Search(query string, topK int) []Document {
+	scores := make(map[int]float64)
+	n := float64(len(idx.docs))
+	for _, term := range uniqueTerms(tokenize(strings.ToLower(query))) {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range postings {
+			freq := float64(p.freq)
+			norm := 1 - idx.b + idx.b*float64(idx.lengths[p.doc])/idx.avgLen
+			scores[p.doc] += idf * freq * (idx.k1 + 1) / (freq + idx.k1*norm)
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for doc := range scores {
+		ranked = append(ranked, doc)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if topK > 0 && len(ranked) > topK {
+		ranked = ranked[:topK]
+	}
+
+	results := make([]Document, len(ranked))
+	for i, doc := range ranked {
+		results[i] = idx.docs[doc]
+		results[i].Score = scores[doc] / scores[ranked[0]]
+		results[i].Scores = map[string]float64{ScoreSourceBM25: scores[doc]}
+	}
+	return results
+}
+
+// uniqueTerms removes duplicate terms, keeping their order
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+// fuseRankings merges rankings with weighted reciprocal rank fusion: each
+// document scores the sum of weight/(k+rank) over the rankings containing
+// it. Documents are matched by content; their per-source scores are merged.
+func fuseRankings(k float64, weights []float64, rankings ...[]Document) []Document {
+	var fused []Document
+	positions := make(map[string]int)
+	for r, ranking := range rankings {
+		for rank, doc := range ranking {
+			pos, seen := positions[doc.Content]
+			if !seen {
+				pos = len(fused)
+				positions[doc.Content] = pos
+				doc.Scores = copyScores(doc.Scores)
+				doc.Score = 0
+				fused = append(fused, doc)
+			} else {
+				for source, score := range doc.Scores {
+					fused[pos].Scores[source] = score
+				}
+			}
+			fused[pos].Score += weights[r] / (k + float64(rank+1))
+		}
+	}
+
+	for i := range fused {
+		fused[i].Scores[ScoreSourceRRF] = fused[i].Score
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	return fused
+}
+
+// copyScores returns a copy of scores, never nil
+func copyScores(scores map[string]float64) map[string]float64 {
+	copied := make(map[string]float64, len(scores)+1)
+	for source, score := range scores {
+		copied[source] = score
+	}
+	return copied
+}
@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"testing"
+)
+
+// rankedVectorStore answers text searches with its documents in order.
+// Unused VectorStore methods panic via the embedded nil interface.
+type rankedVectorStore struct {
+	VectorStore
+	docs []string
+	topK int
+}
+
+func (s *rankedVectorStore) SearchByText(ctx context.Context, req *TextSearchRequest) ([]*SearchResult, error) {
+	s.topK = req.TopK
+	var results []*SearchResult
+	for i, content := range s.docs {
+		if i == req.TopK {
+			break
+		}
+		results = append(results, &SearchResult{
+			Document: &VectorDocument{Content: content},
+			Score:    0.9 - 0.1*float32(i),
+			Rank:     i + 1,
+		})
+	}
+	return results, nil
+}
+
+func TestBM25Index_Search(t *testing.T) {
+	idx := NewBM25Index([]Document{
+		{Content: "Kubernetes schedules containers across nodes"},
+		{Content: "Docker builds container images from a Dockerfile"},
+		{Content: "Kubernetes evicts pods when nodes run out of memory, and Kubernetes restarts them"},
+		{Content: "Memory leaks slowly exhaust memory on long running servers"},
+	}, 0, 0)
+
+	if idx.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", idx.Len())
+	}
+
+	results := idx.Search("kubernetes memory eviction", 3)
+	if len(results) != 3 {
+		t.Fatalf("Search returned %d documents, want 3: %v", len(results), results)
+	}
+	// The only document with both terms ranks first
+	if results[0].Content != idx.docs[2].Content {
+		t.Errorf("top result = %q", results[0].Content)
+	}
+	if results[0].Score != 1.0 || results[1].Score >= 1.0 || results[1].Score <= 0 {
+		t.Errorf("scores = %v, %v, want normalized by the best match", results[0].Score, results[1].Score)
+	}
+	for _, doc := range results {
+		if raw := doc.Scores[ScoreSourceBM25]; raw <= 0 || raw < doc.Score*results[0].Scores[ScoreSourceBM25]-1e-9 {
+			t.Errorf("raw BM25 score of %q = %v", doc.Content, raw)
+		}
+	}
+
+	if results := idx.Search("unrelated query words", 3); len(results) != 0 {
+		t.Errorf("Search without matching terms = %v, want none", results)
+	}
+}
+
+func TestBuilder_RAGKeywordRetrieval(t *testing.T) {
+	source := Document{Content: "Rust provides memory safety without garbage collection.", Metadata: map[string]string{"source": "rust.md"}}
+	ai := NewOpenAI("gpt-4o-mini", "test-key").
+		WithRAGDocuments(source, Document{Content: "Python is popular for data science."}).
+		WithRAGTopK(1)
+
+	docs, err := ai.retrieveRelevantDocs(context.Background(), "memory safety")
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("retrieveRelevantDocs = %v, %v", docs, err)
+	}
+	if docs[0].Metadata["source"] != "rust.md" || docs[0].Metadata["chunk"] != "0" || docs[0].Scores[ScoreSourceBM25] <= 0 {
+		t.Errorf("retrieved document = %+v", docs[0])
+	}
+	if _, ok := source.Metadata["chunk"]; ok {
+		t.Error("chunking should not modify the metadata of the source document")
+	}
+}
+
+func TestBuilder_RAGHybridRetrieval(t *testing.T) {
+	docs := []string{
+		"Error E1234 means the disk quota is exceeded.",
+		"Storage limits can be raised in the admin console.",
+		"Quotas reset at the start of each billing month.",
+	}
+	// The vector store ranks the paraphrases first, missing the exact error code
+	store := &rankedVectorStore{docs: []string{docs[1], docs[2], docs[0]}}
+
+	newBuilder := func(config *RAGConfig) *Builder {
+		return NewOpenAI("gpt-4o-mini", "test-key").
+			WithRAG(docs...).
+			WithVectorRAG(NewMockEmbeddingProvider("mock", 3), store, "kb").
+			WithRAGConfig(config)
+	}
+
+	config := DefaultRAGConfig()
+	config.Hybrid = true
+	config.TopK = 2
+	results, err := newBuilder(config).retrieveRelevantDocs(context.Background(), "what does E1234 mean")
+	if err != nil || len(results) != 2 {
+		t.Fatalf("retrieveRelevantDocs = %v, %v", results, err)
+	}
+	if store.topK != 8 {
+		t.Errorf("vector candidates = %d, want 4 × TopK", store.topK)
+	}
+
+	// Found by both sources, the exact match ranks first
+	top := results[0]
+	if top.Content != docs[0] {
+		t.Errorf("top result = %q, want the exact match", top.Content)
+	}
+	if top.Scores[ScoreSourceBM25] <= 0 || top.Scores[ScoreSourceVector] != float64(float32(0.7)) || top.Scores[ScoreSourceRRF] != top.Score {
+		t.Errorf("scores = %v", top.Scores)
+	}
+	if want := 1/61.0 + 1/63.0; top.Score < want-1e-9 || top.Score > want+1e-9 {
+		t.Errorf("fused score = %v, want %v", top.Score, want)
+	}
+
+	// Without weight, the keyword ranking no longer lifts the exact match
+	config.KeywordWeight, config.VectorWeight = 0, 1.0
+	results, _ = newBuilder(config).retrieveRelevantDocs(context.Background(), "what does E1234 mean")
+	if len(results) == 0 || results[0].Content != docs[1] {
+		t.Errorf("vector-weighted results = %v", results)
+	}
+}
+
+func TestBuilder_RAGHybridRetrieval_GetLastRetrievedDocs(t *testing.T) {
+	var calls int
+	server := newChatServer(t, "Your disk quota is exceeded.", &calls)
+
+	config := DefaultRAGConfig()
+	config.Hybrid = true
+	ai := New(ProviderOllama, "llama3").
+		WithBaseURL(server.URL+"/v1/").
+		WithRAG("Error E1234 means the disk quota is exceeded.").
+		WithVectorRAG(NewMockEmbeddingProvider("mock", 3), &rankedVectorStore{docs: []string{"Storage limits can be raised."}}, "kb").
+		WithRAGConfig(config)
+
+	if _, err := ai.Ask(context.Background(), "what does E1234 mean"); err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	retrieved := ai.GetLastRetrievedDocs()
+	if len(retrieved) != 2 {
+		t.Fatalf("GetLastRetrievedDocs() = %v, want both sources", retrieved)
+	}
+	for _, doc := range retrieved {
+		if _, ok := doc.Scores[ScoreSourceRRF]; !ok {
+			t.Errorf("document %q has no fused score: %v", doc.Content, doc.Scores)
+		}
+	}
+	if _, ok := retrieved[0].Scores[ScoreSourceBM25]; !ok {
+		t.Errorf("keyword result scores = %v", retrieved[0].Scores)
+	}
+	if _, ok := retrieved[1].Scores[ScoreSourceVector]; !ok {
+		t.Errorf("vector result scores = %v", retrieved[1].Scores)
+	}
+}
+
+func BenchmarkBM25Search(b *testing.B) {
+	docs := make([]Document, 1000)
+	for i := range docs {
+		docs[i] = Document{Content: "Go is a statically typed, compiled programming language designed at Google"}
+	}
+	idx := NewBM25Index(docs, 0, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = idx.Search("Go programming language features", 3)
+	}
+}
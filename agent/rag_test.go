@@ -155,50 +155,6 @@ func TestDefaultRAGConfig(t *testing.T) {
 	}
 }
 
-func TestCalculateSimilarity(t *testing.T) {
-	tests := []struct {
-		name     string
-		query    string
-		document string
-		minScore float64
-	}{
-		{
-			name:     "Exact match",
-			query:    "golang programming",
-			document: "This is about golang programming language",
-			minScore: 0.3,
-		},
-		{
-			name:     "Partial match",
-			query:    "python tutorial",
-			document: "Learn python with this comprehensive guide",
-			minScore: 0.2,
-		},
-		{
-			name:     "No match",
-			query:    "javascript",
-			document: "This is about golang programming",
-			minScore: 0.0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			score := calculateSimilarity(tt.query, tt.document)
-
-			if score < 0.0 || score > 1.0 {
-				t.Errorf("Score should be between 0 and 1, got %f", score)
-			}
-
-			if score < tt.minScore {
-				t.Errorf("Expected score >= %f, got %f", tt.minScore, score)
-			}
-
-			t.Logf("Score for '%s': %f", tt.name, score)
-		})
-	}
-}
-
 func TestTokenize(t *testing.T) {
 	text := "This is a test! How are you?"
 	tokens := tokenize(text)
@@ -526,16 +482,6 @@ func BenchmarkChunkDocument(b *testing.B) {
 	}
 }
 
-func BenchmarkCalculateSimilarity(b *testing.B) {
-	query := "Go programming language features"
-	document := "Go is a statically typed, compiled programming language designed at Google"
-
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = calculateSimilarity(query, document)
-	}
-}
-
 func BenchmarkTokenize(b *testing.B) {
 	text := "This is a longer text with many words that need to be tokenized efficiently"
 
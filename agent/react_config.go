@@ -242,23 +242,10 @@ func (c *ReActConfig) Clone() *ReActConfig {
 		return NewReActConfig()
 	}
 
-	// Deep copy Examples slice
-	var examples []ReActExample
+	clone := *c
 	if c.Examples != nil {
-		examples = make([]ReActExample, len(c.Examples))
-		copy(examples, c.Examples)
-	}
-
-	return &ReActConfig{
-		Enabled:        c.Enabled,
-		Mode:           c.Mode,
-		MaxIterations:  c.MaxIterations,
-		Timeout:        c.Timeout,
-		Strict:         c.Strict,
-		SystemPrompt:   c.SystemPrompt,
-		Callback:       c.Callback,
-		EnableMetrics:  c.EnableMetrics,
-		EnableTimeline: c.EnableTimeline,
-		Examples:       examples,
+		clone.Examples = make([]ReActExample, len(c.Examples))
+		copy(clone.Examples, c.Examples)
 	}
+	return &clone
 }
@@ -1,7 +1,9 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/openai/openai-go/v3"
 )
@@ -13,6 +15,11 @@ type Tool struct {
 	Description string                            // What the function does
 	Parameters  map[string]interface{}            // JSON schema for parameters
 	Handler     func(args string) (string, error) // Function implementation
+
+	// ContextHandler is a context-aware implementation. When set, it is used
+	// instead of Handler and receives the request context, so cancellation and
+	// WithToolTimeout can stop the work.
+	ContextHandler func(ctx context.Context, args string) (string, error)
 }
 
 // NewTool creates a new tool with the given name and description.
@@ -69,9 +76,45 @@ func (t *Tool) AddParameter(name, paramType, description string, required bool)
 //	})
 func (t *Tool) WithHandler(handler func(string) (string, error)) *Tool {
 	t.Handler = handler
+	t.ContextHandler = nil
 	return t
 }
 
+// WithContextHandler sets a context-aware handler for this tool.
+// The context is cancelled when the request is cancelled or the tool timeout expires.
+// Handler is also set (with a background context) for callers that invoke it directly.
+//
+// Example:
+//
+//	tool.WithContextHandler(func(ctx context.Context, args string) (string, error) {
+//	    req, _ := http.NewRequestWithContext(ctx, "GET", "https://api.example.com", nil)
+//	    resp, err := http.DefaultClient.Do(req)
+//	    ...
+//	})
+func (t *Tool) WithContextHandler(handler func(ctx context.Context, args string) (string, error)) *Tool {
+	t.ContextHandler = handler
+	t.Handler = func(args string) (string, error) {
+		return handler(context.Background(), args)
+	}
+	return t
+}
+
+// hasHandler reports whether the tool can be executed.
+func (t *Tool) hasHandler() bool {
+	return t.ContextHandler != nil || t.Handler != nil
+}
+
+// call executes the tool, preferring ContextHandler over Handler.
+func (t *Tool) call(ctx context.Context, args string) (string, error) {
+	if t.ContextHandler != nil {
+		return t.ContextHandler(ctx, args)
+	}
+	if t.Handler != nil {
+		return t.Handler(args)
+	}
+	return "", fmt.Errorf("tool %s has no handler", t.Name)
+}
+
 // toOpenAI converts our Tool to OpenAI's ChatCompletionToolUnionParam format.
 func (t *Tool) toOpenAI() openai.ChatCompletionToolUnionParam {
 	// Create function parameters from our schema
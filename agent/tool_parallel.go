@@ -160,15 +160,15 @@ func (b *Builder) executeOneTool(ctx context.Context, toolCall openai.ChatComple
 	toolName := toolCall.Function.Name
 
 	// Find handler
-	var handler func(string) (string, error)
+	var targetTool *Tool
 	for _, tool := range b.tools {
-		if tool.Name == toolName {
-			handler = tool.Handler
+		if tool.Name == toolName && tool.hasHandler() {
+			targetTool = tool
 			break
 		}
 	}
 
-	if handler == nil {
+	if targetTool == nil {
 		return "", fmt.Errorf("no handler found for tool: %s\n\n"+
 			"Fix:\n"+
 			"  1. Register tool: .WithTool(agent.Tool{Name: \"%s\", ...})\n"+
@@ -185,7 +185,8 @@ func (b *Builder) executeOneTool(ctx context.Context, toolCall openai.ChatComple
 	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Execute in goroutine to support timeout
+	// Execute in goroutine to support timeout. Context handlers also receive
+	// execCtx so they can stop their work; plain handlers are abandoned.
 	done := make(chan struct{})
 	var result string
 	var err error
@@ -198,7 +199,7 @@ func (b *Builder) executeOneTool(ctx context.Context, toolCall openai.ChatComple
 			F("tool_name", toolName),
 			F("args_length", len(toolCall.Function.Arguments)))
 
-		result, err = handler(toolCall.Function.Arguments)
+		result, err = b.callTool(execCtx, targetTool, toolCall.Function.Arguments)
 	}()
 
 	// Wait for completion or timeout
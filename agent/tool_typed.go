@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// NewTypedTool creates a tool whose parameter schema is derived from the struct
+// type T. The JSON arguments are unmarshaled into T before the handler is called,
+// and the handler receives the request context (see WithContextHandler).
+//
+// Schema derivation uses struct tags:
+//   - json:"name,omitempty" sets the parameter name; "-" skips the field.
+//     Fields are required unless tagged omitempty or declared as pointers.
+//   - description:"..." sets the parameter description
+//   - enum:"a,b,c" restricts a string parameter to the listed values
+//   - required:"true" / required:"false" overrides the default
+//
+// Supported field types: strings, bools, integers, floats, time.Time,
+// slices/arrays, maps with string keys and nested structs.
+//
+// Example:
+//
+//	type WeatherArgs struct {
+//	    City  string `json:"city" description:"City name"`
+//	    Units string `json:"units,omitempty" description:"Temperature units" enum:"celsius,fahrenheit"`
+//	}
+//
+//	tool := agent.NewTypedTool("get_weather", "Get weather for a city",
+//	    func(ctx context.Context, args WeatherArgs) (string, error) {
+//	        return fmt.Sprintf("Weather in %s: Sunny", args.City), nil
+//	    })
+func NewTypedTool[T any](name, description string, handler func(ctx context.Context, args T) (string, error)) *Tool {
+	tool := NewTool(name, description)
+	tool.Parameters = schemaForType(reflect.TypeOf((*T)(nil)).Elem())
+
+	return tool.WithContextHandler(func(ctx context.Context, args string) (string, error) {
+		var params T
+		if strings.TrimSpace(args) != "" {
+			if err := json.Unmarshal([]byte(args), &params); err != nil {
+				return "", fmt.Errorf("invalid arguments for tool %s: %w", name, err)
+			}
+		}
+		return handler(ctx, params)
+	})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType builds the JSON schema of the top-level tool parameters.
+// The result always has the "object" shape expected by NewTool.
+func schemaForType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == timeType {
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+			"required":   []string{},
+		}
+	}
+	return structSchema(t, map[reflect.Type]bool{})
+}
+
+// structSchema builds an object schema from the exported fields of a struct.
+// Embedded structs without a json name are flattened, like encoding/json does.
+// visiting holds the structs being built by the callers: a struct nested in
+// itself, such as a tree node, is described as a plain object at the repeat.
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	if visiting[t] {
+		return map[string]interface{}{"type": "object"}
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+
+			jsonTag := field.Tag.Get("json")
+			if jsonTag == "-" {
+				continue
+			}
+			tagName, tagOpts, _ := strings.Cut(jsonTag, ",")
+
+			fieldType := field.Type
+			if field.Anonymous && tagName == "" {
+				for fieldType.Kind() == reflect.Ptr {
+					fieldType = fieldType.Elem()
+				}
+				if fieldType.Kind() == reflect.Struct {
+					if !visiting[fieldType] {
+						visiting[fieldType] = true
+						addFields(fieldType)
+						delete(visiting, fieldType)
+					}
+					continue
+				}
+				if !field.IsExported() {
+					continue
+				}
+			}
+
+			name := field.Name
+			if tagName != "" {
+				name = tagName
+			}
+
+			prop := typeSchema(field.Type, visiting)
+			if desc := field.Tag.Get("description"); desc != "" {
+				prop["description"] = desc
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				values := strings.Split(enum, ",")
+				for i := range values {
+					values[i] = strings.TrimSpace(values[i])
+				}
+				prop["enum"] = values
+			}
+			properties[name] = prop
+
+			isRequired := !strings.Contains(tagOpts, "omitempty") && field.Type.Kind() != reflect.Ptr
+			switch field.Tag.Get("required") {
+			case "true":
+				isRequired = true
+			case "false":
+				isRequired = false
+			}
+			if isRequired {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// typeSchema maps a Go type to a JSON schema fragment.
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as a base64 string, but [N]byte as an array
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	default:
+		// interface{} and other dynamic types accept any JSON value
+		return map[string]interface{}{}
+	}
+}
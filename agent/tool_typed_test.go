@@ -0,0 +1,279 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+type weatherArgs struct {
+	City     string            `json:"city" description:"City name"`
+	Units    string            `json:"units,omitempty" description:"Temperature units" enum:"celsius, fahrenheit"`
+	Days     int               `json:"days" required:"false"`
+	Detailed *bool             `json:"detailed"`
+	Tags     []string          `json:"tags,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+	Location struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"location" required:"false"`
+	Since   time.Time `json:"since,omitempty"`
+	Ignored string    `json:"-"`
+	secret  string
+	typedToolEmbedded
+}
+
+type typedToolEmbedded struct {
+	RequestID string `json:"request_id,omitempty" description:"Correlation ID"`
+}
+
+// TestNewTypedTool_Schema tests schema derivation from struct tags
+func TestNewTypedTool_Schema(t *testing.T) {
+	tool := NewTypedTool("get_weather", "Get weather", func(ctx context.Context, args weatherArgs) (string, error) {
+		return "", nil
+	})
+
+	if tool.Parameters["type"] != "object" {
+		t.Errorf("Expected object schema, got %v", tool.Parameters["type"])
+	}
+	props := tool.Parameters["properties"].(map[string]interface{})
+
+	wantTypes := map[string]string{
+		"city":       "string",
+		"units":      "string",
+		"days":       "integer",
+		"detailed":   "boolean",
+		"tags":       "array",
+		"extra":      "object",
+		"location":   "object",
+		"since":      "string",
+		"request_id": "string",
+	}
+	if len(props) != len(wantTypes) {
+		t.Errorf("Expected %d properties, got %d: %v", len(wantTypes), len(props), props)
+	}
+	for name, wantType := range wantTypes {
+		prop, ok := props[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("Missing property %s", name)
+			continue
+		}
+		if prop["type"] != wantType {
+			t.Errorf("Property %s: expected type %s, got %v", name, wantType, prop["type"])
+		}
+	}
+
+	city := props["city"].(map[string]interface{})
+	if city["description"] != "City name" {
+		t.Errorf("Expected description from tag, got %v", city["description"])
+	}
+	units := props["units"].(map[string]interface{})
+	if !reflect.DeepEqual(units["enum"], []string{"celsius", "fahrenheit"}) {
+		t.Errorf("Expected enum values, got %v", units["enum"])
+	}
+	tags := props["tags"].(map[string]interface{})
+	if tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Expected string items, got %v", tags["items"])
+	}
+	location := props["location"].(map[string]interface{})
+	if !reflect.DeepEqual(location["required"], []string{"lat", "lon"}) {
+		t.Errorf("Expected nested required fields, got %v", location["required"])
+	}
+
+	// Required unless omitempty, pointer or required:"false"
+	required := tool.Parameters["required"].([]string)
+	if !reflect.DeepEqual(required, []string{"city"}) {
+		t.Errorf("Expected required=[city], got %v", required)
+	}
+
+	// The schema must be accepted by the OpenAI conversion
+	_ = tool.toOpenAI()
+}
+
+type treeNode struct {
+	Name     string     `json:"name"`
+	Children []treeNode `json:"children,omitempty"`
+	Parent   *treeNode  `json:"parent,omitempty"`
+	*treeNode
+}
+
+// TestNewTypedTool_RecursiveSchema tests that self-referential types terminate
+func TestNewTypedTool_RecursiveSchema(t *testing.T) {
+	type args struct {
+		Root  treeNode `json:"root"`
+		Other treeNode `json:"other"`
+	}
+	tool := NewTypedTool("walk_tree", "Walk a tree", func(ctx context.Context, args args) (string, error) {
+		return "", nil
+	})
+
+	props := tool.Parameters["properties"].(map[string]interface{})
+	root := props["root"].(map[string]interface{})
+	rootProps := root["properties"].(map[string]interface{})
+	children := rootProps["children"].(map[string]interface{})
+	if !reflect.DeepEqual(children["items"], map[string]interface{}{"type": "object"}) {
+		t.Errorf("Expected repeated type as plain object, got %v", children["items"])
+	}
+	if rootProps["parent"].(map[string]interface{})["type"] != "object" {
+		t.Errorf("Expected parent object, got %v", rootProps["parent"])
+	}
+
+	// A sibling of the same type is described in full
+	if _, ok := props["other"].(map[string]interface{})["properties"]; !ok {
+		t.Errorf("Expected full schema for sibling, got %v", props["other"])
+	}
+}
+
+// TestNewTypedTool_ByteSchema tests that bytes are described as encoding/json encodes them
+func TestNewTypedTool_ByteSchema(t *testing.T) {
+	type args struct {
+		Data   []byte  `json:"data"`
+		Digest [4]byte `json:"digest"`
+	}
+	tool := NewTypedTool("upload", "Upload data", func(ctx context.Context, args args) (string, error) {
+		return "", nil
+	})
+
+	props := tool.Parameters["properties"].(map[string]interface{})
+	if !reflect.DeepEqual(props["data"], map[string]interface{}{"type": "string"}) {
+		t.Errorf("Expected []byte as base64 string, got %v", props["data"])
+	}
+	want := map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}}
+	if !reflect.DeepEqual(props["digest"], want) {
+		t.Errorf("Expected [4]byte as array of integers, got %v", props["digest"])
+	}
+}
+
+// TestNewTypedTool_Handler tests argument unmarshaling and context propagation
+func TestNewTypedTool_Handler(t *testing.T) {
+	type ctxKey struct{}
+	var got weatherArgs
+	var gotValue interface{}
+
+	tool := NewTypedTool("get_weather", "Get weather", func(ctx context.Context, args weatherArgs) (string, error) {
+		got = args
+		gotValue = ctx.Value(ctxKey{})
+		return "Sunny in " + args.City, nil
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request-1")
+	result, err := tool.call(ctx, `{"city": "Paris", "tags": ["a", "b"], "request_id": "r1"}`)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if result != "Sunny in Paris" {
+		t.Errorf("Unexpected result: %s", result)
+	}
+	if got.City != "Paris" || len(got.Tags) != 2 || got.RequestID != "r1" {
+		t.Errorf("Arguments not unmarshaled: %+v", got)
+	}
+	if gotValue != "request-1" {
+		t.Errorf("Expected request context, got value %v", gotValue)
+	}
+
+	// The plain Handler still works for direct callers
+	if result, err := tool.Handler(`{"city": "Rome"}`); err != nil || result != "Sunny in Rome" {
+		t.Errorf("Handler: got %q, %v", result, err)
+	}
+
+	if _, err := tool.call(ctx, `{"city": 42}`); err == nil || !strings.Contains(err.Error(), "invalid arguments") {
+		t.Errorf("Expected invalid arguments error, got %v", err)
+	}
+}
+
+// TestWithContextHandler tests handler precedence
+func TestWithContextHandler(t *testing.T) {
+	tool := NewTool("test", "Test").WithContextHandler(func(ctx context.Context, args string) (string, error) {
+		return "context", nil
+	})
+	if result, _ := tool.call(context.Background(), "{}"); result != "context" {
+		t.Errorf("Expected context handler, got %s", result)
+	}
+
+	// WithHandler replaces the context handler
+	tool.WithHandler(func(args string) (string, error) { return "plain", nil })
+	if tool.ContextHandler != nil {
+		t.Error("Expected WithHandler to clear ContextHandler")
+	}
+	if result, _ := tool.call(context.Background(), "{}"); result != "plain" {
+		t.Errorf("Expected plain handler, got %s", result)
+	}
+
+	if _, err := NewTool("empty", "No handler").call(context.Background(), "{}"); err == nil {
+		t.Error("Expected error for tool without handler")
+	}
+}
+
+// blockingTool returns a tool that blocks until its context is cancelled
+// and reports the cancellation on the returned channel
+func blockingTool() (*Tool, chan error) {
+	cancelled := make(chan error, 1)
+	tool := NewTool("blocking", "Blocks until cancelled").
+		WithContextHandler(func(ctx context.Context, args string) (string, error) {
+			select {
+			case <-ctx.Done():
+				cancelled <- ctx.Err()
+				return "", ctx.Err()
+			case <-time.After(5 * time.Second):
+				return "finished", nil
+			}
+		})
+	return tool, cancelled
+}
+
+func expectCancelled(t *testing.T, cancelled chan error) {
+	t.Helper()
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Handler context was not cancelled")
+	}
+}
+
+// TestContextHandler_ToolTimeout tests that WithToolTimeout cancels context
+// handlers in the OpenAI, adapter and ReAct paths
+func TestContextHandler_ToolTimeout(t *testing.T) {
+	t.Run("OpenAI", func(t *testing.T) {
+		tool, cancelled := blockingTool()
+		b := &Builder{tools: []*Tool{tool}, toolTimeout: 20 * time.Millisecond}
+
+		_, err := b.executeOneTool(context.Background(), openai.ChatCompletionMessageToolCallUnion{
+			ID:       "call1",
+			Function: openai.ChatCompletionMessageFunctionToolCallFunction{Name: "blocking", Arguments: "{}"},
+		})
+		if err == nil {
+			t.Fatal("Expected timeout error")
+		}
+		expectCancelled(t, cancelled)
+	})
+
+	t.Run("Adapter", func(t *testing.T) {
+		tool, cancelled := blockingTool()
+		b := &Builder{tools: []*Tool{tool}, toolTimeout: 20 * time.Millisecond}
+
+		_, err := b.executeSingleAdapterTool(context.Background(), ToolCall{ID: "call1", Name: "blocking", Arguments: "{}"})
+		if err == nil {
+			t.Fatal("Expected timeout error")
+		}
+		expectCancelled(t, cancelled)
+	})
+
+	t.Run("ReAct", func(t *testing.T) {
+		tool, cancelled := blockingTool()
+		b := &Builder{tools: []*Tool{tool}, toolTimeout: 20 * time.Millisecond}
+
+		_, err := b.executeTool(context.Background(), "blocking", map[string]interface{}{})
+		if err == nil {
+			t.Fatal("Expected timeout error")
+		}
+		expectCancelled(t, cancelled)
+	})
+}
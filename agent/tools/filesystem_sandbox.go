@@ -0,0 +1,496 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/taipm/go-deep-agent/agent"
+)
+
+// FileSystemConfig configures a sandboxed filesystem tool.
+//
+// All paths are resolved inside RootDir. Relative paths are relative to RootDir,
+// absolute paths must point inside it, and symlinks are resolved before checking,
+// so a link pointing outside the root is refused.
+//
+// Glob patterns are matched against the slash-separated path relative to RootDir:
+//   - Patterns without "/" match any path element ("*.go", ".env")
+//   - Patterns with "/" match the path or one of its parents ("docs/*.md")
+//   - A trailing "/**" matches a directory and everything below it ("secrets/**")
+//
+// Deletes are disabled unless AllowDelete is set. There is no size limit
+// unless MaxFileSize is set.
+type FileSystemConfig struct {
+	// RootDir is the directory the tool is jailed to (required, must exist)
+	RootDir string
+
+	// ReadOnly disables write_file, append_file, delete_file and create_directory
+	ReadOnly bool
+
+	// AllowDelete enables delete_file (ignored when ReadOnly is set)
+	AllowDelete bool
+
+	// AllowPatterns restricts file access to paths matching at least one glob.
+	// Empty means all files are allowed. Directories are not filtered.
+	AllowPatterns []string
+
+	// DenyPatterns refuses access to files and directories matching any glob.
+	// Deny always wins over allow.
+	DenyPatterns []string
+
+	// MaxFileSize is the largest file in bytes that can be read or produced
+	// by write/append. 0 means unlimited.
+	MaxFileSize int64
+}
+
+// fileSystemSandbox holds the resolved configuration of a sandboxed tool
+type fileSystemSandbox struct {
+	root   string
+	config FileSystemConfig
+}
+
+// NewFileSystemToolWithConfig creates a filesystem tool jailed to config.RootDir.
+// Use it instead of NewFileSystemTool whenever an agent gets filesystem access
+// in production.
+//
+// Returns an error if RootDir is empty, does not exist, is not a directory,
+// or a glob pattern is malformed.
+//
+// Example:
+//
+//	fsTool, err := tools.NewFileSystemToolWithConfig(tools.FileSystemConfig{
+//	    RootDir:      "./workspace",
+//	    DenyPatterns: []string{".env", "secrets/**"},
+//	    MaxFileSize:  1 << 20, // 1 MB
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	agent.NewOpenAI("gpt-4o", apiKey).
+//	    WithTool(fsTool).
+//	    WithAutoExecute(true)
+func NewFileSystemToolWithConfig(config FileSystemConfig) (*agent.Tool, error) {
+	if config.RootDir == "" {
+		return nil, fmt.Errorf("%w: root directory is required", ErrInvalidInput)
+	}
+
+	root, err := filepath.Abs(config.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root directory: %w", err)
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root directory: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: root is not a directory: %s", ErrInvalidInput, root)
+	}
+
+	for _, pattern := range append(append([]string{}, config.AllowPatterns...), config.DenyPatterns...) {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+
+	sandbox := &fileSystemSandbox{root: root, config: config}
+
+	return agent.NewTool("filesystem", sandbox.description()).
+		AddParameter("operation", "string", "Operation: "+strings.Join(sandbox.operations(), ", "), true).
+		AddParameter("path", "string", "File or directory path, relative to the workspace root", true).
+		AddParameter("content", "string", "Content to write/append (only for write_file, append_file)", false).
+		WithHandler(sandbox.handle), nil
+}
+
+// description describes the tool and its restrictions for the model
+func (s *fileSystemSandbox) description() string {
+	desc := "File system operations inside a sandboxed workspace directory"
+	if s.config.ReadOnly {
+		desc += " (read-only)"
+	}
+	if s.config.MaxFileSize > 0 {
+		desc += fmt.Sprintf(". Files are limited to %d bytes", s.config.MaxFileSize)
+	}
+	return desc
+}
+
+// operations lists the operations permitted by the configuration
+func (s *fileSystemSandbox) operations() []string {
+	ops := []string{"read_file", "list_directory", "file_exists"}
+	if s.config.ReadOnly {
+		return ops
+	}
+	ops = append(ops, "write_file", "append_file", "create_directory")
+	if s.config.AllowDelete {
+		ops = append(ops, "delete_file")
+	}
+	return ops
+}
+
+// handle executes a file system operation inside the sandbox
+func (s *fileSystemSandbox) handle(args string) (string, error) {
+	var params struct {
+		Operation string `json:"operation"`
+		Path      string `json:"path"`
+		Content   string `json:"content"`
+	}
+
+	if err := json.Unmarshal([]byte(args), &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := s.checkOperation(params.Operation); err != nil {
+		return "", err
+	}
+
+	fullPath, rel, err := s.resolve(params.Path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+
+	isDir := params.Operation == "list_directory" || params.Operation == "create_directory"
+	if info, err := os.Stat(fullPath); err == nil {
+		isDir = info.IsDir()
+	}
+	if err := s.checkPattern(rel, isDir); err != nil {
+		return "", err
+	}
+
+	result, err := s.run(params.Operation, fullPath, rel, params.Content)
+	return s.hideRoot(result), s.hideRootError(err)
+}
+
+// run performs an operation on a path that has passed the sandbox checks
+func (s *fileSystemSandbox) run(operation, fullPath, rel, content string) (string, error) {
+	switch operation {
+	case "read_file":
+		if err := s.checkSize(fullPath, 0, true); err != nil {
+			return "", err
+		}
+		return readFile(fullPath)
+	case "write_file":
+		if err := s.checkSize(fullPath, int64(len(content)), false); err != nil {
+			return "", err
+		}
+		return writeFile(fullPath, content)
+	case "append_file":
+		if err := s.checkSize(fullPath, int64(len(content)), true); err != nil {
+			return "", err
+		}
+		return appendFile(fullPath, content)
+	case "delete_file":
+		if fullPath == s.root {
+			return "", fmt.Errorf("%w: cannot delete the root directory", ErrPermissionDenied)
+		}
+		return deleteFile(fullPath)
+	case "list_directory":
+		return s.listDirectory(fullPath, rel)
+	case "file_exists":
+		return fileExists(fullPath)
+	case "create_directory":
+		return createDirectory(fullPath)
+	default:
+		return "", fmt.Errorf("unknown operation: %s", operation)
+	}
+}
+
+// hideRoot rewrites host paths under the root as paths relative to it, so
+// results don't disclose where the sandbox lives on the host
+func (s *fileSystemSandbox) hideRoot(text string) string {
+	text = strings.ReplaceAll(text, s.root+string(filepath.Separator), "")
+	return strings.ReplaceAll(text, s.root, ".")
+}
+
+// hideRootError applies hideRoot to an error message, keeping the original
+// error available to errors.Is and errors.As
+func (s *fileSystemSandbox) hideRootError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := s.hideRoot(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &sandboxError{msg: msg, err: err}
+}
+
+// sandboxError is an error whose message has had host paths removed
+type sandboxError struct {
+	msg string
+	err error
+}
+
+func (e *sandboxError) Error() string { return e.msg }
+
+func (e *sandboxError) Unwrap() error { return e.err }
+
+// checkOperation refuses operations not permitted by the configuration
+func (s *fileSystemSandbox) checkOperation(operation string) error {
+	switch operation {
+	case "read_file", "list_directory", "file_exists":
+		return nil
+	case "write_file", "append_file", "create_directory", "delete_file":
+		if s.config.ReadOnly {
+			return s.denyOperation(operation, "read-only mode")
+		}
+		if operation == "delete_file" && !s.config.AllowDelete {
+			return s.denyOperation(operation, "delete not allowed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation: %s", operation)
+	}
+}
+
+// denyOperation logs and returns a permission error
+func (s *fileSystemSandbox) denyOperation(operation, reason string) error {
+	logWarn(getContext(), "Filesystem operation denied", map[string]interface{}{
+		"tool":      "filesystem",
+		"operation": operation,
+		"reason":    reason,
+	})
+	return fmt.Errorf("%w: %s (%s)", ErrPermissionDenied, operation, reason)
+}
+
+// resolve maps a user-supplied path to an absolute path inside the root.
+// Symlinks in the existing part of the path are resolved before the jail check.
+// Returns the absolute path and the slash-separated path relative to the root.
+func (s *fileSystemSandbox) resolve(userPath string) (string, string, error) {
+	ctx := getContext()
+
+	if userPath == "" {
+		return "", "", fmt.Errorf("path cannot be empty")
+	}
+
+	var fullPath string
+	if filepath.IsAbs(userPath) {
+		fullPath = filepath.Clean(userPath)
+	} else {
+		fullPath = filepath.Join(s.root, userPath)
+	}
+
+	resolved, err := resolveExisting(fullPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	rel, ok := relativeTo(s.root, resolved)
+	if !ok {
+		logWarn(ctx, "Path outside sandbox root blocked", map[string]interface{}{
+			"tool":          "filesystem",
+			"original_path": userPath,
+			"resolved_path": resolved,
+			"root":          s.root,
+		})
+		return "", "", fmt.Errorf("%w: path escapes root directory", ErrSecurityViolation)
+	}
+
+	logDebug(ctx, "Path resolved in sandbox", map[string]interface{}{
+		"tool":          "filesystem",
+		"original_path": userPath,
+		"resolved_path": resolved,
+	})
+
+	return resolved, rel, nil
+}
+
+// maxSymlinkHops bounds the dangling symlinks followed while resolving a path
+const maxSymlinkHops = 40
+
+// resolveExisting evaluates symlinks in the longest existing prefix of path
+// and appends the remaining (not yet created) elements. Dangling symlinks are
+// followed to their target, which is where a write through them would land.
+func resolveExisting(path string) (string, error) {
+	var missing []string
+	current := path
+	hops := 0
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if info, lerr := os.Lstat(current); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+			if hops++; hops > maxSymlinkHops {
+				return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+			}
+			target, err := os.Readlink(current)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(current), target)
+			}
+			current = filepath.Clean(target)
+			continue
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path, nil
+		}
+		missing = append(missing, filepath.Base(current))
+		current = parent
+	}
+}
+
+// relativeTo returns the slash-separated path of target relative to root,
+// or false if target is outside root
+func relativeTo(root, target string) (string, bool) {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", false
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// checkPattern applies the deny and allow globs to a path relative to the root
+func (s *fileSystemSandbox) checkPattern(rel string, isDir bool) error {
+	if reason := s.filterReason(rel, isDir); reason != "" {
+		return s.denyPath(rel, reason)
+	}
+	return nil
+}
+
+// filterReason returns why the globs refuse a path, or "" if it is accessible
+func (s *fileSystemSandbox) filterReason(rel string, isDir bool) string {
+	if rel == "." {
+		return ""
+	}
+
+	for _, pattern := range s.config.DenyPatterns {
+		if matchGlob(pattern, rel) {
+			return "matches deny pattern " + pattern
+		}
+	}
+
+	if isDir || len(s.config.AllowPatterns) == 0 {
+		return ""
+	}
+	for _, pattern := range s.config.AllowPatterns {
+		if matchGlob(pattern, rel) {
+			return ""
+		}
+	}
+	return "not in allow list"
+}
+
+// denyPath logs and returns a permission error for a filtered path
+func (s *fileSystemSandbox) denyPath(rel, reason string) error {
+	logWarn(getContext(), "Filesystem path denied", map[string]interface{}{
+		"tool":   "filesystem",
+		"path":   rel,
+		"reason": reason,
+	})
+	return fmt.Errorf("%w: %s (%s)", ErrPermissionDenied, rel, reason)
+}
+
+// matchGlob matches a slash-separated relative path against a glob pattern.
+// A path also matches when one of its parent directories matches, so denying
+// a directory denies everything inside it.
+func matchGlob(pattern, rel string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return rel == prefix || strings.HasPrefix(rel, prefix+"/")
+	}
+
+	parts := strings.Split(rel, "/")
+	if !strings.Contains(pattern, "/") {
+		for _, part := range parts {
+			if matched, _ := path.Match(pattern, part); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := len(parts); i > 0; i-- {
+		if matched, _ := path.Match(pattern, strings.Join(parts[:i], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSize enforces MaxFileSize for the resulting size of a file.
+// When existing is set, the current size of the file on disk is added to size.
+func (s *fileSystemSandbox) checkSize(fullPath string, size int64, existing bool) error {
+	limit := s.config.MaxFileSize
+	if limit <= 0 {
+		return nil
+	}
+
+	if existing {
+		info, err := os.Stat(fullPath)
+		if err == nil {
+			size += info.Size()
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to check file size: %w", err)
+		}
+	}
+
+	if size > limit {
+		logWarn(getContext(), "File size limit exceeded", map[string]interface{}{
+			"tool":  "filesystem",
+			"path":  fullPath,
+			"bytes": size,
+			"limit": limit,
+		})
+		return fmt.Errorf("%w: file size %d bytes exceeds limit of %d bytes", ErrPermissionDenied, size, limit)
+	}
+	return nil
+}
+
+// listDirectory lists a directory, hiding entries filtered by the globs
+func (s *fileSystemSandbox) listDirectory(fullPath, rel string) (string, error) {
+	entries, err := os.ReadDir(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var result strings.Builder
+	count := 0
+	for _, entry := range entries {
+		entryRel := entry.Name()
+		if rel != "." {
+			entryRel = rel + "/" + entry.Name()
+		}
+		if s.filterReason(entryRel, entry.IsDir()) != "" {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		fileType := "FILE"
+		if entry.IsDir() {
+			fileType = "DIR "
+		}
+
+		result.WriteString(fmt.Sprintf("  [%s] %s (%d bytes)\n", fileType, entry.Name(), info.Size()))
+		count++
+	}
+
+	if count == 0 {
+		return fmt.Sprintf("Directory %s is empty", rel), nil
+	}
+
+	return fmt.Sprintf("Directory %s (%d items):\n", rel, count) + result.String(), nil
+}
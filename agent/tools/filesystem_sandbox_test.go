@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSandboxTool creates a sandboxed filesystem tool rooted at a temp directory
+func newSandboxTool(t *testing.T, config FileSystemConfig) (func(string) (string, error), string) {
+	t.Helper()
+	root := t.TempDir()
+	config.RootDir = root
+	tool, err := NewFileSystemToolWithConfig(config)
+	if err != nil {
+		t.Fatalf("NewFileSystemToolWithConfig failed: %v", err)
+	}
+	return tool.Handler, root
+}
+
+func TestNewFileSystemToolWithConfig_InvalidConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(file, []byte("x"), 0644)
+
+	tests := []struct {
+		name   string
+		config FileSystemConfig
+	}{
+		{"Missing root", FileSystemConfig{}},
+		{"Root does not exist", FileSystemConfig{RootDir: filepath.Join(t.TempDir(), "missing")}},
+		{"Root is a file", FileSystemConfig{RootDir: file}},
+		{"Bad glob", FileSystemConfig{RootDir: t.TempDir(), DenyPatterns: []string{"[abc"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFileSystemToolWithConfig(tt.config); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestFileSystemSandbox_RootJail(t *testing.T) {
+	handler, root := newSandboxTool(t, FileSystemConfig{})
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+
+	// Relative paths resolve against the root, not the CWD
+	result, err := handler(`{"operation": "write_file", "path": "notes/a.txt", "content": "hello"}`)
+	if err != nil {
+		t.Fatalf("write_file failed: %v", err)
+	}
+	if !strings.Contains(result, "Successfully wrote") {
+		t.Errorf("Unexpected result: %s", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "notes", "a.txt")); string(data) != "hello" {
+		t.Errorf("Expected file inside root, got %q", string(data))
+	}
+
+	// Absolute paths inside the root are accepted
+	result, err = handler(`{"operation": "read_file", "path": "` + filepath.Join(root, "notes", "a.txt") + `"}`)
+	if err != nil || !strings.Contains(result, "hello") {
+		t.Errorf("Expected to read absolute path inside root, got %q, %v", result, err)
+	}
+
+	escapes := []string{
+		"/etc/passwd",
+		filepath.Join(outside, "secret.txt"),
+		"../secret.txt",
+		"notes/../../secret.txt",
+	}
+	for _, path := range escapes {
+		_, err := handler(`{"operation": "read_file", "path": "` + path + `"}`)
+		if !errors.Is(err, ErrSecurityViolation) {
+			t.Errorf("Path %s: expected security violation, got %v", path, err)
+		}
+	}
+
+	// Paths that stay inside the root after cleaning are fine
+	if _, err := handler(`{"operation": "read_file", "path": "notes/../notes/a.txt"}`); err != nil {
+		t.Errorf("Expected in-root path with .. to be allowed, got %v", err)
+	}
+}
+
+func TestFileSystemSandbox_Symlinks(t *testing.T) {
+	handler, root := newSandboxTool(t, FileSystemConfig{})
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	os.WriteFile(filepath.Join(root, "inside.txt"), []byte("inside"), 0644)
+
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret-link.txt"))
+	os.Symlink(filepath.Join(root, "inside.txt"), filepath.Join(root, "inside-link.txt"))
+
+	for _, args := range []string{
+		`{"operation": "read_file", "path": "escape/secret.txt"}`,
+		`{"operation": "read_file", "path": "secret-link.txt"}`,
+		`{"operation": "write_file", "path": "escape/new.txt", "content": "x"}`,
+		`{"operation": "list_directory", "path": "escape"}`,
+	} {
+		if _, err := handler(args); !errors.Is(err, ErrSecurityViolation) {
+			t.Errorf("%s: expected security violation, got %v", args, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Error("File was written outside the root")
+	}
+
+	// Dangling links are checked against the root by their target
+	os.Symlink(filepath.Join(outside, "missing.txt"), filepath.Join(root, "dangling.txt"))
+	os.Symlink("dangling.txt", filepath.Join(root, "dangling-chain.txt"))
+	for _, path := range []string{"dangling.txt", "dangling-chain.txt"} {
+		args := `{"operation": "write_file", "path": "` + path + `", "content": "escaped"}`
+		if _, err := handler(args); !errors.Is(err, ErrSecurityViolation) {
+			t.Errorf("%s: expected security violation, got %v", args, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "missing.txt")); !os.IsNotExist(err) {
+		t.Error("File was written through a dangling symlink")
+	}
+	os.Symlink("new-inside.txt", filepath.Join(root, "dangling-inside.txt"))
+	if _, err := handler(`{"operation": "write_file", "path": "dangling-inside.txt", "content": "ok"}`); err != nil {
+		t.Errorf("Expected write through in-root dangling symlink, got %v", err)
+	}
+
+	// Links that stay inside the root are followed
+	result, err := handler(`{"operation": "read_file", "path": "inside-link.txt"}`)
+	if err != nil || !strings.Contains(result, "inside") {
+		t.Errorf("Expected in-root symlink to be readable, got %q, %v", result, err)
+	}
+}
+
+func TestFileSystemSandbox_Permissions(t *testing.T) {
+	t.Run("ReadOnly", func(t *testing.T) {
+		handler, root := newSandboxTool(t, FileSystemConfig{ReadOnly: true, AllowDelete: true})
+		os.WriteFile(filepath.Join(root, "a.txt"), []byte("data"), 0644)
+
+		if _, err := handler(`{"operation": "read_file", "path": "a.txt"}`); err != nil {
+			t.Errorf("read_file failed: %v", err)
+		}
+		if _, err := handler(`{"operation": "list_directory", "path": "."}`); err != nil {
+			t.Errorf("list_directory failed: %v", err)
+		}
+		for _, op := range []string{"write_file", "append_file", "delete_file", "create_directory"} {
+			_, err := handler(`{"operation": "` + op + `", "path": "a.txt", "content": "x"}`)
+			if !errors.Is(err, ErrPermissionDenied) {
+				t.Errorf("%s: expected permission denied, got %v", op, err)
+			}
+		}
+		if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "data" {
+			t.Errorf("File was modified in read-only mode: %q", string(data))
+		}
+	})
+
+	t.Run("DeleteDisabledByDefault", func(t *testing.T) {
+		handler, root := newSandboxTool(t, FileSystemConfig{})
+		os.WriteFile(filepath.Join(root, "a.txt"), []byte("data"), 0644)
+
+		if _, err := handler(`{"operation": "delete_file", "path": "a.txt"}`); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("Expected permission denied, got %v", err)
+		}
+		if _, err := handler(`{"operation": "write_file", "path": "b.txt", "content": "x"}`); err != nil {
+			t.Errorf("write_file failed: %v", err)
+		}
+	})
+
+	t.Run("AllowDelete", func(t *testing.T) {
+		handler, root := newSandboxTool(t, FileSystemConfig{AllowDelete: true})
+		os.WriteFile(filepath.Join(root, "a.txt"), []byte("data"), 0644)
+
+		if _, err := handler(`{"operation": "delete_file", "path": "a.txt"}`); err != nil {
+			t.Errorf("delete_file failed: %v", err)
+		}
+		if _, err := handler(`{"operation": "delete_file", "path": "."}`); !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("Expected root deletion to be denied, got %v", err)
+		}
+	})
+}
+
+func TestFileSystemSandbox_Patterns(t *testing.T) {
+	handler, root := newSandboxTool(t, FileSystemConfig{
+		AllowPatterns: []string{"*.txt", "docs/*.md"},
+		DenyPatterns:  []string{"secret*", "private/**"},
+	})
+	os.MkdirAll(filepath.Join(root, "docs"), 0755)
+	os.MkdirAll(filepath.Join(root, "private"), 0755)
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("notes"), 0644)
+	os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644)
+	os.WriteFile(filepath.Join(root, "main.go"), []byte("package main"), 0644)
+	os.WriteFile(filepath.Join(root, "docs", "guide.md"), []byte("guide"), 0644)
+	os.WriteFile(filepath.Join(root, "private", "plan.txt"), []byte("plan"), 0644)
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"notes.txt", true},
+		{"docs/guide.md", true},
+		{"main.go", false},          // not in allow list
+		{"secret.txt", false},       // deny wins over allow
+		{"private/plan.txt", false}, // denied directory
+	}
+	for _, tt := range tests {
+		_, err := handler(`{"operation": "read_file", "path": "` + tt.path + `"}`)
+		if tt.allowed && err != nil {
+			t.Errorf("%s: expected access, got %v", tt.path, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrPermissionDenied) {
+			t.Errorf("%s: expected permission denied, got %v", tt.path, err)
+		}
+	}
+
+	// New files must match the allow list too
+	if _, err := handler(`{"operation": "write_file", "path": "run.sh", "content": "x"}`); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected permission denied, got %v", err)
+	}
+	if _, err := handler(`{"operation": "list_directory", "path": "private"}`); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected denied directory listing, got %v", err)
+	}
+
+	// Listings hide filtered entries
+	result, err := handler(`{"operation": "list_directory", "path": "."}`)
+	if err != nil {
+		t.Fatalf("list_directory failed: %v", err)
+	}
+	if !strings.Contains(result, "notes.txt") || !strings.Contains(result, "docs") {
+		t.Errorf("Expected allowed entries in listing: %s", result)
+	}
+	for _, hidden := range []string{"secret.txt", "main.go", "private"} {
+		if strings.Contains(result, hidden) {
+			t.Errorf("Expected %s to be hidden from listing: %s", hidden, result)
+		}
+	}
+}
+
+func TestFileSystemSandbox_MaxFileSize(t *testing.T) {
+	handler, root := newSandboxTool(t, FileSystemConfig{MaxFileSize: 10})
+	os.WriteFile(filepath.Join(root, "big.txt"), []byte(strings.Repeat("x", 20)), 0644)
+	os.WriteFile(filepath.Join(root, "small.txt"), []byte("12345"), 0644)
+
+	if _, err := handler(`{"operation": "read_file", "path": "big.txt"}`); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected read of big file to be denied, got %v", err)
+	}
+	if _, err := handler(`{"operation": "read_file", "path": "small.txt"}`); err != nil {
+		t.Errorf("read_file failed: %v", err)
+	}
+	if _, err := handler(`{"operation": "write_file", "path": "new.txt", "content": "` + strings.Repeat("y", 11) + `"}`); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected oversized write to be denied, got %v", err)
+	}
+
+	// Appends count the existing content
+	if _, err := handler(`{"operation": "append_file", "path": "small.txt", "content": "67890"}`); err != nil {
+		t.Errorf("append_file failed: %v", err)
+	}
+	if _, err := handler(`{"operation": "append_file", "path": "small.txt", "content": "!"}`); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected oversized append to be denied, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "small.txt")); string(data) != "1234567890" {
+		t.Errorf("Unexpected content: %q", string(data))
+	}
+}
+
+func TestFileSystemSandbox_HidesRoot(t *testing.T) {
+	handler, root := newSandboxTool(t, FileSystemConfig{AllowDelete: true})
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{"operation": "write_file", "path": "a.txt", "content": "hello"}`, "Successfully wrote 5 bytes to a.txt"},
+		{`{"operation": "append_file", "path": "a.txt", "content": "!"}`, "Successfully appended 1 bytes to a.txt"},
+		{`{"operation": "file_exists", "path": "a.txt"}`, "Path exists: a.txt"},
+		{`{"operation": "file_exists", "path": "missing.txt"}`, "Path does not exist: missing.txt"},
+		{`{"operation": "file_exists", "path": "."}`, "Path exists: . "},
+		{`{"operation": "create_directory", "path": "sub/dir"}`, "Successfully created directory: sub/dir"},
+		{`{"operation": "delete_file", "path": "a.txt"}`, "Successfully deleted a.txt"},
+	}
+	for _, tt := range tests {
+		result, err := handler(tt.args)
+		if err != nil {
+			t.Fatalf("%s failed: %v", tt.args, err)
+		}
+		if !strings.Contains(result, tt.want) || strings.Contains(result, root) {
+			t.Errorf("%s: expected %q without the root, got %q", tt.args, tt.want, result)
+		}
+	}
+
+	// Errors from the OS don't leak the root either
+	_, err := handler(`{"operation": "read_file", "path": "missing.txt"}`)
+	if err == nil || strings.Contains(err.Error(), root) || !strings.Contains(err.Error(), "missing.txt") {
+		t.Errorf("Expected relative path in error, got %v", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected wrapped os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/util.go", true},
+		{".env", "config/.env", true},
+		{"*.go", "main.txt", false},
+		{"docs/*.md", "docs/guide.md", true},
+		{"docs/*.md", "other/guide.md", false},
+		{"docs", "docs/guide.md", true},
+		{"secrets/**", "secrets", true},
+		{"secrets/**", "secrets/a/b.txt", true},
+		{"secrets/**", "secretsfile", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
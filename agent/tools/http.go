@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,6 +18,7 @@ import (
 //
 // Available methods: GET, POST, PUT, DELETE
 // Supports: custom headers, query parameters, request body, JSON parsing
+// The trace context of the tool call is propagated to the requested service.
 //
 // Example:
 //
@@ -32,11 +34,16 @@ func NewHTTPRequestTool() *agent.Tool {
 		AddParameter("headers", "string", "Optional headers as JSON object (e.g., {\"Authorization\": \"Bearer token\"})", false).
 		AddParameter("body", "string", "Optional request body (for POST, PUT)", false).
 		AddParameter("timeout_seconds", "number", "Optional timeout in seconds (default: 30)", false).
-		WithHandler(httpRequestHandler)
+		WithContextHandler(httpRequestContextHandler)
 }
 
 // httpRequestHandler executes HTTP requests
 func httpRequestHandler(args string) (string, error) {
+	return httpRequestContextHandler(context.Background(), args)
+}
+
+// httpRequestContextHandler executes HTTP requests within the context of the tool call
+func httpRequestContextHandler(ctx context.Context, args string) (string, error) {
 	var params struct {
 		Method         string  `json:"method"`
 		URL            string  `json:"url"`
@@ -70,7 +77,7 @@ func httpRequestHandler(args string) (string, error) {
 	}
 
 	// Make the request
-	return makeHTTPRequest(method, params.URL, params.Headers, params.Body, timeout)
+	return makeHTTPRequest(ctx, method, params.URL, params.Headers, params.Body, timeout)
 }
 
 // isValidHTTPMethod checks if the HTTP method is supported
@@ -85,9 +92,7 @@ func isValidHTTPMethod(method string) bool {
 }
 
 // makeHTTPRequest performs the actual HTTP request
-func makeHTTPRequest(method, url, headersJSON, body string, timeout time.Duration) (string, error) {
-	ctx := getContext()
-
+func makeHTTPRequest(ctx context.Context, method, url, headersJSON, body string, timeout time.Duration) (string, error) {
 	logInfo(ctx, "Making HTTP request", map[string]interface{}{
 		"tool":         "http_request",
 		"method":       method,
@@ -108,7 +113,7 @@ func makeHTTPRequest(method, url, headersJSON, body string, timeout time.Duratio
 	}
 
 	// Create request
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		logError(ctx, "Failed to create HTTP request", map[string]interface{}{
 			"tool":   "http_request",
@@ -119,8 +124,9 @@ func makeHTTPRequest(method, url, headersJSON, body string, timeout time.Duratio
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set default headers
+	// Set default headers and propagate the trace context
 	req.Header.Set("User-Agent", "go-deep-agent/0.5.3")
+	agent.InjectTraceContext(ctx, req.Header)
 
 	// Parse and set custom headers
 	if headersJSON != "" {
@@ -29,6 +29,8 @@
 // Security Notes:
 //   - DateTimeTool and MathTool are SAFE (no file access, no network calls)
 //   - FileSystemTool includes path traversal prevention (use with caution)
+//   - NewFileSystemToolWithConfig jails file access to a root directory with
+//     permissions, glob allow/deny lists and size limits (use in production)
 //   - HTTPRequestTool has timeout protection (use with caution)
 //   - All tools include proper error handling
 package tools
@@ -77,6 +79,7 @@ var (
 	ErrInvalidInput      = fmt.Errorf("invalid input parameters")
 	ErrOperationFailed   = fmt.Errorf("operation failed")
 	ErrSecurityViolation = fmt.Errorf("security violation detected")
+	ErrPermissionDenied  = fmt.Errorf("permission denied")
 	ErrTimeout           = fmt.Errorf("operation timeout")
 )
 
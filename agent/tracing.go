@@ -0,0 +1,80 @@
+// Package agent implements OpenTelemetry tracing helpers
+// This file contains the span attributes following the GenAI semantic conventions
+package agent
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans created by this package
+const tracerName = "github.com/taipm/go-deep-agent/agent"
+
+// Span attributes of the OpenTelemetry GenAI and database semantic conventions
+const (
+	attrGenAIOperationName      = attribute.Key("gen_ai.operation.name")
+	attrGenAISystem             = attribute.Key("gen_ai.system")
+	attrGenAIRequestModel       = attribute.Key("gen_ai.request.model")
+	attrGenAIRequestTemperature = attribute.Key("gen_ai.request.temperature")
+	attrGenAIRequestMaxTokens   = attribute.Key("gen_ai.request.max_tokens")
+	attrGenAIResponseModel      = attribute.Key("gen_ai.response.model")
+	attrGenAIFinishReasons      = attribute.Key("gen_ai.response.finish_reasons")
+	attrGenAIInputTokens        = attribute.Key("gen_ai.usage.input_tokens")
+	attrGenAIOutputTokens       = attribute.Key("gen_ai.usage.output_tokens")
+	attrGenAIToolName           = attribute.Key("gen_ai.tool.name")
+	attrDBOperationName         = attribute.Key("db.operation.name")
+	attrDBCollectionName        = attribute.Key("db.collection.name")
+	attrDBReturnedRows          = attribute.Key("db.response.returned_rows")
+	attrCacheHit                = attribute.Key("cache.hit")
+	attrRAGDocuments            = attribute.Key("rag.documents")
+	attrReActIteration          = attribute.Key("react.iteration")
+)
+
+// endSpan records err on the span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// InjectTraceContext adds the trace context of ctx to the headers of an
+// outgoing HTTP request, using the global propagator or, when none is
+// configured, the W3C Trace Context format.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	propagator := otel.GetTextMapPropagator()
+	if len(propagator.Fields()) == 0 {
+		propagator = propagation.TraceContext{}
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// iterationSpans creates a span per iteration of a loop, ending the span of
+// the previous iteration when the next one starts
+type iterationSpans struct {
+	builder *Builder
+	name    string
+	current trace.Span
+}
+
+// next ends the span of the previous iteration and starts one for iteration
+func (s *iterationSpans) next(ctx context.Context, iteration int) context.Context {
+	s.end()
+	ctx, s.current = s.builder.startSpan(ctx, s.name, trace.SpanKindInternal, attrReActIteration.Int(iteration))
+	return ctx
+}
+
+// end ends the span of the current iteration, if any
+func (s *iterationSpans) end() {
+	if s.current != nil {
+		s.current.End()
+		s.current = nil
+	}
+}